@@ -0,0 +1,85 @@
+// Package activity fan out the entries posted to the ledger to the clients watching their accounts. Postgres
+// notify EntryCreatedChannel when an entry is committed, Listen forward the notifications to a Hub and every
+// subscriber of the Hub receive them. A subscriber that fall behind is dropped instead of blocking the others,
+// it resume from the last entry it saw.
+package activity
+
+import (
+	"sync"
+)
+
+// EntryCreatedChannel is notified by the entries_notify_entry_created trigger
+const EntryCreatedChannel = "entry_created"
+
+// SubscriberBuffer is how many events a subscriber can fall behind before it is dropped
+const SubscriberBuffer = 256
+
+// Event tell an entry was posted, the subscriber load the entry itself
+type Event struct {
+	EntryID   int64 `json:"entry_id"`
+	AccountID int64 `json:"account_id"`
+}
+
+// Hub send the published events to every subscriber
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Subscribe return the channel receiving the events published from now on and the func to unsubscribe.
+// The channel is closed when the subscriber is dropped, the events published after that are lost.
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, SubscriberBuffer)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.drop(ch)
+	}
+
+	return ch, unsubscribe
+}
+
+// Publish send the event to every subscriber without waiting, a subscriber with a full buffer is dropped
+func (h *Hub) Publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		select {
+		case ch <- e:
+		default:
+			h.drop(ch)
+		}
+	}
+}
+
+// Reset drop every subscriber, it is called when events may have been missed, e.g. after the
+// connection to Postgres was lost
+func (h *Hub) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers {
+		h.drop(ch)
+	}
+}
+
+// drop must be called with mu held
+func (h *Hub) drop(ch chan Event) {
+	if _, ok := h.subscribers[ch]; !ok {
+		return
+	}
+	delete(h.subscribers, ch)
+	close(ch)
+}
@@ -0,0 +1,69 @@
+package activity
+
+import (
+	"testing"
+)
+
+func TestHubPublish(t *testing.T) {
+	hub := NewHub()
+	ch1, unsubscribe1 := hub.Subscribe()
+	ch2, unsubscribe2 := hub.Subscribe()
+	defer unsubscribe2()
+
+	hub.Publish(Event{EntryID: 1, AccountID: 2})
+	for i, ch := range []<-chan Event{ch1, ch2} {
+		e := <-ch
+		if e.EntryID != 1 || e.AccountID != 2 {
+			t.Fatalf("subscriber %d got wrong event %+v", i, e)
+		}
+	}
+
+	unsubscribe1()
+	if _, ok := <-ch1; ok {
+		t.Fatalf("channel should be closed after unsubscribe")
+	}
+	// unsubscribe twice must not panic
+	unsubscribe1()
+
+	hub.Publish(Event{EntryID: 2, AccountID: 2})
+	if e := <-ch2; e.EntryID != 2 {
+		t.Fatalf("got wrong event %+v", e)
+	}
+}
+
+func TestHubDropSlowSubscriber(t *testing.T) {
+	hub := NewHub()
+	slow, unsubscribeSlow := hub.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := hub.Subscribe()
+	defer unsubscribeFast()
+
+	for i := 1; i <= SubscriberBuffer+1; i++ {
+		hub.Publish(Event{EntryID: int64(i)})
+		<-fast
+	}
+
+	n := 0
+	for range slow {
+		n++
+	}
+	if n != SubscriberBuffer {
+		t.Fatalf("slow subscriber want %d buffered events got %d", SubscriberBuffer, n)
+	}
+
+	hub.Publish(Event{EntryID: 1000})
+	if e, ok := <-fast; !ok || e.EntryID != 1000 {
+		t.Fatalf("fast subscriber should still receive events")
+	}
+}
+
+func TestHubReset(t *testing.T) {
+	hub := NewHub()
+	ch, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	hub.Reset()
+	if _, ok := <-ch; ok {
+		t.Fatalf("channel should be closed after reset")
+	}
+}
@@ -0,0 +1,60 @@
+package activity
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	minReconnectInterval = 10 * time.Second
+	maxReconnectInterval = time.Minute
+	// the connection is pinged when no notification came for this long, so a dead connection is noticed
+	pingInterval = 90 * time.Second
+)
+
+// Listen forward the notifications of EntryCreatedChannel to the hub until ctx is done. The listener reconnect
+// by itself when the connection is lost, the hub is reset then because notifications sent meanwhile are lost.
+func Listen(ctx context.Context, dbSource string, hub *Hub) error {
+	listener := pq.NewListener(dbSource, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error().Err(err).Msg("activity listener connection error")
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(EntryCreatedChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-listener.Notify:
+			// nil is sent after a reconnect
+			if n == nil {
+				hub.Reset()
+				continue
+			}
+
+			var e Event
+			err = json.Unmarshal([]byte(n.Extra), &e)
+			if err != nil {
+				log.Error().Err(err).Str("payload", n.Extra).Msg("invalid entry created notification")
+				continue
+			}
+			hub.Publish(e)
+		case <-time.After(pingInterval):
+			go func() {
+				err := listener.Ping()
+				if err != nil {
+					log.Error().Err(err).Msg("activity listener ping failed")
+				}
+			}()
+		}
+	}
+}
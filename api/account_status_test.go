@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_changeAccountStatus(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		AccountID             int64
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			AccountID:             2,
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"status": "frozen", "reason": "lost card"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "InvalidChange",
+			AccountID:             2,
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"status": "active", "reason": "found card"},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "BadRequest",
+			AccountID:             2,
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"status": "closed", "reason": "close"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			AccountID:             1,
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"status": "frozen", "reason": "lost card"},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			AccountID:             2,
+			Username:              "other-user",
+			ReqBody:               map[string]interface{}{"status": "frozen", "reason": "lost card"},
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "ServerError",
+			AccountID:             3,
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"status": "frozen", "reason": "lost card"},
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/accounts/%d/status", tc.AccountID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_adminChangeAccountStatus(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		AccountID             int64
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "AcceptedReactivate",
+			AccountID:             5,
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"status": "active", "reason": "kyc completed"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedDormant",
+			AccountID:             2,
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"status": "dormant", "reason": "no activity"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			AccountID:             2,
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"status": "closed", "reason": "close"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			AccountID:             1,
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"status": "frozen", "reason": "fraud"},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "ForbiddenNotAdmin",
+			AccountID:             2,
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"status": "active", "reason": "found card"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "ServerError",
+			AccountID:             3,
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"status": "frozen", "reason": "fraud"},
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/admin/accounts/%d/status", tc.AccountID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_listAccountStatusHistory(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		AccountID             int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			AccountID:             2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			AccountID:             1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			AccountID:             2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/status_history", tc.AccountID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
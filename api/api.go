@@ -10,12 +10,15 @@ import (
 	"github.com/ismail118/simple-bank/repository"
 	"github.com/ismail118/simple-bank/token"
 	"github.com/ismail118/simple-bank/util"
+	"io"
 	"net/http"
 	"time"
 )
 
 type createAccountRequest struct {
-	Currency string `json:"currency" binding:"required,oneof=USD EUR"`
+	Currency string `json:"currency" binding:"required,len=3"`
+	// optional account product that make the account earn interest, must be of the same currency
+	ProductID int64 `json:"product_id" binding:"omitempty,min=1"`
 }
 
 func (s *Server) createAccount(ctx *gin.Context) {
@@ -30,9 +33,37 @@ func (s *Server) createAccount(ctx *gin.Context) {
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
 	acc := models.Account{
-		Owner:    authPayload.Username,
-		Balance:  0,
-		Currency: req.Currency,
+		Owner:     authPayload.Username,
+		Balance:   0,
+		Currency:  req.Currency,
+		Status:    models.AccountStatusActive,
+		ProductID: req.ProductID,
+	}
+
+	currency, err := s.repo.GetCurrency(ctx, req.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if currency.Code == "" {
+		ctx.JSON(http.StatusBadRequest, fmt.Sprintf("currency %s not supported", req.Currency))
+		return
+	}
+
+	if req.ProductID > 0 {
+		product, err := s.repo.GetAccountProduct(ctx, req.ProductID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if product.ID < 1 {
+			ctx.JSON(http.StatusBadRequest, fmt.Sprintf("account product %d not found", req.ProductID))
+			return
+		}
+		if product.Currency != req.Currency {
+			ctx.JSON(http.StatusBadRequest, fmt.Sprintf("account product %d mismatch: %s vs %s", product.ID, product.Currency, req.Currency))
+			return
+		}
 	}
 
 	user, err := s.repo.GetUsersByUsername(ctx, acc.Owner)
@@ -51,7 +82,7 @@ func (s *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
-	if a.ID > 1 {
+	if a.ID > 0 {
 		ctx.JSON(http.StatusForbidden, fmt.Sprintf("account with owner %s and %s alredy exists", acc.Owner, acc.Currency))
 		return
 	}
@@ -100,38 +131,72 @@ func (s *Server) getAccount(ctx *gin.Context) {
 	ctx.JSON(http.StatusAccepted, acc)
 }
 
+// getOwnedAccount return the account if it exist and belong to authenticated user,
+// it write the error response and return false if not
+func (s *Server) getOwnedAccount(ctx *gin.Context, id int64) (models.Account, bool) {
+	acc, err := s.repo.GetAccountByID(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return acc, false
+	}
+	if acc.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "account not found")
+		return acc, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if acc.Owner != authPayload.Username {
+		err = errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return acc, false
+	}
+
+	return acc, true
+}
+
 type listRequest struct {
 	Page int `json:"page" form:"page" binding:"required,min=1"`
 	Size int `json:"size" form:"size" binding:"required,min=5,max=10"`
 }
 
+type listAccountsResponse struct {
+	Accounts   []*models.Account `json:"accounts"`
+	NextCursor string            `json:"next_cursor"`
+}
+
 func (s *Server) listAccounts(ctx *gin.Context) {
-	var req listRequest
+	var req cursorListRequest
 
 	err := ctx.BindQuery(&req)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	afterID, err := util.DecodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 	// authorization
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	accounts, err := s.repo.GetListAccounts(ctx,
-		authPayload.Username,
-		req.Size,
-		(req.Page-1)*req.Size,
-	)
+	accounts, err := s.repo.GetListAccounts(ctx, authPayload.Username, afterID, req.Size+1)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, accounts)
+	var res listAccountsResponse
+	res.Accounts, res.NextCursor = cursorPage(accounts, req.Size, func(a *models.Account) int64 { return a.ID })
+
+	ctx.JSON(http.StatusAccepted, res)
 }
 
+// updateAccountRequest carry the balance and the currency of the account, both are only moved by the ledger
+// so the request is refused when they differ from the account
 type updateAccountRequest struct {
 	ID       int64  `json:"id" binding:"required"`
-	Balance  int64  `json:"balance" binding:"required"`
+	Balance  int64  `json:"balance"`
 	Currency string `json:"currency" binding:"required"`
 }
 
@@ -162,12 +227,14 @@ func (s *Server) updateAccount(ctx *gin.Context) {
 		return
 	}
 
-	account.Balance = req.Balance
-	account.Currency = req.Currency
-
-	err = s.repo.UpdateAccount(ctx, account)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	// the balance only move with ledger entries and the currency can't change under the entries already posted,
+	// writing them here would skip the overdraft, status and hold checks and break the reconciliation
+	if req.Balance != account.Balance {
+		ctx.JSON(http.StatusBadRequest, "balance can't be updated, money is moved by transfers")
+		return
+	}
+	if req.Currency != account.Currency {
+		ctx.JSON(http.StatusBadRequest, fmt.Sprintf("currency can't be updated, open an account in %s instead", req.Currency))
 		return
 	}
 
@@ -201,13 +268,147 @@ func (s *Server) deleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	err = s.repo.DeleteAccount(ctx, account.ID)
+	// the account is closed instead of deleted so its ledger history is kept
+	res, err := s.store.ChangeAccountStatusTx(ctx, models.AccountStatusHistory{
+		AccountID: account.ID,
+		ToStatus:  models.AccountStatusClosed,
+		Reason:    "closed by owner",
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res.Account)
+}
+
+type changeAccountStatusRequest struct {
+	// closing is done by DELETE /accounts/:id and dormant is set by the bank
+	Status string `json:"status" binding:"required,oneof=active frozen"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// changeAccountStatus let the owner freeze the account, e.g. when the card is lost, and activate it again.
+// An account frozen or made dormant by the bank is reactivated with adminChangeAccountStatus.
+func (s *Server) changeAccountStatus(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req changeAccountStatusRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "account not found")
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err = errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	res, err := s.store.ChangeAccountStatusTx(ctx, models.AccountStatusHistory{
+		AccountID: account.ID,
+		ToStatus:  req.Status,
+		Reason:    req.Reason,
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+type adminChangeAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen dormant"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// adminChangeAccountStatus let an admin freeze any account or lift a freeze or dormancy set by the bank,
+// the admin is recorded as the actor of the change
+func (s *Server) adminChangeAccountStatus(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req adminChangeAccountStatusRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	res, err := s.store.ChangeAccountStatusTx(ctx, models.AccountStatusHistory{
+		AccountID: uri.ID,
+		ToStatus:  req.Status,
+		Reason:    req.Reason,
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+func (s *Server) listAccountStatusHistory(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "account not found")
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err = errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	items, err := s.repo.GetListAccountStatusHistory(ctx, account.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, "success delete account")
+	ctx.JSON(http.StatusAccepted, items)
 }
 
 func (s *Server) getEntry(ctx *gin.Context) {
@@ -249,8 +450,19 @@ func (s *Server) getEntry(ctx *gin.Context) {
 
 type listEntryRequest struct {
 	AccountID int64 `json:"account_id" form:"account_id" binding:"required,min=1"`
-	Page      int   `json:"page" form:"page" binding:"required,min=1"`
-	Size      int   `json:"size" form:"size" binding:"required,min=5,max=10"`
+	cursorListRequest
+	// created in [from, to), RFC 3339
+	From time.Time `json:"from" form:"from"`
+	To   time.Time `json:"to" form:"to" binding:"omitempty,gtfield=From"`
+	// range of the absolute amount, both included
+	MinAmount int64  `json:"min_amount" form:"min_amount" binding:"min=0"`
+	MaxAmount int64  `json:"max_amount" form:"max_amount" binding:"omitempty,gtefield=MinAmount"`
+	Direction string `json:"direction" form:"direction" binding:"omitempty,oneof=credit debit"`
+}
+
+type listEntriesResponse struct {
+	Entries    []*models.Entry `json:"entries"`
+	NextCursor string          `json:"next_cursor"`
 }
 
 func (s *Server) listEntries(ctx *gin.Context) {
@@ -261,6 +473,11 @@ func (s *Server) listEntries(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
+	afterID, err := util.DecodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
 
 	account, err := s.repo.GetAccountByID(ctx, req.AccountID)
 	if err != nil {
@@ -276,17 +493,29 @@ func (s *Server) listEntries(ctx *gin.Context) {
 		return
 	}
 
-	entries, err := s.repo.GetListEntries(ctx,
-		req.AccountID,
-		req.Size,
-		(req.Page-1)*req.Size,
-	)
+	filter := models.EntryFilter{
+		AccountID: req.AccountID,
+		From:      req.From,
+		To:        req.To,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+		Direction: req.Direction,
+	}
+	entries, err := s.repo.GetListEntries(ctx, filter, afterID, req.Size+1)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, entries)
+	var res listEntriesResponse
+	res.Entries, res.NextCursor = cursorPage(entries, req.Size, func(e *models.Entry) int64 { return e.ID })
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+type getTransferResponse struct {
+	models.Transfer
+	Reversals []*models.Transfer `json:"reversals"`
 }
 
 func (s *Server) getTransfer(ctx *gin.Context) {
@@ -329,14 +558,35 @@ func (s *Server) getTransfer(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, transfer)
+	reversals, err := s.repo.GetListReversals(ctx, transfer.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	resp := getTransferResponse{
+		Transfer:  transfer,
+		Reversals: reversals,
+	}
+
+	ctx.JSON(http.StatusAccepted, resp)
 }
 
 type listTransferRequest struct {
-	FromAccountID int64 `json:"from_account_id" form:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64 `json:"to_account_id" form:"to_account_id" binding:"required,min=1"`
-	Page          int   `json:"page" form:"page" binding:"required,min=1"`
-	Size          int   `json:"size" form:"size" binding:"required,min=5,max=10"`
+	// account that sent or received the transfers
+	AccountID int64 `json:"account_id" form:"account_id" binding:"required,min=1"`
+	cursorListRequest
+	// the other account of the transfers
+	CounterpartyAccountID int64 `json:"counterparty_account_id" form:"counterparty_account_id" binding:"omitempty,min=1"`
+	// created in [from, to), RFC 3339
+	From   time.Time `json:"from" form:"from"`
+	To     time.Time `json:"to" form:"to" binding:"omitempty,gtfield=From"`
+	Status string    `json:"status" form:"status" binding:"omitempty,oneof=posted reversed reversal"`
+}
+
+type listTransfersResponse struct {
+	Transfers  []*models.Transfer `json:"transfers"`
+	NextCursor string             `json:"next_cursor"`
 }
 
 func (s *Server) listTransfer(ctx *gin.Context) {
@@ -347,46 +597,44 @@ func (s *Server) listTransfer(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
-
-	fAccount, err := s.repo.GetAccountByID(ctx, req.FromAccountID)
+	afterID, err := util.DecodeCursor(req.Cursor)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	tAccount, err := s.repo.GetAccountByID(ctx, req.ToAccountID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	_, ok := s.getOwnedAccount(ctx, req.AccountID)
+	if !ok {
 		return
 	}
 
-	// authorization
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if (fAccount.Owner != authPayload.Username) && (tAccount.Owner != authPayload.Username) {
-		err = errors.New("transfer doesn't belong to authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
-		return
+	filter := models.TransferFilter{
+		AccountID:             req.AccountID,
+		CounterpartyAccountID: req.CounterpartyAccountID,
+		From:                  req.From,
+		To:                    req.To,
+		Status:                req.Status,
 	}
-
-	transfers, err := s.repo.GetListTransfers(ctx,
-		req.FromAccountID,
-		req.ToAccountID,
-		req.Size,
-		(req.Page-1)*req.Size,
-	)
+	transfers, err := s.repo.GetListTransfers(ctx, filter, afterID, req.Size+1)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusAccepted, transfers)
+	var res listTransfersResponse
+	res.Transfers, res.NextCursor = cursorPage(transfers, req.Size, func(t *models.Transfer) int64 { return t.ID })
+
+	ctx.JSON(http.StatusAccepted, res)
 }
 
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,min=1"`
-	Currency      string `json:"currency" binding:"required,oneof=USD EUR CAD"`
+	FromAccountID int64 `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64 `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64 `json:"amount" binding:"required,min=1"`
+	// currency of the from account
+	Currency string `json:"currency" binding:"required,len=3"`
+	// currency of the to account, only needed to allow converting when it differs from currency
+	ToCurrency string `json:"to_currency" binding:"omitempty,len=3"`
 }
 
 func (s *Server) transfer(ctx *gin.Context) {
@@ -420,7 +668,9 @@ func (s *Server) transfer(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, "to account not found")
 		return
 	}
-	if fAccount.Currency != req.Currency {
+	// converting to another currency must be asked explicitly with the currency of the to account
+	crossCurrency := tAccount.Currency != req.Currency
+	if crossCurrency && tAccount.Currency != req.ToCurrency {
 		ctx.JSON(http.StatusBadRequest, fmt.Sprintf("account %d mismatch: %s vs %s", tAccount.ID, tAccount.Currency, req.Currency))
 		return
 	}
@@ -432,17 +682,119 @@ func (s *Server) transfer(ctx *gin.Context) {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
+	if !s.checkPayee(ctx, fAccount, tAccount, req.Amount) {
+		return
+	}
+
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > maxIdempotencyKeyLength {
+		err = fmt.Errorf("%s header must not be longer than %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !s.screenTransferRequest(ctx, req, fAccount, tAccount, idempotencyKey) {
+		return
+	}
 
 	tf := models.Transfer{
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
 	}
-	res, err := s.store.TransferTx(ctx, tf)
+
+	if idempotencyKey == "" {
+		var res repository.TransferTxResult
+		if crossCurrency {
+			res, err = s.store.CrossCurrencyTransferTx(ctx, tf)
+		} else {
+			res, err = s.store.TransferTx(ctx, tf)
+		}
+		if err != nil {
+			ctx.JSON(storeErrorStatus(err), errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, res)
+		return
+	}
+
+	requestHash, err := hashRequest(req)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	key := models.IdempotencyKey{
+		Username:       authPayload.Username,
+		IdempotencyKey: idempotencyKey,
+		RequestHash:    requestHash,
+	}
+	res, err := s.store.IdempotentTransferTx(ctx, key, tf)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	if res.Replayed {
+		ctx.Header(idempotentReplayedHeader, "true")
+	}
+
+	ctx.JSON(http.StatusAccepted, res.TransferTxResult)
+}
+
+type reverseTransferRequest struct {
+	// Amount 0 reverse the whole remaining amount
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+// reverseTransfer send the money of a transfer back to the sender,
+// only the owner of the account that received the transfer can reverse it
+func (s *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// body is optional, empty body reverse the whole remaining amount
+	var req reverseTransferRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	transfer, err := s.repo.GetTransferByID(ctx, uri.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	if transfer.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "transfer not found")
+		return
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, transfer.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if tAccount.Owner != authPayload.Username {
+		err = errors.New("transfer destination account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	res, err := s.store.ReverseTransferTx(ctx, transfer.ID, req.Amount)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
 
 	ctx.JSON(http.StatusAccepted, res)
 }
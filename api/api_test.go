@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -139,6 +140,95 @@ func Test_createAccount(t *testing.T) {
 			},
 			ExpectationStatusCode: http.StatusBadRequest,
 		},
+		{
+			Name: "AcceptedWithProduct",
+			ReqBody: map[string]interface{}{
+				"currency":   "USD",
+				"product_id": 2,
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
+				if err != nil {
+					t.Fatalf("failed create token error:%s", err)
+				}
+				if payload == nil {
+					t.Fatalf("failed payload is empty")
+				}
+				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name: "ProductNotFound",
+			ReqBody: map[string]interface{}{
+				"currency":   "USD",
+				"product_id": 3,
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
+				if err != nil {
+					t.Fatalf("failed create token error:%s", err)
+				}
+				if payload == nil {
+					t.Fatalf("failed payload is empty")
+				}
+				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "ProductCurrencyMismatch",
+			ReqBody: map[string]interface{}{
+				"currency":   "EUR",
+				"product_id": 2,
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
+				if err != nil {
+					t.Fatalf("failed create token error:%s", err)
+				}
+				if payload == nil {
+					t.Fatalf("failed payload is empty")
+				}
+				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			// some-user closed its JPY account, a new one can be opened
+			Name: "AcceptedClosedCurrency",
+			ReqBody: map[string]interface{}{
+				"currency": "JPY",
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
+				if err != nil {
+					t.Fatalf("failed create token error:%s", err)
+				}
+				if payload == nil {
+					t.Fatalf("failed payload is empty")
+				}
+				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name: "ForbiddenOpenCurrency",
+			ReqBody: map[string]interface{}{
+				"currency": "CAD",
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
+				if err != nil {
+					t.Fatalf("failed create token error:%s", err)
+				}
+				if payload == nil {
+					t.Fatalf("failed payload is empty")
+				}
+				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+			},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
 	}
 
 	tokenMaker := serverTest.tokenMaker
@@ -170,7 +260,7 @@ func Test_listAccount(t *testing.T) {
 	}{
 		{
 			Name:        "Accepted",
-			QueryParams: "page=1&size=10",
+			QueryParams: "size=10",
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
 				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
 				if err != nil {
@@ -199,7 +289,7 @@ func Test_listAccount(t *testing.T) {
 		},
 		{
 			Name:        "ServerError",
-			QueryParams: "page=1001&size=10",
+			QueryParams: fmt.Sprintf("cursor=%s&size=10", util.EncodeCursor(1001)),
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
 				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
 				if err != nil {
@@ -296,10 +386,11 @@ func Test_updateAccount(t *testing.T) {
 			ExpectationStatusCode: http.StatusInternalServerError,
 		},
 		{
-			Name: "ServerErrorUpdate",
+			// the balance is only moved by transfers
+			Name: "BadRequestBalance",
 			ReqBody: map[string]interface{}{
-				"id":       3,
-				"balance":  100,
+				"id":       2,
+				"balance":  1000000,
 				"currency": "USD",
 			},
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
@@ -312,7 +403,26 @@ func Test_updateAccount(t *testing.T) {
 				}
 				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
 			},
-			ExpectationStatusCode: http.StatusInternalServerError,
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "BadRequestCurrency",
+			ReqBody: map[string]interface{}{
+				"id":       2,
+				"balance":  100,
+				"currency": "EUR",
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
+				if err != nil {
+					t.Fatalf("failed create token error:%s", err)
+				}
+				if payload == nil {
+					t.Fatalf("failed payload is empty")
+				}
+				r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
 		},
 		{
 			Name: "Accepted",
@@ -551,7 +661,7 @@ func Test_listEntries(t *testing.T) {
 	}{
 		{
 			Name:        "Accepted",
-			QueryParams: "account_id=2&page=1&size=10",
+			QueryParams: "account_id=2&size=10&direction=credit&min_amount=1",
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
 				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
 				if err != nil {
@@ -580,7 +690,7 @@ func Test_listEntries(t *testing.T) {
 		},
 		{
 			Name:        "ServerError",
-			QueryParams: "account_id=2&page=1001&size=10",
+			QueryParams: fmt.Sprintf("account_id=2&cursor=%s&size=10", util.EncodeCursor(1001)),
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
 				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
 				if err != nil {
@@ -709,7 +819,7 @@ func Test_listTransfer(t *testing.T) {
 	}{
 		{
 			Name:        "Accepted",
-			QueryParams: "from_account_id=1&to_account_id=2&page=1&size=10",
+			QueryParams: "account_id=2&size=10&status=posted",
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
 				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
 				if err != nil {
@@ -738,7 +848,7 @@ func Test_listTransfer(t *testing.T) {
 		},
 		{
 			Name:        "ServerError",
-			QueryParams: "from_account_id=1&to_account_id=2&page=1001&size=10",
+			QueryParams: fmt.Sprintf("account_id=2&cursor=%s&size=10", util.EncodeCursor(1001)),
 			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
 				token, payload, err := tokenMaker.CreateToken("some-user", time.Minute)
 				if err != nil {
@@ -798,6 +908,19 @@ func Test_transfer(t *testing.T) {
 			},
 			ExpectationStatusCode: http.StatusAccepted,
 		},
+		{
+			Name: "InsufficientFunds",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          101,
+				"currency":        "USD",
+			},
+			setupRequest: func(t *testing.T, r *http.Request, tokenMaker token.Maker) {
+				setAuthorizationHeader(t, r, "some-user")
+			},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
 		{
 			Name: "BadRequest",
 			ReqBody: map[string]interface{}{
@@ -880,6 +1003,69 @@ func Test_transfer(t *testing.T) {
 	}
 }
 
+func Test_transferIdempotencyKey(t *testing.T) {
+	testCase := []struct {
+		Name                  string
+		IdempotencyKey        string
+		ExpectationStatusCode int
+		ExpectationReplayed   bool
+	}{
+		{
+			Name:                  "Accepted",
+			IdempotencyKey:        "new-key",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "Replayed",
+			IdempotencyKey:        "replayed-key",
+			ExpectationStatusCode: http.StatusAccepted,
+			ExpectationReplayed:   true,
+		},
+		{
+			Name:                  "KeyReused",
+			IdempotencyKey:        "reused-key",
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "KeyTooLong",
+			IdempotencyKey:        util.RandomString(maxIdempotencyKeyLength + 1),
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	tokenMaker := serverTest.tokenMaker
+
+	for _, tc := range testCase {
+		body, _ := json.Marshal(map[string]interface{}{
+			"from_account_id": 2,
+			"to_account_id":   3,
+			"amount":          10,
+			"currency":        "USD",
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotencyKeyHeader, tc.IdempotencyKey)
+
+		token, _, err := tokenMaker.CreateToken("some-user", time.Minute)
+		if err != nil {
+			t.Fatalf("failed create token error:%s", err)
+		}
+		req.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+		replayed := rr.Header().Get(idempotentReplayedHeader) == "true"
+		if replayed != tc.ExpectationReplayed {
+			t.Fatalf("failed %s wrong replayed header, want %v got %v", tc.Name, tc.ExpectationReplayed, replayed)
+		}
+	}
+}
+
 func Test_createUsers(t *testing.T) {
 	testCases := []struct {
 		Name                  string
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"net/http"
+	"time"
+)
+
+type getAccountBalanceRequest struct {
+	// RFC 3339 time, now if empty
+	AsOf time.Time `form:"as_of"`
+}
+
+// getAccountBalance return the balance of the account at as_of, the sum of its entries created before it.
+// It start from the nearest end of day snapshot so it stay fast for old accounts.
+func (s *Server) getAccountBalance(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req getAccountBalanceRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	now := time.Now()
+	if req.AsOf.IsZero() {
+		req.AsOf = now
+	}
+	if req.AsOf.After(now) {
+		err = errors.New("as_of can't be in the future")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := s.getOwnedAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	balance, err := s.repo.GetAccountBalanceAt(ctx, account.ID, req.AsOf)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, models.AccountBalance{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Balance:   balance,
+		AsOf:      req.AsOf,
+	})
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func Test_getAccountBalance(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		AccountID             int64
+		AsOf                  string
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			AccountID:             2,
+			AsOf:                  "2023-01-31T00:00:00Z",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedNow",
+			AccountID:             2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestFormat",
+			AccountID:             2,
+			AsOf:                  "31-01-2023",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestFuture",
+			AccountID:             2,
+			AsOf:                  time.Now().Add(time.Hour).Format(time.RFC3339),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			AccountID:             1,
+			AsOf:                  "2023-01-31T00:00:00Z",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			AccountID:             2,
+			AsOf:                  "2023-01-31T00:00:00Z",
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "ServerError",
+			AccountID:             2,
+			AsOf:                  "1990-01-31T00:00:00Z",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		path := fmt.Sprintf("/accounts/%d/balance", tc.AccountID)
+		if tc.AsOf != "" {
+			path += "?as_of=" + url.QueryEscape(tc.AsOf)
+		}
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
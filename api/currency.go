@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+func (s *Server) listCurrencies(ctx *gin.Context) {
+	items, err := s.repo.GetListCurrencies(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+type getFxRateRequest struct {
+	Base  string `form:"base" binding:"required,len=3"`
+	Quote string `form:"quote" binding:"required,len=3"`
+}
+
+// getFxRate return the rate currently used to convert base currency to quote currency
+func (s *Server) getFxRate(ctx *gin.Context) {
+	var req getFxRateRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rate, err := s.repo.GetFxRateAt(ctx, req.Base, req.Quote, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if rate.ID < 1 {
+		ctx.JSON(http.StatusNotFound, fmt.Sprintf("no fx rate from %s to %s", req.Base, req.Quote))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, rate)
+}
+
+type listFeeSchedulesRequest struct {
+	Currency string `form:"currency" binding:"required,len=3"`
+}
+
+// listFeeSchedules return the transfer fee schedules of the currency, the default schedule first
+// followed by the schedules of each account product
+func (s *Server) listFeeSchedules(ctx *gin.Context) {
+	var req listFeeSchedulesRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	items, err := s.repo.GetListFeeSchedules(ctx, req.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_crossCurrencyTransfer(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name: "Accepted",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   4,
+				"amount":          10,
+				"currency":        "USD",
+				"to_currency":     "EUR",
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name: "MissingToCurrency",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   4,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "WrongToCurrency",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   4,
+				"amount":          10,
+				"currency":        "USD",
+				"to_currency":     "CAD",
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name: "InsufficientFunds",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   4,
+				"amount":          101,
+				"currency":        "USD",
+				"to_currency":     "EUR",
+			},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, "some-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_getFxRate(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "?base=USD&quote=EUR",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			Query:                 "?base=EUR&quote=USD",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "BadRequest",
+			Query:                 "?base=USD",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/fx_rates"+tc.Query, nil)
+		setAuthorizationHeader(t, req, "some-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_listFeeSchedules(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "?currency=USD",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			Query:                 "?currency=WRONG",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "ServerError",
+			Query:                 "?currency=CAD",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/fee_schedules"+tc.Query, nil)
+		setAuthorizationHeader(t, req, "some-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"github.com/ismail118/simple-bank/repository"
+	"google.golang.org/grpc/codes"
+	"net/http"
+)
+
+// storeErrorStatus map error returned by store transaction to http status code,
+// business rule violations are client errors and everything else is internal server error
+func storeErrorStatus(err error) int {
+	var ruleErr *repository.PayeeRuleError
+	if errors.As(err, &ruleErr) {
+		return payeeRuleStatus(ruleErr)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrAccountNotFound),
+		errors.Is(err, repository.ErrHoldNotFound),
+		errors.Is(err, repository.ErrTransferNotFound),
+		errors.Is(err, repository.ErrExternalPayoutNotFound),
+		errors.Is(err, repository.ErrTransferReviewNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrReactivationNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrIdempotencyKeyReused),
+		errors.Is(err, repository.ErrCurrencyMismatch),
+		errors.Is(err, repository.ErrInsufficientFunds),
+		errors.Is(err, repository.ErrHoldNotAuthorized),
+		errors.Is(err, repository.ErrHoldExpired),
+		errors.Is(err, repository.ErrCaptureExceedsHold),
+		errors.Is(err, repository.ErrReverseReversal),
+		errors.Is(err, repository.ErrReversalExceedsAmount),
+		errors.Is(err, repository.ErrAccountNotActive),
+		errors.Is(err, repository.ErrInvalidStatusChange),
+		errors.Is(err, repository.ErrAccountNotEmpty),
+		errors.Is(err, repository.ErrCurrencyNotFound),
+		errors.Is(err, repository.ErrFxRateNotFound),
+		errors.Is(err, repository.ErrFxAmountTooSmall),
+		errors.Is(err, repository.ErrAccountProductNotFound),
+		errors.Is(err, repository.ErrInterestAlreadyPosted),
+		errors.Is(err, repository.ErrExternalPayoutNotSubmitted),
+		errors.Is(err, repository.ErrTransferLimitExceeded),
+		errors.Is(err, repository.ErrTransferReviewNotPending):
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusInternalServerError
+}
+
+// storeErrorCode is the gRPC counterpart of storeErrorStatus
+func storeErrorCode(err error) codes.Code {
+	switch storeErrorStatus(err) {
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	}
+
+	return codes.Internal
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ismail118/simple-bank/repository"
+	"google.golang.org/grpc/codes"
+	"net/http"
+	"testing"
+)
+
+func Test_storeError(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Err        error
+		StatusCode int
+		GrpcCode   codes.Code
+	}{
+		{
+			Name:       "InsufficientFunds",
+			Err:        fmt.Errorf("%w: account 1", repository.ErrInsufficientFunds),
+			StatusCode: http.StatusUnprocessableEntity,
+			GrpcCode:   codes.FailedPrecondition,
+		},
+		{
+			Name:       "TransferLimit",
+			Err:        &repository.TransferLimitError{Period: "daily", Limit: 1000, Remaining: 200, Currency: "USD"},
+			StatusCode: http.StatusUnprocessableEntity,
+			GrpcCode:   codes.FailedPrecondition,
+		},
+		{
+			Name:       "NotFound",
+			Err:        repository.ErrAccountNotFound,
+			StatusCode: http.StatusNotFound,
+			GrpcCode:   codes.NotFound,
+		},
+		{
+			Name:       "ReactivationNotAllowed",
+			Err:        fmt.Errorf("%w: account 1 is dormant", repository.ErrReactivationNotAllowed),
+			StatusCode: http.StatusForbidden,
+			GrpcCode:   codes.PermissionDenied,
+		},
+		{
+			Name:       "PayeeRule",
+			Err:        &repository.PayeeRuleError{Message: "account 1 must be added as payee first"},
+			StatusCode: http.StatusForbidden,
+			GrpcCode:   codes.PermissionDenied,
+		},
+		{
+			Name:       "PayeeCoolingOff",
+			Err:        &repository.PayeeRuleError{CoolingOff: true, Message: "payee 1 is limited"},
+			StatusCode: http.StatusUnprocessableEntity,
+			GrpcCode:   codes.FailedPrecondition,
+		},
+		{
+			Name:       "Internal",
+			Err:        errors.New("some error"),
+			StatusCode: http.StatusInternalServerError,
+			GrpcCode:   codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := storeErrorStatus(tc.Err); got != tc.StatusCode {
+			t.Fatalf("failed %s wrong status code, want %d got %d", tc.Name, tc.StatusCode, got)
+		}
+		if got := storeErrorCode(tc.Err); got != tc.GrpcCode {
+			t.Fatalf("failed %s wrong grpc code, want %s got %s", tc.Name, tc.GrpcCode, got)
+		}
+	}
+}
+
+func Test_errorResponseTransferLimit(t *testing.T) {
+	limitErr := &repository.TransferLimitError{Period: "daily", Limit: 1000, Remaining: 200, Currency: "USD"}
+
+	res := errorResponse(fmt.Errorf("transfer batch item 1: %w", limitErr))
+	if res["transfer_limit"] != limitErr {
+		t.Fatalf("failed transfer limit missing from response %v", res)
+	}
+
+	res = errorResponse(repository.ErrInsufficientFunds)
+	if _, ok := res["transfer_limit"]; ok {
+		t.Fatalf("failed unexpected transfer limit in response %v", res)
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/activity"
 	"github.com/ismail118/simple-bank/models"
 	pb "github.com/ismail118/simple-bank/proto"
 	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/risk"
 	"github.com/ismail118/simple-bank/token"
 	"github.com/ismail118/simple-bank/util"
 	"github.com/ismail118/simple-bank/worker"
@@ -15,7 +16,6 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
-	"time"
 )
 
 // GrpcServer serves gRPC request for our banking service
@@ -26,6 +26,8 @@ type GrpcServer struct {
 	tokenMaker      token.Maker
 	config          *util.Config
 	taskDistributor worker.TaskDistributor
+	riskEngine      *risk.Engine
+	activity        *activity.Hub
 }
 
 // NewGrpcServer create a new gRPC server and setup routing
@@ -35,6 +37,7 @@ func NewGrpcServer(
 	tokenMaker token.Maker,
 	config *util.Config,
 	taskDistributor worker.TaskDistributor,
+	activityHub *activity.Hub,
 ) *GrpcServer {
 	server := &GrpcServer{
 		store:           store,
@@ -42,6 +45,8 @@ func NewGrpcServer(
 		tokenMaker:      tokenMaker,
 		config:          config,
 		taskDistributor: taskDistributor,
+		riskEngine:      newRiskEngine(repo, config),
+		activity:        activityHub,
 	}
 
 	return server
@@ -81,25 +86,10 @@ func (s *GrpcServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		Email:          req.GetEmail(),
 	}
 
-	result, err := s.store.CreateUserTx(ctx, user, func(user models.Users) error {
-		// Send verify email to user
+	result, err := s.store.CreateUserTx(ctx, user, func(user models.Users) (models.OutboxMessage, error) {
+		// Send verify email to user once the user is committed
 		taskPayload := &worker.PayloadSendVerifyEmail{Username: user.Username}
-		opts := []asynq.Option{
-			asynq.MaxRetry(10),
-			asynq.ProcessIn(10 * time.Second),
-			asynq.Queue(worker.QueueCritical),
-		}
-
-		err = s.taskDistributor.DistributeTaskSendVerifyEmail(
-			ctx,
-			taskPayload,
-			opts...,
-		)
-		if err != nil {
-			return err
-		}
-
-		return nil
+		return worker.NewOutboxMessage(worker.TaskSendVerifyEmail, taskPayload, worker.QueueCritical, 10)
 	})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed create user tx err:%s", err)
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *GrpcServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateCreateAccountRequest(req)
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	acc := models.Account{
+		Owner:     authPayload.Username,
+		Balance:   0,
+		Currency:  req.GetCurrency(),
+		Status:    models.AccountStatusActive,
+		ProductID: req.GetProductId(),
+	}
+
+	currency, err := s.repo.GetCurrency(ctx, req.GetCurrency())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if currency.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "currency %s not supported", req.GetCurrency())
+	}
+
+	if req.GetProductId() > 0 {
+		product, err := s.repo.GetAccountProduct(ctx, req.GetProductId())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "err:%s", err)
+		}
+		if product.ID < 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "account product %d not found", req.GetProductId())
+		}
+		if product.Currency != req.GetCurrency() {
+			return nil, status.Errorf(codes.InvalidArgument, "account product %d mismatch: %s vs %s", product.ID, product.Currency, req.GetCurrency())
+		}
+	}
+
+	user, err := s.repo.GetUsersByUsername(ctx, acc.Owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if user.Username == "" {
+		return nil, status.Errorf(codes.PermissionDenied, "user with username %s not exists", acc.Owner)
+	}
+
+	a, err := s.repo.GetAccountByOwnerAndCurrency(ctx, acc.Owner, acc.Currency)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if a.ID > 0 {
+		return nil, status.Errorf(codes.AlreadyExists, "account with owner %s and %s alredy exists", acc.Owner, acc.Currency)
+	}
+
+	acc.ID, err = s.repo.InsertAccount(ctx, acc)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	return &pb.CreateAccountResponse{Account: util.ConvertAccount(acc)}, nil
+}
+
+func (s *GrpcServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateIDRequest(req.GetId())
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	account, err := s.getOwnedAccount(ctx, req.GetId(), authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: util.ConvertAccount(account)}, nil
+}
+
+// getOwnedAccount return the account when it belong to username, otherwise the status error to return
+func (s *GrpcServer) getOwnedAccount(ctx context.Context, id int64, username string) (models.Account, error) {
+	account, err := s.repo.GetAccountByID(ctx, id)
+	if err != nil {
+		return account, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if account.ID < 1 {
+		return account, status.Error(codes.NotFound, "account not found")
+	}
+
+	// authorization
+	if account.Owner != username {
+		return account, status.Errorf(codes.PermissionDenied, "account doesn't belong to user login")
+	}
+
+	return account, nil
+}
+
+func (s *GrpcServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateCursorListRequest(req.GetSize(), req.GetCursor())
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+	afterID, _ := util.DecodeCursor(req.GetCursor())
+
+	size := int(req.GetSize())
+	accounts, err := s.repo.GetListAccounts(ctx, authPayload.Username, afterID, size+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	accounts, nextCursor := cursorPage(accounts, size, func(a *models.Account) int64 { return a.ID })
+
+	res := &pb.ListAccountsResponse{
+		Accounts:   make([]*pb.Account, 0, len(accounts)),
+		NextCursor: nextCursor,
+	}
+	for _, a := range accounts {
+		res.Accounts = append(res.Accounts, util.ConvertAccount(*a))
+	}
+
+	return res, nil
+}
+
+func (s *GrpcServer) UpdateAccount(ctx context.Context, req *pb.UpdateAccountRequest) (*pb.UpdateAccountResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateUpdateAccountRequest(req)
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	account, err := s.getOwnedAccount(ctx, req.GetId(), authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// the balance only move with ledger entries and the currency can't change under the entries already posted,
+	// writing them here would skip the overdraft, status and hold checks and break the reconciliation
+	if req.GetBalance() != account.Balance {
+		return nil, status.Errorf(codes.InvalidArgument, "balance:can't be updated, money is moved by transfers")
+	}
+	if req.GetCurrency() != account.Currency {
+		return nil, status.Errorf(codes.InvalidArgument, "currency:can't be updated, open an account in %s instead", req.GetCurrency())
+	}
+
+	return &pb.UpdateAccountResponse{Account: util.ConvertAccount(account)}, nil
+}
+
+func (s *GrpcServer) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateIDRequest(req.GetId())
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	account, err := s.getOwnedAccount(ctx, req.GetId(), authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	// the account is closed instead of deleted so its ledger history is kept
+	res, err := s.store.ChangeAccountStatusTx(ctx, models.AccountStatusHistory{
+		AccountID: account.ID,
+		ToStatus:  models.AccountStatusClosed,
+		Reason:    "closed by owner",
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		return nil, status.Errorf(storeErrorCode(err), "err:%s", err)
+	}
+
+	return &pb.DeleteAccountResponse{Account: util.ConvertAccount(res.Account)}, nil
+}
+
+func (s *GrpcServer) GetEntry(ctx context.Context, req *pb.GetEntryRequest) (*pb.GetEntryResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateIDRequest(req.GetId())
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	entry, err := s.repo.GetEntryByID(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if entry.ID < 1 {
+		return nil, status.Error(codes.NotFound, "entry not found")
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, entry.AccountID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	// authorization
+	if account.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "entry doesn't belong to user login")
+	}
+
+	return &pb.GetEntryResponse{Entry: util.ConvertEntry(entry)}, nil
+}
+
+func (s *GrpcServer) ListEntries(ctx context.Context, req *pb.ListEntriesRequest) (*pb.ListEntriesResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateListEntriesRequest(req)
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	_, err = s.getOwnedAccount(ctx, req.GetAccountId(), authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	afterID, _ := util.DecodeCursor(req.GetCursor())
+	filter := models.EntryFilter{
+		AccountID: req.GetAccountId(),
+		MinAmount: req.GetMinAmount(),
+		MaxAmount: req.GetMaxAmount(),
+		Direction: req.GetDirection(),
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+
+	size := int(req.GetSize())
+	entries, err := s.repo.GetListEntries(ctx, filter, afterID, size+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	entries, nextCursor := cursorPage(entries, size, func(e *models.Entry) int64 { return e.ID })
+
+	res := &pb.ListEntriesResponse{
+		Entries:    make([]*pb.Entry, 0, len(entries)),
+		NextCursor: nextCursor,
+	}
+	for _, e := range entries {
+		res.Entries = append(res.Entries, util.ConvertEntry(*e))
+	}
+
+	return res, nil
+}
+
+func validateIDRequest(id int64) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateID(id)
+	if err != nil {
+		violation = append(violation, util.FieldViolation("id", err))
+	}
+
+	return violation
+}
+
+func validateCursorListRequest(size int32, cursor string) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateRange(int64(size), 1, maxPageSize)
+	if err != nil {
+		violation = append(violation, util.FieldViolation("size", err))
+	}
+
+	_, err = util.DecodeCursor(cursor)
+	if err != nil {
+		violation = append(violation, util.FieldViolation("cursor", err))
+	}
+
+	return violation
+}
+
+// validateCreatedRange check the optional [from, to) range of the list filters
+func validateCreatedRange(from, to *timestamppb.Timestamp) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	if from != nil && from.CheckValid() != nil {
+		violation = append(violation, util.FieldViolation("from", fmt.Errorf("invalid timestamp")))
+	}
+	if to != nil && to.CheckValid() != nil {
+		violation = append(violation, util.FieldViolation("to", fmt.Errorf("invalid timestamp")))
+	}
+	if violation == nil && from != nil && to != nil && !to.AsTime().After(from.AsTime()) {
+		violation = append(violation, util.FieldViolation("to", fmt.Errorf("must be after from")))
+	}
+
+	return violation
+}
+
+func validateCreateAccountRequest(req *pb.CreateAccountRequest) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateCurrency(req.GetCurrency())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("currency", err))
+	}
+
+	if req.GetProductId() < 0 {
+		violation = append(violation, util.FieldViolation("product_id", fmt.Errorf("must not be negative")))
+	}
+
+	return violation
+}
+
+func validateUpdateAccountRequest(req *pb.UpdateAccountRequest) []*errdetails.BadRequest_FieldViolation {
+	violation := validateIDRequest(req.GetId())
+
+	err := util.ValidateCurrency(req.GetCurrency())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("currency", err))
+	}
+
+	return violation
+}
+
+func validateListEntriesRequest(req *pb.ListEntriesRequest) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateID(req.GetAccountId())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("account_id", err))
+	}
+
+	violation = append(violation, validateCursorListRequest(req.GetSize(), req.GetCursor())...)
+	violation = append(violation, validateCreatedRange(req.GetFrom(), req.GetTo())...)
+
+	if req.GetMinAmount() < 0 {
+		violation = append(violation, util.FieldViolation("min_amount", fmt.Errorf("must not be negative")))
+	}
+	if req.GetMaxAmount() != 0 && req.GetMaxAmount() < req.GetMinAmount() {
+		violation = append(violation, util.FieldViolation("max_amount", fmt.Errorf("must not be less than min_amount")))
+	}
+
+	switch req.GetDirection() {
+	case "", models.EntryDirectionCredit, models.EntryDirectionDebit:
+	default:
+		violation = append(violation, util.FieldViolation("direction", fmt.Errorf("must be credit or debit")))
+	}
+
+	return violation
+}
@@ -0,0 +1,347 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"testing"
+	"time"
+)
+
+// grpcAuthContext return an incoming context with the bearer token of the given username
+func grpcAuthContext(t *testing.T, username string) context.Context {
+	token, _, err := grpcServerTest.tokenMaker.CreateToken(username, time.Minute)
+	if err != nil {
+		t.Fatalf("failed create token error:%s", err)
+	}
+	md := metadata.Pairs(authorizationHeader, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func Test_Grpc_CreateAccount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *pb.CreateAccountRequest
+		username string
+		code     codes.Code
+	}{
+		{
+			name:     "ok",
+			req:      &pb.CreateAccountRequest{Currency: "USD"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "ok-product",
+			req:      &pb.CreateAccountRequest{Currency: "USD", ProductId: 2},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			// some-user closed its JPY account, a new one can be opened
+			name:     "ok-closed-currency",
+			req:      &pb.CreateAccountRequest{Currency: "JPY"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "already-exists",
+			req:      &pb.CreateAccountRequest{Currency: "CAD"},
+			username: "some-user",
+			code:     codes.AlreadyExists,
+		},
+		{
+			name:     "invalid-currency",
+			req:      &pb.CreateAccountRequest{Currency: "US"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "unsupported-currency",
+			req:      &pb.CreateAccountRequest{Currency: "XXX"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "product-mismatch",
+			req:      &pb.CreateAccountRequest{Currency: "EUR", ProductId: 2},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "user-not-found",
+			req:      &pb.CreateAccountRequest{Currency: "USD"},
+			username: "user",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "internal-error",
+			req:      &pb.CreateAccountRequest{Currency: "USD"},
+			username: "test-error-db",
+			code:     codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.CreateAccount(grpcAuthContext(t, tc.username), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err != nil {
+			continue
+		}
+		if res.GetAccount().GetOwner() != tc.username || res.GetAccount().GetCurrency() != tc.req.GetCurrency() {
+			t.Fatalf("failed %s wrong account %v", tc.name, res.GetAccount())
+		}
+	}
+
+	_, err := grpcServerTest.CreateAccount(context.Background(), &pb.CreateAccountRequest{Currency: "USD"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("failed unauthenticated wrong code, got %s", status.Code(err))
+	}
+}
+
+func Test_Grpc_GetAccount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       int64
+		username string
+		code     codes.Code
+	}{
+		{name: "ok", id: 2, username: "some-user", code: codes.OK},
+		{name: "invalid-id", id: 0, username: "some-user", code: codes.InvalidArgument},
+		{name: "not-found", id: 1, username: "some-user", code: codes.NotFound},
+		{name: "permission-denied", id: 2, username: "other-user", code: codes.PermissionDenied},
+		{name: "internal-error", id: 1001, username: "some-user", code: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.GetAccount(grpcAuthContext(t, tc.username), &pb.GetAccountRequest{Id: tc.id})
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err == nil && res.GetAccount().GetId() != tc.id {
+			t.Fatalf("failed %s wrong account id %d", tc.name, res.GetAccount().GetId())
+		}
+	}
+}
+
+func Test_Grpc_ListAccounts(t *testing.T) {
+	testCases := []struct {
+		name       string
+		req        *pb.ListAccountsRequest
+		code       codes.Code
+		ids        []int64
+		nextCursor string
+	}{
+		{name: "ok", req: &pb.ListAccountsRequest{Size: 5}, code: codes.OK, ids: []int64{2, 3}},
+		{name: "first-page", req: &pb.ListAccountsRequest{Size: 1}, code: codes.OK, ids: []int64{2}, nextCursor: util.EncodeCursor(2)},
+		{name: "next-page", req: &pb.ListAccountsRequest{Size: 1, Cursor: util.EncodeCursor(2)}, code: codes.OK, ids: []int64{3}},
+		{name: "invalid-size", req: &pb.ListAccountsRequest{Size: 101}, code: codes.InvalidArgument},
+		{name: "invalid-cursor", req: &pb.ListAccountsRequest{Size: 5, Cursor: "abc"}, code: codes.InvalidArgument},
+		{name: "internal-error", req: &pb.ListAccountsRequest{Size: 5, Cursor: util.EncodeCursor(1001)}, code: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.ListAccounts(grpcAuthContext(t, "some-user"), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err != nil {
+			continue
+		}
+
+		ids := make([]int64, 0, len(res.GetAccounts()))
+		for _, a := range res.GetAccounts() {
+			ids = append(ids, a.GetId())
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(tc.ids) || res.GetNextCursor() != tc.nextCursor {
+			t.Fatalf("failed %s wrong page, want %v %q got %v %q", tc.name, tc.ids, tc.nextCursor, ids, res.GetNextCursor())
+		}
+	}
+}
+
+func Test_Grpc_UpdateAccount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *pb.UpdateAccountRequest
+		username string
+		code     codes.Code
+	}{
+		{
+			name:     "ok-unchanged",
+			req:      &pb.UpdateAccountRequest{Id: 2, Balance: 100, Currency: "USD"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "balance-change",
+			req:      &pb.UpdateAccountRequest{Id: 2, Balance: 1000000, Currency: "USD"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "currency-change",
+			req:      &pb.UpdateAccountRequest{Id: 2, Balance: 100, Currency: "EUR"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "invalid-currency",
+			req:      &pb.UpdateAccountRequest{Id: 2, Balance: 100, Currency: "USDX"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "permission-denied",
+			req:      &pb.UpdateAccountRequest{Id: 2, Balance: 100, Currency: "USD"},
+			username: "other-user",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "internal-error",
+			req:      &pb.UpdateAccountRequest{Id: 1001, Balance: 100, Currency: "USD"},
+			username: "some-user",
+			code:     codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.UpdateAccount(grpcAuthContext(t, tc.username), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err != nil {
+			if res != nil {
+				t.Fatalf("failed %s refused update return account %v", tc.name, res.GetAccount())
+			}
+			continue
+		}
+		if res.GetAccount().GetBalance() != 100 || res.GetAccount().GetCurrency() != "USD" {
+			t.Fatalf("failed %s account changed %v", tc.name, res.GetAccount())
+		}
+	}
+}
+
+func Test_Grpc_DeleteAccount(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       int64
+		username string
+		code     codes.Code
+	}{
+		{name: "ok", id: 2, username: "some-user", code: codes.OK},
+		{name: "not-found", id: 1, username: "some-user", code: codes.NotFound},
+		{name: "permission-denied", id: 2, username: "other-user", code: codes.PermissionDenied},
+	}
+
+	for _, tc := range testCases {
+		_, err := grpcServerTest.DeleteAccount(grpcAuthContext(t, tc.username), &pb.DeleteAccountRequest{Id: tc.id})
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+	}
+}
+
+func Test_Grpc_GetEntry(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       int64
+		username string
+		code     codes.Code
+	}{
+		{name: "ok", id: 2, username: "some-user", code: codes.OK},
+		{name: "not-found", id: 1, username: "some-user", code: codes.NotFound},
+		{name: "permission-denied", id: 2, username: "other-user", code: codes.PermissionDenied},
+		{name: "internal-error", id: 1001, username: "some-user", code: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.GetEntry(grpcAuthContext(t, tc.username), &pb.GetEntryRequest{Id: tc.id})
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err == nil && res.GetEntry().GetId() != tc.id {
+			t.Fatalf("failed %s wrong entry id %d", tc.name, res.GetEntry().GetId())
+		}
+	}
+}
+
+func Test_Grpc_ListEntries(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *pb.ListEntriesRequest
+		username string
+		code     codes.Code
+	}{
+		{
+			name:     "ok",
+			req:      &pb.ListEntriesRequest{AccountId: 2, Size: 5},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name: "ok-filter",
+			req: &pb.ListEntriesRequest{
+				AccountId: 2,
+				Size:      5,
+				From:      timestamppb.New(time.Now().Add(-time.Hour)),
+				To:        timestamppb.Now(),
+				MinAmount: 10,
+				MaxAmount: 100,
+				Direction: models.EntryDirectionDebit,
+			},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "invalid-range",
+			req:      &pb.ListEntriesRequest{AccountId: 2, Size: 5, From: timestamppb.Now(), To: timestamppb.New(time.Now().Add(-time.Hour))},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "invalid-amount",
+			req:      &pb.ListEntriesRequest{AccountId: 2, Size: 5, MinAmount: 100, MaxAmount: 10},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "invalid-direction",
+			req:      &pb.ListEntriesRequest{AccountId: 2, Size: 5, Direction: "both"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "invalid-account-id",
+			req:      &pb.ListEntriesRequest{AccountId: 0, Size: 5},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "permission-denied",
+			req:      &pb.ListEntriesRequest{AccountId: 2, Size: 5},
+			username: "other-user",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "internal-error",
+			req:      &pb.ListEntriesRequest{AccountId: 2, Size: 5, Cursor: util.EncodeCursor(1001)},
+			username: "some-user",
+			code:     codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := grpcServerTest.ListEntries(grpcAuthContext(t, tc.username), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+	}
+}
@@ -58,6 +58,13 @@ func (rec *ResponseWriterRecorder) Write(body []byte) (int, error) {
 	return rec.ResponseWriter.Write(body)
 }
 
+// Flush let the streaming handlers flush through the recorder
+func (rec *ResponseWriterRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func HttpGatewayInterceptorLogger(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		logger := log.Info()
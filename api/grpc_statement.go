@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/statement"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const statementDateLayout = "2006-01-02"
+
+var statementFormats = map[string]bool{
+	statement.FormatCSV:     true,
+	statement.FormatPDF:     true,
+	statement.FormatOFX:     true,
+	statement.FormatCamt053: true,
+	statement.FormatMT940:   true,
+}
+
+// GetAccountStatement is the gRPC counterpart of getAccountStatement, periods longer than
+// maxDownloadStatementDays are emailed to the owner and the response has no content
+func (s *GrpcServer) GetAccountStatement(ctx context.Context, req *pb.GetAccountStatementRequest) (*pb.GetAccountStatementResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateGetAccountStatementRequest(req)
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+	from, _ := time.Parse(statementDateLayout, req.GetFrom())
+	to, _ := time.Parse(statementDateLayout, req.GetTo())
+
+	days, err := statementDays(from, to)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s", err)
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, req.GetAccountId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if account.ID < 1 {
+		return nil, status.Error(codes.NotFound, "account not found")
+	}
+
+	// authorization
+	if account.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account doesn't belong to user login")
+	}
+
+	if days > maxDownloadStatementDays {
+		payload := &worker.PayloadSendStatement{
+			AccountID: account.ID,
+			From:      from,
+			To:        to,
+			Format:    req.GetFormat(),
+		}
+		err = s.taskDistributor.DistributeTaskSendStatement(ctx, payload,
+			asynq.Queue(worker.QueueDefault),
+			asynq.MaxRetry(3),
+		)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to distribute task send statement err:%s", err)
+		}
+
+		return &pb.GetAccountStatementResponse{Emailed: true}, nil
+	}
+
+	st, err := statement.Load(ctx, s.repo, account, from, to)
+	if err != nil {
+		return nil, status.Errorf(storeErrorCode(err), "failed to load statement err:%s", err)
+	}
+	data, contentType, err := statement.Render(st, req.GetFormat())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to render statement err:%s", err)
+	}
+
+	return &pb.GetAccountStatementResponse{
+		FileName:    statement.FileName(st, req.GetFormat()),
+		ContentType: contentType,
+		Content:     data,
+	}, nil
+}
+
+func validateGetAccountStatementRequest(req *pb.GetAccountStatementRequest) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateID(req.GetAccountId())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("account_id", err))
+	}
+
+	_, err = time.Parse(statementDateLayout, req.GetFrom())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("from", fmt.Errorf("must be a date formatted as YYYY-MM-DD")))
+	}
+
+	_, err = time.Parse(statementDateLayout, req.GetTo())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("to", fmt.Errorf("must be a date formatted as YYYY-MM-DD")))
+	}
+
+	if !statementFormats[req.GetFormat()] {
+		violation = append(violation, util.FieldViolation("format", fmt.Errorf("must be one of csv, pdf, ofx, camt053 or mt940")))
+	}
+
+	return violation
+}
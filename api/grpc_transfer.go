@@ -0,0 +1,338 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/risk"
+	"github.com/ismail118/simple-bank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+func (s *GrpcServer) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateIDRequest(req.GetId())
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	transfer, err := s.repo.GetTransferByID(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if transfer.ID < 1 {
+		return nil, status.Error(codes.NotFound, "transfer not found")
+	}
+
+	err = s.authorizeTransferAccounts(ctx, transfer.FromAccountID, transfer.ToAccountID, authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	reversals, err := s.repo.GetListReversals(ctx, transfer.ID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	res := &pb.GetTransferResponse{
+		Transfer:  util.ConvertTransfer(transfer),
+		Reversals: make([]*pb.Transfer, 0, len(reversals)),
+	}
+	for _, r := range reversals {
+		res.Reversals = append(res.Reversals, util.ConvertTransfer(*r))
+	}
+
+	return res, nil
+}
+
+// authorizeTransferAccounts return the status error to return when neither account belong to username
+func (s *GrpcServer) authorizeTransferAccounts(ctx context.Context, fromAccountID, toAccountID int64, username string) error {
+	fAccount, err := s.repo.GetAccountByID(ctx, fromAccountID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, toAccountID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	// authorization
+	if fAccount.Owner != username && tAccount.Owner != username {
+		return status.Errorf(codes.PermissionDenied, "transfer doesn't belong to user login")
+	}
+
+	return nil
+}
+
+func (s *GrpcServer) ListTransfers(ctx context.Context, req *pb.ListTransfersRequest) (*pb.ListTransfersResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateListTransfersRequest(req)
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	_, err = s.getOwnedAccount(ctx, req.GetAccountId(), authPayload.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	afterID, _ := util.DecodeCursor(req.GetCursor())
+	filter := models.TransferFilter{
+		AccountID:             req.GetAccountId(),
+		CounterpartyAccountID: req.GetCounterpartyAccountId(),
+		Status:                req.GetStatus(),
+	}
+	if req.GetFrom() != nil {
+		filter.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		filter.To = req.GetTo().AsTime()
+	}
+
+	size := int(req.GetSize())
+	transfers, err := s.repo.GetListTransfers(ctx, filter, afterID, size+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	transfers, nextCursor := cursorPage(transfers, size, func(t *models.Transfer) int64 { return t.ID })
+
+	res := &pb.ListTransfersResponse{
+		Transfers:  make([]*pb.Transfer, 0, len(transfers)),
+		NextCursor: nextCursor,
+	}
+	for _, t := range transfers {
+		res.Transfers = append(res.Transfers, util.ConvertTransfer(*t))
+	}
+
+	return res, nil
+}
+
+// Transfer is the gRPC counterpart of transfer, the payee rules, the risk rules and the idempotency key
+// apply the same way. A transfer parked by the risk rules return only the review
+func (s *GrpcServer) Transfer(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return nil, util.UnauthenticatedError(err)
+	}
+
+	violations := validateTransferRequest(req)
+	if violations != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	fAccount, err := s.repo.GetAccountByID(ctx, req.GetFromAccountId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if fAccount.ID < 1 {
+		return nil, status.Error(codes.NotFound, "from account not found")
+	}
+	if fAccount.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account %d mismatch: %s vs %s", fAccount.ID, fAccount.Currency, req.GetCurrency())
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, req.GetToAccountId())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	if tAccount.ID < 1 {
+		return nil, status.Error(codes.NotFound, "to account not found")
+	}
+	// converting to another currency must be asked explicitly with the currency of the to account
+	crossCurrency := tAccount.Currency != req.GetCurrency()
+	if crossCurrency && tAccount.Currency != req.GetToCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account %d mismatch: %s vs %s", tAccount.ID, tAccount.Currency, req.GetCurrency())
+	}
+
+	// authorization
+	if authPayload.Username != fAccount.Owner {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to user login")
+	}
+
+	err = verifyPayee(ctx, s.repo, s.config, fAccount, tAccount, req.GetAmount())
+	if err != nil {
+		var ruleErr *repository.PayeeRuleError
+		if !errors.As(err, &ruleErr) {
+			return nil, status.Errorf(codes.Internal, "err:%s", err)
+		}
+		if ruleErr.CoolingOff {
+			return nil, status.Error(codes.FailedPrecondition, ruleErr.Message)
+		}
+		return nil, status.Error(codes.PermissionDenied, ruleErr.Message)
+	}
+
+	toCurrency := ""
+	if crossCurrency {
+		toCurrency = req.GetToCurrency()
+	}
+	mtdt := s.extractMetadata(ctx)
+	a, review, err := screenTransfer(ctx, s.riskEngine, s.repo, risk.Transfer{
+		Username:    authPayload.Username,
+		FromAccount: fAccount,
+		ToAccount:   tAccount,
+		Amount:      req.GetAmount(),
+		UserAgent:   mtdt.UserAgent,
+		ClientIP:    mtdt.ClientIP,
+	}, toCurrency, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, status.Errorf(storeErrorCode(err), "err:%s", err)
+	}
+	switch a.Decision {
+	case models.RiskDecisionDeny:
+		return nil, status.Error(codes.PermissionDenied, riskDeniedMessage(a.Findings))
+	case models.RiskDecisionReview:
+		return &pb.TransferResponse{Review: util.ConvertTransferReview(review)}, nil
+	}
+
+	tf := models.Transfer{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	}
+
+	if req.GetIdempotencyKey() == "" {
+		var res repository.TransferTxResult
+		if crossCurrency {
+			res, err = s.store.CrossCurrencyTransferTx(ctx, tf)
+		} else {
+			res, err = s.store.TransferTx(ctx, tf)
+		}
+		if err != nil {
+			return nil, status.Errorf(storeErrorCode(err), "err:%s", err)
+		}
+
+		return convertTransferTxResult(res), nil
+	}
+
+	// hashed as the request of the gin server so a key is bound to the same transfer on both
+	requestHash, err := hashRequest(transferRequest{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Currency:      req.GetCurrency(),
+		ToCurrency:    req.GetToCurrency(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	key := models.IdempotencyKey{
+		Username:       authPayload.Username,
+		IdempotencyKey: req.GetIdempotencyKey(),
+		RequestHash:    requestHash,
+	}
+	res, err := s.store.IdempotentTransferTx(ctx, key, tf)
+	if err != nil {
+		return nil, status.Errorf(storeErrorCode(err), "err:%s", err)
+	}
+
+	rsp := convertTransferTxResult(res.TransferTxResult)
+	rsp.Replayed = res.Replayed
+
+	return rsp, nil
+}
+
+func convertTransferTxResult(res repository.TransferTxResult) *pb.TransferResponse {
+	return &pb.TransferResponse{
+		Transfer:        util.ConvertTransfer(res.Transfer),
+		FromAccount:     util.ConvertAccount(res.FromAccount),
+		ToAccount:       util.ConvertAccount(res.ToAccount),
+		FromEntry:       util.ConvertEntry(res.FromEntry),
+		ToEntry:         util.ConvertEntry(res.ToEntry),
+		FeeEntry:        util.ConvertEntry(res.FeeEntry),
+		FeeRevenueEntry: util.ConvertEntry(res.FeeRevenueEntry),
+	}
+}
+
+// riskDeniedMessage describe the findings of a transfer denied by the risk rules
+func riskDeniedMessage(findings []*models.RiskFinding) string {
+	reasons := make([]string, 0, len(findings))
+	for _, f := range findings {
+		if f.Decision == models.RiskDecisionDeny {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", f.Rule, f.Reason))
+		}
+	}
+
+	return fmt.Sprintf("transfer denied by the risk rules: %s", strings.Join(reasons, "; "))
+}
+
+func validateListTransfersRequest(req *pb.ListTransfersRequest) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateID(req.GetAccountId())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("account_id", err))
+	}
+
+	if req.GetCounterpartyAccountId() < 0 {
+		violation = append(violation, util.FieldViolation("counterparty_account_id", fmt.Errorf("must not be negative")))
+	}
+
+	violation = append(violation, validateCursorListRequest(req.GetSize(), req.GetCursor())...)
+	violation = append(violation, validateCreatedRange(req.GetFrom(), req.GetTo())...)
+
+	switch req.GetStatus() {
+	case "", models.TransferStatusPosted, models.TransferStatusReversed, models.TransferStatusReversal:
+	default:
+		violation = append(violation, util.FieldViolation("status", fmt.Errorf("must be posted, reversed or reversal")))
+	}
+
+	return violation
+}
+
+func validateTransferRequest(req *pb.TransferRequest) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	err := util.ValidateID(req.GetFromAccountId())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("from_account_id", err))
+	}
+
+	err = util.ValidateID(req.GetToAccountId())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("to_account_id", err))
+	}
+
+	err = util.ValidateID(req.GetAmount())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("amount", err))
+	}
+
+	err = util.ValidateCurrency(req.GetCurrency())
+	if err != nil {
+		violation = append(violation, util.FieldViolation("currency", err))
+	}
+
+	if req.GetToCurrency() != "" {
+		err = util.ValidateCurrency(req.GetToCurrency())
+		if err != nil {
+			violation = append(violation, util.FieldViolation("to_currency", err))
+		}
+	}
+
+	err = util.ValidateString(req.GetIdempotencyKey(), 0, maxIdempotencyKeyLength)
+	if err != nil {
+		violation = append(violation, util.FieldViolation("idempotency_key", err))
+	}
+
+	return violation
+}
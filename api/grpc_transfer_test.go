@@ -0,0 +1,264 @@
+package api
+
+import (
+	"context"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/activity"
+	"github.com/ismail118/simple-bank/models"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/worker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+	"time"
+)
+
+func Test_Grpc_GetTransfer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		id       int64
+		username string
+		code     codes.Code
+	}{
+		{name: "ok", id: 2, username: "some-user", code: codes.OK},
+		{name: "not-found", id: 1, username: "some-user", code: codes.NotFound},
+		{name: "permission-denied", id: 2, username: "other-user", code: codes.PermissionDenied},
+		{name: "internal-error", id: 1001, username: "some-user", code: codes.Internal},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.GetTransfer(grpcAuthContext(t, tc.username), &pb.GetTransferRequest{Id: tc.id})
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err == nil && (res.GetTransfer().GetId() != tc.id || len(res.GetReversals()) != 1) {
+			t.Fatalf("failed %s wrong response %v", tc.name, res)
+		}
+	}
+}
+
+func Test_Grpc_ListTransfers(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *pb.ListTransfersRequest
+		username string
+		code     codes.Code
+	}{
+		{
+			name:     "ok",
+			req:      &pb.ListTransfersRequest{AccountId: 2, Size: 5},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "ok-filter",
+			req:      &pb.ListTransfersRequest{AccountId: 4, Size: 5, CounterpartyAccountId: 2, Status: models.TransferStatusReversed},
+			username: "other-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "invalid-size",
+			req:      &pb.ListTransfersRequest{AccountId: 2, Size: 0},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "invalid-status",
+			req:      &pb.ListTransfersRequest{AccountId: 2, Size: 5, Status: "pending"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "permission-denied",
+			req:      &pb.ListTransfersRequest{AccountId: 2, Size: 5},
+			username: "other-user",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "internal-error",
+			req:      &pb.ListTransfersRequest{AccountId: 2, Size: 5, Cursor: util.EncodeCursor(1001)},
+			username: "some-user",
+			code:     codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := grpcServerTest.ListTransfers(grpcAuthContext(t, tc.username), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+	}
+}
+
+func Test_Grpc_Transfer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *pb.TransferRequest
+		username string
+		code     codes.Code
+		replayed bool
+	}{
+		{
+			name:     "ok",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "USD"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "ok-cross-currency",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 4, Amount: 10, Currency: "USD", ToCurrency: "EUR"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "ok-idempotent",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "USD", IdempotencyKey: "new-key"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "replayed",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "USD", IdempotencyKey: "replayed-key"},
+			username: "some-user",
+			code:     codes.OK,
+			replayed: true,
+		},
+		{
+			name:     "key-reused",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "USD", IdempotencyKey: "reused-key"},
+			username: "some-user",
+			code:     codes.FailedPrecondition,
+		},
+		{
+			name:     "invalid-amount",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 0, Currency: "USD"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "currency-mismatch",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "EUR"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "conversion-not-asked",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 4, Amount: 10, Currency: "USD"},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "from-account-not-found",
+			req:      &pb.TransferRequest{FromAccountId: 1, ToAccountId: 3, Amount: 10, Currency: "USD"},
+			username: "some-user",
+			code:     codes.NotFound,
+		},
+		{
+			name:     "permission-denied",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "USD"},
+			username: "other-user",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "insufficient-funds",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 101, Currency: "USD"},
+			username: "some-user",
+			code:     storeErrorCode(repository.ErrInsufficientFunds),
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := grpcServerTest.Transfer(grpcAuthContext(t, tc.username), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err != nil {
+			continue
+		}
+		if res.GetReplayed() != tc.replayed || res.GetReview() != nil {
+			t.Fatalf("failed %s wrong response %v", tc.name, res)
+		}
+	}
+
+	_, err := grpcServerTest.Transfer(context.Background(), &pb.TransferRequest{FromAccountId: 2, ToAccountId: 3, Amount: 10, Currency: "USD"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("failed unauthenticated wrong code, got %s", status.Code(err))
+	}
+}
+
+func Test_Grpc_TransferRules(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey:        grpcServerTest.config.TokenSymmetricKey,
+		AccessTokenDuration:      time.Minute,
+		PayeeCoolingOff:          24 * time.Hour,
+		PayeeCoolingOffLimit:     util.CurrencyAmounts{"USD": 70},
+		RequirePayee:             true,
+		RiskVelocityMaxTransfers: 5,
+		RiskVelocityWindow:       time.Minute,
+		RiskFirstPayeeMinAmount:  50,
+	}
+	server := NewGrpcServer(
+		repository.NewStoreMock(nil),
+		repository.NewPostgresRepoMock(nil),
+		grpcServerTest.tokenMaker,
+		&config,
+		worker.NewRedisTaskDistributorMock(asynq.RedisClientOpt{}),
+		activity.NewHub(),
+	)
+
+	testCases := []struct {
+		name     string
+		req      *pb.TransferRequest
+		username string
+		code     codes.Code
+		parked   bool
+	}{
+		{
+			name:     "allowed",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 4, Amount: 10, Currency: "USD", ToCurrency: "EUR"},
+			username: "some-user",
+			code:     codes.OK,
+		},
+		{
+			name:     "review-first-time-payee",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 4, Amount: 60, Currency: "USD", ToCurrency: "EUR"},
+			username: "some-user",
+			code:     codes.OK,
+			parked:   true,
+		},
+		{
+			name:     "review-retried",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 4, Amount: 60, Currency: "USD", ToCurrency: "EUR", IdempotencyKey: "parked-key"},
+			username: "some-user",
+			code:     codes.OK,
+			parked:   true,
+		},
+		{
+			name:     "payee-cooling-off",
+			req:      &pb.TransferRequest{FromAccountId: 2, ToAccountId: 4, Amount: 80, Currency: "USD", ToCurrency: "EUR"},
+			username: "some-user",
+			code:     codes.FailedPrecondition,
+		},
+		{
+			name:     "not-payee",
+			req:      &pb.TransferRequest{FromAccountId: 4, ToAccountId: 2, Amount: 5, Currency: "EUR", ToCurrency: "USD"},
+			username: "other-user",
+			code:     codes.PermissionDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := server.Transfer(grpcAuthContext(t, tc.username), tc.req)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+		if err != nil {
+			continue
+		}
+		if (res.GetReview() != nil) != tc.parked {
+			t.Fatalf("failed %s want parked %t got %v", tc.name, tc.parked, res)
+		}
+	}
+}
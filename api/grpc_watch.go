@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/ismail118/simple-bank/activity"
+	"github.com/ismail118/simple-bank/models"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"net/http"
+	"strconv"
+)
+
+// watchPageSize is how many accounts or entries are loaded at once when a watch start or resume
+const watchPageSize = 100
+
+// lastEventIDHeader is sent by a reconnecting EventSource with the id of the last event it received
+const lastEventIDHeader = "Last-Event-ID"
+
+// watchEventMarshaler encode the events like the gateway encode its responses
+var watchEventMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+// accountWatch follow the entries posted to the watched accounts of a user
+type accountWatch struct {
+	server    *GrpcServer
+	username  string
+	accountID int64
+	// account id to whether it belong to the user, accounts opened during the watch are looked up once
+	owned       map[int64]bool
+	events      <-chan activity.Event
+	unsubscribe func()
+}
+
+// startWatch check the user can watch the account and subscribe to the activity, accountID 0 watch every
+// account of the user. The watch must be closed
+func (s *GrpcServer) startWatch(ctx context.Context, username string, accountID int64) (*accountWatch, error) {
+	w := &accountWatch{
+		server:    s,
+		username:  username,
+		accountID: accountID,
+		owned:     make(map[int64]bool),
+	}
+
+	if accountID > 0 {
+		_, err := s.getOwnedAccount(ctx, accountID, username)
+		if err != nil {
+			return nil, err
+		}
+		w.owned[accountID] = true
+	} else {
+		var afterID int64
+		for {
+			accounts, err := s.repo.GetListAccounts(ctx, username, afterID, watchPageSize)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "err:%s", err)
+			}
+			for _, a := range accounts {
+				w.owned[a.ID] = true
+				afterID = a.ID
+			}
+			if len(accounts) < watchPageSize {
+				break
+			}
+		}
+	}
+
+	// subscribed before the resume so an entry posted in between isn't missed
+	w.events, w.unsubscribe = s.activity.Subscribe()
+
+	return w, nil
+}
+
+func (w *accountWatch) Close() {
+	w.unsubscribe()
+}
+
+// run send the entries posted after afterEntryID, then the new entries until ctx is done
+func (w *accountWatch) run(ctx context.Context, afterEntryID int64, send func(*pb.AccountActivity) error) error {
+	// entries sent by the resume may be published again by the hub
+	resumed := make(map[int64]bool)
+	if afterEntryID > 0 {
+		accountIDs := make([]int64, 0, len(w.owned))
+		for id := range w.owned {
+			accountIDs = append(accountIDs, id)
+		}
+
+		for {
+			entries, err := w.server.repo.GetListEntriesOfAccountsAfter(ctx, accountIDs, afterEntryID, watchPageSize)
+			if err != nil {
+				return status.Errorf(codes.Internal, "err:%s", err)
+			}
+			for _, e := range entries {
+				err = w.send(ctx, *e, send)
+				if err != nil {
+					return err
+				}
+				resumed[e.ID] = true
+				afterEntryID = e.ID
+			}
+			if len(entries) < watchPageSize {
+				break
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-w.events:
+			if !ok {
+				return status.Error(codes.Aborted, "watch fell behind, resume after the last entry seen")
+			}
+			if resumed[e.EntryID] {
+				continue
+			}
+
+			watched, err := w.watches(ctx, e.AccountID)
+			if err != nil {
+				return err
+			}
+			if !watched {
+				continue
+			}
+
+			entry, err := w.server.repo.GetEntryByID(ctx, e.EntryID)
+			if err != nil {
+				return status.Errorf(codes.Internal, "err:%s", err)
+			}
+			if entry.ID < 1 {
+				continue
+			}
+			err = w.send(ctx, entry, send)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *accountWatch) watches(ctx context.Context, accountID int64) (bool, error) {
+	if w.accountID > 0 {
+		return accountID == w.accountID, nil
+	}
+
+	owned, ok := w.owned[accountID]
+	if ok {
+		return owned, nil
+	}
+
+	account, err := w.server.repo.GetAccountByID(ctx, accountID)
+	if err != nil {
+		return false, status.Errorf(codes.Internal, "err:%s", err)
+	}
+	w.owned[accountID] = account.Owner == w.username
+
+	return w.owned[accountID], nil
+}
+
+func (w *accountWatch) send(ctx context.Context, entry models.Entry, send func(*pb.AccountActivity) error) error {
+	account, err := w.server.repo.GetAccountByID(ctx, entry.AccountID)
+	if err != nil {
+		return status.Errorf(codes.Internal, "err:%s", err)
+	}
+
+	return send(&pb.AccountActivity{
+		Entry:   util.ConvertEntry(entry),
+		Account: util.ConvertAccount(account),
+	})
+}
+
+// WatchAccount stream the entries posted to the accounts of the user with the account balance, a client
+// that reconnect resume with the id of the last entry it received
+func (s *GrpcServer) WatchAccount(req *pb.WatchAccountRequest, stream pb.SimpleBank_WatchAccountServer) error {
+	ctx := stream.Context()
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		return util.UnauthenticatedError(err)
+	}
+
+	violations := validateWatchAccountRequest(req)
+	if violations != nil {
+		return status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description)
+	}
+
+	w, err := s.startWatch(ctx, authPayload.Username, req.GetAccountId())
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	return w.run(ctx, req.GetAfterEntryId(), stream.Send)
+}
+
+// WatchAccountEvents serve WatchAccount as server-sent events for the gateway, it take the account_id and
+// after_entry_id query parameters. The id of each event is the entry id so a reconnecting EventSource resume
+// with its Last-Event-ID header
+func (s *GrpcServer) WatchAccountEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// the gateway pass the authorization header as metadata, so does this handler
+	md := metadata.Pairs(authorizationHeader, r.Header.Get(authorizationHeader))
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	// authorization
+	authPayload, err := s.authorization(ctx)
+	if err != nil {
+		writeStatusError(w, util.UnauthenticatedError(err))
+		return
+	}
+
+	req, err := parseWatchAccountEventsRequest(r)
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+	violations := validateWatchAccountRequest(req)
+	if violations != nil {
+		writeStatusError(w, status.Errorf(codes.InvalidArgument, "%s:%s", violations[0].Field, violations[0].Description))
+		return
+	}
+
+	watch, err := s.startWatch(ctx, authPayload.Username, req.GetAccountId())
+	if err != nil {
+		writeStatusError(w, err)
+		return
+	}
+	defer watch.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err = watch.run(ctx, req.GetAfterEntryId(), func(a *pb.AccountActivity) error {
+		data, err := watchEventMarshaler.Marshal(a)
+		if err != nil {
+			return status.Errorf(codes.Internal, "err:%s", err)
+		}
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", a.GetEntry().GetId(), data)
+		if err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil {
+		// the client reconnect and resume from the last event id
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", status.Convert(err).Message())
+		flusher.Flush()
+	}
+}
+
+func parseWatchAccountEventsRequest(r *http.Request) (*pb.WatchAccountRequest, error) {
+	req := &pb.WatchAccountRequest{}
+	query := r.URL.Query()
+
+	var err error
+	if v := query.Get("account_id"); v != "" {
+		req.AccountId, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "account_id:must be a number")
+		}
+	}
+
+	// Last-Event-ID is newer than the after_entry_id of the url the EventSource was opened with
+	after := query.Get("after_entry_id")
+	if v := r.Header.Get(lastEventIDHeader); v != "" {
+		after = v
+	}
+	if after != "" {
+		req.AfterEntryId, err = strconv.ParseInt(after, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "after_entry_id:must be a number")
+		}
+	}
+
+	return req, nil
+}
+
+// writeStatusError write the status error with the http status the gateway would use
+func writeStatusError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	http.Error(w, st.Message(), runtime.HTTPStatusFromCode(st.Code()))
+}
+
+func validateWatchAccountRequest(req *pb.WatchAccountRequest) []*errdetails.BadRequest_FieldViolation {
+	var violation []*errdetails.BadRequest_FieldViolation
+	if req.GetAccountId() < 0 {
+		violation = append(violation, util.FieldViolation("account_id", fmt.Errorf("must not be negative")))
+	}
+
+	if req.GetAfterEntryId() < 0 {
+		violation = append(violation, util.FieldViolation("after_entry_id", fmt.Errorf("must not be negative")))
+	}
+
+	return violation
+}
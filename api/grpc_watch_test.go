@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/activity"
+	pb "github.com/ismail118/simple-bank/proto"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/worker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type watchAccountStreamMock struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *pb.AccountActivity
+}
+
+func (m *watchAccountStreamMock) Context() context.Context {
+	return m.ctx
+}
+
+func (m *watchAccountStreamMock) Send(a *pb.AccountActivity) error {
+	m.sent <- a
+	return nil
+}
+
+func newWatchServer(hub *activity.Hub) *GrpcServer {
+	return NewGrpcServer(
+		repository.NewStoreMock(nil),
+		repository.NewPostgresRepoMock(nil),
+		grpcServerTest.tokenMaker,
+		grpcServerTest.config,
+		worker.NewRedisTaskDistributorMock(asynq.RedisClientOpt{}),
+		hub,
+	)
+}
+
+func receiveActivity(t *testing.T, sent <-chan *pb.AccountActivity) *pb.AccountActivity {
+	select {
+	case a := <-sent:
+		return a
+	case <-time.After(time.Second):
+		t.Fatalf("no activity received")
+		return nil
+	}
+}
+
+func Test_Grpc_WatchAccount(t *testing.T) {
+	hub := activity.NewHub()
+	server := newWatchServer(hub)
+
+	ctx, cancel := context.WithCancel(grpcAuthContext(t, "some-user"))
+	stream := &watchAccountStreamMock{ctx: ctx, sent: make(chan *pb.AccountActivity, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.WatchAccount(&pb.WatchAccountRequest{AccountId: 2, AfterEntryId: 2}, stream)
+	}()
+
+	// the resume send the entries posted after 2, the watch is subscribed by then
+	a := receiveActivity(t, stream.sent)
+	if a.GetEntry().GetId() != 3 || a.GetAccount().GetId() != 2 || a.GetAccount().GetBalance() != 100 {
+		t.Fatalf("wrong resumed activity %v", a)
+	}
+
+	// entry 3 was sent by the resume and account 4 isn't watched
+	hub.Publish(activity.Event{EntryID: 3, AccountID: 2})
+	hub.Publish(activity.Event{EntryID: 7, AccountID: 4})
+	hub.Publish(activity.Event{EntryID: 2, AccountID: 2})
+	a = receiveActivity(t, stream.sent)
+	if a.GetEntry().GetId() != 2 {
+		t.Fatalf("wrong new activity %v", a)
+	}
+
+	cancel()
+	err := <-done
+	if err != nil {
+		t.Fatalf("watch should end without error when the client leave got %s", err)
+	}
+}
+
+func Test_Grpc_WatchAccountDropped(t *testing.T) {
+	hub := activity.NewHub()
+	server := newWatchServer(hub)
+
+	ctx, cancel := context.WithCancel(grpcAuthContext(t, "some-user"))
+	defer cancel()
+	stream := &watchAccountStreamMock{ctx: ctx, sent: make(chan *pb.AccountActivity, 10)}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.WatchAccount(&pb.WatchAccountRequest{AccountId: 2, AfterEntryId: 2}, stream)
+	}()
+	receiveActivity(t, stream.sent)
+
+	hub.Reset()
+	err := <-done
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("dropped watch want %s got %s", codes.Aborted, status.Code(err))
+	}
+}
+
+func Test_Grpc_WatchAccountErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		req      *pb.WatchAccountRequest
+		username string
+		code     codes.Code
+	}{
+		{
+			name:     "invalid-after-entry-id",
+			req:      &pb.WatchAccountRequest{AccountId: 2, AfterEntryId: -1},
+			username: "some-user",
+			code:     codes.InvalidArgument,
+		},
+		{
+			name:     "not-found",
+			req:      &pb.WatchAccountRequest{AccountId: 1},
+			username: "some-user",
+			code:     codes.NotFound,
+		},
+		{
+			name:     "permission-denied",
+			req:      &pb.WatchAccountRequest{AccountId: 2},
+			username: "other-user",
+			code:     codes.PermissionDenied,
+		},
+		{
+			name:     "resume-error",
+			req:      &pb.WatchAccountRequest{AccountId: 2, AfterEntryId: 1001},
+			username: "some-user",
+			code:     codes.Internal,
+		},
+	}
+
+	for _, tc := range testCases {
+		stream := &watchAccountStreamMock{ctx: grpcAuthContext(t, tc.username), sent: make(chan *pb.AccountActivity, 10)}
+		err := grpcServerTest.WatchAccount(tc.req, stream)
+		if status.Code(err) != tc.code {
+			t.Fatalf("failed %s wrong code, want %s got %s", tc.name, tc.code, status.Code(err))
+		}
+	}
+
+	stream := &watchAccountStreamMock{ctx: context.Background()}
+	err := grpcServerTest.WatchAccount(&pb.WatchAccountRequest{AccountId: 2}, stream)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("failed unauthenticated wrong code, got %s", status.Code(err))
+	}
+}
+
+func Test_WatchAccountEvents(t *testing.T) {
+	server := newWatchServer(activity.NewHub())
+	srv := httptest.NewServer(http.HandlerFunc(server.WatchAccountEvents))
+	defer srv.Close()
+
+	token, _, err := grpcServerTest.tokenMaker.CreateToken("some-user", time.Minute)
+	if err != nil {
+		t.Fatalf("failed create token error:%s", err)
+	}
+	authorization := fmt.Sprintf("%s %s", authorizationTypeBearer, token)
+
+	testCases := []struct {
+		name          string
+		query         string
+		authorization string
+		lastEventID   string
+		code          int
+		ids           []string
+	}{
+		{
+			name:          "resume-after-entry-id",
+			query:         "?account_id=2&after_entry_id=1",
+			authorization: authorization,
+			code:          http.StatusOK,
+			ids:           []string{"id: 2", "id: 3"},
+		},
+		{
+			name:          "resume-last-event-id",
+			query:         "?account_id=2&after_entry_id=1",
+			authorization: authorization,
+			lastEventID:   "2",
+			code:          http.StatusOK,
+			ids:           []string{"id: 3"},
+		},
+		{
+			name:          "invalid-account-id",
+			query:         "?account_id=abc",
+			authorization: authorization,
+			code:          http.StatusBadRequest,
+		},
+		{
+			name:          "not-found",
+			query:         "?account_id=1",
+			authorization: authorization,
+			code:          http.StatusNotFound,
+		},
+		{
+			name:  "unauthenticated",
+			query: "?account_id=2",
+			code:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+tc.query, nil)
+		req.Header.Set("Authorization", tc.authorization)
+		if tc.lastEventID != "" {
+			req.Header.Set(lastEventIDHeader, tc.lastEventID)
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed %s request error:%s", tc.name, err)
+		}
+		if res.StatusCode != tc.code {
+			t.Fatalf("failed %s wrong status code, want %d got %d", tc.name, tc.code, res.StatusCode)
+		}
+
+		scanner := bufio.NewScanner(res.Body)
+		var ids []string
+		for len(ids) < len(tc.ids) && scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "id: ") {
+				ids = append(ids, scanner.Text())
+			}
+		}
+		if strings.Join(ids, ",") != strings.Join(tc.ids, ",") {
+			t.Fatalf("failed %s wrong events, want %v got %v", tc.name, tc.ids, ids)
+		}
+
+		cancel()
+		res.Body.Close()
+	}
+}
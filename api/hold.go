@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/token"
+	"io"
+	"net/http"
+	"time"
+)
+
+type authorizeHoldRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,len=3"`
+}
+
+// authorizeHold reserve funds of the authenticated user account for the destination account
+func (s *Server) authorizeHold(ctx *gin.Context) {
+	var req authorizeHoldRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fAccount, err := s.repo.GetAccountByID(ctx, req.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if fAccount.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "from account not found")
+		return
+	}
+	if fAccount.Currency != req.Currency {
+		ctx.JSON(http.StatusBadRequest, fmt.Sprintf("account %d mismatch: %s vs %s", fAccount.ID, fAccount.Currency, req.Currency))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fAccount.Owner {
+		err = errors.New("from account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, req.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if tAccount.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "to account not found")
+		return
+	}
+	if !s.checkPayee(ctx, fAccount, tAccount, req.Amount) {
+		return
+	}
+
+	hold := models.Hold{
+		AccountID:   req.FromAccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		ExpiredAt:   time.Now().Add(s.config.HoldDuration),
+	}
+	res, err := s.store.AuthorizeTransferTx(ctx, hold)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+func (s *Server) getHold(ctx *gin.Context) {
+	var req getByIdRequest
+
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hold, err := s.repo.GetHoldByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if hold.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "hold not found")
+		return
+	}
+
+	fAccount, err := s.repo.GetAccountByID(ctx, hold.AccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if (fAccount.Owner != authPayload.Username) && (tAccount.Owner != authPayload.Username) {
+		err = errors.New("hold doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, hold)
+}
+
+type captureHoldRequest struct {
+	// Amount 0 capture the whole held amount
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+// captureHold settle the hold, only the owner of the destination account that receive the money can capture it
+func (s *Server) captureHold(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// body is optional, empty body capture the whole held amount
+	var req captureHoldRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ok := s.authorizeHoldReceiver(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	res, err := s.store.CaptureHoldTx(ctx, uri.ID, req.Amount)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// voidHold cancel the hold, only the owner of the destination account can give up the reserved funds
+func (s *Server) voidHold(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	ok := s.authorizeHoldReceiver(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	res, err := s.store.VoidHoldTx(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// authorizeHoldReceiver check the hold exist and its destination account belong to authenticated user,
+// it write the error response and return false if not
+func (s *Server) authorizeHoldReceiver(ctx *gin.Context, holdID int64) bool {
+	hold, err := s.repo.GetHoldByID(ctx, holdID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	if hold.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "hold not found")
+		return false
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, hold.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if tAccount.Owner != authPayload.Username {
+		err = errors.New("hold destination account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_authorizeHold(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:     "Accepted",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:     "BadRequest",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          0,
+				"currency":        "USD",
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "NotFound",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 1,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:     "Unauthorized",
+			Username: "other-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+			},
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:     "InsufficientFunds",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          1000,
+				"currency":        "USD",
+			},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/holds", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_captureHold(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		HoldID                int64
+		Username              string
+		ReqBody               string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			HoldID:                2,
+			Username:              "some-user",
+			ReqBody:               `{"amount": 5}`,
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedEmptyBody",
+			HoldID:                2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "ExceedsHold",
+			HoldID:                2,
+			Username:              "some-user",
+			ReqBody:               `{"amount": 50}`,
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "NotFound",
+			HoldID:                1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "ServerError",
+			HoldID:                1001,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:                  "Unauthorized",
+			HoldID:                2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/holds/%d/capture", tc.HoldID), bytes.NewReader([]byte(tc.ReqBody)))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_voidHold(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		HoldID                int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			HoldID:                2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			HoldID:                1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			HoldID:                2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/holds/%d/void", tc.HoldID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/token"
+	"net/http"
+)
+
+func (s *Server) listAccountProducts(ctx *gin.Context) {
+	items, err := s.repo.GetListAccountProducts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+// listInterestPostings return the monthly interest credited to the account, the latest first
+func (s *Server) listInterestPostings(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, uri.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "account not found")
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err = errors.New("account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	items, err := s.repo.GetListInterestPostings(ctx,
+		account.ID,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
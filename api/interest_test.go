@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_listInterestPostings(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		AccountID             int64
+		Query                 string
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			AccountID:             2,
+			Query:                 "page=1&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			AccountID:             2,
+			Query:                 "page=0&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			AccountID:             1,
+			Query:                 "page=1&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			AccountID:             2,
+			Query:                 "page=1&size=5",
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "ServerError",
+			AccountID:             2,
+			Query:                 "page=1000&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/interest_postings?%s", tc.AccountID, tc.Query), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
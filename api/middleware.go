@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
 	"github.com/ismail118/simple-bank/token"
 	"net/http"
 	"strings"
@@ -56,3 +58,24 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// adminMiddleware only let admin users through, it must be used after authMiddleware.
+// The role is read from the database so revoking it take effect before the token expire.
+func adminMiddleware(repo repository.Repository) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		user, err := repo.GetUsersByUsername(ctx, authPayload.Username)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if user.Role != models.UserRoleAdmin {
+			err = errors.New("authenticated user is not an admin")
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(err))
+			return
+		}
+
+		ctx.Next()
+	}
+}
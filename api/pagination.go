@@ -0,0 +1,27 @@
+package api
+
+import (
+	"github.com/ismail118/simple-bank/util"
+)
+
+// maxPageSize is the largest page of the cursor paginated lists
+const maxPageSize = 100
+
+// cursorListRequest is the paging of the lists of accounts, entries and transfers. Pages start after the
+// last row of the previous page instead of skipping rows, so rows inserted meanwhile don't shift them
+type cursorListRequest struct {
+	// next_cursor of the previous page, empty for the first page
+	Cursor string `json:"cursor" form:"cursor"`
+	Size   int    `json:"size" form:"size" binding:"required,min=1,max=100"`
+}
+
+// cursorPage trim the extra row loaded after the page, the rows are loaded with a limit of size+1 to know
+// whether another page exist. It return the cursor of the next page, empty for the last page
+func cursorPage[T any](items []*T, size int, id func(*T) int64) ([]*T, string) {
+	if len(items) <= size {
+		return items, ""
+	}
+
+	items = items[:size]
+	return items, util.EncodeCursor(id(items[size-1]))
+}
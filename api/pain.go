@@ -0,0 +1,264 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/pain"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	maxPain001FileSize     = 10 << 20
+	maxPain001Transactions = 1000
+)
+
+// importPain001 execute the credit transfers of an uploaded pain.001 file from the accounts of the
+// authenticated user and answer with a pain.002 report of the status of every transaction.
+// Each transaction is an idempotent transfer keyed by its position in the file, so uploading the
+// same file again replays the posted transfers instead of paying twice.
+func (s *Server) importPain001(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if fileHeader.Size > maxPain001FileSize {
+		err = fmt.Errorf("file must not be larger than %d bytes", maxPain001FileSize)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	in, err := pain.Parse(data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if in.CountTransactions() > maxPain001Transactions {
+		err = fmt.Errorf("file must not have more than %d transactions", maxPain001Transactions)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	now := time.Now()
+
+	// a file whose totals don't match is rejected as a whole without moving any money
+	groupReason, groupInfo := in.Check()
+
+	imp := pain001Import{
+		server:     s,
+		ctx:        ctx,
+		username:   authPayload.Username,
+		messageID:  in.MessageID,
+		today:      now.UTC().Format("2006-01-02"),
+		accounts:   make(map[int64]models.Account),
+		currencies: make(map[string]models.Currency),
+	}
+	report := pain.StatusReport{
+		MessageID: "PAIN002-" + now.UTC().Format("20060102150405.000000"),
+		CreatedAt: now,
+		Original:  in,
+	}
+	for _, p := range in.PaymentInfos {
+		ps := pain.PaymentInfoStatus{PaymentInfo: p}
+		for i, t := range p.Transactions {
+			var status pain.TransactionStatus
+			if groupReason != "" {
+				status = rejectPain001(t, groupReason, groupInfo)
+			} else {
+				status = imp.execute(p, i)
+			}
+			ps.Transactions = append(ps.Transactions, status)
+		}
+		report.PaymentInfos = append(report.PaymentInfos, ps)
+	}
+
+	res, err := pain.RenderStatusReport(report)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="pain002-%s.xml"`, report.MessageID))
+	ctx.Data(http.StatusOK, "application/xml", res)
+}
+
+// pain001Import hold the accounts and currencies already read while executing the transactions of a file
+type pain001Import struct {
+	server     *Server
+	ctx        *gin.Context
+	username   string
+	messageID  string
+	today      string
+	accounts   map[int64]models.Account
+	currencies map[string]models.Currency
+}
+
+// execute validate the i-th transaction of the payment information and post it
+func (imp *pain001Import) execute(p pain.PaymentInfo, i int) pain.TransactionStatus {
+	t := p.Transactions[i]
+
+	if p.Method != "TRF" {
+		return rejectPain001(t, pain.ReasonNarrative, "payment method must be TRF")
+	}
+	if !p.ExecutionDate.IsZero() && p.ExecutionDate.Format("2006-01-02") > imp.today {
+		return rejectPain001(t, pain.ReasonInvalidDate, "future execution dates are not supported")
+	}
+
+	fAccount, err := imp.account(p.DebtorAccount)
+	if err != nil {
+		return rejectPain001Error(t, err)
+	}
+	if fAccount.ID < 1 {
+		return rejectPain001(t, pain.ReasonIncorrectAccountNumber, "debtor account not found")
+	}
+	// authorization
+	if fAccount.Owner != imp.username {
+		return rejectPain001(t, pain.ReasonTransactionForbidden, "debtor account doesn't belong to authenticated user")
+	}
+	if (p.DebtorCurrency != "" && p.DebtorCurrency != fAccount.Currency) || t.Currency != fAccount.Currency {
+		return rejectPain001(t, pain.ReasonNotAllowedCurrency, fmt.Sprintf("debtor account currency is %s", fAccount.Currency))
+	}
+
+	tAccount, err := imp.account(t.CreditorAccount)
+	if err != nil {
+		return rejectPain001Error(t, err)
+	}
+	if tAccount.ID < 1 {
+		return rejectPain001(t, pain.ReasonInvalidCreditorAccount, "creditor account not found")
+	}
+	if tAccount.Currency != t.Currency {
+		return rejectPain001(t, pain.ReasonNotAllowedCurrency, fmt.Sprintf("creditor account currency is %s", tAccount.Currency))
+	}
+
+	currency, err := imp.currency(t.Currency)
+	if err != nil {
+		return rejectPain001Error(t, err)
+	}
+	amount, err := util.ParseAmount(t.Amount, currency.MinorUnit)
+	if err != nil {
+		return rejectPain001(t, pain.ReasonInvalidAmount, err.Error())
+	}
+	if amount == 0 {
+		return rejectPain001(t, pain.ReasonZeroAmount, "amount must be positive")
+	}
+
+	err = verifyPayee(imp.ctx, imp.server.repo, imp.server.config, fAccount, tAccount, amount)
+	if err != nil {
+		return rejectPain001Error(t, err)
+	}
+
+	tf := models.Transfer{
+		FromAccountID: fAccount.ID,
+		ToAccountID:   tAccount.ID,
+		Amount:        amount,
+	}
+	requestHash, err := hashRequest(tf)
+	if err != nil {
+		return rejectPain001Error(t, err)
+	}
+	key := models.IdempotencyKey{
+		Username:       imp.username,
+		IdempotencyKey: fmt.Sprintf("pain001/%s/%s/%d", imp.messageID, p.ID, i+1),
+		RequestHash:    requestHash,
+	}
+	res, err := imp.server.store.IdempotentTransferTx(imp.ctx, key, tf)
+	if err != nil {
+		return rejectPain001Error(t, err)
+	}
+
+	return pain.TransactionStatus{
+		Transaction: t,
+		Status:      pain.StatusAccepted,
+		TransferID:  res.Transfer.ID,
+	}
+}
+
+// account return the account from its id written in the file, an empty account if the id is not valid or unknown
+func (imp *pain001Import) account(id string) (models.Account, error) {
+	accountID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || accountID < 1 {
+		return models.Account{}, nil
+	}
+	if acc, ok := imp.accounts[accountID]; ok {
+		return acc, nil
+	}
+
+	acc, err := imp.server.repo.GetAccountByID(imp.ctx, accountID)
+	if err != nil {
+		return acc, err
+	}
+	imp.accounts[accountID] = acc
+	return acc, nil
+}
+
+func (imp *pain001Import) currency(code string) (models.Currency, error) {
+	if c, ok := imp.currencies[code]; ok {
+		return c, nil
+	}
+
+	c, err := imp.server.repo.GetCurrency(imp.ctx, code)
+	if err != nil {
+		return c, err
+	}
+	if c.Code == "" {
+		return c, fmt.Errorf("%w: %s", repository.ErrCurrencyNotFound, code)
+	}
+	imp.currencies[code] = c
+	return c, nil
+}
+
+func rejectPain001(t pain.Transaction, reason, info string) pain.TransactionStatus {
+	return pain.TransactionStatus{
+		Transaction:    t,
+		Status:         pain.StatusRejected,
+		Reason:         reason,
+		AdditionalInfo: info,
+	}
+}
+
+// rejectPain001Error map the error of the store to the reason code of the rejected transaction,
+// internal errors are not detailed in the report
+func rejectPain001Error(t pain.Transaction, err error) pain.TransactionStatus {
+	var ruleErr *repository.PayeeRuleError
+	if errors.As(err, &ruleErr) {
+		if ruleErr.CoolingOff {
+			return rejectPain001(t, pain.ReasonNotAllowedAmount, ruleErr.Message)
+		}
+		return rejectPain001(t, pain.ReasonTransactionForbidden, ruleErr.Message)
+	}
+
+	switch {
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return rejectPain001(t, pain.ReasonInsufficientFunds, err.Error())
+	case errors.Is(err, repository.ErrAccountNotActive):
+		return rejectPain001(t, pain.ReasonBlockedAccount, err.Error())
+	case errors.Is(err, repository.ErrIdempotencyKeyReused):
+		return rejectPain001(t, pain.ReasonDuplication, "message id already used by a different file")
+	case errors.Is(err, repository.ErrCurrencyNotFound):
+		return rejectPain001(t, pain.ReasonNotAllowedCurrency, err.Error())
+	case storeErrorStatus(err) == http.StatusUnprocessableEntity:
+		return rejectPain001(t, pain.ReasonNarrative, err.Error())
+	}
+	return rejectPain001(t, pain.ReasonNarrative, "internal error, upload the file again later")
+}
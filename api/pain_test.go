@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testPainTransaction struct {
+	CreditorAccount string
+	Currency        string
+	Amount          string
+}
+
+// testPain001 build a pain.001 file with one payment information debiting the account
+func testPain001(debtorAccount, ctrlSum string, txs ...testPainTransaction) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"><CstmrCdtTrfInitn>
+<GrpHdr><MsgId>TEST-1</MsgId><CreDtTm>2023-03-28T10:00:00</CreDtTm><NbOfTxs>%d</NbOfTxs><CtrlSum>%s</CtrlSum></GrpHdr>
+<PmtInf><PmtInfId>P1</PmtInfId><PmtMtd>TRF</PmtMtd><ReqdExctnDt>2023-03-28</ReqdExctnDt>
+<DbtrAcct><Id><Othr><Id>%s</Id></Othr></Id></DbtrAcct>`, len(txs), ctrlSum, debtorAccount)
+	for i, tx := range txs {
+		fmt.Fprintf(&b, `<CdtTrfTxInf><PmtId><EndToEndId>E2E-%d</EndToEndId></PmtId><Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+<CdtrAcct><Id><Othr><Id>%s</Id></Othr></Id></CdtrAcct></CdtTrfTxInf>`, i+1, tx.Currency, tx.Amount, tx.CreditorAccount)
+	}
+	b.WriteString(`</PmtInf></CstmrCdtTrfInitn></Document>`)
+	return b.String()
+}
+
+type testPain002 struct {
+	GroupStatus  string `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>GrpSts"`
+	Transactions []struct {
+		EndToEndID string `xml:"OrgnlEndToEndId"`
+		Status     string `xml:"TxSts"`
+		Reason     string `xml:"StsRsnInf>Rsn>Cd"`
+	} `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts>TxInfAndSts"`
+}
+
+func Test_importPain001(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		File                  string
+		Username              string
+		ExpectationStatusCode int
+		ExpectationGroup      string
+		ExpectationReasons    []string
+	}{
+		{
+			Name: "OKPartiallyAccepted",
+			File: testPain001("2", "",
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"},
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "5.00"},
+				testPainTransaction{CreditorAccount: "4", Currency: "USD", Amount: "0.50"},
+				testPainTransaction{CreditorAccount: "99", Currency: "USD", Amount: "0.50"},
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.505"},
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0"},
+				testPainTransaction{CreditorAccount: "3", Currency: "EUR", Amount: "0.50"},
+			),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationGroup:      "PART",
+			ExpectationReasons:    []string{"", "AM04", "AM03", "AC03", "AM12", "AM01", "AM03"},
+		},
+		{
+			Name: "OKAccepted",
+			File: testPain001("2", "1.00",
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"},
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"},
+			),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationGroup:      "ACSC",
+			ExpectationReasons:    []string{"", ""},
+		},
+		{
+			Name: "RejectedControlSum",
+			File: testPain001("2", "2.00",
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"},
+				testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"},
+			),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationGroup:      "RJCT",
+			ExpectationReasons:    []string{"AM10", "AM10"},
+		},
+		{
+			Name:                  "RejectedNotOwner",
+			File:                  testPain001("4", "", testPainTransaction{CreditorAccount: "2", Currency: "EUR", Amount: "0.50"}),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationGroup:      "RJCT",
+			ExpectationReasons:    []string{"AG01"},
+		},
+		{
+			Name:                  "RejectedDebtorNotFound",
+			File:                  testPain001("DE89370400440532013000", "", testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"}),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationGroup:      "RJCT",
+			ExpectationReasons:    []string{"AC01"},
+		},
+		{
+			Name:                  "RejectedInternalError",
+			File:                  testPain001("1001", "", testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.50"}),
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationGroup:      "RJCT",
+			ExpectationReasons:    []string{"NARR"},
+		},
+		{
+			Name:                  "BadRequestNotPain001",
+			File:                  "from,to,amount\n2,3,10",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestNoFile",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		if tc.File != "" {
+			part, _ := w.CreateFormFile("file", "payments.xml")
+			part.Write([]byte(tc.File))
+		}
+		w.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/transfer/pain001", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+		if rr.Code != http.StatusOK {
+			continue
+		}
+
+		var report testPain002
+		err := xml.Unmarshal(rr.Body.Bytes(), &report)
+		if err != nil {
+			t.Fatalf("failed %s parse pain.002 error:%s", tc.Name, err)
+		}
+		if report.GroupStatus != tc.ExpectationGroup {
+			t.Fatalf("failed %s wrong group status, want %s got %s", tc.Name, tc.ExpectationGroup, report.GroupStatus)
+		}
+		if len(report.Transactions) != len(tc.ExpectationReasons) {
+			t.Fatalf("failed %s wrong transactions, want %d got %d", tc.Name, len(tc.ExpectationReasons), len(report.Transactions))
+		}
+		for i, tx := range report.Transactions {
+			if tx.Reason != tc.ExpectationReasons[i] {
+				t.Fatalf("failed %s transaction %s wrong reason, want %q got %q", tc.Name, tx.EndToEndID, tc.ExpectationReasons[i], tx.Reason)
+			}
+		}
+	}
+}
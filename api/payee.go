@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/worker"
+	"net/http"
+	"time"
+)
+
+type createPayeeRequest struct {
+	AccountID int64  `json:"account_id" binding:"required,min=1"`
+	Nickname  string `json:"nickname" binding:"required,max=64"`
+}
+
+// createPayee save the account of another user as a payee of the authenticated user, transfers to the payee
+// are limited until its cooling-off period end and the owner is emailed so an unknown payee can be deleted
+func (s *Server) createPayee(ctx *gin.Context) {
+	var req createPayeeRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	account, err := s.repo.GetAccountByID(ctx, req.AccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "account not found")
+		return
+	}
+	if account.Owner == authPayload.Username {
+		ctx.JSON(http.StatusBadRequest, "own accounts can't be added as payee")
+		return
+	}
+
+	p, err := s.repo.GetPayeeByOwnerAndAccount(ctx, authPayload.Username, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if p.ID > 0 {
+		ctx.JSON(http.StatusForbidden, fmt.Sprintf("account %d is already payee %d", account.ID, p.ID))
+		return
+	}
+
+	payee := models.Payee{
+		Owner:     authPayload.Username,
+		Nickname:  req.Nickname,
+		AccountID: account.ID,
+		ActiveAt:  time.Now().Add(s.config.PayeeCoolingOff),
+	}
+
+	res, err := s.store.CreatePayeeTx(ctx, payee, func(payee models.Payee) (models.OutboxMessage, error) {
+		taskPayload := &worker.PayloadSendPayeeAddedEmail{
+			PayeeID:         payee.ID,
+			CoolingOffLimit: s.config.PayeeCoolingOffLimit,
+		}
+		return worker.NewOutboxMessage(worker.TaskSendPayeeAddedEmail, taskPayload, worker.QueueCritical, 10)
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// getOwnedPayee return the payee after checking it belong to the authenticated user, otherwise the
+// response is written and ok is false
+func (s *Server) getOwnedPayee(ctx *gin.Context, id int64) (models.Payee, bool) {
+	payee, err := s.repo.GetPayeeByID(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return payee, false
+	}
+	if payee.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "payee not found")
+		return payee, false
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if payee.Owner != authPayload.Username {
+		err = errors.New("payee doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return payee, false
+	}
+
+	return payee, true
+}
+
+func (s *Server) getPayee(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := s.getOwnedPayee(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, payee)
+}
+
+func (s *Server) listPayees(ctx *gin.Context) {
+	var req listRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	items, err := s.repo.GetListPayees(ctx,
+		authPayload.Username,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+func (s *Server) deletePayee(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payee, ok := s.getOwnedPayee(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	err = s.repo.DeletePayee(ctx, payee.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, fmt.Sprintf("payee %d deleted", payee.ID))
+}
+
+// verifyPayee enforce the payee rules of the config on a transfer to the account of another user,
+// a refused transfer return a *repository.PayeeRuleError
+func verifyPayee(ctx context.Context, repo repository.Repository, config *util.Config, fromAccount, toAccount models.Account, amount int64) error {
+	return repository.VerifyPayee(ctx, repo, payeeRules(config), fromAccount, toAccount, amount)
+}
+
+func payeeRules(config *util.Config) repository.PayeeRules {
+	return repository.PayeeRules{
+		RequirePayee:    config.RequirePayee,
+		CoolingOffLimit: config.PayeeCoolingOffLimit,
+	}
+}
+
+// payeeRuleStatus return the http status of a transfer refused by the payee rules
+func payeeRuleStatus(ruleErr *repository.PayeeRuleError) int {
+	if ruleErr.CoolingOff {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusForbidden
+}
+
+// checkPayee run verifyPayee for the transfer handler, when the transfer isn't allowed the response is written
+// and false is returned
+func (s *Server) checkPayee(ctx *gin.Context, fromAccount, toAccount models.Account, amount int64) bool {
+	err := verifyPayee(ctx, s.repo, s.config, fromAccount, toAccount, amount)
+	if err == nil {
+		return true
+	}
+
+	var ruleErr *repository.PayeeRuleError
+	if !errors.As(err, &ruleErr) {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	ctx.JSON(payeeRuleStatus(ruleErr), ruleErr.Message)
+
+	return false
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/pain"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/worker"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_createPayee(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Username:              "other-user",
+			ReqBody:               map[string]interface{}{"account_id": 2, "nickname": "some"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestNickname",
+			Username:              "other-user",
+			ReqBody:               map[string]interface{}{"account_id": 2},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestOwnAccount",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"account_id": 2, "nickname": "mine"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"account_id": 1, "nickname": "nobody"},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "ForbiddenExists",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"account_id": 4, "nickname": "other"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "InternalServerError",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"account_id": 1001, "nickname": "other"},
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/payees", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_getAndDeletePayee(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			ID:                    2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "InternalServerError",
+			ID:                    1001,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		for _, tc := range testCases {
+			req, _ := http.NewRequest(method, fmt.Sprintf("/payees/%d", tc.ID), nil)
+			setAuthorizationHeader(t, req, tc.Username)
+
+			rr := httptest.NewRecorder()
+
+			serverTest.router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.ExpectationStatusCode {
+				t.Fatalf("failed %s %s wrong response code, want %d got %d", method, tc.Name, tc.ExpectationStatusCode, rr.Code)
+			}
+		}
+	}
+}
+
+func Test_listPayees(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "page=1&size=5",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			Query:                 "page=0&size=5",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "InternalServerError",
+			Query:                 "page=1000&size=5",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/payees?"+tc.Query, nil)
+		setAuthorizationHeader(t, req, "some-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_transferPayeeRules(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey:    serverTest.config.TokenSymmetricKey,
+		AccessTokenDuration:  time.Minute,
+		PayeeCoolingOff:      24 * time.Hour,
+		PayeeCoolingOffLimit: util.CurrencyAmounts{"USD": 8},
+		RequirePayee:         true,
+	}
+	server := NewServer(
+		repository.NewStoreMock(nil),
+		repository.NewPostgresRepoMock(nil),
+		serverTest.tokenMaker,
+		&config,
+		worker.NewRedisTaskDistributorMock(asynq.RedisClientOpt{}),
+	)
+
+	testCases := []struct {
+		Name                  string
+		Path                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "AcceptedOwnAccount",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 3, "amount": 10, "currency": "USD"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedUnderCoolingOffLimit",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 5, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			// 3 USD were already sent to the payee, 6 more go over the limit of 8 even though 6 alone is under it
+			Name:                  "UnprocessableEntityCoolingOff",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 6, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "ForbiddenNotPayee",
+			Username:              "other-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 4, "to_account_id": 2, "amount": 5, "currency": "EUR", "to_currency": "USD"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:     "ForbiddenBatchNotPayee",
+			Path:     "/transfer/batch",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{"mode": "atomic", "items": []map[string]interface{}{
+				{"from_account_id": 2, "to_account_id": 3, "amount": 5, "currency": "USD"},
+				{"from_account_id": 2, "to_account_id": 5, "amount": 5, "currency": "USD"},
+			}},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:     "UnprocessableEntityBatchCoolingOff",
+			Path:     "/transfer/batch",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{"mode": "best_effort", "items": []map[string]interface{}{
+				{"from_account_id": 2, "to_account_id": 4, "amount": 6, "currency": "USD"},
+			}},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "ForbiddenScheduledNotPayee",
+			Path:                  "/scheduled_transfers",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 5, "amount": 5, "currency": "USD", "cron_spec": "0 9 1 * *"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "ForbiddenHoldNotPayee",
+			Path:                  "/holds",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 5, "amount": 5, "currency": "USD"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "UnprocessableEntityHoldCoolingOff",
+			Path:                  "/holds",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 6, "currency": "USD"},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		path := tc.Path
+		if path == "" {
+			path = "/transfer"
+		}
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		server.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+
+	// the transactions of a pain.001 file to a non payee are rejected
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, _ := w.CreateFormFile("file", "payments.xml")
+	part.Write([]byte(testPain001("2", "",
+		testPainTransaction{CreditorAccount: "3", Currency: "USD", Amount: "0.05"},
+		testPainTransaction{CreditorAccount: "5", Currency: "USD", Amount: "0.05"},
+	)))
+	w.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/transfer/pain001", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	setAuthorizationHeader(t, req, "some-user")
+
+	rr := httptest.NewRecorder()
+
+	server.router.ServeHTTP(rr, req)
+
+	var report testPain002
+	err := xml.Unmarshal(rr.Body.Bytes(), &report)
+	if err != nil {
+		t.Fatalf("failed parse pain.002 error:%s", err)
+	}
+	if len(report.Transactions) != 2 || report.Transactions[0].Reason != "" || report.Transactions[1].Reason != pain.ReasonTransactionForbidden {
+		t.Fatalf("failed pain.001 to a non payee want %s got %+v", pain.ReasonTransactionForbidden, report.Transactions)
+	}
+}
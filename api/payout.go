@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/nacha"
+	"github.com/ismail118/simple-bank/token"
+	"net/http"
+)
+
+// payouts are settled through ach which only move dollars
+const payoutCurrency = "USD"
+
+type createPayoutRequest struct {
+	AccountID int64 `json:"account_id" binding:"required,min=1"`
+	Amount    int64 `json:"amount" binding:"required,min=1,max=9999999999"`
+	// beneficiary account at the receiving bank
+	RoutingNumber   string `json:"routing_number" binding:"required,len=9,numeric"`
+	AccountNumber   string `json:"account_number" binding:"required,max=17,alphanum"`
+	AccountType     string `json:"account_type" binding:"required,oneof=checking savings"`
+	BeneficiaryName string `json:"beneficiary_name" binding:"required,max=22"`
+}
+
+// createPayout debit the account and queue the payment to the external bank account for the next ach file
+func (s *Server) createPayout(ctx *gin.Context) {
+	var req createPayoutRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if !nacha.ValidRoutingNumber(req.RoutingNumber) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(nacha.ErrInvalidRoutingNumber))
+		return
+	}
+
+	account, ok := s.getOwnedAccount(ctx, req.AccountID)
+	if !ok {
+		return
+	}
+	if account.Currency != payoutCurrency {
+		ctx.JSON(http.StatusBadRequest, "external payouts are only supported from USD accounts")
+		return
+	}
+
+	res, err := s.store.CreateExternalPayoutTx(ctx, models.ExternalPayout{
+		Owner:           account.Owner,
+		AccountID:       account.ID,
+		Amount:          req.Amount,
+		Currency:        account.Currency,
+		RoutingNumber:   req.RoutingNumber,
+		AccountNumber:   req.AccountNumber,
+		AccountType:     req.AccountType,
+		BeneficiaryName: req.BeneficiaryName,
+	})
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+func (s *Server) getPayout(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	payout, err := s.repo.GetExternalPayoutByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if payout.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "payout not found")
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if payout.Owner != authPayload.Username {
+		err = errors.New("payout doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, payout)
+}
+
+func (s *Server) listPayouts(ctx *gin.Context) {
+	var req listRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	items, err := s.repo.GetListExternalPayouts(ctx,
+		authPayload.Username,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
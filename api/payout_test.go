@@ -0,0 +1,172 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_createPayout(t *testing.T) {
+	body := func(accountID, amount int64, routingNumber string) map[string]interface{} {
+		return map[string]interface{}{
+			"account_id":       accountID,
+			"amount":           amount,
+			"routing_number":   routingNumber,
+			"account_number":   "123456789",
+			"account_type":     "checking",
+			"beneficiary_name": "John Doe",
+		}
+	}
+
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Username:              "some-user",
+			ReqBody:               body(2, 10, "021000021"),
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestCheckDigit",
+			Username:              "some-user",
+			ReqBody:               body(2, 10, "021000022"),
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestAccountType",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"account_id": 2, "amount": 10, "routing_number": "021000021", "account_number": "123456789", "account_type": "loan", "beneficiary_name": "John Doe"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestCurrency",
+			Username:              "other-user",
+			ReqBody:               body(4, 10, "021000021"),
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			Username:              "some-user",
+			ReqBody:               body(1, 10, "021000021"),
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			Username:              "other-user",
+			ReqBody:               body(2, 10, "021000021"),
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "InsufficientFunds",
+			Username:              "some-user",
+			ReqBody:               body(2, 101, "021000021"),
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/payouts", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_getPayout(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			ID:                    2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "ServerError",
+			ID:                    1001,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/payouts/%d", tc.ID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_listPayouts(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "page=1&size=5",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			Query:                 "page=0&size=5",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "ServerError",
+			Query:                 "page=1000&size=5",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/payouts?"+tc.Query, nil)
+		setAuthorizationHeader(t, req, "some-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// listReconciliationReports return the reports of the ledger reconciliation, the latest first
+func (s *Server) listReconciliationReports(ctx *gin.Context) {
+	var req listRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	items, err := s.repo.GetListReconciliationReports(ctx,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+func (s *Server) getReconciliationReport(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	report, err := s.repo.GetReconciliationReportByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if report.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "reconciliation report not found")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, report)
+}
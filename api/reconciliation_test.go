@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_listReconciliationReports(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "page=1&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			Query:                 "page=0&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "Forbidden",
+			Query:                 "page=1&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "ServerError",
+			Query:                 "page=1000&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/admin/reconciliation_reports?%s", tc.Query), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_getReconciliationReport(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Forbidden",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "ServerError",
+			ID:                    1001,
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/admin/reconciliation_reports/%d", tc.ID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_reverseTransfer(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		TransferID            int64
+		Username              string
+		ReqBody               string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			TransferID:            2,
+			Username:              "some-user",
+			ReqBody:               `{"amount": 5}`,
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedEmptyBody",
+			TransferID:            2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "ExceedsAmount",
+			TransferID:            2,
+			Username:              "some-user",
+			ReqBody:               `{"amount": 50}`,
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "BadRequest",
+			TransferID:            2,
+			Username:              "some-user",
+			ReqBody:               `{"amount": -1}`,
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			TransferID:            1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			TransferID:            2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/transfer/%d/reverse", tc.TransferID), bytes.NewReader([]byte(tc.ReqBody)))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_getTransferReversals(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/transfer/2", nil)
+	setAuthorizationHeader(t, req, "some-user")
+
+	rr := httptest.NewRecorder()
+
+	serverTest.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("failed wrong response code, want %d got %d", http.StatusAccepted, rr.Code)
+	}
+
+	var resp getTransferResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatalf("failed unmarshal response error:%s", err)
+	}
+	if resp.ID != 2 {
+		t.Fatalf("failed transfer id want %d got %d", 2, resp.ID)
+	}
+	if len(resp.Reversals) != 1 {
+		t.Fatalf("failed reversals length want %d got %d", 1, len(resp.Reversals))
+	}
+	var reversal *models.Transfer = resp.Reversals[0]
+	if reversal.ReversalOf != resp.ID {
+		t.Fatalf("failed reversal_of want %d got %d", resp.ID, reversal.ReversalOf)
+	}
+}
@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/risk"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
+	"net/http"
+	"time"
+)
+
+// newRiskEngine build the rules enabled in the config
+func newRiskEngine(repo repository.Repository, config *util.Config) *risk.Engine {
+	var rules []risk.Rule
+	if config.RiskVelocityMaxTransfers > 0 {
+		rules = append(rules, risk.VelocityRule{
+			Store:        repo,
+			MaxTransfers: config.RiskVelocityMaxTransfers,
+			Window:       config.RiskVelocityWindow,
+		})
+	}
+	if config.RiskNewDeviceMinAge > 0 {
+		rules = append(rules, risk.NewDeviceRule{
+			Store:     repo,
+			MinAge:    config.RiskNewDeviceMinAge,
+			MinAmount: config.RiskNewDeviceMinAmount,
+		})
+	}
+	if config.RiskAnomalyMultiplier > 0 {
+		rules = append(rules, risk.AmountAnomalyRule{
+			Store:      repo,
+			Lookback:   config.RiskAnomalyLookback,
+			MinHistory: config.RiskAnomalyMinHistory,
+			Multiplier: config.RiskAnomalyMultiplier,
+		})
+	}
+	if config.RiskFirstPayeeMinAmount > 0 {
+		rules = append(rules, risk.FirstTimePayeeRule{
+			Store:     repo,
+			MinAmount: config.RiskFirstPayeeMinAmount,
+		})
+	}
+
+	return risk.NewEngine(rules...)
+}
+
+// screenTransfer evaluate the risk rules on a transfer before it is executed, a reviewed transfer is parked
+// and its review returned. With an idempotency key a retry get the review parked by the first request, and
+// the replay of an executed transfer is not screened again.
+func screenTransfer(ctx context.Context, engine *risk.Engine, repo repository.Repository, t risk.Transfer, toCurrency, idempotencyKey string) (risk.Assessment, models.TransferReview, error) {
+	var review models.TransferReview
+
+	if idempotencyKey != "" {
+		var err error
+		review, err = repo.GetTransferReviewByIdempotencyKey(ctx, t.Username, idempotencyKey)
+		if err != nil {
+			return risk.Assessment{}, review, err
+		}
+		if review.ID > 0 {
+			if review.FromAccountID != t.FromAccount.ID || review.ToAccountID != t.ToAccount.ID || review.Amount != t.Amount {
+				return risk.Assessment{}, review, repository.ErrIdempotencyKeyReused
+			}
+			return risk.Assessment{Decision: models.RiskDecisionReview, Findings: review.Findings}, review, nil
+		}
+
+		key, err := repo.GetIdempotencyKey(ctx, t.Username, idempotencyKey)
+		if err != nil {
+			return risk.Assessment{}, review, err
+		}
+		if key.Response != nil {
+			return risk.Assessment{Decision: models.RiskDecisionAllow}, review, nil
+		}
+	}
+
+	a, err := engine.Evaluate(ctx, t)
+	if err != nil {
+		return a, review, err
+	}
+	if a.Decision != models.RiskDecisionReview {
+		return a, review, nil
+	}
+
+	review = models.TransferReview{
+		Owner:          t.Username,
+		FromAccountID:  t.FromAccount.ID,
+		ToAccountID:    t.ToAccount.ID,
+		Amount:         t.Amount,
+		ToCurrency:     toCurrency,
+		IdempotencyKey: idempotencyKey,
+		Status:         models.TransferReviewStatusPending,
+		Findings:       a.Findings,
+		CreatedAt:      time.Now(),
+	}
+	review.ID, err = repo.InsertTransferReview(ctx, review)
+	if err != nil {
+		return a, review, err
+	}
+
+	return a, review, nil
+}
+
+// screenTransferRequest run screenTransfer for the transfer handler, it write the response and return false
+// when the transfer must not be executed now
+func (s *Server) screenTransferRequest(ctx *gin.Context, req transferRequest, fAccount, tAccount models.Account, idempotencyKey string) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	toCurrency := ""
+	if tAccount.Currency != req.Currency {
+		toCurrency = req.ToCurrency
+	}
+	a, review, err := screenTransfer(ctx, s.riskEngine, s.repo, risk.Transfer{
+		Username:    authPayload.Username,
+		FromAccount: fAccount,
+		ToAccount:   tAccount,
+		Amount:      req.Amount,
+		UserAgent:   ctx.Request.UserAgent(),
+		ClientIP:    ctx.ClientIP(),
+	}, toCurrency, idempotencyKey)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return false
+	}
+
+	switch a.Decision {
+	case models.RiskDecisionDeny:
+		res := errorResponse(errors.New("transfer denied by the risk rules"))
+		res["findings"] = a.Findings
+		ctx.JSON(http.StatusForbidden, res)
+		return false
+	case models.RiskDecisionReview:
+		ctx.JSON(http.StatusAccepted, gin.H{"review": review})
+		return false
+	}
+
+	return true
+}
+
+// getTransferReview let the owner follow a transfer parked by the risk rules
+func (s *Server) getTransferReview(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	review, err := s.repo.GetTransferReviewByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if review.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "transfer review not found")
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if review.Owner != authPayload.Username {
+		err = errors.New("transfer review doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, review)
+}
+
+type listTransferReviewsRequest struct {
+	// pending when empty
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	cursorListRequest
+}
+
+type listTransferReviewsResponse struct {
+	Reviews    []*models.TransferReview `json:"reviews"`
+	NextCursor string                   `json:"next_cursor"`
+}
+
+// listTransferReviews return the reviews with the status, the oldest first so they are handled in order
+func (s *Server) listTransferReviews(ctx *gin.Context) {
+	var req listTransferReviewsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	afterID, err := util.DecodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if req.Status == "" {
+		req.Status = models.TransferReviewStatusPending
+	}
+
+	items, err := s.repo.GetListTransferReviews(ctx, req.Status, afterID, req.Size+1)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var res listTransferReviewsResponse
+	res.Reviews, res.NextCursor = cursorPage(items, req.Size, func(r *models.TransferReview) int64 { return r.ID })
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// approveTransferReview execute the parked transfer, the payee rules are checked again
+func (s *Server) approveTransferReview(ctx *gin.Context) {
+	s.decideTransferReview(ctx, func(ctx context.Context, id int64, reviewedBy string) (repository.TransferReviewTxResult, error) {
+		return s.store.ApproveTransferReviewTx(ctx, id, reviewedBy, payeeRules(s.config))
+	})
+}
+
+// rejectTransferReview drop the parked transfer
+func (s *Server) rejectTransferReview(ctx *gin.Context) {
+	s.decideTransferReview(ctx, s.store.RejectTransferReviewTx)
+}
+
+func (s *Server) decideTransferReview(ctx *gin.Context, decide func(ctx context.Context, id int64, reviewedBy string) (repository.TransferReviewTxResult, error)) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	res, err := decide(ctx, req.ID, authPayload.Username)
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
@@ -0,0 +1,256 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/worker"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_transferRiskScreening(t *testing.T) {
+	config := util.Config{
+		TokenSymmetricKey:        serverTest.config.TokenSymmetricKey,
+		AccessTokenDuration:      time.Minute,
+		RiskVelocityMaxTransfers: 5,
+		RiskVelocityWindow:       time.Minute,
+		RiskFirstPayeeMinAmount:  50,
+	}
+	server := NewServer(
+		repository.NewStoreMock(nil),
+		repository.NewPostgresRepoMock(nil),
+		serverTest.tokenMaker,
+		&config,
+		worker.NewRedisTaskDistributorMock(asynq.RedisClientOpt{}),
+	)
+
+	testCases := []struct {
+		Name                  string
+		Username              string
+		IdempotencyKey        string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+		Parked                bool
+	}{
+		{
+			Name:                  "Allowed",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 10, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AllowedOwnAccount",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 3, "amount": 60, "currency": "USD"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "ReviewFirstTimePayee",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 60, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusAccepted,
+			Parked:                true,
+		},
+		{
+			Name:                  "ReviewRetried",
+			Username:              "some-user",
+			IdempotencyKey:        "parked-key",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 60, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusAccepted,
+			Parked:                true,
+		},
+		{
+			Name:                  "ReviewKeyReused",
+			Username:              "some-user",
+			IdempotencyKey:        "parked-key",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 70, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "ReplayNotScreened",
+			Username:              "some-user",
+			IdempotencyKey:        "replayed-key",
+			ReqBody:               map[string]interface{}{"from_account_id": 2, "to_account_id": 4, "amount": 60, "currency": "USD", "to_currency": "EUR"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "DenyVelocity",
+			Username:              "other-user",
+			ReqBody:               map[string]interface{}{"from_account_id": 4, "to_account_id": 2, "amount": 5, "currency": "EUR", "to_currency": "USD"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.IdempotencyKey != "" {
+			req.Header.Set(idempotencyKeyHeader, tc.IdempotencyKey)
+		}
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		server.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+
+		var res map[string]interface{}
+		_ = json.Unmarshal(rr.Body.Bytes(), &res)
+		if _, parked := res["review"]; parked != tc.Parked {
+			t.Fatalf("failed %s want parked %t got body %s", tc.Name, tc.Parked, rr.Body.String())
+		}
+	}
+}
+
+func Test_getTransferReview(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			ID:                    2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/transfer_reviews/%d", tc.ID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_listTransferReviews(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestStatus",
+			Query:                 "status=done&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestCursor",
+			Query:                 "cursor=not-a-cursor&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "Forbidden",
+			Query:                 "size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "ServerError",
+			Query:                 fmt.Sprintf("status=approved&cursor=%s&size=5", util.EncodeCursor(1001)),
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/admin/transfer_reviews?"+tc.Query, nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_decideTransferReview(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotPending",
+			ID:                    3,
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1001,
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Forbidden",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, decision := range []string{"approve", "reject"} {
+		for _, tc := range testCases {
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/admin/transfer_reviews/%d/%s", tc.ID, decision), nil)
+			setAuthorizationHeader(t, req, tc.Username)
+
+			rr := httptest.NewRecorder()
+
+			serverTest.router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.ExpectationStatusCode {
+				t.Fatalf("failed %s %s wrong response code, want %d got %d", decision, tc.Name, tc.ExpectationStatusCode, rr.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
+	"net/http"
+	"time"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,len=3"`
+	// standard 5 fields cron spec in UTC, e.g. "0 9 1 * *" for 09:00 on the 1st of every month
+	CronSpec string `json:"cron_spec" binding:"required"`
+	// first run is the first time matching cron_spec after start_at, now if empty
+	StartAt       time.Time `json:"start_at"`
+	EndAt         time.Time `json:"end_at"`
+	FailurePolicy string    `json:"failure_policy" binding:"omitempty,oneof=skip pause"`
+}
+
+func (s *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	fAccount, err := s.repo.GetAccountByID(ctx, req.FromAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if fAccount.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "from account not found")
+		return
+	}
+	if fAccount.Currency != req.Currency {
+		ctx.JSON(http.StatusBadRequest, fmt.Sprintf("account %d mismatch: %s vs %s", fAccount.ID, fAccount.Currency, req.Currency))
+		return
+	}
+
+	tAccount, err := s.repo.GetAccountByID(ctx, req.ToAccountID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if tAccount.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "to account not found")
+		return
+	}
+	if tAccount.Currency != req.Currency {
+		ctx.JSON(http.StatusBadRequest, fmt.Sprintf("account %d mismatch: %s vs %s", tAccount.ID, tAccount.Currency, req.Currency))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != fAccount.Owner {
+		err = errors.New("from account doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if !s.checkPayee(ctx, fAccount, tAccount, req.Amount) {
+		return
+	}
+
+	after := time.Now()
+	if req.StartAt.After(after) {
+		after = req.StartAt
+	}
+	nextRunAt, err := nextScheduledRun(req.CronSpec, after, req.EndAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.FailurePolicy == "" {
+		req.FailurePolicy = models.FailurePolicySkip
+	}
+
+	st := models.ScheduledTransfer{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		CronSpec:      req.CronSpec,
+		NextRunAt:     nextRunAt,
+		EndAt:         req.EndAt,
+		FailurePolicy: req.FailurePolicy,
+		Status:        models.ScheduledTransferStatusActive,
+	}
+	st.ID, err = s.repo.InsertScheduledTransfer(ctx, st)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, st)
+}
+
+func (s *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st, ok := s.getOwnedScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, st)
+}
+
+func (s *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	items, err := s.repo.GetListScheduledTransfers(ctx,
+		authPayload.Username,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount        int64     `json:"amount" binding:"required,min=1"`
+	CronSpec      string    `json:"cron_spec" binding:"required"`
+	EndAt         time.Time `json:"end_at"`
+	FailurePolicy string    `json:"failure_policy" binding:"required,oneof=skip pause"`
+	// paused schedule is resumed by setting it back to active
+	Status string `json:"status" binding:"required,oneof=active paused"`
+}
+
+func (s *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st, ok := s.getOwnedScheduledTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+	if !isScheduledTransferEditable(st) {
+		ctx.JSON(http.StatusUnprocessableEntity, fmt.Sprintf("scheduled transfer is %s", st.Status))
+		return
+	}
+
+	// runs missed while paused are not executed, the schedule continue from now
+	nextRunAt, err := nextScheduledRun(req.CronSpec, time.Now(), req.EndAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st.Amount = req.Amount
+	st.CronSpec = req.CronSpec
+	st.NextRunAt = nextRunAt
+	st.EndAt = req.EndAt
+	st.FailurePolicy = req.FailurePolicy
+	st.Status = req.Status
+
+	err = s.repo.UpdateScheduledTransfer(ctx, st)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, st)
+}
+
+// deleteScheduledTransfer cancel the scheduled transfer, it is kept with its executions
+func (s *Server) deleteScheduledTransfer(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st, ok := s.getOwnedScheduledTransfer(ctx, req.ID)
+	if !ok {
+		return
+	}
+	if !isScheduledTransferEditable(st) {
+		ctx.JSON(http.StatusUnprocessableEntity, fmt.Sprintf("scheduled transfer is %s", st.Status))
+		return
+	}
+
+	st.Status = models.ScheduledTransferStatusCancelled
+	err = s.repo.UpdateScheduledTransfer(ctx, st)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, st)
+}
+
+func (s *Server) listScheduledTransferExecutions(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	st, ok := s.getOwnedScheduledTransfer(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	items, err := s.repo.GetListScheduledTransferExecutions(ctx,
+		st.ID,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+// getOwnedScheduledTransfer return the scheduled transfer if it exist and belong to authenticated user,
+// it write the error response and return false if not
+func (s *Server) getOwnedScheduledTransfer(ctx *gin.Context, id int64) (models.ScheduledTransfer, bool) {
+	st, err := s.repo.GetScheduledTransferByID(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return st, false
+	}
+	if st.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "scheduled transfer not found")
+		return st, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if st.Owner != authPayload.Username {
+		err = errors.New("scheduled transfer doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return st, false
+	}
+
+	return st, true
+}
+
+func isScheduledTransferEditable(st models.ScheduledTransfer) bool {
+	return st.Status == models.ScheduledTransferStatusActive || st.Status == models.ScheduledTransferStatusPaused
+}
+
+// nextScheduledRun return the first run of cron spec after the given time that is not after endAt
+func nextScheduledRun(spec string, after, endAt time.Time) (time.Time, error) {
+	next, err := util.NextCronTime(spec, after)
+	if err != nil {
+		return next, err
+	}
+	if !endAt.IsZero() && next.After(endAt) {
+		return next, fmt.Errorf("cron spec %q has no run before end_at", spec)
+	}
+
+	return next, nil
+}
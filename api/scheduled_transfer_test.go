@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_createScheduledTransfer(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:     "Accepted",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+				"cron_spec":       "0 9 1 * *",
+				"failure_policy":  "pause",
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:     "InvalidCronSpec",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+				"cron_spec":       "every month",
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "EndBeforeFirstRun",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+				"cron_spec":       "0 9 1 * *",
+				"end_at":          time.Now().Add(time.Minute),
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "NotFound",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 1,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+				"cron_spec":       "0 9 1 * *",
+			},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:     "Unauthorized",
+			Username: "other-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          10,
+				"currency":        "USD",
+				"cron_spec":       "0 9 1 * *",
+			},
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:     "ServerError",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"from_account_id": 2,
+				"to_account_id":   3,
+				"amount":          1001,
+				"currency":        "USD",
+				"cron_spec":       "0 9 1 * *",
+			},
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_updateScheduledTransfer(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:     "Accepted",
+			ID:       2,
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"amount":         20,
+				"cron_spec":      "0 9 15 * *",
+				"failure_policy": "skip",
+				"status":         "paused",
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:     "BadRequest",
+			ID:       2,
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"amount":         20,
+				"cron_spec":      "0 9 15 * *",
+				"failure_policy": "skip",
+				"status":         "completed",
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "NotFound",
+			ID:       1,
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"amount":         20,
+				"cron_spec":      "0 9 15 * *",
+				"failure_policy": "skip",
+				"status":         "active",
+			},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:     "Unauthorized",
+			ID:       2,
+			Username: "other-user",
+			ReqBody: map[string]interface{}{
+				"amount":         20,
+				"cron_spec":      "0 9 15 * *",
+				"failure_policy": "skip",
+				"status":         "active",
+			},
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/scheduled_transfers/%d", tc.ID), bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_scheduledTransferByID(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Method                string
+		Path                  string
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Get",
+			Method:                http.MethodGet,
+			Path:                  "/scheduled_transfers/2",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "GetServerError",
+			Method:                http.MethodGet,
+			Path:                  "/scheduled_transfers/1001",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:                  "Delete",
+			Method:                http.MethodDelete,
+			Path:                  "/scheduled_transfers/2",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "DeleteUnauthorized",
+			Method:                http.MethodDelete,
+			Path:                  "/scheduled_transfers/2",
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "Executions",
+			Method:                http.MethodGet,
+			Path:                  "/scheduled_transfers/2/executions?page=1&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "ExecutionsBadRequest",
+			Method:                http.MethodGet,
+			Path:                  "/scheduled_transfers/2/executions",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "List",
+			Method:                http.MethodGet,
+			Path:                  "/scheduled_transfers?page=1&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(tc.Method, tc.Path, nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
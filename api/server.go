@@ -1,19 +1,24 @@
 package api
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/risk"
 	"github.com/ismail118/simple-bank/token"
 	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/worker"
 )
 
 // Server serves HTTP request for our banking service
 type Server struct {
-	store      repository.Store
-	repo       repository.Repository
-	router     *gin.Engine
-	tokenMaker token.Maker
-	config     *util.Config
+	store           repository.Store
+	repo            repository.Repository
+	router          *gin.Engine
+	tokenMaker      token.Maker
+	config          *util.Config
+	taskDistributor worker.TaskDistributor
+	riskEngine      *risk.Engine
 }
 
 // NewServer create a new HTTP server and setup routing
@@ -22,13 +27,16 @@ func NewServer(
 	repo repository.Repository,
 	tokenMaker token.Maker,
 	config *util.Config,
+	taskDistributor worker.TaskDistributor,
 ) Server {
 	server := Server{
-		store:      store,
-		repo:       repo,
-		router:     nil,
-		tokenMaker: tokenMaker,
-		config:     config,
+		store:           store,
+		repo:            repo,
+		router:          nil,
+		tokenMaker:      tokenMaker,
+		config:          config,
+		taskDistributor: taskDistributor,
+		riskEngine:      newRiskEngine(repo, config),
 	}
 
 	server.setupRouter()
@@ -37,7 +45,13 @@ func NewServer(
 }
 
 func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+	res := gin.H{"error": err.Error()}
+	// let the client know what it can still send
+	var limitErr *repository.TransferLimitError
+	if errors.As(err, &limitErr) {
+		res["transfer_limit"] = limitErr
+	}
+	return res
 }
 
 func (s *Server) Start(addr string) error {
@@ -60,6 +74,12 @@ func (server *Server) setupRouter() {
 	authRoutes.GET("/accounts", server.listAccounts)
 	authRoutes.PUT("/accounts", server.updateAccount)
 	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
+	authRoutes.POST("/accounts/:id/status", server.changeAccountStatus)
+	authRoutes.GET("/accounts/:id/status_history", server.listAccountStatusHistory)
+	authRoutes.GET("/accounts/:id/interest_postings", server.listInterestPostings)
+	authRoutes.GET("/accounts/:id/balance", server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
+	authRoutes.GET("/account_products", server.listAccountProducts)
 
 	authRoutes.GET("/entries/:id", server.getEntry)
 	authRoutes.GET("/entries", server.listEntries)
@@ -67,11 +87,56 @@ func (server *Server) setupRouter() {
 	authRoutes.GET("/transfer/:id", server.getTransfer)
 	authRoutes.GET("/transfer", server.listTransfer)
 	authRoutes.POST("/transfer", server.transfer)
+	authRoutes.POST("/transfer/batch", server.createTransferBatch)
+	authRoutes.POST("/transfer/pain001", server.importPain001)
+	authRoutes.GET("/transfer/batch/:id", server.getTransferBatch)
+	authRoutes.GET("/transfer_reviews/:id", server.getTransferReview)
+	authRoutes.GET("/currencies", server.listCurrencies)
+	authRoutes.POST("/scheduled_transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers", server.listScheduledTransfers)
+	authRoutes.GET("/scheduled_transfers/:id", server.getScheduledTransfer)
+	authRoutes.PUT("/scheduled_transfers/:id", server.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled_transfers/:id", server.deleteScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id/executions", server.listScheduledTransferExecutions)
+	authRoutes.GET("/fx_rates", server.getFxRate)
+	authRoutes.GET("/fee_schedules", server.listFeeSchedules)
+	authRoutes.POST("/transfer/:id/reverse", server.reverseTransfer)
+	authRoutes.POST("/payouts", server.createPayout)
+	authRoutes.GET("/payouts", server.listPayouts)
+	authRoutes.GET("/payouts/:id", server.getPayout)
+	authRoutes.POST("/payees", server.createPayee)
+	authRoutes.GET("/payees", server.listPayees)
+	authRoutes.GET("/payees/:id", server.getPayee)
+	authRoutes.DELETE("/payees/:id", server.deletePayee)
+	authRoutes.POST("/webhooks", server.createWebhookEndpoint)
+	authRoutes.GET("/webhooks", server.listWebhookEndpoints)
+	authRoutes.GET("/webhooks/:id", server.getWebhookEndpoint)
+	authRoutes.DELETE("/webhooks/:id", server.deleteWebhookEndpoint)
+	authRoutes.GET("/webhooks/:id/deliveries", server.listWebhookDeliveries)
+	authRoutes.POST("/webhook_deliveries/:id/replay", server.replayWebhookDelivery)
+
+	authRoutes.POST("/holds", server.authorizeHold)
+	authRoutes.GET("/holds/:id", server.getHold)
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/void", server.voidHold)
 
 	authRoutes.GET("/users/:username", server.getUsers)
 	authRoutes.GET("/users", server.listUsers)
 	authRoutes.PUT("/users", server.updateUsers)
 	authRoutes.DELETE("/users/:username", server.deleteUsers)
 
+	// need admin role
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.tokenMaker), adminMiddleware(server.repo))
+	adminRoutes.GET("/reconciliation_reports", server.listReconciliationReports)
+	adminRoutes.GET("/reconciliation_reports/:id", server.getReconciliationReport)
+	adminRoutes.GET("/transfer_limits", server.listTransferLimits)
+	adminRoutes.PUT("/transfer_limits", server.setTransferLimit)
+	adminRoutes.DELETE("/transfer_limits/:id", server.deleteTransferLimit)
+	adminRoutes.GET("/accounts/:id/transfer_limits", server.getAccountTransferLimits)
+	adminRoutes.POST("/accounts/:id/status", server.adminChangeAccountStatus)
+	adminRoutes.GET("/transfer_reviews", server.listTransferReviews)
+	adminRoutes.POST("/transfer_reviews/:id/approve", server.approveTransferReview)
+	adminRoutes.POST("/transfer_reviews/:id/reject", server.rejectTransferReview)
+
 	server.router = router
 }
@@ -1,13 +1,16 @@
 package api
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/activity"
 	"github.com/ismail118/simple-bank/repository"
 	"github.com/ismail118/simple-bank/token"
 	"github.com/ismail118/simple-bank/util"
 	"github.com/ismail118/simple-bank/worker"
 	"github.com/rs/zerolog/log"
+	"net/http"
 	"os"
 	"testing"
 	"time"
@@ -33,9 +36,21 @@ func TestMain(m *testing.M) {
 		log.Fatal().Msgf("failed setup NewPasetoMaker error: %s", err)
 	}
 
-	serverTest = NewServer(storeMock, repoMock, tokenMaker, &config)
+	serverTest = NewServer(storeMock, repoMock, tokenMaker, &config, taskDistributorMock)
 
 	// Grpc
-	grpcServerTest = NewGrpcServer(storeMock, repoMock, tokenMaker, &config, taskDistributorMock)
+	grpcServerTest = NewGrpcServer(storeMock, repoMock, tokenMaker, &config, taskDistributorMock, activity.NewHub())
 	os.Exit(m.Run())
 }
+
+// setAuthorizationHeader set bearer token of the given username to the request
+func setAuthorizationHeader(t *testing.T, r *http.Request, username string) {
+	token, payload, err := serverTest.tokenMaker.CreateToken(username, time.Minute)
+	if err != nil {
+		t.Fatalf("failed create token error:%s", err)
+	}
+	if payload == nil {
+		t.Fatalf("failed payload is empty")
+	}
+	r.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, token))
+}
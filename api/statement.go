@@ -0,0 +1,99 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/statement"
+	"github.com/ismail118/simple-bank/worker"
+	"net/http"
+	"time"
+)
+
+const (
+	// statements of longer periods are generated by a task and emailed to the owner of the account
+	maxDownloadStatementDays = 93
+	maxStatementDays         = 5 * 366
+)
+
+type getAccountStatementRequest struct {
+	// first and last day of the period in UTC, both included
+	From   time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To     time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	Format string    `form:"format" binding:"required,oneof=csv pdf ofx camt053 mt940"`
+}
+
+// getAccountStatement return the statement of the account as a file with the opening and closing balances
+// and the running balance after each entry. Periods longer than maxDownloadStatementDays are emailed instead.
+func (s *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req getAccountStatementRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	days, err := statementDays(req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, ok := s.getOwnedAccount(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	if days > maxDownloadStatementDays {
+		payload := &worker.PayloadSendStatement{
+			AccountID: account.ID,
+			From:      req.From,
+			To:        req.To,
+			Format:    req.Format,
+		}
+		err = s.taskDistributor.DistributeTaskSendStatement(ctx, payload,
+			asynq.Queue(worker.QueueDefault),
+			asynq.MaxRetry(3),
+		)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, "statement will be emailed to the account owner")
+		return
+	}
+
+	st, err := statement.Load(ctx, s.repo, account, req.From, req.To)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	data, contentType, err := statement.Render(st, req.Format)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, statement.FileName(st, req.Format)))
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// statementDays return the number of days of the statement period and an error if the period is not valid
+func statementDays(from, to time.Time) (int, error) {
+	if from.IsZero() || to.IsZero() {
+		return 0, errors.New("from and to are required")
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days < 1 || days > maxStatementDays {
+		return 0, fmt.Errorf("period must be between 1 and %d days", maxStatementDays)
+	}
+	return days, nil
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_getAccountStatement(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		AccountID             int64
+		Query                 string
+		Username              string
+		ExpectationStatusCode int
+		ExpectationType       string
+	}{
+		{
+			Name:                  "OKCsv",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationType:       "text/csv",
+		},
+		{
+			Name:                  "OKPdf",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=pdf",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationType:       "application/pdf",
+		},
+		{
+			Name:                  "OKOfx",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-01&format=ofx",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationType:       "application/x-ofx",
+		},
+		{
+			Name:                  "OKCamt053",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=camt053",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationType:       "application/xml",
+		},
+		{
+			Name:                  "OKMT940",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=mt940",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusOK,
+			ExpectationType:       "text/plain",
+		},
+		{
+			Name:                  "AcceptedEmailed",
+			AccountID:             2,
+			Query:                 "from=2022-01-01&to=2022-12-31&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestFormat",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=xls",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestMissingPeriod",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestToBeforeFrom",
+			AccountID:             2,
+			Query:                 "from=2023-01-31&to=2023-01-01&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestTooLong",
+			AccountID:             2,
+			Query:                 "from=2010-01-01&to=2023-01-01&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFound",
+			AccountID:             1,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			AccountID:             2,
+			Query:                 "from=2023-01-01&to=2023-01-31&format=csv",
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "ServerErrorLoad",
+			AccountID:             2,
+			Query:                 "from=1990-01-01&to=1990-01-31&format=csv",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:                  "ServerErrorEnqueue",
+			AccountID:             2,
+			Query:                 "from=2022-01-01&to=2022-12-31&format=ofx",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/statement?%s", tc.AccountID, tc.Query), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+		if tc.ExpectationType != "" && rr.Header().Get("Content-Type") != tc.ExpectationType {
+			t.Fatalf("failed %s wrong content type, want %s got %s", tc.Name, tc.ExpectationType, rr.Header().Get("Content-Type"))
+		}
+	}
+}
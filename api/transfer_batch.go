@@ -0,0 +1,166 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/worker"
+	"net/http"
+)
+
+type transferBatchItemRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,min=1"`
+	Currency      string `json:"currency" binding:"required,len=3"`
+}
+
+type createTransferBatchRequest struct {
+	// atomic run all items in one transaction, best_effort run them one by one in background
+	Mode  string                     `json:"mode" binding:"required,oneof=atomic best_effort"`
+	Items []transferBatchItemRequest `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// createTransferBatch submit many transfers from the authenticated user accounts at once.
+// The result of each item can be polled with getTransferBatch.
+func (s *Server) createTransferBatch(ctx *gin.Context) {
+	var req createTransferBatchRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	accounts := make(map[int64]models.Account)
+	items := make([]models.TransferBatchItem, 0, len(req.Items))
+	for i, item := range req.Items {
+		fAccount, ok := accounts[item.FromAccountID]
+		if !ok {
+			fAccount, err = s.repo.GetAccountByID(ctx, item.FromAccountID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			if fAccount.ID < 1 {
+				ctx.JSON(http.StatusNotFound, fmt.Sprintf("item %d: from account not found", i))
+				return
+			}
+			accounts[fAccount.ID] = fAccount
+		}
+		if fAccount.Currency != item.Currency {
+			ctx.JSON(http.StatusBadRequest, fmt.Sprintf("item %d: account %d mismatch: %s vs %s", i, fAccount.ID, fAccount.Currency, item.Currency))
+			return
+		}
+
+		// authorization
+		if authPayload.Username != fAccount.Owner {
+			err = fmt.Errorf("item %d: from account doesn't belong to authenticated user", i)
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+
+		tAccount, ok := accounts[item.ToAccountID]
+		if !ok {
+			tAccount, err = s.repo.GetAccountByID(ctx, item.ToAccountID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			if tAccount.ID < 1 {
+				ctx.JSON(http.StatusNotFound, fmt.Sprintf("item %d: to account not found", i))
+				return
+			}
+			accounts[tAccount.ID] = tAccount
+		}
+		err = verifyPayee(ctx, s.repo, s.config, fAccount, tAccount, item.Amount)
+		if err != nil {
+			var ruleErr *repository.PayeeRuleError
+			if !errors.As(err, &ruleErr) {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			ctx.JSON(payeeRuleStatus(ruleErr), fmt.Sprintf("item %d: %s", i, ruleErr.Message))
+			return
+		}
+
+		items = append(items, models.TransferBatchItem{
+			FromAccountID: item.FromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+		})
+	}
+
+	batch := models.TransferBatch{
+		Owner: authPayload.Username,
+		Mode:  req.Mode,
+	}
+
+	var res repository.TransferBatchTxResult
+	if req.Mode == models.BatchModeAtomic {
+		res, err = s.store.AtomicTransferBatchTx(ctx, batch, items)
+		if err != nil {
+			ctx.JSON(storeErrorStatus(err), errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, res)
+		return
+	}
+
+	res, err = s.store.CreateTransferBatchTx(ctx, batch, items, func(batch models.TransferBatch) (models.OutboxMessage, error) {
+		taskPayload := &worker.PayloadProcessTransferBatch{
+			BatchID: batch.ID,
+		}
+		return worker.NewOutboxMessage(worker.TaskProcessTransferBatch, taskPayload, worker.QueueDefault, 10)
+	})
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// getTransferBatch return the batch with the status of each item
+func (s *Server) getTransferBatch(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	batch, err := s.repo.GetTransferBatchByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if batch.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "transfer batch not found")
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if authPayload.Username != batch.Owner {
+		err = errors.New("transfer batch doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	items, err := s.repo.GetListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, repository.TransferBatchTxResult{
+		Batch: batch,
+		Items: items,
+	})
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_createTransferBatch(t *testing.T) {
+	item := func(from int64, amount int64) map[string]interface{} {
+		return map[string]interface{}{
+			"from_account_id": from,
+			"to_account_id":   3,
+			"amount":          amount,
+			"currency":        "USD",
+		}
+	}
+
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+		ExpectationStatus     string
+	}{
+		{
+			Name:     "AcceptedAtomic",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "atomic",
+				"items": []interface{}{item(2, 10), item(2, 20)},
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+			ExpectationStatus:     models.BatchStatusCompleted,
+		},
+		{
+			Name:     "FailedAtomic",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "atomic",
+				"items": []interface{}{item(2, 10), item(2, 1000)},
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+			ExpectationStatus:     models.BatchStatusFailed,
+		},
+		{
+			Name:     "AcceptedBestEffort",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "best_effort",
+				"items": []interface{}{item(2, 10), item(2, 1000)},
+			},
+			ExpectationStatusCode: http.StatusAccepted,
+			ExpectationStatus:     models.BatchStatusPending,
+		},
+		{
+			Name:     "BadRequestMode",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "some-mode",
+				"items": []interface{}{item(2, 10)},
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "BadRequestItem",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "atomic",
+				"items": []interface{}{item(2, 10), item(2, 0)},
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "BadRequestEmpty",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "atomic",
+				"items": []interface{}{},
+			},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:     "NotFound",
+			Username: "some-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "atomic",
+				"items": []interface{}{item(2, 10), item(1, 10)},
+			},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:     "Unauthorized",
+			Username: "other-user",
+			ReqBody: map[string]interface{}{
+				"mode":  "atomic",
+				"items": []interface{}{item(2, 10)},
+			},
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/transfer/batch", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+		if tc.ExpectationStatus == "" {
+			continue
+		}
+
+		var res repository.TransferBatchTxResult
+		err := json.Unmarshal(rr.Body.Bytes(), &res)
+		if err != nil {
+			t.Fatalf("failed %s unmarshal response error:%s", tc.Name, err)
+		}
+		if res.Batch.Status != tc.ExpectationStatus {
+			t.Fatalf("failed %s wrong batch status, want %s got %s", tc.Name, tc.ExpectationStatus, res.Batch.Status)
+		}
+		if len(res.Items) != len(tc.ReqBody["items"].([]interface{})) {
+			t.Fatalf("failed %s wrong number of items, want %d got %d", tc.Name, len(tc.ReqBody["items"].([]interface{})), len(res.Items))
+		}
+	}
+}
+
+func Test_getTransferBatch(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		BatchID               int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			BatchID:               2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			BatchID:               1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "ServerError",
+			BatchID:               1001,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:                  "Unauthorized",
+			BatchID:               2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("/transfer/batch/%d", tc.BatchID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
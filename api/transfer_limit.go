@@ -0,0 +1,197 @@
+package api
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
+	"net/http"
+	"time"
+)
+
+type listTransferLimitsRequest struct {
+	// optional, only the policies of the user and its accounts
+	Owner string `json:"owner" form:"owner"`
+	Page  int    `json:"page" form:"page" binding:"required,min=1"`
+	Size  int    `json:"size" form:"size" binding:"required,min=5,max=10"`
+}
+
+func (s *Server) listTransferLimits(ctx *gin.Context) {
+	var req listTransferLimitsRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	items, err := s.repo.GetListTransferLimits(ctx,
+		req.Owner,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+type setTransferLimitRequest struct {
+	// the policy of the account when AccountID is set, otherwise the policy of Owner in Currency
+	// or the default of Currency when Owner is empty
+	AccountID int64  `json:"account_id" binding:"omitempty,min=1"`
+	Owner     string `json:"owner" binding:"excluded_with=AccountID"`
+	Currency  string `json:"currency" binding:"required_without=AccountID,omitempty,len=3"`
+	// 0 means no limit
+	PerTransaction int64 `json:"per_transaction" binding:"min=0"`
+	Daily          int64 `json:"daily" binding:"min=0"`
+	Monthly        int64 `json:"monthly" binding:"min=0"`
+}
+
+// setTransferLimit create or override the transfer limit policy of a scope
+func (s *Server) setTransferLimit(ctx *gin.Context) {
+	var req setTransferLimitRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.Owner != "" {
+		user, err := s.repo.GetUsersByUsername(ctx, req.Owner)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if user.Username == "" {
+			ctx.JSON(http.StatusNotFound, fmt.Sprintf("user with username %s not exists", req.Owner))
+			return
+		}
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	policy, err := s.store.SetTransferLimitTx(ctx, models.TransferLimit{
+		Owner:          req.Owner,
+		AccountID:      req.AccountID,
+		Currency:       req.Currency,
+		PerTransaction: req.PerTransaction,
+		Daily:          req.Daily,
+		Monthly:        req.Monthly,
+		UpdatedBy:      authPayload.Username,
+	})
+	if err != nil {
+		ctx.JSON(storeErrorStatus(err), errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, policy)
+}
+
+func (s *Server) deleteTransferLimit(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	policy, err := s.repo.GetTransferLimitByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if policy.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "transfer limit not found")
+		return
+	}
+
+	err = s.repo.DeleteTransferLimit(ctx, policy.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, fmt.Sprintf("transfer limit %d deleted", policy.ID))
+}
+
+// transferLimitAllowance is a policy that apply to an account with what was sent and what remain in its periods,
+// a remaining of -1 means the period has no limit
+type transferLimitAllowance struct {
+	Limit                   models.TransferLimit      `json:"limit"`
+	Usage                   models.TransferLimitUsage `json:"usage"`
+	RemainingPerTransaction int64                     `json:"remaining_per_transaction"`
+	RemainingDaily          int64                     `json:"remaining_daily"`
+	RemainingMonthly        int64                     `json:"remaining_monthly"`
+}
+
+type accountTransferLimitsResponse struct {
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	// policy of the owner or default of the currency, nil if neither exist
+	Owner *transferLimitAllowance `json:"owner"`
+	// nil if the account has no policy
+	Account *transferLimitAllowance `json:"account"`
+}
+
+// getAccountTransferLimits return the policies enforced on the transfers from the account and their allowance
+func (s *Server) getAccountTransferLimits(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := s.repo.GetAccountByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if account.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "account not found")
+		return
+	}
+
+	policies, err := s.repo.GetTransferLimitsOfAccount(ctx, account.Owner, account.Currency, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	res := accountTransferLimitsResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+	}
+	ownerLimit, accountLimit := util.ResolveTransferLimits(policies)
+	now := time.Now()
+	for _, l := range []models.TransferLimit{ownerLimit, accountLimit} {
+		if l.ID < 1 {
+			continue
+		}
+
+		usage, err := s.repo.GetTransferLimitUsage(ctx, account.Owner, account.Currency, l.AccountID,
+			now.Add(-util.TransferLimitDay), now.Add(-util.TransferLimitMonth))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		allowance := &transferLimitAllowance{
+			Limit:                   l,
+			Usage:                   usage,
+			RemainingPerTransaction: util.TransferLimitRemaining(l.PerTransaction, 0),
+			RemainingDaily:          util.TransferLimitRemaining(l.Daily, usage.Daily),
+			RemainingMonthly:        util.TransferLimitRemaining(l.Monthly, usage.Monthly),
+		}
+		if l.AccountID > 0 {
+			res.Account = allowance
+		} else {
+			res.Owner = allowance
+		}
+	}
+
+	ctx.JSON(http.StatusAccepted, res)
+}
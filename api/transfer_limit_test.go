@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_setTransferLimit(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "AcceptedDefault",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"currency": "USD", "daily": 100000, "monthly": 1000000},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedOwner",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"owner": "some-user", "currency": "USD", "per_transaction": 5000},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedAccount",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"account_id": 2, "daily": 500},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestMissingCurrency",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"owner": "some-user", "daily": 500},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestOwnerAndAccount",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"owner": "some-user", "account_id": 2, "daily": 500},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestNegative",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"currency": "USD", "daily": -1},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFoundOwner",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"owner": "user", "currency": "USD", "daily": 500},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "NotFoundAccount",
+			Username:              "admin-user",
+			ReqBody:               map[string]interface{}{"account_id": 1001, "daily": 500},
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Forbidden",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"owner": "some-user", "currency": "USD"},
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPut, "/admin/transfer_limits", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_listTransferLimits(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Query                 string
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Query:                 "owner=some-user&page=1&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequest",
+			Query:                 "page=0&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "Forbidden",
+			Query:                 "page=1&size=5",
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusForbidden,
+		},
+		{
+			Name:                  "ServerError",
+			Query:                 "page=1000&size=5",
+			Username:              "admin-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, "/admin/transfer_limits?"+tc.Query, nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_deleteTransferLimit(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "ServerError",
+			ID:                    1001,
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/transfer_limits/%d", tc.ID), nil)
+		setAuthorizationHeader(t, req, "admin-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_getAccountTransferLimits(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/admin/accounts/2/transfer_limits", nil)
+	setAuthorizationHeader(t, req, "admin-user")
+
+	rr := httptest.NewRecorder()
+
+	serverTest.router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("failed wrong response code, want %d got %d", http.StatusAccepted, rr.Code)
+	}
+
+	var res accountTransferLimitsResponse
+	err := json.Unmarshal(rr.Body.Bytes(), &res)
+	if err != nil {
+		t.Fatalf("failed unmarshal response error:%s", err)
+	}
+	// default policy of the mock with a daily limit of 1000 and 300 already sent
+	if res.Owner == nil || res.Owner.RemainingDaily != 700 || res.Owner.RemainingMonthly != -1 {
+		t.Fatalf("failed wrong owner allowance %+v", res.Owner)
+	}
+	if res.Account != nil {
+		t.Fatalf("failed unexpected account allowance %+v", res.Account)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/admin/accounts/1/transfer_limits", nil)
+	setAuthorizationHeader(t, req, "admin-user")
+	rr = httptest.NewRecorder()
+	serverTest.router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("failed wrong response code, want %d got %d", http.StatusNotFound, rr.Code)
+	}
+}
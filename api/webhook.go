@@ -0,0 +1,247 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/token"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/ismail118/simple-bank/webhook"
+	"net/http"
+)
+
+type createWebhookEndpointRequest struct {
+	URL string `json:"url" binding:"required,url,max=2048"`
+	// every event type when empty
+	EventTypes []string `json:"event_types" binding:"omitempty,unique,dive,oneof=transfer.posted account.status_changed user.email_verified"`
+}
+
+type createWebhookEndpointResponse struct {
+	Endpoint models.WebhookEndpoint `json:"endpoint"`
+	// only returned here, it is needed to verify the signature of the deliveries
+	Secret string `json:"secret"`
+}
+
+// createWebhookEndpoint register an url receiving the events of the authenticated user, the deliveries are
+// signed with the secret of the response
+func (s *Server) createWebhookEndpoint(ctx *gin.Context) {
+	var req createWebhookEndpointRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the deliveries are sent from inside the bank network, they must not reach its internal services
+	err = webhook.ValidateURL(req.URL)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	endpoint := models.WebhookEndpoint{
+		Owner:      authPayload.Username,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+	}
+	if endpoint.EventTypes == nil {
+		endpoint.EventTypes = []string{}
+	}
+	endpoint.ID, err = s.repo.InsertWebhookEndpoint(ctx, endpoint)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, createWebhookEndpointResponse{
+		Endpoint: endpoint,
+		Secret:   secret,
+	})
+}
+
+// getOwnedWebhookEndpoint return the webhook endpoint after checking it belong to the authenticated user,
+// otherwise the response is written and ok is false
+func (s *Server) getOwnedWebhookEndpoint(ctx *gin.Context, id int64) (models.WebhookEndpoint, bool) {
+	endpoint, err := s.repo.GetWebhookEndpointByID(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return endpoint, false
+	}
+	if endpoint.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "webhook endpoint not found")
+		return endpoint, false
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if endpoint.Owner != authPayload.Username {
+		err = errors.New("webhook endpoint doesn't belong to authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return endpoint, false
+	}
+
+	return endpoint, true
+}
+
+func (s *Server) getWebhookEndpoint(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, ok := s.getOwnedWebhookEndpoint(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, endpoint)
+}
+
+func (s *Server) listWebhookEndpoints(ctx *gin.Context) {
+	var req listRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// authorization
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	items, err := s.repo.GetListWebhookEndpoints(ctx,
+		authPayload.Username,
+		req.Size,
+		(req.Page-1)*req.Size,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, items)
+}
+
+// deleteWebhookEndpoint delete the endpoint with its deliveries, the pending ones are not attempted anymore
+func (s *Server) deleteWebhookEndpoint(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, ok := s.getOwnedWebhookEndpoint(ctx, req.ID)
+	if !ok {
+		return
+	}
+
+	err = s.repo.DeleteWebhookEndpoint(ctx, endpoint.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, fmt.Sprintf("webhook endpoint %d deleted", endpoint.ID))
+}
+
+type listWebhookDeliveriesRequest struct {
+	// every status when empty, dead for the dead letters
+	Status string `json:"status" form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+	cursorListRequest
+}
+
+type listWebhookDeliveriesResponse struct {
+	Deliveries []*models.WebhookDelivery `json:"deliveries"`
+	NextCursor string                    `json:"next_cursor"`
+}
+
+// listWebhookDeliveries return the deliveries to the endpoint, the newest first. The next page hold the
+// deliveries older than the cursor, so the deliveries of new events don't shift the pages.
+func (s *Server) listWebhookDeliveries(ctx *gin.Context) {
+	var uri getByIdRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req listWebhookDeliveriesRequest
+	err = ctx.ShouldBindQuery(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	beforeID, err := util.DecodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	endpoint, ok := s.getOwnedWebhookEndpoint(ctx, uri.ID)
+	if !ok {
+		return
+	}
+
+	items, err := s.repo.GetListWebhookDeliveries(ctx, endpoint.ID, req.Status, beforeID, req.Size+1)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	var res listWebhookDeliveriesResponse
+	res.Deliveries, res.NextCursor = cursorPage(items, req.Size, func(d *models.WebhookDelivery) int64 { return d.ID })
+
+	ctx.JSON(http.StatusAccepted, res)
+}
+
+// replayWebhookDelivery attempt a dead or succeeded delivery again with its attempts reset, it is picked up
+// by the next relay of the webhook outbox
+func (s *Server) replayWebhookDelivery(ctx *gin.Context) {
+	var req getByIdRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	delivery, err := s.repo.GetWebhookDeliveryByID(ctx, req.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if delivery.ID < 1 {
+		ctx.JSON(http.StatusNotFound, "webhook delivery not found")
+		return
+	}
+
+	_, ok := s.getOwnedWebhookEndpoint(ctx, delivery.EndpointID)
+	if !ok {
+		return
+	}
+
+	if delivery.Status == models.WebhookDeliveryStatusPending {
+		ctx.JSON(http.StatusUnprocessableEntity, fmt.Sprintf("webhook delivery %d is already pending", delivery.ID))
+		return
+	}
+
+	delivery, err = s.repo.ReplayWebhookDelivery(ctx, delivery.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, delivery)
+}
@@ -0,0 +1,267 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/ismail118/simple-bank/util"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func Test_createWebhookEndpoint(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Username              string
+		ReqBody               map[string]interface{}
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "https://example.com/webhooks", "event_types": []string{"transfer.posted"}},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "AcceptedEveryEventType",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "https://example.com/webhooks"},
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestURL",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "not an url"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestScheme",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "ftp://example.com/webhooks"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestHttp",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "http://example.com/webhooks"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestMetadataAddress",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "https://169.254.169.254/latest/meta-data"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestPrivateAddress",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "https://10.0.0.5/webhooks"},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestEventType",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "https://example.com/webhooks", "event_types": []string{"transfer.created"}},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "BadRequestDuplicateEventType",
+			Username:              "some-user",
+			ReqBody:               map[string]interface{}{"url": "https://example.com/webhooks", "event_types": []string{"transfer.posted", "transfer.posted"}},
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "InternalServerError",
+			Username:              "test-error-db",
+			ReqBody:               map[string]interface{}{"url": "https://example.com/webhooks"},
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		body, _ := json.Marshal(tc.ReqBody)
+		req, _ := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+		if rr.Code == http.StatusAccepted {
+			var res createWebhookEndpointResponse
+			err := json.Unmarshal(rr.Body.Bytes(), &res)
+			if err != nil || !strings.HasPrefix(res.Secret, "whsec_") || res.Endpoint.EventTypes == nil {
+				t.Fatalf("failed %s wrong response %s", tc.Name, rr.Body.String())
+			}
+		}
+	}
+}
+
+func Test_getAndDeleteWebhookEndpoint(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "Accepted",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			ID:                    2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "InternalServerError",
+			ID:                    1001,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		for _, tc := range testCases {
+			req, _ := http.NewRequest(method, fmt.Sprintf("/webhooks/%d", tc.ID), nil)
+			setAuthorizationHeader(t, req, tc.Username)
+
+			rr := httptest.NewRecorder()
+
+			serverTest.router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.ExpectationStatusCode {
+				t.Fatalf("failed %s %s wrong response code, want %d got %d", method, tc.Name, tc.ExpectationStatusCode, rr.Code)
+			}
+			// the secret is only returned when the endpoint is created
+			if strings.Contains(rr.Body.String(), "whsec_") {
+				t.Fatalf("failed %s %s secret returned %s", method, tc.Name, rr.Body.String())
+			}
+		}
+	}
+}
+
+func Test_listWebhooks(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		Path                  string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "AcceptedEndpoints",
+			Path:                  "/webhooks?page=1&size=5",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestEndpoints",
+			Path:                  "/webhooks?page=0&size=5",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "InternalServerErrorEndpoints",
+			Path:                  "/webhooks?page=1000&size=5",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+		{
+			Name:                  "AcceptedDeliveries",
+			Path:                  "/webhooks/2/deliveries?status=dead&size=5",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "BadRequestDeliveriesStatus",
+			Path:                  "/webhooks/2/deliveries?status=failed&size=5",
+			ExpectationStatusCode: http.StatusBadRequest,
+		},
+		{
+			Name:                  "NotFoundDeliveries",
+			Path:                  "/webhooks/1/deliveries?size=5",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "InternalServerErrorDeliveries",
+			Path:                  fmt.Sprintf("/webhooks/2/deliveries?cursor=%s&size=5", util.EncodeCursor(1001)),
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodGet, tc.Path, nil)
+		setAuthorizationHeader(t, req, "some-user")
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
+
+func Test_replayWebhookDelivery(t *testing.T) {
+	testCases := []struct {
+		Name                  string
+		ID                    int64
+		Username              string
+		ExpectationStatusCode int
+	}{
+		{
+			Name:                  "AcceptedDead",
+			ID:                    2,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusAccepted,
+		},
+		{
+			Name:                  "UnprocessableEntityPending",
+			ID:                    3,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			Name:                  "NotFound",
+			ID:                    1,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusNotFound,
+		},
+		{
+			Name:                  "Unauthorized",
+			ID:                    2,
+			Username:              "other-user",
+			ExpectationStatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:                  "InternalServerError",
+			ID:                    1001,
+			Username:              "some-user",
+			ExpectationStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/webhook_deliveries/%d/replay", tc.ID), nil)
+		setAuthorizationHeader(t, req, tc.Username)
+
+		rr := httptest.NewRecorder()
+
+		serverTest.router.ServeHTTP(rr, req)
+
+		if rr.Code != tc.ExpectationStatusCode {
+			t.Fatalf("failed %s wrong response code, want %d got %d", tc.Name, tc.ExpectationStatusCode, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+CREATE TABLE "idempotency_keys" (
+    "username" varchar NOT NULL,
+    "idempotency_key" varchar NOT NULL,
+    "request_hash" varchar NOT NULL,
+    "response" jsonb,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("username", "idempotency_key")
+);
+
+COMMENT ON COLUMN "idempotency_keys"."response" IS 'null until the request it guards has been committed';
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username") ON DELETE CASCADE;
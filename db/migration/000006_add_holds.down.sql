@@ -0,0 +1,3 @@
+drop table if exists holds;
+
+ALTER TABLE "accounts" DROP COLUMN "held_balance";
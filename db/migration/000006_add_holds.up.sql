@@ -0,0 +1,29 @@
+ALTER TABLE "accounts" ADD COLUMN "held_balance" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "accounts"."held_balance" IS 'sum of authorized holds, available balance is balance - held_balance';
+
+CREATE TABLE "holds" (
+    "id" bigserial PRIMARY KEY,
+    "account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'authorized',
+    "transfer_id" bigint,
+    "expired_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "holds" ("account_id");
+
+CREATE INDEX ON "holds" ("status", "expired_at");
+
+COMMENT ON COLUMN "holds"."amount" IS 'it must be positive';
+
+COMMENT ON COLUMN "holds"."status" IS 'authorized, captured, voided or expired';
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "holds" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "reversal_of" bigint;
+
+COMMENT ON COLUMN "transfers"."reversal_of" IS 'the transfer reversed by this transfer, total reversals must not exceed its amount';
+
+CREATE INDEX ON "transfers" ("reversal_of");
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reversal_of") REFERENCES "transfers" ("id");
@@ -0,0 +1,3 @@
+drop table if exists account_status_history;
+
+ALTER TABLE "accounts" DROP COLUMN "status";
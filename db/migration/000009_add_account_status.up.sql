@@ -0,0 +1,19 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen, dormant or closed, only active account can send or receive money';
+
+CREATE TABLE "account_status_history" (
+    "id" bigserial PRIMARY KEY,
+    "account_id" bigint NOT NULL,
+    "from_status" varchar NOT NULL,
+    "to_status" varchar NOT NULL,
+    "reason" varchar NOT NULL,
+    "changed_by" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "account_status_history" ("account_id");
+
+COMMENT ON COLUMN "account_status_history"."changed_by" IS 'username of the user or name of the system process that changed the status';
+
+ALTER TABLE "account_status_history" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,11 @@
+ALTER TABLE "transfers" DROP COLUMN "fx_spread_bps";
+
+ALTER TABLE "transfers" DROP COLUMN "fx_rate";
+
+ALTER TABLE "transfers" DROP COLUMN "to_amount";
+
+drop table if exists fx_rates;
+
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "accounts_currency_fkey";
+
+drop table if exists currencies;
@@ -0,0 +1,57 @@
+CREATE TABLE "currencies" (
+    "code" varchar(3) PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "minor_unit" int NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 alphabetic code';
+
+COMMENT ON COLUMN "currencies"."minor_unit" IS 'ISO 4217 minor unit exponent, amounts are stored as integer of 10^-minor_unit';
+
+INSERT INTO "currencies" ("code", "name", "minor_unit") VALUES
+    ('USD', 'US Dollar', 2),
+    ('EUR', 'Euro', 2),
+    ('CAD', 'Canadian Dollar', 2),
+    ('GBP', 'Pound Sterling', 2),
+    ('JPY', 'Yen', 0),
+    ('IDR', 'Rupiah', 2),
+    ('KWD', 'Kuwaiti Dinar', 3);
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+CREATE TABLE "fx_rates" (
+    "id" bigserial PRIMARY KEY,
+    "base_currency" varchar(3) NOT NULL,
+    "quote_currency" varchar(3) NOT NULL,
+    "rate" numeric(20,10) NOT NULL,
+    "spread_bps" int NOT NULL DEFAULT 0,
+    "effective_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("rate" > 0),
+    CHECK ("spread_bps" >= 0 AND "spread_bps" < 10000)
+);
+
+COMMENT ON COLUMN "fx_rates"."rate" IS 'amount of quote currency for one unit of base currency';
+
+COMMENT ON COLUMN "fx_rates"."spread_bps" IS 'bank margin in basis points taken from the converted amount';
+
+CREATE INDEX ON "fx_rates" ("base_currency", "quote_currency", "effective_at");
+
+ALTER TABLE "fx_rates" ADD FOREIGN KEY ("base_currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "fx_rates" ADD FOREIGN KEY ("quote_currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "fx_rate" numeric(20,10);
+
+ALTER TABLE "transfers" ADD COLUMN "fx_spread_bps" int NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited in the currency of to account, equal to amount when both accounts use the same currency';
+
+COMMENT ON COLUMN "transfers"."fx_rate" IS 'quoted rate used for cross currency transfer, null for same currency transfer';
@@ -0,0 +1,3 @@
+drop table if exists scheduled_transfer_executions;
+
+drop table if exists scheduled_transfers;
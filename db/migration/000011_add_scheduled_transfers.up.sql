@@ -0,0 +1,51 @@
+CREATE TABLE "scheduled_transfers" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "cron_spec" varchar NOT NULL,
+    "next_run_at" timestamptz NOT NULL,
+    "end_at" timestamptz,
+    "failure_policy" varchar NOT NULL DEFAULT 'skip',
+    "status" varchar NOT NULL DEFAULT 'active',
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("amount" > 0)
+);
+
+COMMENT ON COLUMN "scheduled_transfers"."cron_spec" IS 'standard 5 fields cron spec evaluated in UTC';
+
+COMMENT ON COLUMN "scheduled_transfers"."end_at" IS 'no run after this time, null run forever';
+
+COMMENT ON COLUMN "scheduled_transfers"."failure_policy" IS 'skip keep the schedule for the next run, pause stop the schedule until the owner resume it';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'active, paused, completed or cancelled';
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "next_run_at");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+CREATE TABLE "scheduled_transfer_executions" (
+    "id" bigserial PRIMARY KEY,
+    "scheduled_transfer_id" bigint NOT NULL,
+    "scheduled_at" timestamptz NOT NULL,
+    "status" varchar NOT NULL,
+    "transfer_id" bigint,
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "scheduled_transfer_executions"."status" IS 'succeeded or failed';
+
+CREATE UNIQUE INDEX ON "scheduled_transfer_executions" ("scheduled_transfer_id", "scheduled_at");
+
+ALTER TABLE "scheduled_transfer_executions" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "scheduled_transfer_executions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,3 @@
+drop table if exists transfer_batch_items;
+
+drop table if exists transfer_batches;
@@ -0,0 +1,38 @@
+CREATE TABLE "transfer_batches" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "mode" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "total_items" int NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "transfer_batches"."mode" IS 'atomic run all items in one transaction, best_effort run each item on its own in the worker';
+
+COMMENT ON COLUMN "transfer_batches"."status" IS 'pending, completed or failed';
+
+CREATE INDEX ON "transfer_batches" ("owner");
+
+ALTER TABLE "transfer_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+CREATE TABLE "transfer_batch_items" (
+    "id" bigserial PRIMARY KEY,
+    "batch_id" bigint NOT NULL,
+    "item_index" int NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "transfer_id" bigint,
+    "error" varchar NOT NULL DEFAULT '',
+    "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "transfer_batch_items"."status" IS 'pending, succeeded or failed';
+
+CREATE UNIQUE INDEX ON "transfer_batch_items" ("batch_id", "item_index");
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "transfer_batches" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,9 @@
+drop table if exists interest_accruals;
+
+drop table if exists interest_postings;
+
+drop table if exists house_accounts;
+
+ALTER TABLE "accounts" DROP COLUMN "product_id";
+
+drop table if exists account_products;
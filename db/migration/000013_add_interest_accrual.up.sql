@@ -0,0 +1,71 @@
+CREATE TABLE "account_products" (
+    "id" bigserial PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "currency" varchar(3) NOT NULL,
+    "annual_rate" numeric(9,6) NOT NULL,
+    "day_count" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("annual_rate" >= 0),
+    CHECK ("day_count" IN ('ACT/365', '30/360'))
+);
+
+COMMENT ON COLUMN "account_products"."annual_rate" IS 'nominal annual interest rate, 0.0425 is 4.25%';
+
+ALTER TABLE "account_products" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "accounts" ADD COLUMN "product_id" bigint;
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("product_id") REFERENCES "account_products" ("id");
+
+CREATE TABLE "house_accounts" (
+    "purpose" varchar NOT NULL,
+    "currency" varchar(3) NOT NULL,
+    "account_id" bigint NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    PRIMARY KEY ("purpose", "currency")
+);
+
+COMMENT ON TABLE "house_accounts" IS 'accounts owned by the bank used as the other side of system postings, e.g. interest_expense';
+
+ALTER TABLE "house_accounts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE TABLE "interest_postings" (
+    "id" bigserial PRIMARY KEY,
+    "account_id" bigint NOT NULL,
+    "period_end" date NOT NULL,
+    "accrued_micros" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "carry_micros" bigint NOT NULL,
+    "transfer_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "interest_postings"."accrued_micros" IS 'accruals of the period plus carry of the previous posting, in millionths of minor unit';
+
+COMMENT ON COLUMN "interest_postings"."carry_micros" IS 'fraction of minor unit not posted, carried to the next posting';
+
+CREATE UNIQUE INDEX ON "interest_postings" ("account_id", "period_end");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_postings" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE TABLE "interest_accruals" (
+    "id" bigserial PRIMARY KEY,
+    "account_id" bigint NOT NULL,
+    "accrual_date" date NOT NULL,
+    "balance" bigint NOT NULL,
+    "annual_rate" numeric(9,6) NOT NULL,
+    "day_count" varchar NOT NULL,
+    "amount_micros" bigint NOT NULL,
+    "posting_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "interest_accruals"."amount_micros" IS 'interest of the day in millionths of minor unit, rounded down';
+
+CREATE UNIQUE INDEX ON "interest_accruals" ("account_id", "accrual_date");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "interest_accruals" ADD FOREIGN KEY ("posting_id") REFERENCES "interest_postings" ("id");
@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" DROP COLUMN "fee";
+
+drop table if exists fee_schedules;
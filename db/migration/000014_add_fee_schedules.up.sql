@@ -0,0 +1,29 @@
+CREATE TABLE "fee_schedules" (
+    "id" bigserial PRIMARY KEY,
+    "currency" varchar(3) NOT NULL,
+    "product_id" bigint,
+    "fee_type" varchar NOT NULL,
+    "flat_amount" bigint NOT NULL DEFAULT 0,
+    "percentage_bps" bigint NOT NULL DEFAULT 0,
+    "min_amount" bigint NOT NULL DEFAULT 0,
+    "max_amount" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("fee_type" IN ('flat', 'percentage', 'free')),
+    CHECK ("flat_amount" >= 0 AND "percentage_bps" >= 0 AND "min_amount" >= 0 AND "max_amount" >= 0)
+);
+
+COMMENT ON COLUMN "fee_schedules"."product_id" IS 'account product the schedule apply to, null is the default of the currency';
+
+COMMENT ON COLUMN "fee_schedules"."max_amount" IS 'upper bound of percentage fee, 0 means no upper bound';
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency", "product_id") WHERE "product_id" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "fee_schedules" ("currency") WHERE "product_id" IS NULL;
+
+ALTER TABLE "fee_schedules" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+ALTER TABLE "fee_schedules" ADD FOREIGN KEY ("product_id") REFERENCES "account_products" ("id");
+
+ALTER TABLE "transfers" ADD COLUMN "fee" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "transfers"."fee" IS 'fee charged to the from account in its currency, credited to the fee_revenue house account';
@@ -0,0 +1,5 @@
+drop table if exists reconciliation_reports;
+
+ALTER TABLE "users" DROP COLUMN "role";
+
+ALTER TABLE "entries" DROP COLUMN "transfer_id";
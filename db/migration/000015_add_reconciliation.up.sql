@@ -0,0 +1,40 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that posted the entry, null for entries posted before it was recorded';
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id") ON DELETE CASCADE;
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+-- best effort link of the existing entries, they were inserted right after their transfer in the same
+-- transaction. Entries that can't be matched are reported by the first reconciliation run.
+UPDATE "entries" AS e
+SET "transfer_id" = m."transfer_id"
+FROM (
+    SELECT DISTINCT ON (e."id") e."id", t."id" AS "transfer_id"
+    FROM "transfers" t
+    JOIN "entries" e ON e."created_at" >= t."created_at" AND e."created_at" < t."created_at" + interval '1 second'
+    WHERE (e."account_id" = t."from_account_id" AND (e."amount" = -t."amount" OR (t."fee" > 0 AND e."amount" = -t."fee")))
+       OR (e."account_id" = t."to_account_id" AND e."amount" = t."to_amount")
+    ORDER BY e."id", t."created_at" DESC
+) m
+WHERE e."id" = m."id";
+
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+ALTER TABLE "users" ADD CHECK ("role" IN ('depositor', 'admin'));
+
+COMMENT ON COLUMN "users"."role" IS 'admin users can use the back office endpoints, it is only granted from the database';
+
+CREATE TABLE "reconciliation_reports" (
+    "id" bigserial PRIMARY KEY,
+    "started_at" timestamptz NOT NULL,
+    "finished_at" timestamptz NOT NULL,
+    "accounts_checked" bigint NOT NULL,
+    "transfers_checked" bigint NOT NULL,
+    "findings_count" bigint NOT NULL,
+    "findings" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "reconciliation_reports"."findings" IS 'the first findings of the run, findings_count is the total';
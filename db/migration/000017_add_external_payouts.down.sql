@@ -0,0 +1,3 @@
+drop table if exists external_payouts;
+
+drop table if exists ach_files;
@@ -0,0 +1,61 @@
+CREATE TABLE "ach_files" (
+    "id" bigserial PRIMARY KEY,
+    "file_name" varchar NOT NULL UNIQUE,
+    "file_id_modifier" varchar(1) NOT NULL,
+    "content" text NOT NULL,
+    "entry_count" int NOT NULL,
+    "total_amount" bigint NOT NULL,
+    "written_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "ach_files"."file_id_modifier" IS 'A-Z then 0-9, tell apart the files created the same day';
+
+COMMENT ON COLUMN "ach_files"."written_at" IS 'null until the file is written to the output directory';
+
+CREATE INDEX ON "ach_files" ("created_at");
+
+CREATE TABLE "external_payouts" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" varchar(3) NOT NULL,
+    "routing_number" varchar(9) NOT NULL,
+    "account_number" varchar(17) NOT NULL,
+    "account_type" varchar NOT NULL,
+    "beneficiary_name" varchar(22) NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'queued',
+    "transfer_id" bigint NOT NULL,
+    "ach_file_id" bigint,
+    "trace_number" varchar(15),
+    "return_code" varchar(3) NOT NULL DEFAULT '',
+    "return_transfer_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("amount" > 0),
+    CHECK ("account_type" IN ('checking', 'savings')),
+    CHECK ("status" IN ('queued', 'submitted', 'returned'))
+);
+
+COMMENT ON COLUMN "external_payouts"."transfer_id" IS 'debit of the customer account into the ach clearing house account';
+
+COMMENT ON COLUMN "external_payouts"."trace_number" IS 'set when the payout is written to an ach file, matched by the returns, the sequence wrap so it is not unique';
+
+COMMENT ON COLUMN "external_payouts"."return_transfer_id" IS 'reversal crediting the customer back when the payout is returned';
+
+CREATE INDEX ON "external_payouts" ("owner");
+
+CREATE INDEX ON "external_payouts" ("status", "currency");
+
+CREATE INDEX ON "external_payouts" ("trace_number");
+
+ALTER TABLE "external_payouts" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "external_payouts" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "external_payouts" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "external_payouts" ADD FOREIGN KEY ("ach_file_id") REFERENCES "ach_files" ("id");
+
+ALTER TABLE "external_payouts" ADD FOREIGN KEY ("return_transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,16 @@
+CREATE TABLE "payees" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "nickname" varchar NOT NULL,
+    "account_id" bigint NOT NULL,
+    "active_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "payees"."active_at" IS 'end of the cooling-off period, transfers to the payee are limited before it';
+
+CREATE UNIQUE INDEX ON "payees" ("owner", "account_id");
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "payees" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
@@ -0,0 +1,2 @@
+drop index if exists transfers_from_account_id_created_at_idx;
+drop table if exists transfer_limits;
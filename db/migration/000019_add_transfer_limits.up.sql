@@ -0,0 +1,38 @@
+CREATE TABLE "transfer_limits" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar,
+    "account_id" bigint,
+    "currency" varchar(3) NOT NULL,
+    "per_transaction" bigint NOT NULL DEFAULT 0,
+    "daily" bigint NOT NULL DEFAULT 0,
+    "monthly" bigint NOT NULL DEFAULT 0,
+    "updated_by" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "updated_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("owner" IS NULL OR "account_id" IS NULL),
+    CHECK ("per_transaction" >= 0 AND "daily" >= 0 AND "monthly" >= 0)
+);
+
+COMMENT ON COLUMN "transfer_limits"."owner" IS 'user the policy apply to over all its accounts in the currency, null with account_id null is the default of the currency';
+
+COMMENT ON COLUMN "transfer_limits"."account_id" IS 'single account the policy apply to, on top of the policy of its owner';
+
+COMMENT ON COLUMN "transfer_limits"."daily" IS 'limit over the last 24 hours, 0 means no limit like per_transaction and monthly';
+
+COMMENT ON COLUMN "transfer_limits"."monthly" IS 'limit over the last 30 days';
+
+COMMENT ON COLUMN "transfer_limits"."updated_by" IS 'admin who set the policy';
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("currency") WHERE "owner" IS NULL AND "account_id" IS NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("owner", "currency") WHERE "owner" IS NOT NULL;
+
+CREATE UNIQUE INDEX ON "transfer_limits" ("account_id") WHERE "account_id" IS NOT NULL;
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_limits" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
@@ -0,0 +1,2 @@
+drop index if exists sessions_username_user_agent_client_ip_idx;
+drop table if exists transfer_reviews;
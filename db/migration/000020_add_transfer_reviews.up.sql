@@ -0,0 +1,41 @@
+CREATE TABLE "transfer_reviews" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "to_currency" varchar(3) NOT NULL DEFAULT '',
+    "idempotency_key" varchar,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "findings" jsonb NOT NULL DEFAULT '[]',
+    "reviewed_by" varchar NOT NULL DEFAULT '',
+    "transfer_id" bigint,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "reviewed_at" timestamptz,
+    CHECK ("amount" > 0),
+    CHECK ("status" IN ('pending', 'approved', 'rejected'))
+);
+
+COMMENT ON COLUMN "transfer_reviews"."to_currency" IS 'currency of the to account when converting was asked, empty for same currency transfer';
+
+COMMENT ON COLUMN "transfer_reviews"."idempotency_key" IS 'idempotency key of the request, a retry return the review instead of parking another one';
+
+COMMENT ON COLUMN "transfer_reviews"."findings" IS 'rules that asked for the review';
+
+COMMENT ON COLUMN "transfer_reviews"."transfer_id" IS 'transfer executed when the review is approved';
+
+CREATE INDEX ON "transfer_reviews" ("status", "created_at");
+
+CREATE INDEX ON "transfer_reviews" ("owner");
+
+CREATE UNIQUE INDEX ON "transfer_reviews" ("owner", "idempotency_key") WHERE "idempotency_key" IS NOT NULL;
+
+CREATE INDEX ON "sessions" ("username", "user_agent", "client_ip");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "transfer_reviews" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
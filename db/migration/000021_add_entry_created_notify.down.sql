@@ -0,0 +1,2 @@
+drop trigger if exists entries_notify_entry_created on entries;
+drop function if exists notify_entry_created;
@@ -0,0 +1,11 @@
+CREATE FUNCTION "notify_entry_created"() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('entry_created', json_build_object('entry_id', NEW."id", 'account_id', NEW."account_id")::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON FUNCTION "notify_entry_created"() IS 'notify the listeners of entry_created, the notification is only delivered when the transaction commit';
+
+CREATE TRIGGER "entries_notify_entry_created" AFTER INSERT ON "entries"
+    FOR EACH ROW EXECUTE FUNCTION "notify_entry_created"();
@@ -0,0 +1,56 @@
+CREATE TABLE "webhook_endpoints" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "url" varchar NOT NULL,
+    "secret" varchar NOT NULL,
+    "event_types" varchar[] NOT NULL DEFAULT '{}',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "webhook_endpoints"."secret" IS 'key of the HMAC-SHA256 signature of the deliveries';
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'event types delivered to the endpoint, empty for every type';
+
+CREATE INDEX ON "webhook_endpoints" ("owner");
+
+CREATE TABLE "webhook_events" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "event_type" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON TABLE "webhook_events" IS 'outbox of the events, written in the transaction of the change they describe';
+
+CREATE TABLE "webhook_deliveries" (
+    "id" bigserial PRIMARY KEY,
+    "event_id" bigint NOT NULL,
+    "endpoint_id" bigint NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "attempts" int NOT NULL DEFAULT 0,
+    "last_status_code" int NOT NULL DEFAULT 0,
+    "last_error" varchar NOT NULL DEFAULT '',
+    "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+    "delivered_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    CHECK ("status" IN ('pending', 'succeeded', 'dead'))
+);
+
+COMMENT ON COLUMN "webhook_deliveries"."last_status_code" IS 'http status of the response to the last attempt, 0 when there was no response';
+
+COMMENT ON COLUMN "webhook_deliveries"."next_attempt_at" IS 'when the pending delivery is attempted, pushed back after every failed attempt';
+
+CREATE UNIQUE INDEX ON "webhook_deliveries" ("event_id", "endpoint_id");
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "status");
+
+CREATE INDEX ON "webhook_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_events" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "webhook_events" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id") ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+CREATE TABLE "outbox" (
+    "id" bigserial PRIMARY KEY,
+    "task_type" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "queue" varchar NOT NULL,
+    "max_retry" int NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "relayed_at" timestamptz
+);
+
+COMMENT ON TABLE "outbox" IS 'worker tasks written in the transaction that need them, relayed to the task queue after the commit';
+
+COMMENT ON COLUMN "outbox"."relayed_at" IS 'when the task was enqueued, null until relayed';
+
+CREATE INDEX ON "outbox" ("id") WHERE "relayed_at" IS NULL;
@@ -0,0 +1,3 @@
+drop index if exists "accounts_owner_currency_open_key";
+
+alter table if exists "accounts" add constraint "owner_currency_key" unique ("owner", "currency");
@@ -0,0 +1,4 @@
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+
+-- a closed account keep its row for the history, the owner can open a new account in the same currency
+CREATE UNIQUE INDEX "accounts_owner_currency_open_key" ON "accounts" ("owner", "currency") WHERE "status" <> 'closed';
@@ -0,0 +1,4 @@
+alter table if exists "external_payouts" drop constraint if exists "external_payouts_status_check";
+
+alter table if exists "external_payouts" add constraint "external_payouts_status_check"
+    check ("status" in ('queued', 'submitted', 'returned'));
@@ -0,0 +1,6 @@
+ALTER TABLE "external_payouts" DROP CONSTRAINT IF EXISTS "external_payouts_status_check";
+
+ALTER TABLE "external_payouts" ADD CONSTRAINT "external_payouts_status_check"
+    CHECK ("status" IN ('queued', 'submitted', 'returned', 'return_exception'));
+
+COMMENT ON COLUMN "external_payouts"."status" IS 'return_exception when the payout is returned to a closed account, the amount stay in the ach clearing house account';
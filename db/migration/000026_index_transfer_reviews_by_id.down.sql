@@ -0,0 +1,3 @@
+drop index if exists "transfer_reviews_status_id_idx";
+
+create index on "transfer_reviews" ("status", "created_at");
@@ -0,0 +1,4 @@
+-- the review queue is paged by id after the cursor instead of by created_at with an offset
+DROP INDEX IF EXISTS "transfer_reviews_status_created_at_idx";
+
+CREATE INDEX ON "transfer_reviews" ("status", "id");
@@ -0,0 +1,26 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SendAttachment email data as an attachment named filename. SendEmail attach files from disk
+// so the data is written to a temporary directory that is removed once the email is sent.
+func SendAttachment(sender SenderEmail, subject string, content string, to []string, filename string, data []byte) error {
+	dir, err := os.MkdirTemp("", "attachment-")
+	if err != nil {
+		return fmt.Errorf("failed to create attachment dir:%w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the base name is kept so the attachment has the same name as the file
+	path := filepath.Join(dir, filepath.Base(filename))
+	err = os.WriteFile(path, data, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write attachment %s:%w", filename, err)
+	}
+
+	return sender.SendEmail(subject, content, to, nil, nil, []string{path})
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fileSender struct {
+	files map[string][]byte
+	paths []string
+}
+
+func (s *fileSender) SendEmail(subject string, content string, to []string, cc []string, bcc []string, attachFiles []string) error {
+	for _, f := range attachFiles {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		s.files[filepath.Base(f)] = data
+		s.paths = append(s.paths, f)
+	}
+	return nil
+}
+
+func Test_SendAttachment(t *testing.T) {
+	sender := &fileSender{files: map[string][]byte{}}
+	data := []byte("date,amount\n2023-01-01,10.00\n")
+
+	err := SendAttachment(sender, "Statement", "your statement", []string{"some@email.com"}, "statement-1.csv", data)
+	if err != nil {
+		t.Fatalf("failed send attachment: %s", err)
+	}
+
+	got, ok := sender.files["statement-1.csv"]
+	if !ok || !bytes.Equal(got, data) {
+		t.Fatalf("failed attachment want %q got %q", data, got)
+	}
+
+	// the temporary file is removed after sending
+	_, err = os.Stat(sender.paths[0])
+	if !os.IsNotExist(err) {
+		t.Errorf("failed attachment file %s not removed", sender.paths[0])
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/activity"
 	"github.com/ismail118/simple-bank/api"
 	"github.com/ismail118/simple-bank/mail"
+	"github.com/ismail118/simple-bank/nacha"
 	pb "github.com/ismail118/simple-bank/proto"
 	"github.com/ismail118/simple-bank/repository"
 	"github.com/ismail118/simple-bank/token"
@@ -19,6 +21,7 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 	"net"
 	"net/http"
+	"os"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -53,18 +56,43 @@ func main() {
 	mailer := mail.NewGmailSender(conf.EmailSenderName, conf.EmailSenderAddress, conf.EmailSenderPassword)
 
 	repo := repository.NewPostgresRepo(conn)
-	store := repository.NewStore(conn)
+	isolation, err := repository.ParseIsolationLevel(conf.DbIsolationLevel)
+	if err != nil {
+		log.Fatal().Msgf("cannot parse db isolation level error:%s", err)
+	}
+	store := repository.NewStoreWithTxOptions(conn, repository.TxOptions{
+		Isolation:    isolation,
+		MaxAttempts:  conf.DbTxMaxAttempts,
+		RetryBackoff: conf.DbTxRetryBackoff,
+	})
+
+	// "reconcile" subcommand check the ledger once and exit without starting the servers
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		runReconcile(store)
+		return
+	}
 
 	// run task processor
-	go runTaskProcessor(redisOpt, store, mailer, conf.GatewayServerAddr)
+	go runTaskProcessor(redisOpt, store, taskDistributor, mailer, conf.GatewayServerAddr, achConfig(conf), repository.PayeeRules{
+		RequirePayee:    conf.RequirePayee,
+		CoolingOffLimit: conf.PayeeCoolingOffLimit,
+	})
+	// run periodic task scheduler
+	go runTaskScheduler(redisOpt)
+	// enqueue the tasks written to the outbox by the committed transactions
+	go runOutboxRelay(store, taskDistributor)
+
+	// entries posted to the ledger are pushed to the clients watching their accounts
+	activityHub := activity.NewHub()
+	go runActivityListener(conf.DbSource, activityHub)
 
 	// run grpc server
-	go runGrpcServer(store, repo, tokenMaker, conf, taskDistributor)
+	go runGrpcServer(store, repo, tokenMaker, conf, taskDistributor, activityHub)
 
-	go runGatewayServer(store, repo, tokenMaker, conf, taskDistributor)
+	go runGatewayServer(store, repo, tokenMaker, conf, taskDistributor, activityHub)
 
 	// run http server
-	runGinServer(store, repo, tokenMaker, conf)
+	runGinServer(store, repo, tokenMaker, conf, taskDistributor)
 
 }
 
@@ -84,8 +112,8 @@ func runDBMigration(migrationURL, dbSource string) {
 	log.Info().Msg("success run db migration")
 }
 
-func runGrpcServer(store repository.Store, repo repository.Repository, tokenMaker token.Maker, conf util.Config, taskDistributor worker.TaskDistributor) {
-	server := api.NewGrpcServer(store, repo, tokenMaker, &conf, taskDistributor)
+func runGrpcServer(store repository.Store, repo repository.Repository, tokenMaker token.Maker, conf util.Config, taskDistributor worker.TaskDistributor, activityHub *activity.Hub) {
+	server := api.NewGrpcServer(store, repo, tokenMaker, &conf, taskDistributor, activityHub)
 
 	// middleware/interceptor logger
 	grpcInterceptor := grpc.UnaryInterceptor(api.GrpcInterceptorLogger)
@@ -109,8 +137,8 @@ func runGrpcServer(store repository.Store, repo repository.Repository, tokenMake
 	}
 }
 
-func runGatewayServer(store repository.Store, repo repository.Repository, tokenMaker token.Maker, conf util.Config, taskDistributor worker.TaskDistributor) {
-	server := api.NewGrpcServer(store, repo, tokenMaker, &conf, taskDistributor)
+func runGatewayServer(store repository.Store, repo repository.Repository, tokenMaker token.Maker, conf util.Config, taskDistributor worker.TaskDistributor, activityHub *activity.Hub) {
+	server := api.NewGrpcServer(store, repo, tokenMaker, &conf, taskDistributor, activityHub)
 
 	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
 		MarshalOptions: protojson.MarshalOptions{
@@ -133,6 +161,8 @@ func runGatewayServer(store repository.Store, repo repository.Repository, tokenM
 
 	// reroute http mux to grpcMux
 	mux := http.NewServeMux()
+	// the in-process gateway can't stream, WatchAccount is served as server-sent events
+	mux.HandleFunc("/v1/watch_account", server.WatchAccountEvents)
 	mux.Handle("/", grpcMux)
 
 	listener, err := net.Listen("tcp", conf.GatewayServerAddr)
@@ -148,8 +178,16 @@ func runGatewayServer(store repository.Store, repo repository.Repository, tokenM
 	}
 }
 
-func runGinServer(store repository.Store, repo repository.Repository, tokenMaker token.Maker, conf util.Config) {
-	srv := api.NewServer(store, repo, tokenMaker, &conf)
+func runActivityListener(dbSource string, hub *activity.Hub) {
+	log.Info().Msg("start activity listener")
+	err := activity.Listen(context.Background(), dbSource, hub)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to listen account activity")
+	}
+}
+
+func runGinServer(store repository.Store, repo repository.Repository, tokenMaker token.Maker, conf util.Config, taskDistributor worker.TaskDistributor) {
+	srv := api.NewServer(store, repo, tokenMaker, &conf, taskDistributor)
 
 	err := srv.Start(conf.HttpServerAddr)
 	if err != nil {
@@ -157,8 +195,8 @@ func runGinServer(store repository.Store, repo repository.Repository, tokenMaker
 	}
 }
 
-func runTaskProcessor(redisOpt asynq.RedisClientOpt, store repository.Store, mailer mail.SenderEmail, gatewaySeverAddress string) {
-	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer, gatewaySeverAddress)
+func runTaskProcessor(redisOpt asynq.RedisClientOpt, store repository.Store, taskDistributor worker.TaskDistributor, mailer mail.SenderEmail, gatewaySeverAddress string, ach worker.ACHConfig, payeeRules repository.PayeeRules) {
+	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, taskDistributor, mailer, gatewaySeverAddress, ach, payeeRules)
 	log.Info().Msg("start task processor")
 
 	err := taskProcessor.Start()
@@ -166,3 +204,78 @@ func runTaskProcessor(redisOpt asynq.RedisClientOpt, store repository.Store, mai
 		log.Fatal().Err(err).Msg("failed to start task processor")
 	}
 }
+
+// achConfig read the ach settings, the originator is checked at start so a wrong setting doesn't
+// only show up when the nightly file is written
+func achConfig(conf util.Config) worker.ACHConfig {
+	ach := worker.ACHConfig{
+		OutputDir:  conf.AchOutputDir,
+		ReturnsDir: conf.AchReturnsDir,
+		Originator: nacha.Originator{
+			ImmediateDestination:     conf.AchDestination,
+			ImmediateDestinationName: conf.AchDestinationName,
+			ImmediateOrigin:          conf.AchOrigin,
+			ImmediateOriginName:      conf.AchOriginName,
+			CompanyName:              conf.AchCompanyName,
+			CompanyID:                conf.AchCompanyID,
+			OriginatingDFI:           conf.AchOriginatingDFI,
+		},
+	}
+	err := ach.Originator.Validate()
+	if err != nil {
+		log.Fatal().Msgf("invalid ach originator config error:%s", err)
+	}
+	for _, dir := range []string{ach.OutputDir, ach.ReturnsDir} {
+		err = os.MkdirAll(dir, 0750)
+		if err != nil {
+			log.Fatal().Msgf("cannot create ach directory error:%s", err)
+		}
+	}
+
+	return ach
+}
+
+// runReconcile save a reconciliation report of the whole ledger, it exit with status 1 when
+// the ledger is inconsistent so it can be used from a cron job or a deploy check
+func runReconcile(store repository.Store) {
+	report, err := store.ReconcileLedger(context.Background(), worker.ReconcileChunkSize)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to reconcile ledger")
+	}
+
+	for _, f := range report.Findings {
+		log.Error().
+			Str("kind", f.Kind).
+			Int64("account_id", f.AccountID).
+			Int64("transfer_id", f.TransferID).
+			Msg(f.Detail)
+	}
+	log.Info().
+		Int64("report_id", report.ID).
+		Int64("accounts_checked", report.AccountsChecked).
+		Int64("transfers_checked", report.TransfersChecked).
+		Int64("findings", report.FindingsCount).
+		Msg("ledger reconciled")
+
+	if report.FindingsCount > 0 {
+		os.Exit(1)
+	}
+}
+
+func runOutboxRelay(store repository.Store, taskDistributor worker.TaskDistributor) {
+	relay := worker.NewOutboxRelay(store, taskDistributor)
+	log.Info().Msg("start outbox relay")
+	err := relay.Start(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to relay outbox")
+	}
+}
+
+func runTaskScheduler(redisOpt asynq.RedisClientOpt) {
+	taskScheduler := worker.NewRedisTaskScheduler(redisOpt)
+	log.Info().Msg("start task scheduler")
+	err := taskScheduler.Start()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to start task scheduler")
+	}
+}
@@ -1,15 +1,45 @@
 package models
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// ledger balance, include funds reserved by authorized holds
+	Balance int64 `json:"balance"`
+	// sum of authorized holds that not captured or released yet
+	HeldBalance int64 `json:"held_balance"`
+	// balance that can be spent, Balance - HeldBalance
+	AvailableBalance int64 `json:"available_balance"`
+	// how far below zero AvailableBalance may go, 0 means no overdraft
+	OverdraftLimit int64  `json:"overdraft_limit"`
+	Currency       string `json:"currency"`
+	// one of AccountStatusActive, AccountStatusFrozen, AccountStatusDormant or AccountStatusClosed
+	Status string `json:"status"`
+	// account product that decide the interest, 0 means the account doesn't earn interest
+	ProductID int64     `json:"product_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	AccountStatusActive  = "active"
+	AccountStatusFrozen  = "frozen"
+	AccountStatusDormant = "dormant"
+	AccountStatusClosed  = "closed"
+)
+
+type AccountStatusHistory struct {
+	ID         int64  `json:"id"`
+	AccountID  int64  `json:"account_id"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Reason     string `json:"reason"`
+	// username or system process that changed the status
+	ChangedBy string    `json:"changed_by"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -17,8 +47,30 @@ type Entry struct {
 	ID        int64 `json:"id"`
 	AccountID int64 `json:"account_id"`
 	// can be positive or negative
-	Amount    int64     `json:"amount"`
-	CreatedAt time.Time `json:"created_at"`
+	Amount int64 `json:"amount"`
+	// transfer that posted the entry, 0 for entries posted before it was recorded
+	TransferID int64     `json:"transfer_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const (
+	// EntryDirectionCredit is an entry with a positive amount
+	EntryDirectionCredit = "credit"
+	// EntryDirectionDebit is an entry with a negative amount
+	EntryDirectionDebit = "debit"
+)
+
+// EntryFilter narrow the entries of an account, zero fields are ignored
+type EntryFilter struct {
+	AccountID int64
+	// created in [From, To)
+	From time.Time
+	To   time.Time
+	// range of the absolute amount, both included
+	MinAmount int64
+	MaxAmount int64
+	// EntryDirectionCredit or EntryDirectionDebit
+	Direction string
 }
 
 type Transfer struct {
@@ -26,20 +78,79 @@ type Transfer struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
 	// must be positive
-	Amount    int64     `json:"amount"`
+	Amount int64 `json:"amount"`
+	// amount credited to the to account in its currency, equal to Amount for same currency transfer
+	ToAmount int64 `json:"to_amount"`
+	// quoted rate of cross currency transfer, empty for same currency transfer
+	FxRate      string `json:"fx_rate,omitempty"`
+	FxSpreadBps int64  `json:"fx_spread_bps"`
+	// id of the transfer reversed by this transfer, 0 if it is not a reversal
+	ReversalOf int64 `json:"reversal_of"`
+	// charged to the from account on top of Amount, in its currency
+	Fee       int64     `json:"fee"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// status of a transfer derived from its reversals, transfers have no status column
+const (
+	// TransferStatusPosted is a transfer that isn't a reversal and wasn't reversed
+	TransferStatusPosted = "posted"
+	// TransferStatusReversed is a transfer reversed at least partially
+	TransferStatusReversed = "reversed"
+	// TransferStatusReversal is a transfer that reverse another one
+	TransferStatusReversal = "reversal"
+)
+
+// TransferFilter narrow the transfers sent or received by an account, zero fields are ignored
+type TransferFilter struct {
+	AccountID int64
+	// the other account of the transfer
+	CounterpartyAccountID int64
+	// created in [From, To)
+	From time.Time
+	To   time.Time
+	// TransferStatusPosted, TransferStatusReversed or TransferStatusReversal
+	Status string
+}
+
+type Currency struct {
+	// ISO 4217 alphabetic code
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// ISO 4217 minor unit exponent, amounts are stored in 10^-MinorUnit of the currency
+	MinorUnit int       `json:"minor_unit"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type FxRate struct {
+	ID            int64  `json:"id"`
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// amount of quote currency for one unit of base currency, decimal string to keep it exact
+	Rate string `json:"rate"`
+	// bank margin in basis points taken from the converted amount
+	SpreadBps   int64     `json:"spread_bps"`
+	EffectiveAt time.Time `json:"effective_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 type Users struct {
-	Username       string    `json:"username"`
-	HashedPassword string    `json:"-"`
-	FullName       string    `json:"full_name"`
-	Email          string    `json:"email"`
-	IsEmailVerify  bool      `json:"is_email_verify"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	Username       string `json:"username"`
+	HashedPassword string `json:"-"`
+	FullName       string `json:"full_name"`
+	Email          string `json:"email"`
+	IsEmailVerify  bool   `json:"is_email_verify"`
+	// UserRoleDepositor or UserRoleAdmin
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
+const (
+	UserRoleDepositor = "depositor"
+	UserRoleAdmin     = "admin"
+)
+
 type Sessions struct {
 	ID           uuid.UUID `json:"id"`
 	Username     string    `json:"username"`
@@ -60,3 +171,508 @@ type VerifyEmail struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
 }
+
+type IdempotencyKey struct {
+	Username       string    `json:"username"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	RequestHash    string    `json:"request_hash"`
+	Response       []byte    `json:"response"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+const (
+	HoldStatusAuthorized = "authorized"
+	HoldStatusCaptured   = "captured"
+	HoldStatusVoided     = "voided"
+	HoldStatusExpired    = "expired"
+)
+
+type Hold struct {
+	ID          int64  `json:"id"`
+	AccountID   int64  `json:"account_id"`
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount"`
+	Status      string `json:"status"`
+	// transfer created when the hold captured, 0 if not captured
+	TransferID int64     `json:"transfer_id"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+const (
+	ScheduledTransferStatusActive    = "active"
+	ScheduledTransferStatusPaused    = "paused"
+	ScheduledTransferStatusCompleted = "completed"
+	ScheduledTransferStatusCancelled = "cancelled"
+)
+
+const (
+	// FailurePolicySkip keep the schedule active and try again at the next run
+	FailurePolicySkip = "skip"
+	// FailurePolicyPause pause the schedule until the owner resume it
+	FailurePolicyPause = "pause"
+)
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	// standard 5 fields cron spec evaluated in UTC
+	CronSpec  string    `json:"cron_spec"`
+	NextRunAt time.Time `json:"next_run_at"`
+	// zero time if the schedule has no end
+	EndAt         time.Time `json:"end_at"`
+	FailurePolicy string    `json:"failure_policy"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+const (
+	ExecutionStatusSucceeded = "succeeded"
+	ExecutionStatusFailed    = "failed"
+)
+
+type ScheduledTransferExecution struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledAt         time.Time `json:"scheduled_at"`
+	Status              string    `json:"status"`
+	// transfer created by a succeeded execution, 0 if failed
+	TransferID int64 `json:"transfer_id"`
+	// reason of a failed execution
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	// BatchModeAtomic run all items in one transaction, one failed item fail the whole batch
+	BatchModeAtomic = "atomic"
+	// BatchModeBestEffort run each item on its own in the worker
+	BatchModeBestEffort = "best_effort"
+)
+
+const (
+	BatchStatusPending   = "pending"
+	BatchStatusCompleted = "completed"
+	BatchStatusFailed    = "failed"
+)
+
+type TransferBatch struct {
+	ID         int64     `json:"id"`
+	Owner      string    `json:"owner"`
+	Mode       string    `json:"mode"`
+	Status     string    `json:"status"`
+	TotalItems int       `json:"total_items"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+const (
+	BatchItemStatusPending   = "pending"
+	BatchItemStatusSucceeded = "succeeded"
+	BatchItemStatusFailed    = "failed"
+)
+
+type TransferBatchItem struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// position of the item in the submitted batch, start from 0
+	ItemIndex     int    `json:"item_index"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Status        string `json:"status"`
+	// transfer created by a succeeded item, 0 otherwise
+	TransferID int64 `json:"transfer_id"`
+	// reason of a failed item
+	Error     string    `json:"error"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	DayCountAct365 = "ACT/365"
+	DayCount30360  = "30/360"
+)
+
+type AccountProduct struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+	// nominal annual interest rate, decimal string to keep it exact, "0.0425" is 4.25%
+	AnnualRate string `json:"annual_rate"`
+	// DayCountAct365 or DayCount30360
+	DayCount  string    `json:"day_count"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	HouseAccountInterestExpense = "interest_expense"
+	HouseAccountFeeRevenue      = "fee_revenue"
+	// HouseAccountACHClearing hold the external payouts until they are settled by the ach operator
+	HouseAccountACHClearing = "ach_clearing"
+)
+
+// HouseAccount is an account owned by the bank used as the other side of system postings
+type HouseAccount struct {
+	Purpose   string    `json:"purpose"`
+	Currency  string    `json:"currency"`
+	AccountID int64     `json:"account_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InterestMicrosPerMinorUnit is the precision of accrued interest, accruals are kept in
+// millionths of minor unit so daily interest of small balances is not rounded away
+const InterestMicrosPerMinorUnit = 1000000
+
+type InterestAccrual struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	AccrualDate time.Time `json:"accrual_date"`
+	// end of day balance the interest is computed from
+	Balance    int64  `json:"balance"`
+	AnnualRate string `json:"annual_rate"`
+	DayCount   string `json:"day_count"`
+	// interest of the day in millionths of minor unit, rounded down
+	AmountMicros int64 `json:"amount_micros"`
+	// 0 until the accrual is posted
+	PostingID int64     `json:"posting_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type InterestPosting struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	PeriodEnd time.Time `json:"period_end"`
+	// accruals of the period plus the carry of the previous posting
+	AccruedMicros int64 `json:"accrued_micros"`
+	// whole minor units credited to the account
+	Amount int64 `json:"amount"`
+	// fraction of minor unit not posted, carried to the next posting
+	CarryMicros int64 `json:"carry_micros"`
+	// 0 when nothing is credited
+	TransferID int64     `json:"transfer_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const (
+	FeeTypeFlat       = "flat"
+	FeeTypePercentage = "percentage"
+	FeeTypeFree       = "free"
+)
+
+// FeeSchedule decide the fee of a transfer, the schedule of the account product of the from account
+// is used when it exists, otherwise the default schedule of its currency
+type FeeSchedule struct {
+	ID       int64  `json:"id"`
+	Currency string `json:"currency"`
+	// 0 is the default schedule of the currency
+	ProductID int64 `json:"product_id,omitempty"`
+	// FeeTypeFlat, FeeTypePercentage or FeeTypeFree
+	FeeType    string `json:"fee_type"`
+	FlatAmount int64  `json:"flat_amount"`
+	// percentage of the amount in basis points, bounded by MinAmount and MaxAmount
+	PercentageBps int64 `json:"percentage_bps"`
+	MinAmount     int64 `json:"min_amount"`
+	// 0 means no upper bound
+	MaxAmount int64     `json:"max_amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	FindingBalanceMismatch = "balance_mismatch"
+	FindingTransferEntries = "transfer_entries_mismatch"
+)
+
+// ReconciliationFinding is one ledger inconsistency found by the reconciliation
+type ReconciliationFinding struct {
+	// FindingBalanceMismatch or FindingTransferEntries
+	Kind       string `json:"kind"`
+	AccountID  int64  `json:"account_id,omitempty"`
+	TransferID int64  `json:"transfer_id,omitempty"`
+	Detail     string `json:"detail"`
+}
+
+type ReconciliationReport struct {
+	ID               int64     `json:"id"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at"`
+	AccountsChecked  int64     `json:"accounts_checked"`
+	TransfersChecked int64     `json:"transfers_checked"`
+	// total number of findings, only the first ones are kept in Findings
+	FindingsCount int64                    `json:"findings_count"`
+	Findings      []*ReconciliationFinding `json:"findings"`
+	CreatedAt     time.Time                `json:"created_at"`
+}
+
+// AccountLedger is the balance of an account next to the sum of its entries
+type AccountLedger struct {
+	AccountID int64 `json:"account_id"`
+	Balance   int64 `json:"balance"`
+	EntrySum  int64 `json:"entry_sum"`
+}
+
+// TransferLedger is a transfer with the entries it posted summed by account
+type TransferLedger struct {
+	Transfer
+	EntryCount int64 `json:"entry_count"`
+	// sum of the entries on the from account, the amount and the fee
+	FromEntrySum int64 `json:"from_entry_sum"`
+	ToEntrySum   int64 `json:"to_entry_sum"`
+	// sum of the entries on the other accounts, the fee credited to the house account
+	OtherEntrySum int64 `json:"other_entry_sum"`
+}
+
+// AccountBalanceSnapshot is the end of day balance of an account computed from its entries
+type AccountBalanceSnapshot struct {
+	AccountID    int64     `json:"account_id"`
+	SnapshotDate time.Time `json:"snapshot_date"`
+	// end of the snapshot day, the balance is the sum of the entries created before it
+	AsOf      time.Time `json:"as_of"`
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountBalance is the balance of an account at a point in time
+type AccountBalance struct {
+	AccountID int64  `json:"account_id"`
+	Currency  string `json:"currency"`
+	// sum of the entries created before AsOf
+	Balance int64     `json:"balance"`
+	AsOf    time.Time `json:"as_of"`
+}
+
+const (
+	PayoutStatusQueued    = "queued"
+	PayoutStatusSubmitted = "submitted"
+	PayoutStatusReturned  = "returned"
+	// returned to a closed account, the amount stay in the ach clearing house account until the bank pay it out
+	PayoutStatusReturnException = "return_exception"
+)
+
+const (
+	PayoutAccountChecking = "checking"
+	PayoutAccountSavings  = "savings"
+)
+
+// ExternalPayout is a payment to an account of another bank, the customer is debited into the ach
+// clearing house account when it is queued and it is sent in the next nightly ach file
+type ExternalPayout struct {
+	ID        int64  `json:"id"`
+	Owner     string `json:"owner"`
+	AccountID int64  `json:"account_id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	// beneficiary account at the receiving bank
+	RoutingNumber   string `json:"routing_number"`
+	AccountNumber   string `json:"account_number"`
+	AccountType     string `json:"account_type"`
+	BeneficiaryName string `json:"beneficiary_name"`
+	Status          string `json:"status"`
+	TransferID      int64  `json:"transfer_id"`
+	// empty until the payout is submitted in an ach file
+	ACHFileID   int64  `json:"ach_file_id,omitempty"`
+	TraceNumber string `json:"trace_number,omitempty"`
+	// ach return reason code and the reversal of a returned payout
+	ReturnCode       string    `json:"return_code,omitempty"`
+	ReturnTransferID int64     `json:"return_transfer_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// ACHFile is a NACHA file of submitted payouts, the content is kept so a lost file can be written again
+type ACHFile struct {
+	ID             int64  `json:"id"`
+	FileName       string `json:"file_name"`
+	FileIDModifier string `json:"file_id_modifier"`
+	Content        string `json:"-"`
+	EntryCount     int    `json:"entry_count"`
+	TotalAmount    int64  `json:"total_amount"`
+	// zero time until the file is written to the output directory
+	WrittenAt time.Time `json:"written_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Payee is an account of another user saved by the owner as a transfer destination
+type Payee struct {
+	ID        int64  `json:"id"`
+	Owner     string `json:"owner"`
+	Nickname  string `json:"nickname"`
+	AccountID int64  `json:"account_id"`
+	// end of the cooling-off period, transfers to the payee are limited before it
+	ActiveAt  time.Time `json:"active_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransferLimit cap the money sent from the accounts in a currency, a limit of 0 is not enforced.
+// The policy of an owner replace the default of the currency, the policy of an account apply on top of it.
+type TransferLimit struct {
+	ID int64 `json:"id"`
+	// empty for the default of the currency and for the policy of an account
+	Owner string `json:"owner,omitempty"`
+	// 0 unless the policy is of a single account
+	AccountID      int64  `json:"account_id,omitempty"`
+	Currency       string `json:"currency"`
+	PerTransaction int64  `json:"per_transaction"`
+	// over the last 24 hours
+	Daily int64 `json:"daily"`
+	// over the last 30 days
+	Monthly int64 `json:"monthly"`
+	// admin who set the policy
+	UpdatedBy string    `json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	TransferLimitScopeDefault = "default"
+	TransferLimitScopeOwner   = "owner"
+	TransferLimitScopeAccount = "account"
+)
+
+// Scope return whether the policy is the default of the currency, of an owner or of an account
+func (l TransferLimit) Scope() string {
+	switch {
+	case l.AccountID > 0:
+		return TransferLimitScopeAccount
+	case l.Owner != "":
+		return TransferLimitScopeOwner
+	}
+	return TransferLimitScopeDefault
+}
+
+const (
+	TransferLimitPeriodPerTransaction = "per_transaction"
+	TransferLimitPeriodDaily          = "daily"
+	TransferLimitPeriodMonthly        = "monthly"
+)
+
+// TransferLimitUsage is the amount already sent over the periods of the limits
+type TransferLimitUsage struct {
+	Daily   int64 `json:"daily"`
+	Monthly int64 `json:"monthly"`
+}
+
+const (
+	RiskDecisionAllow  = "allow"
+	RiskDecisionReview = "review"
+	RiskDecisionDeny   = "deny"
+)
+
+// RiskFinding is the outcome of a risk rule that didn't allow a transfer
+type RiskFinding struct {
+	Rule string `json:"rule"`
+	// RiskDecisionReview or RiskDecisionDeny
+	Decision string `json:"decision"`
+	Reason   string `json:"reason"`
+}
+
+const (
+	TransferReviewStatusPending  = "pending"
+	TransferReviewStatusApproved = "approved"
+	TransferReviewStatusRejected = "rejected"
+)
+
+// TransferReview is a transfer parked by the risk rules until an admin approve or reject it,
+// the money only move when it is approved
+type TransferReview struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	// currency of the to account when the customer asked to convert, empty for same currency transfer
+	ToCurrency string `json:"to_currency,omitempty"`
+	// idempotency key of the request, a retry return this review instead of parking another one
+	IdempotencyKey string         `json:"-"`
+	Status         string         `json:"status"`
+	Findings       []*RiskFinding `json:"findings"`
+	// admin who approved or rejected the transfer
+	ReviewedBy string `json:"reviewed_by,omitempty"`
+	// transfer executed on approval, 0 until then
+	TransferID int64     `json:"transfer_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	// zero time while pending
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// TransferAmountStats summarize the amounts sent from an account over a period
+type TransferAmountStats struct {
+	Count   int   `json:"count"`
+	Average int64 `json:"average"`
+	Max     int64 `json:"max"`
+}
+
+const (
+	WebhookEventTransferPosted       = "transfer.posted"
+	WebhookEventAccountStatusChanged = "account.status_changed"
+	WebhookEventEmailVerified        = "user.email_verified"
+)
+
+// WebhookEventTypes list the event types an endpoint can subscribe to
+var WebhookEventTypes = []string{
+	WebhookEventTransferPosted,
+	WebhookEventAccountStatusChanged,
+	WebhookEventEmailVerified,
+}
+
+// WebhookEndpoint is an url of an integrator of the user that receive the events of the user
+type WebhookEndpoint struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	URL   string `json:"url"`
+	// key of the signature of the deliveries, only shown when the endpoint is created
+	Secret string `json:"-"`
+	// event types delivered to the endpoint, empty for every type
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// WebhookEvent is a change of the owner data recorded in the transaction of the change, Payload is the json
+// of the changed data
+type WebhookEvent struct {
+	ID        int64           `json:"id"`
+	Owner     string          `json:"owner"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	// the delivery failed too many times, it is only attempted again when replayed
+	WebhookDeliveryStatusDead = "dead"
+)
+
+// WebhookDelivery is the delivery of an event to an endpoint
+type WebhookDelivery struct {
+	ID         int64  `json:"id"`
+	EventID    int64  `json:"event_id"`
+	EndpointID int64  `json:"endpoint_id"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	// http status of the response to the last attempt, 0 when there was no response
+	LastStatusCode int       `json:"last_status_code"`
+	LastError      string    `json:"last_error"`
+	NextAttemptAt  time.Time `json:"next_attempt_at"`
+	// zero time until delivered
+	DeliveredAt time.Time `json:"delivered_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OutboxMessage is a worker task written in the transaction that need it, the relay enqueue it once the
+// transaction is committed
+type OutboxMessage struct {
+	ID       int64           `json:"id"`
+	TaskType string          `json:"task_type"`
+	Payload  json.RawMessage `json:"payload"`
+	Queue    string          `json:"queue"`
+	MaxRetry int             `json:"max_retry"`
+	// zero time until relayed
+	RelayedAt time.Time `json:"relayed_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
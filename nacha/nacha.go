@@ -0,0 +1,295 @@
+// Package nacha write the NACHA ACH files of the external payouts and read the returns sent back
+// by the receiving banks. Only what the payouts need is supported: one PPD batch of credits per file.
+package nacha
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	recordSize     = 94
+	blockingFactor = 10
+	// credits only batch
+	serviceClassCredits = "220"
+	secCode             = "PPD"
+	entryDescription    = "PAYOUT"
+	// entry amounts are 10 digits of cents
+	MaxEntryAmount = 9999999999
+)
+
+// transaction codes of the entries
+const (
+	CheckingCredit = "22"
+	SavingsCredit  = "32"
+)
+
+var ErrInvalidRoutingNumber = errors.New("invalid routing number")
+
+// Originator identify the bank sending the file and the company the entries are sent for
+type Originator struct {
+	// routing number of the ach operator or receiving point, 9 digits
+	ImmediateDestination     string
+	ImmediateDestinationName string
+	// usually the routing number of the bank preceded by a space or the company id, 10 characters
+	ImmediateOrigin     string
+	ImmediateOriginName string
+	CompanyName         string
+	// 10 characters, usually "1" followed by the tax id
+	CompanyID string
+	// first 8 digits of the routing number of the bank, also the prefix of the trace numbers
+	OriginatingDFI string
+}
+
+// Validate check the identifiers of the originator can be written in the fixed width fields
+func (o Originator) Validate() error {
+	if !ValidRoutingNumber(o.ImmediateDestination) {
+		return fmt.Errorf("immediate destination: %w", ErrInvalidRoutingNumber)
+	}
+	if o.ImmediateOrigin == "" || len(o.ImmediateOrigin) > 10 {
+		return errors.New("immediate origin must contain from 1-10 characters")
+	}
+	if o.CompanyName == "" {
+		return errors.New("missing company name")
+	}
+	if o.CompanyID == "" || len(o.CompanyID) > 10 {
+		return errors.New("company id must contain from 1-10 characters")
+	}
+	if len(o.OriginatingDFI) != 8 || !isDigits(o.OriginatingDFI) {
+		return errors.New("originating dfi must be 8 digits")
+	}
+	return nil
+}
+
+// Entry is one credit to an account of a receiving bank
+type Entry struct {
+	// CheckingCredit or SavingsCredit
+	TransactionCode string
+	// 9 digits with the check digit
+	RoutingNumber string
+	AccountNumber string
+	// in cents
+	Amount         int64
+	IndividualID   string
+	IndividualName string
+	TraceNumber    string
+}
+
+// File is the content of an ach file with a single batch
+type File struct {
+	CreatedAt time.Time
+	// A-Z then 0-9, different for every file created the same day, see FileIDModifier
+	FileIDModifier string
+	// date the receiving banks should credit the entries, see NextBusinessDay
+	EffectiveDate time.Time
+	Entries       []Entry
+}
+
+// Write render the file as fixed width records of 94 characters: file header, batch header,
+// entries, batch control and file control, padded with lines of 9 to a multiple of 10 records
+func Write(o Originator, f File) ([]byte, error) {
+	err := o.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if len(f.FileIDModifier) != 1 {
+		return nil, errors.New("file id modifier must be one character")
+	}
+	if len(f.Entries) == 0 {
+		return nil, errors.New("file has no entry")
+	}
+
+	var records []string
+	created := f.CreatedAt.UTC()
+	records = append(records, "1"+
+		"01"+
+		num(o.ImmediateDestination, 10)+
+		alpha(o.ImmediateOrigin, 10, true)+
+		created.Format("060102")+
+		created.Format("1504")+
+		strings.ToUpper(f.FileIDModifier)+
+		"094"+
+		"10"+
+		"1"+
+		alpha(o.ImmediateDestinationName, 23, false)+
+		alpha(o.ImmediateOriginName, 23, false)+
+		alpha("", 8, false))
+
+	const batchNumber = "0000001"
+	records = append(records, "5"+
+		serviceClassCredits+
+		alpha(o.CompanyName, 16, false)+
+		alpha("", 20, false)+
+		alpha(o.CompanyID, 10, false)+
+		secCode+
+		alpha(entryDescription, 10, false)+
+		created.Format("060102")+
+		f.EffectiveDate.Format("060102")+
+		alpha("", 3, false)+
+		"1"+
+		o.OriginatingDFI+
+		batchNumber)
+
+	var hash, total int64
+	for i, e := range f.Entries {
+		err = e.validate()
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		hash += atoi(e.RoutingNumber[:8])
+		total += e.Amount
+
+		records = append(records, "6"+
+			e.TransactionCode+
+			e.RoutingNumber+
+			alpha(e.AccountNumber, 17, false)+
+			fmt.Sprintf("%010d", e.Amount)+
+			alpha(e.IndividualID, 15, false)+
+			alpha(e.IndividualName, 22, false)+
+			alpha("", 2, false)+
+			"0"+
+			e.TraceNumber)
+	}
+	hash %= 10000000000
+
+	entryCount := len(f.Entries)
+	records = append(records, "8"+
+		serviceClassCredits+
+		fmt.Sprintf("%06d", entryCount)+
+		fmt.Sprintf("%010d", hash)+
+		fmt.Sprintf("%012d", 0)+
+		fmt.Sprintf("%012d", total)+
+		alpha(o.CompanyID, 10, false)+
+		alpha("", 19, false)+
+		alpha("", 6, false)+
+		o.OriginatingDFI+
+		batchNumber)
+
+	// the file control is the last record before the padding
+	blocks := (len(records) + 1 + blockingFactor - 1) / blockingFactor
+	records = append(records, "9"+
+		fmt.Sprintf("%06d", 1)+
+		fmt.Sprintf("%06d", blocks)+
+		fmt.Sprintf("%08d", entryCount)+
+		fmt.Sprintf("%010d", hash)+
+		fmt.Sprintf("%012d", 0)+
+		fmt.Sprintf("%012d", total)+
+		alpha("", 39, false))
+
+	for len(records)%blockingFactor != 0 {
+		records = append(records, strings.Repeat("9", recordSize))
+	}
+
+	var b strings.Builder
+	for _, r := range records {
+		if len(r) != recordSize {
+			return nil, fmt.Errorf("record %q is %d characters", r[:1], len(r))
+		}
+		b.WriteString(r)
+		b.WriteString("\n")
+	}
+
+	return []byte(b.String()), nil
+}
+
+func (e Entry) validate() error {
+	if e.TransactionCode != CheckingCredit && e.TransactionCode != SavingsCredit {
+		return fmt.Errorf("unsupported transaction code %s", e.TransactionCode)
+	}
+	if !ValidRoutingNumber(e.RoutingNumber) {
+		return ErrInvalidRoutingNumber
+	}
+	if e.AccountNumber == "" || len(e.AccountNumber) > 17 {
+		return errors.New("account number must contain from 1-17 characters")
+	}
+	if e.Amount < 1 || e.Amount > MaxEntryAmount {
+		return fmt.Errorf("amount must be from 1-%d", int64(MaxEntryAmount))
+	}
+	if len(e.TraceNumber) != 15 || !isDigits(e.TraceNumber) {
+		return errors.New("trace number must be 15 digits")
+	}
+	return nil
+}
+
+// ValidRoutingNumber report whether s is a 9 digits ABA routing number with a valid check digit
+func ValidRoutingNumber(s string) bool {
+	if len(s) != 9 || !isDigits(s) {
+		return false
+	}
+	weights := [9]int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i, c := range s {
+		sum += int(c-'0') * weights[i]
+	}
+	return sum%10 == 0
+}
+
+// TraceNumber return the trace number of the sequence-th entry sent by the originating dfi,
+// only the last 7 digits of the sequence are kept
+func TraceNumber(originatingDFI string, sequence int64) string {
+	return fmt.Sprintf("%s%07d", originatingDFI, sequence%10000000)
+}
+
+const fileIDModifiers = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// FileIDModifier return the modifier of the n-th file created the same day starting from 0
+func FileIDModifier(n int) (string, error) {
+	if n < 0 || n >= len(fileIDModifiers) {
+		return "", fmt.Errorf("no file id modifier left for file %d of the day", n+1)
+	}
+	return fileIDModifiers[n : n+1], nil
+}
+
+// NextBusinessDay return the next weekday after t, the federal holidays are not skipped
+// so the operator settle those entries the next banking day
+func NextBusinessDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// alpha upper case s, replace the characters NACHA doesn't allow with a space and pad it
+// with spaces to n characters, on the left when right is true
+func alpha(s string, n int, right bool) string {
+	b := make([]byte, 0, len(s))
+	for _, c := range strings.ToUpper(s) {
+		if c < 0x20 || c > 0x7e {
+			c = ' '
+		}
+		b = append(b, byte(c))
+	}
+	if len(b) > n {
+		b = b[:n]
+	}
+	if right {
+		return fmt.Sprintf("%*s", n, b)
+	}
+	return fmt.Sprintf("%-*s", n, b)
+}
+
+// num right justify the digits in a field of n characters padded with spaces
+func num(s string, n int) string {
+	return fmt.Sprintf("%*s", n, s)
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func atoi(s string) int64 {
+	var v int64
+	for _, c := range s {
+		v = v*10 + int64(c-'0')
+	}
+	return v
+}
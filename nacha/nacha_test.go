@@ -0,0 +1,250 @@
+package nacha
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+var testOriginator = Originator{
+	ImmediateDestination:     "091000019",
+	ImmediateDestinationName: "Federal Reserve Bank",
+	ImmediateOrigin:          "1234567890",
+	ImmediateOriginName:      "Simple Bank",
+	CompanyName:              "Simple Bank",
+	CompanyID:                "1234567890",
+	OriginatingDFI:           "09100001",
+}
+
+func testFile() File {
+	return File{
+		CreatedAt:      time.Date(2023, 3, 31, 1, 30, 0, 0, time.UTC),
+		FileIDModifier: "A",
+		EffectiveDate:  time.Date(2023, 4, 3, 0, 0, 0, 0, time.UTC),
+		Entries: []Entry{
+			{
+				TransactionCode: CheckingCredit,
+				RoutingNumber:   "021000021",
+				AccountNumber:   "123456789",
+				Amount:          150075,
+				IndividualID:    "1",
+				IndividualName:  "John Doe",
+				TraceNumber:     TraceNumber("09100001", 1),
+			},
+			{
+				TransactionCode: SavingsCredit,
+				RoutingNumber:   "011000015",
+				AccountNumber:   "9876543210",
+				Amount:          2500,
+				IndividualID:    "2",
+				IndividualName:  "Jane Ünicode Smith With A Long Name",
+				TraceNumber:     TraceNumber("09100001", 2),
+			},
+		},
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	got, err := Write(testOriginator, testFile())
+	if err != nil {
+		t.Fatalf("failed write error:%s", err)
+	}
+
+	golden := filepath.Join("testdata", "payouts.ach")
+	if *update {
+		err = os.WriteFile(golden, got, 0644)
+		if err != nil {
+			t.Fatalf("failed update %s error:%s", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed read %s error:%s", golden, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("failed file differ from %s, got\n%s", golden, got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	got, err := Write(testOriginator, testFile())
+	if err != nil {
+		t.Fatalf("failed write error:%s", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")
+	if len(lines)%10 != 0 {
+		t.Fatalf("failed blocking got %d records", len(lines))
+	}
+	for i, l := range lines {
+		if len(l) != 94 {
+			t.Fatalf("failed record %d is %d characters", i+1, len(l))
+		}
+	}
+
+	kinds := ""
+	for _, l := range lines[:6] {
+		kinds += l[:1]
+	}
+	if kinds != "156689" {
+		t.Fatalf("failed record types got %s", kinds)
+	}
+	// 02100002 + 01100001 = 03200003
+	if hash := lines[4][10:20]; hash != "0003200003" {
+		t.Errorf("failed entry hash got %s", hash)
+	}
+	if credit := lines[5][43:55]; credit != "000000152575" {
+		t.Errorf("failed file total credit got %s", credit)
+	}
+	if name := lines[3][54:76]; name != "JANE  NICODE SMITH WIT" {
+		t.Errorf("failed individual name got %q", name)
+	}
+	if lines[9] != strings.Repeat("9", 94) {
+		t.Errorf("failed padding got %s", lines[9])
+	}
+}
+
+func TestWriteInvalid(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Change func(o *Originator, f *File)
+	}{
+		{
+			Name:   "NoEntries",
+			Change: func(o *Originator, f *File) { f.Entries = nil },
+		},
+		{
+			Name:   "RoutingNumber",
+			Change: func(o *Originator, f *File) { f.Entries[0].RoutingNumber = "021000022" },
+		},
+		{
+			Name:   "Amount",
+			Change: func(o *Originator, f *File) { f.Entries[0].Amount = MaxEntryAmount + 1 },
+		},
+		{
+			Name:   "TransactionCode",
+			Change: func(o *Originator, f *File) { f.Entries[0].TransactionCode = "27" },
+		},
+		{
+			Name:   "FileIDModifier",
+			Change: func(o *Originator, f *File) { f.FileIDModifier = "" },
+		},
+		{
+			Name:   "OriginatingDFI",
+			Change: func(o *Originator, f *File) { o.OriginatingDFI = "091000019" },
+		},
+	}
+
+	for _, tc := range testCases {
+		o, f := testOriginator, testFile()
+		tc.Change(&o, &f)
+		_, err := Write(o, f)
+		if err == nil {
+			t.Errorf("failed %s want error", tc.Name)
+		}
+	}
+}
+
+func TestValidRoutingNumber(t *testing.T) {
+	testCases := map[string]bool{
+		"021000021":  true,
+		"011000015":  true,
+		"091000019":  true,
+		"021000022":  false,
+		"02100002":   false,
+		"0210000210": false,
+		"02100002a":  false,
+	}
+	for s, want := range testCases {
+		if got := ValidRoutingNumber(s); got != want {
+			t.Errorf("failed %s want %t got %t", s, want, got)
+		}
+	}
+}
+
+func TestTraceNumber(t *testing.T) {
+	if got := TraceNumber("09100001", 12345678); got != "091000012345678" {
+		t.Errorf("failed trace number got %s", got)
+	}
+}
+
+func TestFileIDModifier(t *testing.T) {
+	first, _ := FileIDModifier(0)
+	last, _ := FileIDModifier(35)
+	if first != "A" || last != "9" {
+		t.Errorf("failed modifiers got %s and %s", first, last)
+	}
+	_, err := FileIDModifier(36)
+	if err == nil {
+		t.Errorf("failed want error after 36 files")
+	}
+}
+
+func TestNextBusinessDay(t *testing.T) {
+	friday := time.Date(2023, 3, 31, 1, 30, 0, 0, time.UTC)
+	if got := NextBusinessDay(friday); !got.Equal(time.Date(2023, 4, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("failed friday got %s", got)
+	}
+	tuesday := time.Date(2023, 4, 4, 1, 30, 0, 0, time.UTC)
+	if got := NextBusinessDay(tuesday); !got.Equal(time.Date(2023, 4, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("failed tuesday got %s", got)
+	}
+}
+
+func TestParseReturns(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "returns.ach"))
+	if err != nil {
+		t.Fatalf("failed read returns.ach error:%s", err)
+	}
+
+	returns, err := ParseReturns(data)
+	if err != nil {
+		t.Fatalf("failed parse error:%s", err)
+	}
+	if len(returns) != 2 {
+		t.Fatalf("failed want 2 returns got %d", len(returns))
+	}
+	if returns[0].ReasonCode != "R03" || returns[0].OriginalTraceNumber != "091000010000001" {
+		t.Errorf("failed first return got %+v", returns[0])
+	}
+	if returns[1].ReasonCode != "R02" || returns[1].OriginalTraceNumber != "091000010000002" {
+		t.Errorf("failed second return got %+v", returns[1])
+	}
+}
+
+func TestParseReturnsInvalid(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Data string
+	}{
+		{
+			Name: "Empty",
+			Data: "",
+		},
+		{
+			Name: "ShortRecord",
+			Data: "101 091000019",
+		},
+		{
+			Name: "NoFileHeader",
+			Data: strings.Repeat("9", 94),
+		},
+		{
+			Name: "InvalidAddenda",
+			Data: "1" + strings.Repeat(" ", 93) + "\n" + "799X03" + strings.Repeat("0", 88),
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := ParseReturns([]byte(tc.Data))
+		if err == nil {
+			t.Errorf("failed %s want error", tc.Name)
+		}
+	}
+}
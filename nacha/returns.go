@@ -0,0 +1,59 @@
+package nacha
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Return is an entry the receiving bank sent back, e.g. R03 when the account doesn't exist
+type Return struct {
+	ReasonCode string
+	// trace number of the entry of the payout file
+	OriginalTraceNumber string
+}
+
+// ParseReturns read the return entries of a returns file, the return reason and the original trace
+// number are in the addenda record of type 99 that follow every return entry
+func ParseReturns(data []byte) ([]Return, error) {
+	var returns []Return
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for sc.Scan() {
+		line++
+		record := strings.TrimRight(sc.Text(), "\r")
+		if record == "" {
+			continue
+		}
+		if len(record) != recordSize {
+			return nil, fmt.Errorf("line %d: record must be %d characters, got %d", line, recordSize, len(record))
+		}
+		if line == 1 && record[0] != '1' {
+			return nil, errors.New("file must start with a file header record")
+		}
+		if !strings.HasPrefix(record, "799") {
+			continue
+		}
+
+		r := Return{
+			ReasonCode:          record[3:6],
+			OriginalTraceNumber: record[6:21],
+		}
+		if r.ReasonCode[0] != 'R' || !isDigits(r.ReasonCode[1:]) || !isDigits(r.OriginalTraceNumber) {
+			return nil, fmt.Errorf("line %d: invalid return addenda record", line)
+		}
+		returns = append(returns, r)
+	}
+	err := sc.Err()
+	if err != nil {
+		return nil, err
+	}
+	if line == 0 {
+		return nil, errors.New("empty file")
+	}
+
+	return returns, nil
+}
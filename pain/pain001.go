@@ -0,0 +1,243 @@
+// Package pain read ISO 20022 pain.001 customer credit transfer initiation files and write the
+// pain.002 payment status report answering them
+package pain
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Pain001Namespace is the only version of pain.001 accepted by Parse
+	Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+	pain001Name      = "pain.001.001.03"
+	isoDateFmt       = "2006-01-02"
+	// identifiers are Max35Text
+	maxIDLength = 35
+)
+
+// ISO 20022 external status reason codes used to reject the transactions
+const (
+	ReasonIncorrectAccountNumber     = "AC01"
+	ReasonInvalidCreditorAccount     = "AC03"
+	ReasonBlockedAccount             = "AC06"
+	ReasonTransactionForbidden       = "AG01"
+	ReasonZeroAmount                 = "AM01"
+	ReasonNotAllowedAmount           = "AM02"
+	ReasonNotAllowedCurrency         = "AM03"
+	ReasonInsufficientFunds          = "AM04"
+	ReasonDuplication                = "AM05"
+	ReasonInvalidControlSum          = "AM10"
+	ReasonInvalidAmount              = "AM12"
+	ReasonInvalidNumberOfTransaction = "AM18"
+	ReasonInvalidDate                = "DT01"
+	ReasonNarrative                  = "NARR"
+)
+
+var ErrUnsupportedMessage = errors.New("unsupported message, want " + pain001Name)
+
+// accountIdentification keep the proprietary id only, accounts of the bank have no IBAN
+type accountIdentification struct {
+	Other string `xml:"Othr>Id"`
+}
+
+type pain001Document struct {
+	XMLName    xml.Name `xml:"Document"`
+	Initiation struct {
+		GroupHeader struct {
+			MsgID       string `xml:"MsgId"`
+			CreatedAt   string `xml:"CreDtTm"`
+			NbOfTxs     string `xml:"NbOfTxs"`
+			CtrlSum     string `xml:"CtrlSum"`
+			InitiatorNm string `xml:"InitgPty>Nm"`
+		} `xml:"GrpHdr"`
+		PaymentInfos []struct {
+			ID            string `xml:"PmtInfId"`
+			Method        string `xml:"PmtMtd"`
+			NbOfTxs       string `xml:"NbOfTxs"`
+			CtrlSum       string `xml:"CtrlSum"`
+			ExecutionDate string `xml:"ReqdExctnDt"`
+			DebtorName    string `xml:"Dbtr>Nm"`
+			DebtorAccount struct {
+				ID       accountIdentification `xml:"Id"`
+				Currency string                `xml:"Ccy"`
+			} `xml:"DbtrAcct"`
+			Transactions []struct {
+				InstructionID string `xml:"PmtId>InstrId"`
+				EndToEndID    string `xml:"PmtId>EndToEndId"`
+				Amount        struct {
+					Currency string `xml:"Ccy,attr"`
+					Value    string `xml:",chardata"`
+				} `xml:"Amt>InstdAmt"`
+				CreditorName    string                `xml:"Cdtr>Nm"`
+				CreditorAccount accountIdentification `xml:"CdtrAcct>Id"`
+				Unstructured    []string              `xml:"RmtInf>Ustrd"`
+			} `xml:"CdtTrfTxInf"`
+		} `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+// Initiation is the content of a pain.001 file
+type Initiation struct {
+	MessageID string
+	CreatedAt string
+	// as written in the group header, the control sum may be empty
+	NumberOfTransactions string
+	ControlSum           string
+	PaymentInfos         []PaymentInfo
+}
+
+// PaymentInfo is a group of transactions debited from the same account
+type PaymentInfo struct {
+	ID     string
+	Method string
+	// as written in the payment information, both are optional
+	NumberOfTransactions string
+	ControlSum           string
+	// zero if the file has no or an invalid requested execution date
+	ExecutionDate time.Time
+	DebtorName    string
+	// account id of the debtor, empty if it is not identified by a proprietary id
+	DebtorAccount  string
+	DebtorCurrency string
+	Transactions   []Transaction
+}
+
+// Transaction is one credit transfer of a payment information
+type Transaction struct {
+	InstructionID string
+	EndToEndID    string
+	Currency      string
+	// decimal amount as written in the file
+	Amount       string
+	CreditorName string
+	// account id of the creditor, empty if it is not identified by a proprietary id
+	CreditorAccount string
+	RemittanceInfo  string
+}
+
+// Parse read a pain.001.001.03 file, only the elements needed to execute internal transfers are kept
+func Parse(data []byte) (Initiation, error) {
+	var doc pain001Document
+	err := xml.Unmarshal(data, &doc)
+	if err != nil {
+		return Initiation{}, fmt.Errorf("invalid xml: %w", err)
+	}
+	if doc.XMLName.Space != Pain001Namespace {
+		return Initiation{}, ErrUnsupportedMessage
+	}
+
+	hdr := doc.Initiation.GroupHeader
+	in := Initiation{
+		MessageID:            strings.TrimSpace(hdr.MsgID),
+		CreatedAt:            strings.TrimSpace(hdr.CreatedAt),
+		NumberOfTransactions: strings.TrimSpace(hdr.NbOfTxs),
+		ControlSum:           strings.TrimSpace(hdr.CtrlSum),
+	}
+	if in.MessageID == "" || len(in.MessageID) > maxIDLength {
+		return Initiation{}, fmt.Errorf("group header message id must contain from 1-%d characters", maxIDLength)
+	}
+	if in.NumberOfTransactions == "" {
+		return Initiation{}, errors.New("missing group header number of transactions")
+	}
+	if len(doc.Initiation.PaymentInfos) == 0 {
+		return Initiation{}, errors.New("missing payment information")
+	}
+
+	for _, p := range doc.Initiation.PaymentInfos {
+		info := PaymentInfo{
+			ID:                   strings.TrimSpace(p.ID),
+			Method:               strings.TrimSpace(p.Method),
+			NumberOfTransactions: strings.TrimSpace(p.NbOfTxs),
+			ControlSum:           strings.TrimSpace(p.CtrlSum),
+			DebtorName:           strings.TrimSpace(p.DebtorName),
+			DebtorAccount:        strings.TrimSpace(p.DebtorAccount.ID.Other),
+			DebtorCurrency:       strings.TrimSpace(p.DebtorAccount.Currency),
+		}
+		if info.ID == "" || len(info.ID) > maxIDLength {
+			return Initiation{}, fmt.Errorf("payment information id must contain from 1-%d characters", maxIDLength)
+		}
+		info.ExecutionDate, _ = time.Parse(isoDateFmt, strings.TrimSpace(p.ExecutionDate))
+
+		for _, t := range p.Transactions {
+			info.Transactions = append(info.Transactions, Transaction{
+				InstructionID:   strings.TrimSpace(t.InstructionID),
+				EndToEndID:      strings.TrimSpace(t.EndToEndID),
+				Currency:        strings.TrimSpace(t.Amount.Currency),
+				Amount:          strings.TrimSpace(t.Amount.Value),
+				CreditorName:    strings.TrimSpace(t.CreditorName),
+				CreditorAccount: strings.TrimSpace(t.CreditorAccount.Other),
+				RemittanceInfo:  strings.TrimSpace(strings.Join(t.Unstructured, " ")),
+			})
+		}
+		in.PaymentInfos = append(in.PaymentInfos, info)
+	}
+
+	return in, nil
+}
+
+// CountTransactions return the number of transactions of all the payment informations
+func (in Initiation) CountTransactions() int {
+	n := 0
+	for _, p := range in.PaymentInfos {
+		n += len(p.Transactions)
+	}
+	return n
+}
+
+// Check verify the number of transactions and the control sum of the group header and of each payment
+// information, it return the reason code and a description of the first mismatch or an empty reason
+func (in Initiation) Check() (string, string) {
+	var all []Transaction
+	for _, p := range in.PaymentInfos {
+		reason, info := checkTotals(p.NumberOfTransactions, p.ControlSum, p.Transactions)
+		if reason != "" {
+			return reason, fmt.Sprintf("payment information %s: %s", p.ID, info)
+		}
+		all = append(all, p.Transactions...)
+	}
+	return checkTotals(in.NumberOfTransactions, in.ControlSum, all)
+}
+
+// checkTotals compare the declared number of transactions and control sum, empty values are not checked
+func checkTotals(nbOfTxs, ctrlSum string, txs []Transaction) (string, string) {
+	if nbOfTxs != "" {
+		n, err := strconv.Atoi(nbOfTxs)
+		if err != nil || n != len(txs) {
+			return ReasonInvalidNumberOfTransaction, fmt.Sprintf("number of transactions is %s but found %d", nbOfTxs, len(txs))
+		}
+	}
+	if ctrlSum == "" {
+		return "", ""
+	}
+
+	want, ok := new(big.Rat).SetString(ctrlSum)
+	if !ok {
+		return ReasonInvalidControlSum, fmt.Sprintf("invalid control sum %s", ctrlSum)
+	}
+	got := new(big.Rat)
+	for _, t := range txs {
+		v, ok := new(big.Rat).SetString(t.Amount)
+		if !ok {
+			return ReasonInvalidAmount, fmt.Sprintf("invalid amount %s", t.Amount)
+		}
+		got.Add(got, v)
+	}
+	if want.Cmp(got) != 0 {
+		return ReasonInvalidControlSum, fmt.Sprintf("control sum is %s but transactions sum to %s", ctrlSum, decimal(got))
+	}
+
+	return "", ""
+}
+
+// decimal format the sum without trailing zeros
+func decimal(v *big.Rat) string {
+	s := v.FloatString(5)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
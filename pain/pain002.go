@@ -0,0 +1,170 @@
+package pain
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// ISO 20022 transaction and group status codes of the pain.002 report
+const (
+	// the transfer was posted
+	StatusAccepted = "ACSC"
+	StatusRejected = "RJCT"
+	// some of the transactions of the group were rejected
+	StatusPartiallyAccepted = "PART"
+)
+
+const (
+	isoDateTimeFmt = "2006-01-02T15:04:05Z"
+	// additional information of a status reason is Max105Text
+	maxAdditionalInfo = 105
+)
+
+// TransactionStatus is the outcome of a transaction of the pain.001 file
+type TransactionStatus struct {
+	Transaction
+	Status string
+	// empty for accepted transactions
+	Reason         string
+	AdditionalInfo string
+	// id of the posted transfer, 0 if the transaction was rejected
+	TransferID int64
+}
+
+// PaymentInfoStatus is the outcome of the transactions of a payment information, in the order of the file
+type PaymentInfoStatus struct {
+	PaymentInfo
+	Transactions []TransactionStatus
+}
+
+// StatusReport answer a pain.001 file with the status of each of its transactions
+type StatusReport struct {
+	MessageID    string
+	CreatedAt    time.Time
+	Original     Initiation
+	PaymentInfos []PaymentInfoStatus
+}
+
+type statusReason struct {
+	Code           string `xml:"Rsn>Cd"`
+	AdditionalInfo string `xml:"AddtlInf,omitempty"`
+}
+
+type pain002Transaction struct {
+	StatusID      string         `xml:"StsId,omitempty"`
+	InstructionID string         `xml:"OrgnlInstrId,omitempty"`
+	EndToEndID    string         `xml:"OrgnlEndToEndId,omitempty"`
+	Status        string         `xml:"TxSts"`
+	Reasons       []statusReason `xml:"StsRsnInf,omitempty"`
+	ServicerRef   string         `xml:"AcctSvcrRef,omitempty"`
+}
+
+type pain002PaymentInfo struct {
+	ID           string               `xml:"OrgnlPmtInfId"`
+	NbOfTxs      string               `xml:"OrgnlNbOfTxs"`
+	Status       string               `xml:"PmtInfSts"`
+	Transactions []pain002Transaction `xml:"TxInfAndSts"`
+}
+
+type pain002Document struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.002.001.03 Document"`
+	Report  struct {
+		GroupHeader struct {
+			MsgID     string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Original struct {
+			MsgID     string `xml:"OrgnlMsgId"`
+			MsgNameID string `xml:"OrgnlMsgNmId"`
+			NbOfTxs   string `xml:"OrgnlNbOfTxs"`
+			CtrlSum   string `xml:"OrgnlCtrlSum,omitempty"`
+			Status    string `xml:"GrpSts"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		PaymentInfos []pain002PaymentInfo `xml:"OrgnlPmtInfAndSts"`
+	} `xml:"CstmrPmtStsRpt"`
+}
+
+// RenderStatusReport write the report as a pain.002.001.03 customer payment status report. The status
+// of the group and of each payment information is derived from the status of their transactions.
+func RenderStatusReport(r StatusReport) ([]byte, error) {
+	var doc pain002Document
+	doc.Report.GroupHeader.MsgID = r.MessageID
+	doc.Report.GroupHeader.CreatedAt = r.CreatedAt.UTC().Format(isoDateTimeFmt)
+
+	orig := &doc.Report.Original
+	orig.MsgID = r.Original.MessageID
+	orig.MsgNameID = pain001Name
+	orig.NbOfTxs = r.Original.NumberOfTransactions
+	orig.CtrlSum = r.Original.ControlSum
+
+	var groupStatuses []string
+	for _, p := range r.PaymentInfos {
+		info := pain002PaymentInfo{
+			ID:           p.ID,
+			NbOfTxs:      strconv.Itoa(len(p.Transactions)),
+			Transactions: make([]pain002Transaction, 0, len(p.Transactions)),
+		}
+
+		var statuses []string
+		for i, t := range p.Transactions {
+			tx := pain002Transaction{
+				StatusID:      p.ID + "-" + strconv.Itoa(i+1),
+				InstructionID: t.InstructionID,
+				EndToEndID:    t.EndToEndID,
+				Status:        t.Status,
+			}
+			if len(tx.StatusID) > 35 {
+				tx.StatusID = ""
+			}
+			if t.Reason != "" {
+				tx.Reasons = []statusReason{{Code: t.Reason, AdditionalInfo: truncate(t.AdditionalInfo, maxAdditionalInfo)}}
+			}
+			if t.TransferID > 0 {
+				tx.ServicerRef = strconv.FormatInt(t.TransferID, 10)
+			}
+			info.Transactions = append(info.Transactions, tx)
+			statuses = append(statuses, t.Status)
+		}
+		info.Status = groupStatus(statuses)
+		groupStatuses = append(groupStatuses, statuses...)
+
+		doc.Report.PaymentInfos = append(doc.Report.PaymentInfos, info)
+	}
+	orig.Status = groupStatus(groupStatuses)
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	err := enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+// groupStatus is accepted or rejected when all the statuses are the same and partially accepted otherwise
+func groupStatus(statuses []string) string {
+	if len(statuses) == 0 {
+		return StatusRejected
+	}
+	for _, s := range statuses[1:] {
+		if s != statuses[0] {
+			return StatusPartiallyAccepted
+		}
+	}
+	return statuses[0]
+}
+
+// truncate s to n characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -0,0 +1,173 @@
+package pain
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func readTestFile(t *testing.T) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", "pain001.xml"))
+	if err != nil {
+		t.Fatalf("failed read pain001.xml error:%s", err)
+	}
+	return data
+}
+
+func TestParse(t *testing.T) {
+	in, err := Parse(readTestFile(t))
+	if err != nil {
+		t.Fatalf("failed parse error:%s", err)
+	}
+
+	if in.MessageID != "PAYROLL-2023-03" || in.NumberOfTransactions != "3" || in.ControlSum != "1250.50" {
+		t.Errorf("failed group header got %+v", in)
+	}
+	if len(in.PaymentInfos) != 2 || in.CountTransactions() != 3 {
+		t.Fatalf("failed payment informations got %d with %d transactions", len(in.PaymentInfos), in.CountTransactions())
+	}
+
+	salaries := in.PaymentInfos[0]
+	if salaries.ID != "SALARIES" || salaries.Method != "TRF" || salaries.DebtorAccount != "2" || salaries.DebtorCurrency != "USD" {
+		t.Errorf("failed payment information got %+v", salaries)
+	}
+	if !salaries.ExecutionDate.Equal(time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("failed execution date got %s", salaries.ExecutionDate)
+	}
+	first := salaries.Transactions[0]
+	if first.InstructionID != "SAL-1" || first.EndToEndID != "E2E-SAL-1" || first.Amount != "500.25" ||
+		first.Currency != "USD" || first.CreditorAccount != "3" || first.RemittanceInfo != "Salary March" {
+		t.Errorf("failed transaction got %+v", first)
+	}
+	// IBAN is not an account of the bank
+	if salaries.Transactions[1].CreditorAccount != "" {
+		t.Errorf("failed creditor identified by IBAN got %s", salaries.Transactions[1].CreditorAccount)
+	}
+	if got := in.PaymentInfos[1].Transactions[0].RemittanceInfo; got != "Invoice 42 Thank you" {
+		t.Errorf("failed remittance information got %s", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	valid := string(readTestFile(t))
+	testCases := []struct {
+		Name string
+		Data string
+	}{
+		{Name: "NotXML", Data: "MsgId,Amount\n1,2"},
+		{Name: "OtherVersion", Data: strings.Replace(valid, "pain.001.001.03", "pain.001.001.09", 1)},
+		{Name: "OtherMessage", Data: strings.Replace(valid, "pain.001.001.03", "camt.053.001.02", 1)},
+		{Name: "NoMessageID", Data: strings.Replace(valid, "<MsgId>PAYROLL-2023-03</MsgId>", "", 1)},
+		{Name: "LongMessageID", Data: strings.Replace(valid, "PAYROLL-2023-03", strings.Repeat("x", 36), 1)},
+		{Name: "NoNumberOfTransactions", Data: strings.Replace(valid, "<NbOfTxs>3</NbOfTxs>", "", 1)},
+		{Name: "NoPaymentInfoID", Data: strings.Replace(valid, "<PmtInfId>SALARIES</PmtInfId>", "", 1)},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse([]byte(tc.Data))
+		if err == nil {
+			t.Errorf("failed %s want error got nil", tc.Name)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	valid := string(readTestFile(t))
+	testCases := []struct {
+		Name   string
+		Data   string
+		Reason string
+	}{
+		{Name: "OK", Data: valid},
+		{Name: "GroupNumberOfTransactions", Data: strings.Replace(valid, "<NbOfTxs>3</NbOfTxs>", "<NbOfTxs>4</NbOfTxs>", 1), Reason: ReasonInvalidNumberOfTransaction},
+		{Name: "GroupControlSum", Data: strings.Replace(valid, "<CtrlSum>1250.50</CtrlSum>", "<CtrlSum>1250.51</CtrlSum>", 1), Reason: ReasonInvalidControlSum},
+		{Name: "PaymentInfoNumberOfTransactions", Data: strings.Replace(valid, "<NbOfTxs>2</NbOfTxs>", "<NbOfTxs>1</NbOfTxs>", 1), Reason: ReasonInvalidNumberOfTransaction},
+		{Name: "PaymentInfoControlSum", Data: strings.Replace(valid, "<CtrlSum>1000.50</CtrlSum>", "<CtrlSum>1000</CtrlSum>", 1), Reason: ReasonInvalidControlSum},
+		{Name: "NoControlSum", Data: strings.Replace(valid, "<CtrlSum>1250.50</CtrlSum>", "", 1)},
+		{Name: "InvalidAmount", Data: strings.Replace(valid, ">250<", ">abc<", 1), Reason: ReasonInvalidAmount},
+	}
+
+	for _, tc := range testCases {
+		in, err := Parse([]byte(tc.Data))
+		if err != nil {
+			t.Fatalf("failed %s parse error:%s", tc.Name, err)
+		}
+		reason, info := in.Check()
+		if reason != tc.Reason {
+			t.Errorf("failed %s reason want %q got %q (%s)", tc.Name, tc.Reason, reason, info)
+		}
+	}
+}
+
+func TestRenderStatusReport(t *testing.T) {
+	in, err := Parse(readTestFile(t))
+	if err != nil {
+		t.Fatalf("failed parse error:%s", err)
+	}
+
+	salaries, suppliers := in.PaymentInfos[0], in.PaymentInfos[1]
+	report := StatusReport{
+		MessageID: "PAIN002-20230328100500.000000",
+		CreatedAt: time.Date(2023, 3, 28, 10, 5, 0, 0, time.UTC),
+		Original:  in,
+		PaymentInfos: []PaymentInfoStatus{
+			{
+				PaymentInfo: salaries,
+				Transactions: []TransactionStatus{
+					{Transaction: salaries.Transactions[0], Status: StatusAccepted, TransferID: 1001},
+					{Transaction: salaries.Transactions[1], Status: StatusRejected, Reason: ReasonInvalidCreditorAccount, AdditionalInfo: "creditor account not found"},
+				},
+			},
+			{
+				PaymentInfo: suppliers,
+				Transactions: []TransactionStatus{
+					{Transaction: suppliers.Transactions[0], Status: StatusRejected, Reason: ReasonNotAllowedCurrency, AdditionalInfo: strings.Repeat("x", 200)},
+				},
+			},
+		},
+	}
+
+	data, err := RenderStatusReport(report)
+	if err != nil {
+		t.Fatalf("failed render error:%s", err)
+	}
+
+	golden := filepath.Join("testdata", "pain002.golden")
+	if *update {
+		err = os.WriteFile(golden, data, 0644)
+		if err != nil {
+			t.Fatalf("failed update %s error:%s", golden, err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("failed read %s error:%s", golden, err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("failed pain.002 doesn't match %s, run go test with -update if the change is intended\n%s", golden, data)
+	}
+}
+
+func TestGroupStatus(t *testing.T) {
+	testCases := []struct {
+		Statuses []string
+		Expected string
+	}{
+		{Statuses: []string{StatusAccepted, StatusAccepted}, Expected: StatusAccepted},
+		{Statuses: []string{StatusRejected}, Expected: StatusRejected},
+		{Statuses: []string{StatusAccepted, StatusRejected}, Expected: StatusPartiallyAccepted},
+		{Statuses: nil, Expected: StatusRejected},
+	}
+
+	for _, tc := range testCases {
+		if got := groupStatus(tc.Statuses); got != tc.Expected {
+			t.Errorf("failed %v want %s got %s", tc.Statuses, tc.Expected, got)
+		}
+	}
+}
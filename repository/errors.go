@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errors returned by the store transactions when a business rule is violated,
+// the transaction is rolled back so the caller may show the error to the client
+var (
+	ErrIdempotencyKeyReused       = errors.New("idempotency key already used with a different request")
+	ErrAccountNotFound            = errors.New("account not found")
+	ErrCurrencyMismatch           = errors.New("account currency mismatch")
+	ErrInsufficientFunds          = errors.New("insufficient funds")
+	ErrHoldNotFound               = errors.New("hold not found")
+	ErrHoldNotAuthorized          = errors.New("hold is not in authorized status")
+	ErrHoldExpired                = errors.New("hold already expired")
+	ErrHoldNotExpired             = errors.New("hold is not expired yet")
+	ErrCaptureExceedsHold         = errors.New("capture amount exceeds the held amount")
+	ErrTransferNotFound           = errors.New("transfer not found")
+	ErrReverseReversal            = errors.New("reversal transfer can't be reversed")
+	ErrReversalExceedsAmount      = errors.New("total reversals exceed the transfer amount")
+	ErrAccountNotActive           = errors.New("account is not active")
+	ErrInvalidStatusChange        = errors.New("account status change is not allowed")
+	ErrAccountNotEmpty            = errors.New("account balance must be zero to close it")
+	ErrReactivationNotAllowed     = errors.New("account can only be reactivated by the bank")
+	ErrCurrencyNotFound           = errors.New("currency not supported")
+	ErrFxRateNotFound             = errors.New("no fx rate for the currency pair")
+	ErrFxAmountTooSmall           = errors.New("converted amount is too small")
+	ErrScheduledTransferNotFound  = errors.New("scheduled transfer not found")
+	ErrScheduledTransferNotDue    = errors.New("scheduled transfer is not due")
+	ErrTransferBatchItemNotFound  = errors.New("transfer batch item not found")
+	ErrAccountProductNotFound     = errors.New("account product not found")
+	ErrHouseAccountNotFound       = errors.New("house account is not set up")
+	ErrInterestAlreadyPosted      = errors.New("interest already posted for the period")
+	ErrExternalPayoutNotFound     = errors.New("external payout not found")
+	ErrExternalPayoutNotSubmitted = errors.New("external payout is not submitted")
+	ErrTransferLimitExceeded      = errors.New("transfer limit exceeded")
+	ErrTransferReviewNotFound     = errors.New("transfer review not found")
+	ErrTransferReviewNotPending   = errors.New("transfer review is not pending")
+)
+
+// TransferLimitError is returned when a transfer exceed a limit policy, it match ErrTransferLimitExceeded
+// with errors.Is and tell the client how much can still be sent in the period of the limit
+type TransferLimitError struct {
+	LimitID int64 `json:"limit_id"`
+	// models.TransferLimitScopeDefault, models.TransferLimitScopeOwner or models.TransferLimitScopeAccount
+	Scope  string `json:"scope"`
+	Period string `json:"period"`
+	Limit  int64  `json:"limit"`
+	// what can still be sent in the period
+	Remaining int64  `json:"remaining"`
+	Currency  string `json:"currency"`
+}
+
+func (e *TransferLimitError) Error() string {
+	return fmt.Sprintf("%s: %s limit of %d %s of the %s policy, %d remaining",
+		ErrTransferLimitExceeded, e.Period, e.Limit, e.Currency, e.Scope, e.Remaining)
+}
+
+func (e *TransferLimitError) Is(target error) bool {
+	return target == ErrTransferLimitExceeded
+}
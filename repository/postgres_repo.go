@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/ismail118/simple-bank/models"
+	"github.com/lib/pq"
 	"log"
 	"time"
 )
@@ -26,18 +28,50 @@ func (r *PostgresRepository) WithTx(tx *sql.Tx) Repository {
 	}
 }
 
+// accountColumns is the column list scanned by scanAccount, keep both in the same order
+const accountColumns = `id, owner, balance, held_balance, overdraft_limit, currency, status, product_id, created_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAccount scan a row selected with accountColumns and fill the derived available balance
+func scanAccount(row rowScanner, a *models.Account) error {
+	var productID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.Balance,
+		&a.HeldBalance,
+		&a.OverdraftLimit,
+		&a.Currency,
+		&a.Status,
+		&productID,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.ProductID = productID.Int64
+	a.AvailableBalance = a.Balance - a.HeldBalance
+	return nil
+}
+
 // InsertAccount insert new account to database and return newID and error if exist
 func (r *PostgresRepository) InsertAccount(ctx context.Context, arg models.Account) (int64, error) {
 	var newID int64
 	query := `
-	insert into accounts (owner, balance, currency, created_at)
-	values ($1, $2, $3, $4)
+	insert into accounts (owner, balance, overdraft_limit, currency, product_id, created_at)
+	values ($1, $2, $3, $4, $5, $6)
 	returning id
 `
 	row := r.db.QueryRowContext(ctx, query,
 		arg.Owner,
 		arg.Balance,
+		arg.OverdraftLimit,
 		arg.Currency,
+		sql.NullInt64{Int64: arg.ProductID, Valid: arg.ProductID > 0},
 		time.Now(),
 	)
 
@@ -59,19 +93,13 @@ func (r *PostgresRepository) InsertAccount(ctx context.Context, arg models.Accou
 // GetAccountByID return account from given id or empty account if not found and error if exist
 func (r *PostgresRepository) GetAccountByID(ctx context.Context, id int64) (models.Account, error) {
 	query := `
-	select id, owner, balance, currency, created_at from accounts
+	select ` + accountColumns + ` from accounts
 	where id = $1
 `
 	var a models.Account
 
 	row := r.db.QueryRowContext(ctx, query, id)
-	err := row.Scan(
-		&a.ID,
-		&a.Owner,
-		&a.Balance,
-		&a.Currency,
-		&a.CreatedAt,
-	)
+	err := scanAccount(row, &a)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("account with id: %d not found in database", id)
@@ -90,19 +118,13 @@ func (r *PostgresRepository) GetAccountByID(ctx context.Context, id int64) (mode
 
 func (r *PostgresRepository) GetAccountByOwnerAndCurrency(ctx context.Context, owner, currency string) (models.Account, error) {
 	query := `
-	select id, owner, balance, currency, created_at from accounts
-	where owner = $1 and currency = $2
+	select ` + accountColumns + ` from accounts
+	where owner = $1 and currency = $2 and status <> 'closed'
 `
 	var a models.Account
 
 	row := r.db.QueryRowContext(ctx, query, owner, currency)
-	err := row.Scan(
-		&a.ID,
-		&a.Owner,
-		&a.Balance,
-		&a.Currency,
-		&a.CreatedAt,
-	)
+	err := scanAccount(row, &a)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("account with owner %s and currency %s not found in database", owner, currency)
@@ -119,19 +141,18 @@ func (r *PostgresRepository) GetAccountByOwnerAndCurrency(ctx context.Context, o
 	return a, nil
 }
 
-// GetListAccounts return list accounts from database and error if exist
-func (r *PostgresRepository) GetListAccounts(ctx context.Context, owner string, limit, offset int) ([]*models.Account, error) {
+// GetListAccounts return the accounts of the owner after the given account id ordered by id and error if exist
+func (r *PostgresRepository) GetListAccounts(ctx context.Context, owner string, afterID int64, limit int) ([]*models.Account, error) {
 	query := `
-	select id, owner, balance, currency, created_at 
+	select ` + accountColumns + `
 	from accounts 
-	where owner = $1 
+	where owner = $1 and id > $2
 	order by id
-	limit $2
-	offset $3
+	limit $3
 `
 	items := []*models.Account{}
 
-	rows, err := r.db.QueryContext(ctx, query, owner, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, owner, afterID, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -139,13 +160,7 @@ func (r *PostgresRepository) GetListAccounts(ctx context.Context, owner string,
 
 	for rows.Next() {
 		var a models.Account
-		err = rows.Scan(
-			&a.ID,
-			&a.Owner,
-			&a.Balance,
-			&a.Currency,
-			&a.CreatedAt,
-		)
+		err = scanAccount(rows, &a)
 		if err != nil {
 			return nil, err
 		}
@@ -174,6 +189,34 @@ func (r *PostgresRepository) UpdateAccount(ctx context.Context, arg models.Accou
 	return nil
 }
 
+// UpdateAccountOverdraftLimit set how far below zero the available balance of account from given id may go
+func (r *PostgresRepository) UpdateAccountOverdraftLimit(ctx context.Context, id, limit int64) error {
+	query := `
+	update accounts set overdraft_limit = $1
+	where id = $2
+`
+	_, err := r.db.ExecContext(ctx, query, limit, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateAccountStatus set status of account from given id
+func (r *PostgresRepository) UpdateAccountStatus(ctx context.Context, id int64, status string) error {
+	query := `
+	update accounts set status = $1
+	where id = $2
+`
+	_, err := r.db.ExecContext(ctx, query, status, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // DeleteAccount delete account from given id return error if exist
 func (r *PostgresRepository) DeleteAccount(ctx context.Context, id int64) error {
 	query := `
@@ -190,14 +233,15 @@ func (r *PostgresRepository) DeleteAccount(ctx context.Context, id int64) error
 // InsertEntry insert new entry to database and return newID and error if exist
 func (r *PostgresRepository) InsertEntry(ctx context.Context, arg models.Entry) (int64, error) {
 	query := `
-	insert into entries (account_id, amount, created_at) 
-	values ($1, $2, $3)
+	insert into entries (account_id, amount, transfer_id, created_at) 
+	values ($1, $2, $3, $4)
 	returning id
 `
 	var newID int64
 	row := r.db.QueryRowContext(ctx, query,
 		arg.AccountID,
 		arg.Amount,
+		sql.NullInt64{Int64: arg.TransferID, Valid: arg.TransferID > 0},
 		time.Now(),
 	)
 
@@ -217,18 +261,21 @@ func (r *PostgresRepository) InsertEntry(ctx context.Context, arg models.Entry)
 // GetEntryByID return entry by given id or empty entry if not found and error if exist
 func (r *PostgresRepository) GetEntryByID(ctx context.Context, id int64) (models.Entry, error) {
 	query := `
-	select id, account_id, amount, created_at from entries
+	select id, account_id, amount, transfer_id, created_at from entries
 	where id = $1
 `
 	var a models.Entry
+	var transferID sql.NullInt64
 
 	row := r.db.QueryRowContext(ctx, query, id)
 	err := row.Scan(
 		&a.ID,
 		&a.AccountID,
 		&a.Amount,
+		&transferID,
 		&a.CreatedAt,
 	)
+	a.TransferID = transferID.Int64
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -246,18 +293,31 @@ func (r *PostgresRepository) GetEntryByID(ctx context.Context, id int64) (models
 	return a, nil
 }
 
-// GetListEntries return list entry from given account_id and error if exist
-func (r *PostgresRepository) GetListEntries(ctx context.Context, accountID int64, limit, offset int) ([]*models.Entry, error) {
+// GetListEntries return the entries matching the filter after the given entry id ordered by id and error if exist
+func (r *PostgresRepository) GetListEntries(ctx context.Context, arg models.EntryFilter, afterID int64, limit int) ([]*models.Entry, error) {
 	query := `
-	select id, account_id, amount, created_at from entries
-	where account_id = $1
+	select id, account_id, amount, transfer_id, created_at from entries
+	where account_id = $1 and id > $2
+	and ($3::timestamptz is null or created_at >= $3)
+	and ($4::timestamptz is null or created_at < $4)
+	and ($5 = 0 or abs(amount) >= $5)
+	and ($6 = 0 or abs(amount) <= $6)
+	and ($7 = '' or ($7 = 'credit' and amount > 0) or ($7 = 'debit' and amount < 0))
 	order by id
-	limit $2
-	offset $3
+	limit $8
 `
 	items := []*models.Entry{}
 
-	rows, err := r.db.QueryContext(ctx, query, accountID, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query,
+		arg.AccountID,
+		afterID,
+		sql.NullTime{Time: arg.From, Valid: !arg.From.IsZero()},
+		sql.NullTime{Time: arg.To, Valid: !arg.To.IsZero()},
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Direction,
+		limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -265,15 +325,18 @@ func (r *PostgresRepository) GetListEntries(ctx context.Context, accountID int64
 
 	for rows.Next() {
 		var a models.Entry
+		var transferID sql.NullInt64
 		err = rows.Scan(
 			&a.ID,
 			&a.AccountID,
 			&a.Amount,
+			&transferID,
 			&a.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
+		a.TransferID = transferID.Int64
 		items = append(items, &a)
 	}
 
@@ -285,19 +348,57 @@ func (r *PostgresRepository) GetListEntries(ctx context.Context, accountID int64
 	return items, nil
 }
 
+// transferColumns is the column list scanned by scanTransfer, keep both in the same order
+const transferColumns = `id, from_account_id, to_account_id, amount, to_amount, fx_rate, fx_spread_bps, reversal_of, fee, created_at`
+
+func scanTransfer(row rowScanner, a *models.Transfer) error {
+	var fxRate sql.NullString
+	var reversalOf sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.FromAccountID,
+		&a.ToAccountID,
+		&a.Amount,
+		&a.ToAmount,
+		&fxRate,
+		&a.FxSpreadBps,
+		&reversalOf,
+		&a.Fee,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.FxRate = fxRate.String
+	a.ReversalOf = reversalOf.Int64
+	return nil
+}
+
 // InsertTransfer insert new transfer to database and return newID and error if exist
 func (r *PostgresRepository) InsertTransfer(ctx context.Context, arg models.Transfer) (int64, error) {
 	query := `
-	insert into transfers (from_account_id, to_account_id, amount, created_at) 
-	values ($1, $2, $3, $4)
+	insert into transfers (from_account_id, to_account_id, amount, to_amount, fx_rate, fx_spread_bps, reversal_of, fee, created_at) 
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	returning id
 `
 	var newID int64
 
+	// same currency transfer credit the same amount it debit
+	toAmount := arg.ToAmount
+	if toAmount == 0 {
+		toAmount = arg.Amount
+	}
+
 	row := r.db.QueryRowContext(ctx, query,
 		arg.FromAccountID,
 		arg.ToAccountID,
 		arg.Amount,
+		toAmount,
+		sql.NullString{String: arg.FxRate, Valid: arg.FxRate != ""},
+		arg.FxSpreadBps,
+		sql.NullInt64{Int64: arg.ReversalOf, Valid: arg.ReversalOf > 0},
+		arg.Fee,
 		time.Now(),
 	)
 	err := row.Scan(&newID)
@@ -316,19 +417,13 @@ func (r *PostgresRepository) InsertTransfer(ctx context.Context, arg models.Tran
 // GetTransferByID return transfers from given id or empty transfers if not found and error if exist
 func (r *PostgresRepository) GetTransferByID(ctx context.Context, id int64) (models.Transfer, error) {
 	query := `
-	select id, from_account_id, to_account_id, amount, created_at from transfers
+	select ` + transferColumns + ` from transfers
 	where id = $1
 `
 	var a models.Transfer
 
 	row := r.db.QueryRowContext(ctx, query, id)
-	err := row.Scan(
-		&a.ID,
-		&a.FromAccountID,
-		&a.ToAccountID,
-		&a.Amount,
-		&a.CreatedAt,
-	)
+	err := scanTransfer(row, &a)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("transfers with id:%d not found", id)
@@ -345,18 +440,33 @@ func (r *PostgresRepository) GetTransferByID(ctx context.Context, id int64) (mod
 	return a, nil
 }
 
-// GetListTransfers return list transfers from given from_account_id or to_account_id and error if exist
-func (r *PostgresRepository) GetListTransfers(ctx context.Context, fromAccountID, toAccountID int64, limit, offset int) ([]*models.Transfer, error) {
+// GetListTransfers return the transfers sent or received by the account of the filter after the given transfer id
+// ordered by id and error if exist
+func (r *PostgresRepository) GetListTransfers(ctx context.Context, arg models.TransferFilter, afterID int64, limit int) ([]*models.Transfer, error) {
 	query := `
-	select id, from_account_id, to_account_id, amount, created_at from transfers 
-	where from_account_id = $1 or to_account_id = $2
-	order by id
-	limit $3
-	offset $4
+	select ` + transferColumns + ` from transfers t
+	where (t.from_account_id = $1 or t.to_account_id = $1) and t.id > $2
+	and ($3 = 0 or (t.from_account_id = $1 and t.to_account_id = $3) or (t.to_account_id = $1 and t.from_account_id = $3))
+	and ($4::timestamptz is null or t.created_at >= $4)
+	and ($5::timestamptz is null or t.created_at < $5)
+	and ($6 = ''
+		or ($6 = 'reversal' and t.reversal_of is not null)
+		or ($6 = 'reversed' and exists (select 1 from transfers r where r.reversal_of = t.id))
+		or ($6 = 'posted' and t.reversal_of is null and not exists (select 1 from transfers r where r.reversal_of = t.id)))
+	order by t.id
+	limit $7
 `
 	items := []*models.Transfer{}
 
-	rows, err := r.db.QueryContext(ctx, query, fromAccountID, toAccountID, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query,
+		arg.AccountID,
+		afterID,
+		arg.CounterpartyAccountID,
+		sql.NullTime{Time: arg.From, Valid: !arg.From.IsZero()},
+		sql.NullTime{Time: arg.To, Valid: !arg.To.IsZero()},
+		arg.Status,
+		limit,
+	)
 	if err != nil {
 		return items, err
 	}
@@ -364,13 +474,7 @@ func (r *PostgresRepository) GetListTransfers(ctx context.Context, fromAccountID
 
 	for rows.Next() {
 		var a models.Transfer
-		err = rows.Scan(
-			&a.ID,
-			&a.FromAccountID,
-			&a.ToAccountID,
-			&a.Amount,
-			&a.CreatedAt,
-		)
+		err = scanTransfer(rows, &a)
 		if err != nil {
 			return nil, err
 		}
@@ -388,20 +492,14 @@ func (r *PostgresRepository) GetListTransfers(ctx context.Context, fromAccountID
 // GetAccountByIdForUpdate return account from given id or empty account if not found and error if exist
 func (r *PostgresRepository) GetAccountByIdForUpdate(ctx context.Context, id int64) (models.Account, error) {
 	query := `
-	select id, owner, balance, currency, created_at from accounts
+	select ` + accountColumns + ` from accounts
 	where id = $1 
 	for no key update;
 `
 	var a models.Account
 
 	row := r.db.QueryRowContext(ctx, query, id)
-	err := row.Scan(
-		&a.ID,
-		&a.Owner,
-		&a.Balance,
-		&a.Currency,
-		&a.CreatedAt,
-	)
+	err := scanAccount(row, &a)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("account with id: %d not found in database", id)
@@ -424,18 +522,12 @@ func (r *PostgresRepository) AddAccountBalanceByID(ctx context.Context, amount,
 	query := `
 	update accounts set balance = balance + $1
 	where id = $2
-	returning id, owner, balance, currency, created_at
+	returning ` + accountColumns + `
 `
 	var a models.Account
 
 	row := r.db.QueryRowContext(ctx, query, amount, id)
-	err := row.Scan(
-		&a.ID,
-		&a.Owner,
-		&a.Balance,
-		&a.Currency,
-		&a.CreatedAt,
-	)
+	err := scanAccount(row, &a)
 	if err != nil {
 		return a, err
 	}
@@ -445,15 +537,20 @@ func (r *PostgresRepository) AddAccountBalanceByID(ctx context.Context, amount,
 
 func (r *PostgresRepository) InsertUsers(ctx context.Context, arg models.Users) error {
 	query := `
-	insert into users (username, hashed_password, full_name, email, created_at, updated_at) 
-	values ($1, $2, $3, $4, $5, $6)
+	insert into users (username, hashed_password, full_name, email, role, created_at, updated_at) 
+	values ($1, $2, $3, $4, $5, $6, $7)
 `
+	role := arg.Role
+	if role == "" {
+		role = models.UserRoleDepositor
+	}
 
 	_, err := r.db.ExecContext(ctx, query,
 		arg.Username,
 		arg.HashedPassword,
 		arg.FullName,
 		arg.Email,
+		role,
 		time.Now(),
 		time.Now(),
 	)
@@ -466,7 +563,7 @@ func (r *PostgresRepository) InsertUsers(ctx context.Context, arg models.Users)
 
 func (r *PostgresRepository) GetUsersByUsername(ctx context.Context, username string) (models.Users, error) {
 	query := `
-	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify from users
+	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify, role from users
 	where username = $1
 `
 	var a models.Users
@@ -479,6 +576,7 @@ func (r *PostgresRepository) GetUsersByUsername(ctx context.Context, username st
 		&a.CreatedAt,
 		&a.UpdatedAt,
 		&a.IsEmailVerify,
+		&a.Role,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -498,7 +596,7 @@ func (r *PostgresRepository) GetUsersByUsername(ctx context.Context, username st
 
 func (r *PostgresRepository) GetUsersByEmail(ctx context.Context, email string) (models.Users, error) {
 	query := `
-	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify from users
+	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify, role from users
 	where email = $1
 `
 	var a models.Users
@@ -511,6 +609,7 @@ func (r *PostgresRepository) GetUsersByEmail(ctx context.Context, email string)
 		&a.CreatedAt,
 		&a.UpdatedAt,
 		&a.IsEmailVerify,
+		&a.Role,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -530,7 +629,7 @@ func (r *PostgresRepository) GetUsersByEmail(ctx context.Context, email string)
 
 func (r *PostgresRepository) GetListUsers(ctx context.Context, limit, offset int) ([]*models.Users, error) {
 	query := `
-	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify from users limit $1 offset $2
+	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify, role from users limit $1 offset $2
 `
 	var items []*models.Users
 	rows, err := r.db.QueryContext(ctx, query, limit, offset)
@@ -548,6 +647,7 @@ func (r *PostgresRepository) GetListUsers(ctx context.Context, limit, offset int
 			&a.CreatedAt,
 			&a.UpdatedAt,
 			&a.IsEmailVerify,
+			&a.Role,
 		)
 		if err != nil {
 			return items, err
@@ -749,3 +849,3239 @@ func (r *PostgresRepository) UpdateVerifyEmailIsUsed(ctx context.Context, id int
 	}
 	return nil
 }
+
+// InsertIdempotencyKey insert new idempotency key, do nothing if the key already exist for the user
+func (r *PostgresRepository) InsertIdempotencyKey(ctx context.Context, arg models.IdempotencyKey) error {
+	query := `
+	insert into idempotency_keys (username, idempotency_key, request_hash, created_at)
+	values ($1, $2, $3, $4)
+	on conflict (username, idempotency_key) do nothing
+`
+	_, err := r.db.ExecContext(ctx, query,
+		arg.Username,
+		arg.IdempotencyKey,
+		arg.RequestHash,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetIdempotencyKeyForUpdate return idempotency key and lock the row until the transaction end,
+// return empty idempotency key if not found and error if exist
+func (r *PostgresRepository) GetIdempotencyKeyForUpdate(ctx context.Context, username, key string) (models.IdempotencyKey, error) {
+	query := `
+	select username, idempotency_key, request_hash, response, created_at
+	from idempotency_keys
+	where username = $1 and idempotency_key = $2
+	for update
+`
+	var a models.IdempotencyKey
+
+	row := r.db.QueryRowContext(ctx, query, username, key)
+	err := row.Scan(
+		&a.Username,
+		&a.IdempotencyKey,
+		&a.RequestHash,
+		&a.Response,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	err = row.Err()
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// UpdateIdempotencyKeyResponse save the response of the request guarded by idempotency key
+func (r *PostgresRepository) UpdateIdempotencyKeyResponse(ctx context.Context, username, key string, response []byte) error {
+	query := `
+	update idempotency_keys set response = $1
+	where username = $2 and idempotency_key = $3
+`
+	_, err := r.db.ExecContext(ctx, query, response, username, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// AddAccountHeldBalanceByID increase or decrease held balance of the account from given id and return account and error if exist
+func (r *PostgresRepository) AddAccountHeldBalanceByID(ctx context.Context, amount, id int64) (models.Account, error) {
+	query := `
+	update accounts set held_balance = held_balance + $1
+	where id = $2
+	returning ` + accountColumns + `
+`
+	var a models.Account
+
+	row := r.db.QueryRowContext(ctx, query, amount, id)
+	err := scanAccount(row, &a)
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+const holdColumns = `id, account_id, to_account_id, amount, status, transfer_id, expired_at, created_at, updated_at`
+
+func scanHold(row rowScanner, a *models.Hold) error {
+	var transferID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.AccountID,
+		&a.ToAccountID,
+		&a.Amount,
+		&a.Status,
+		&transferID,
+		&a.ExpiredAt,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.TransferID = transferID.Int64
+	return nil
+}
+
+// InsertHold insert new authorized hold and return newID and error if exist
+func (r *PostgresRepository) InsertHold(ctx context.Context, arg models.Hold) (int64, error) {
+	query := `
+	insert into holds (account_id, to_account_id, amount, status, expired_at, created_at, updated_at)
+	values ($1, $2, $3, $4, $5, $6, $7)
+	returning id
+`
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.AccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		models.HoldStatusAuthorized,
+		arg.ExpiredAt,
+		time.Now(),
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = row.Err()
+	if err != nil {
+		return newID, err
+	}
+
+	return newID, nil
+}
+
+// GetHoldByID return hold from given id or empty hold if not found and error if exist
+func (r *PostgresRepository) GetHoldByID(ctx context.Context, id int64) (models.Hold, error) {
+	query := `
+	select ` + holdColumns + ` from holds
+	where id = $1
+`
+	var a models.Hold
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanHold(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	err = row.Err()
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetHoldByIDForUpdate return hold from given id and lock it until the transaction end,
+// return empty hold if not found and error if exist
+func (r *PostgresRepository) GetHoldByIDForUpdate(ctx context.Context, id int64) (models.Hold, error) {
+	query := `
+	select ` + holdColumns + ` from holds
+	where id = $1
+	for update
+`
+	var a models.Hold
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanHold(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	err = row.Err()
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// UpdateHoldStatus update hold status and the transfer that settled it, transferID 0 mean no transfer
+func (r *PostgresRepository) UpdateHoldStatus(ctx context.Context, id int64, status string, transferID int64) error {
+	query := `
+	update holds set status = $1, transfer_id = $2, updated_at = $3
+	where id = $4
+`
+	_, err := r.db.ExecContext(ctx, query,
+		status,
+		sql.NullInt64{Int64: transferID, Valid: transferID > 0},
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetListExpiredHolds return authorized holds that already pass the expired time, oldest first
+func (r *PostgresRepository) GetListExpiredHolds(ctx context.Context, limit int) ([]*models.Hold, error) {
+	query := `
+	select ` + holdColumns + ` from holds
+	where status = $1 and expired_at < now()
+	order by expired_at
+	limit $2
+`
+	items := []*models.Hold{}
+
+	rows, err := r.db.QueryContext(ctx, query, models.HoldStatusAuthorized, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Hold
+		err = scanHold(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetTransferByIDForUpdate return transfer from given id and lock it until the transaction end,
+// return empty transfer if not found and error if exist
+func (r *PostgresRepository) GetTransferByIDForUpdate(ctx context.Context, id int64) (models.Transfer, error) {
+	query := `
+	select ` + transferColumns + ` from transfers
+	where id = $1
+	for no key update
+`
+	var a models.Transfer
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanTransfer(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	err = row.Err()
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListReversals return list transfers that reverse the given transfer id, oldest first
+func (r *PostgresRepository) GetListReversals(ctx context.Context, transferID int64) ([]*models.Transfer, error) {
+	query := `
+	select ` + transferColumns + ` from transfers
+	where reversal_of = $1
+	order by id
+`
+	items := []*models.Transfer{}
+
+	rows, err := r.db.QueryContext(ctx, query, transferID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Transfer
+		err = scanTransfer(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// InsertAccountStatusHistory insert new account status change to database and return newID and error if exist
+func (r *PostgresRepository) InsertAccountStatusHistory(ctx context.Context, arg models.AccountStatusHistory) (int64, error) {
+	var newID int64
+	query := `
+	insert into account_status_history (account_id, from_status, to_status, reason, changed_by, created_at)
+	values ($1, $2, $3, $4, $5, $6)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.AccountID,
+		arg.FromStatus,
+		arg.ToStatus,
+		arg.Reason,
+		arg.ChangedBy,
+		time.Now(),
+	)
+
+	err := row.Scan(
+		&newID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetListAccountStatusHistory return status changes of account from given id, oldest first
+func (r *PostgresRepository) GetListAccountStatusHistory(ctx context.Context, accountID int64) ([]*models.AccountStatusHistory, error) {
+	query := `
+	select id, account_id, from_status, to_status, reason, changed_by, created_at
+	from account_status_history
+	where account_id = $1
+	order by id
+`
+	items := []*models.AccountStatusHistory{}
+
+	rows, err := r.db.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.AccountStatusHistory
+		err = rows.Scan(
+			&a.ID,
+			&a.AccountID,
+			&a.FromStatus,
+			&a.ToStatus,
+			&a.Reason,
+			&a.ChangedBy,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetCurrency return currency from given code or empty currency if not found and error if exist
+func (r *PostgresRepository) GetCurrency(ctx context.Context, code string) (models.Currency, error) {
+	query := `
+	select code, name, minor_unit, created_at from currencies
+	where code = $1
+`
+	var a models.Currency
+
+	row := r.db.QueryRowContext(ctx, query, code)
+	err := row.Scan(
+		&a.Code,
+		&a.Name,
+		&a.MinorUnit,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListCurrencies return all supported currencies ordered by code
+func (r *PostgresRepository) GetListCurrencies(ctx context.Context) ([]*models.Currency, error) {
+	query := `
+	select code, name, minor_unit, created_at from currencies
+	order by code
+`
+	items := []*models.Currency{}
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Currency
+		err = rows.Scan(
+			&a.Code,
+			&a.Name,
+			&a.MinorUnit,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const fxRateColumns = `id, base_currency, quote_currency, rate, spread_bps, effective_at, created_at`
+
+func scanFxRate(row rowScanner, a *models.FxRate) error {
+	return row.Scan(
+		&a.ID,
+		&a.BaseCurrency,
+		&a.QuoteCurrency,
+		&a.Rate,
+		&a.SpreadBps,
+		&a.EffectiveAt,
+		&a.CreatedAt,
+	)
+}
+
+// InsertFxRate insert new fx rate to database and return newID and error if exist
+func (r *PostgresRepository) InsertFxRate(ctx context.Context, arg models.FxRate) (int64, error) {
+	var newID int64
+	query := `
+	insert into fx_rates (base_currency, quote_currency, rate, spread_bps, effective_at, created_at)
+	values ($1, $2, $3, $4, $5, $6)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.BaseCurrency,
+		arg.QuoteCurrency,
+		arg.Rate,
+		arg.SpreadBps,
+		arg.EffectiveAt,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetFxRateAt return the rate of the currency pair that is effective at the given time
+// or empty rate if not found and error if exist
+func (r *PostgresRepository) GetFxRateAt(ctx context.Context, base, quote string, at time.Time) (models.FxRate, error) {
+	query := `
+	select ` + fxRateColumns + ` from fx_rates
+	where base_currency = $1 and quote_currency = $2 and effective_at <= $3
+	order by effective_at desc, id desc
+	limit 1
+`
+	var a models.FxRate
+
+	row := r.db.QueryRowContext(ctx, query, base, quote, at)
+	err := scanFxRate(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+const scheduledTransferColumns = `id, owner, from_account_id, to_account_id, amount, cron_spec, next_run_at, end_at, failure_policy, status, created_at, updated_at`
+
+func scanScheduledTransfer(row rowScanner, a *models.ScheduledTransfer) error {
+	var endAt sql.NullTime
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.FromAccountID,
+		&a.ToAccountID,
+		&a.Amount,
+		&a.CronSpec,
+		&a.NextRunAt,
+		&endAt,
+		&a.FailurePolicy,
+		&a.Status,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.EndAt = endAt.Time
+	return nil
+}
+
+// InsertScheduledTransfer insert new scheduled transfer to database and return newID and error if exist
+func (r *PostgresRepository) InsertScheduledTransfer(ctx context.Context, arg models.ScheduledTransfer) (int64, error) {
+	var newID int64
+	query := `
+	insert into scheduled_transfers (owner, from_account_id, to_account_id, amount, cron_spec, next_run_at, end_at, failure_policy, status, created_at, updated_at)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.CronSpec,
+		arg.NextRunAt,
+		sql.NullTime{Time: arg.EndAt, Valid: !arg.EndAt.IsZero()},
+		arg.FailurePolicy,
+		arg.Status,
+		time.Now(),
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetScheduledTransferByID return scheduled transfer from given id or empty scheduled transfer if not found and error if exist
+func (r *PostgresRepository) GetScheduledTransferByID(ctx context.Context, id int64) (models.ScheduledTransfer, error) {
+	query := `
+	select ` + scheduledTransferColumns + ` from scheduled_transfers
+	where id = $1
+`
+	var a models.ScheduledTransfer
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanScheduledTransfer(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetScheduledTransferByIDForUpdate same as GetScheduledTransferByID but lock the row until the transaction end
+func (r *PostgresRepository) GetScheduledTransferByIDForUpdate(ctx context.Context, id int64) (models.ScheduledTransfer, error) {
+	query := `
+	select ` + scheduledTransferColumns + ` from scheduled_transfers
+	where id = $1
+	for no key update
+`
+	var a models.ScheduledTransfer
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanScheduledTransfer(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListScheduledTransfers return list scheduled transfers of the given owner
+func (r *PostgresRepository) GetListScheduledTransfers(ctx context.Context, owner string, limit, offset int) ([]*models.ScheduledTransfer, error) {
+	query := `
+	select ` + scheduledTransferColumns + ` from scheduled_transfers
+	where owner = $1
+	order by id
+	limit $2
+	offset $3
+`
+	return r.queryScheduledTransfers(ctx, query, owner, limit, offset)
+}
+
+// GetListDueScheduledTransfers return active scheduled transfers that should run at or before the given time, oldest first
+func (r *PostgresRepository) GetListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]*models.ScheduledTransfer, error) {
+	query := `
+	select ` + scheduledTransferColumns + ` from scheduled_transfers
+	where status = $1 and next_run_at <= $2
+	order by next_run_at
+	limit $3
+`
+	return r.queryScheduledTransfers(ctx, query, models.ScheduledTransferStatusActive, now, limit)
+}
+
+func (r *PostgresRepository) queryScheduledTransfers(ctx context.Context, query string, args ...interface{}) ([]*models.ScheduledTransfer, error) {
+	items := []*models.ScheduledTransfer{}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ScheduledTransfer
+		err = scanScheduledTransfer(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateScheduledTransfer update the editable fields and the next run of scheduled transfer from given id
+func (r *PostgresRepository) UpdateScheduledTransfer(ctx context.Context, arg models.ScheduledTransfer) error {
+	query := `
+	update scheduled_transfers
+	set amount = $1, cron_spec = $2, next_run_at = $3, end_at = $4, failure_policy = $5, status = $6, updated_at = $7
+	where id = $8
+`
+	_, err := r.db.ExecContext(ctx, query,
+		arg.Amount,
+		arg.CronSpec,
+		arg.NextRunAt,
+		sql.NullTime{Time: arg.EndAt, Valid: !arg.EndAt.IsZero()},
+		arg.FailurePolicy,
+		arg.Status,
+		time.Now(),
+		arg.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertScheduledTransferExecution insert the result of one run of scheduled transfer and return newID and error if exist
+func (r *PostgresRepository) InsertScheduledTransferExecution(ctx context.Context, arg models.ScheduledTransferExecution) (int64, error) {
+	var newID int64
+	query := `
+	insert into scheduled_transfer_executions (scheduled_transfer_id, scheduled_at, status, transfer_id, error, created_at)
+	values ($1, $2, $3, $4, $5, $6)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.ScheduledTransferID,
+		arg.ScheduledAt,
+		arg.Status,
+		sql.NullInt64{Int64: arg.TransferID, Valid: arg.TransferID > 0},
+		arg.Error,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetListScheduledTransferExecutions return the runs of scheduled transfer from given id, newest first
+func (r *PostgresRepository) GetListScheduledTransferExecutions(ctx context.Context, scheduledTransferID int64, limit, offset int) ([]*models.ScheduledTransferExecution, error) {
+	query := `
+	select id, scheduled_transfer_id, scheduled_at, status, transfer_id, error, created_at
+	from scheduled_transfer_executions
+	where scheduled_transfer_id = $1
+	order by scheduled_at desc
+	limit $2
+	offset $3
+`
+	items := []*models.ScheduledTransferExecution{}
+
+	rows, err := r.db.QueryContext(ctx, query, scheduledTransferID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ScheduledTransferExecution
+		var transferID sql.NullInt64
+		err = rows.Scan(
+			&a.ID,
+			&a.ScheduledTransferID,
+			&a.ScheduledAt,
+			&a.Status,
+			&transferID,
+			&a.Error,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.TransferID = transferID.Int64
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// InsertTransferBatch insert new transfer batch to database and return newID and error if exist
+func (r *PostgresRepository) InsertTransferBatch(ctx context.Context, arg models.TransferBatch) (int64, error) {
+	var newID int64
+	query := `
+	insert into transfer_batches (owner, mode, status, total_items, created_at, updated_at)
+	values ($1, $2, $3, $4, $5, $6)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.Mode,
+		arg.Status,
+		arg.TotalItems,
+		time.Now(),
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetTransferBatchByID return transfer batch from given id or empty batch if not found and error if exist
+func (r *PostgresRepository) GetTransferBatchByID(ctx context.Context, id int64) (models.TransferBatch, error) {
+	query := `
+	select id, owner, mode, status, total_items, created_at, updated_at from transfer_batches
+	where id = $1
+`
+	var a models.TransferBatch
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.Mode,
+		&a.Status,
+		&a.TotalItems,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// UpdateTransferBatchStatus set status of transfer batch from given id
+func (r *PostgresRepository) UpdateTransferBatchStatus(ctx context.Context, id int64, status string) error {
+	query := `
+	update transfer_batches set status = $1, updated_at = $2
+	where id = $3
+`
+	_, err := r.db.ExecContext(ctx, query, status, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const transferBatchItemColumns = `id, batch_id, item_index, from_account_id, to_account_id, amount, status, transfer_id, error, updated_at`
+
+func scanTransferBatchItem(row rowScanner, a *models.TransferBatchItem) error {
+	var transferID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.BatchID,
+		&a.ItemIndex,
+		&a.FromAccountID,
+		&a.ToAccountID,
+		&a.Amount,
+		&a.Status,
+		&transferID,
+		&a.Error,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.TransferID = transferID.Int64
+	return nil
+}
+
+// InsertTransferBatchItem insert new item of transfer batch to database and return newID and error if exist
+func (r *PostgresRepository) InsertTransferBatchItem(ctx context.Context, arg models.TransferBatchItem) (int64, error) {
+	var newID int64
+	query := `
+	insert into transfer_batch_items (batch_id, item_index, from_account_id, to_account_id, amount, status, transfer_id, error, updated_at)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.BatchID,
+		arg.ItemIndex,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.Status,
+		sql.NullInt64{Int64: arg.TransferID, Valid: arg.TransferID > 0},
+		arg.Error,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetTransferBatchItemByIDForUpdate return item of transfer batch from given id and lock it until the transaction end,
+// empty item if not found and error if exist
+func (r *PostgresRepository) GetTransferBatchItemByIDForUpdate(ctx context.Context, id int64) (models.TransferBatchItem, error) {
+	query := `
+	select ` + transferBatchItemColumns + ` from transfer_batch_items
+	where id = $1
+	for no key update
+`
+	var a models.TransferBatchItem
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanTransferBatchItem(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// UpdateTransferBatchItemResult set the result of item of transfer batch from given id
+func (r *PostgresRepository) UpdateTransferBatchItemResult(ctx context.Context, id int64, status string, transferID int64, errMessage string) error {
+	query := `
+	update transfer_batch_items set status = $1, transfer_id = $2, error = $3, updated_at = $4
+	where id = $5
+`
+	_, err := r.db.ExecContext(ctx, query,
+		status,
+		sql.NullInt64{Int64: transferID, Valid: transferID > 0},
+		errMessage,
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetListTransferBatchItems return all items of transfer batch from given id in the submitted order
+func (r *PostgresRepository) GetListTransferBatchItems(ctx context.Context, batchID int64) ([]*models.TransferBatchItem, error) {
+	query := `
+	select ` + transferBatchItemColumns + ` from transfer_batch_items
+	where batch_id = $1
+	order by item_index
+`
+	items := []*models.TransferBatchItem{}
+
+	rows, err := r.db.QueryContext(ctx, query, batchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.TransferBatchItem
+		err = scanTransferBatchItem(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const accountProductColumns = `id, name, currency, annual_rate, day_count, created_at`
+
+func scanAccountProduct(row rowScanner, a *models.AccountProduct) error {
+	return row.Scan(
+		&a.ID,
+		&a.Name,
+		&a.Currency,
+		&a.AnnualRate,
+		&a.DayCount,
+		&a.CreatedAt,
+	)
+}
+
+// InsertAccountProduct insert new account product to database and return newID and error if exist
+func (r *PostgresRepository) InsertAccountProduct(ctx context.Context, arg models.AccountProduct) (int64, error) {
+	var newID int64
+	query := `
+	insert into account_products (name, currency, annual_rate, day_count, created_at)
+	values ($1, $2, $3, $4, $5)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Name,
+		arg.Currency,
+		arg.AnnualRate,
+		arg.DayCount,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetAccountProduct return account product from given id or empty product if not found and error if exist
+func (r *PostgresRepository) GetAccountProduct(ctx context.Context, id int64) (models.AccountProduct, error) {
+	query := `
+	select ` + accountProductColumns + ` from account_products
+	where id = $1
+`
+	var a models.AccountProduct
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanAccountProduct(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListAccountProducts return all account products
+func (r *PostgresRepository) GetListAccountProducts(ctx context.Context) ([]*models.AccountProduct, error) {
+	query := `
+	select ` + accountProductColumns + ` from account_products
+	order by id
+`
+	items := []*models.AccountProduct{}
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.AccountProduct
+		err = scanAccountProduct(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetListInterestBearingAccounts return active accounts with account product ordered by id,
+// the next page start after the last id of the previous page
+func (r *PostgresRepository) GetListInterestBearingAccounts(ctx context.Context, afterID int64, limit int) ([]*models.Account, error) {
+	query := `
+	select ` + accountColumns + ` from accounts
+	where product_id is not null and status = $1 and id > $2
+	order by id
+	limit $3
+`
+	items := []*models.Account{}
+
+	rows, err := r.db.QueryContext(ctx, query, models.AccountStatusActive, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Account
+		err = scanAccount(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// InsertHouseAccount register the bank account used for the given purpose and currency
+func (r *PostgresRepository) InsertHouseAccount(ctx context.Context, arg models.HouseAccount) error {
+	query := `
+	insert into house_accounts (purpose, currency, account_id, created_at)
+	values ($1, $2, $3, $4)
+`
+	_, err := r.db.ExecContext(ctx, query,
+		arg.Purpose,
+		arg.Currency,
+		arg.AccountID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetHouseAccount return the bank account used for the given purpose and currency or empty house account if not found
+func (r *PostgresRepository) GetHouseAccount(ctx context.Context, purpose, currency string) (models.HouseAccount, error) {
+	query := `
+	select purpose, currency, account_id, created_at from house_accounts
+	where purpose = $1 and currency = $2
+`
+	var a models.HouseAccount
+
+	row := r.db.QueryRowContext(ctx, query, purpose, currency)
+	err := row.Scan(
+		&a.Purpose,
+		&a.Currency,
+		&a.AccountID,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+const interestAccrualColumns = `id, account_id, accrual_date, balance, annual_rate, day_count, amount_micros, posting_id, created_at`
+
+func scanInterestAccrual(row rowScanner, a *models.InterestAccrual) error {
+	var postingID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.AccountID,
+		&a.AccrualDate,
+		&a.Balance,
+		&a.AnnualRate,
+		&a.DayCount,
+		&a.AmountMicros,
+		&postingID,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.PostingID = postingID.Int64
+	return nil
+}
+
+// InsertInterestAccrual insert the accrual of the day and return newID, it return 0 without error
+// when the account already has an accrual for that day
+func (r *PostgresRepository) InsertInterestAccrual(ctx context.Context, arg models.InterestAccrual) (int64, error) {
+	var newID int64
+	query := `
+	insert into interest_accruals (account_id, accrual_date, balance, annual_rate, day_count, amount_micros, created_at)
+	values ($1, $2, $3, $4, $5, $6, $7)
+	on conflict (account_id, accrual_date) do nothing
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.AccountID,
+		arg.AccrualDate,
+		arg.Balance,
+		arg.AnnualRate,
+		arg.DayCount,
+		arg.AmountMicros,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetListUnpostedInterestAccruals return accruals of the account not posted yet up to the given date
+func (r *PostgresRepository) GetListUnpostedInterestAccruals(ctx context.Context, accountID int64, until time.Time) ([]*models.InterestAccrual, error) {
+	query := `
+	select ` + interestAccrualColumns + ` from interest_accruals
+	where account_id = $1 and posting_id is null and accrual_date <= $2
+	order by accrual_date
+`
+	items := []*models.InterestAccrual{}
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.InterestAccrual
+		err = scanInterestAccrual(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateInterestAccrualsPosting link the accruals from given ids to the posting
+func (r *PostgresRepository) UpdateInterestAccrualsPosting(ctx context.Context, ids []int64, postingID int64) error {
+	query := `
+	update interest_accruals set posting_id = $1
+	where id = any($2)
+`
+	_, err := r.db.ExecContext(ctx, query, postingID, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const interestPostingColumns = `id, account_id, period_end, accrued_micros, amount, carry_micros, transfer_id, created_at`
+
+func scanInterestPosting(row rowScanner, a *models.InterestPosting) error {
+	var transferID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.AccountID,
+		&a.PeriodEnd,
+		&a.AccruedMicros,
+		&a.Amount,
+		&a.CarryMicros,
+		&transferID,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.TransferID = transferID.Int64
+	return nil
+}
+
+// InsertInterestPosting insert new interest posting to database and return newID and error if exist
+func (r *PostgresRepository) InsertInterestPosting(ctx context.Context, arg models.InterestPosting) (int64, error) {
+	var newID int64
+	query := `
+	insert into interest_postings (account_id, period_end, accrued_micros, amount, carry_micros, transfer_id, created_at)
+	values ($1, $2, $3, $4, $5, $6, $7)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.AccountID,
+		arg.PeriodEnd,
+		arg.AccruedMicros,
+		arg.Amount,
+		arg.CarryMicros,
+		sql.NullInt64{Int64: arg.TransferID, Valid: arg.TransferID > 0},
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetLastInterestPosting return the latest interest posting of the account or empty posting if never posted
+func (r *PostgresRepository) GetLastInterestPosting(ctx context.Context, accountID int64) (models.InterestPosting, error) {
+	query := `
+	select ` + interestPostingColumns + ` from interest_postings
+	where account_id = $1
+	order by period_end desc
+	limit 1
+`
+	var a models.InterestPosting
+
+	row := r.db.QueryRowContext(ctx, query, accountID)
+	err := scanInterestPosting(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListInterestPostings return interest postings of the account, the latest first
+func (r *PostgresRepository) GetListInterestPostings(ctx context.Context, accountID int64, limit, offset int) ([]*models.InterestPosting, error) {
+	query := `
+	select ` + interestPostingColumns + ` from interest_postings
+	where account_id = $1
+	order by period_end desc
+	limit $2
+	offset $3
+`
+	items := []*models.InterestPosting{}
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.InterestPosting
+		err = scanInterestPosting(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const feeScheduleColumns = `id, currency, product_id, fee_type, flat_amount, percentage_bps, min_amount, max_amount, created_at`
+
+func scanFeeSchedule(row rowScanner, a *models.FeeSchedule) error {
+	var productID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&a.Currency,
+		&productID,
+		&a.FeeType,
+		&a.FlatAmount,
+		&a.PercentageBps,
+		&a.MinAmount,
+		&a.MaxAmount,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.ProductID = productID.Int64
+	return nil
+}
+
+// InsertFeeSchedule insert new fee schedule to database and return newID and error if exist
+func (r *PostgresRepository) InsertFeeSchedule(ctx context.Context, arg models.FeeSchedule) (int64, error) {
+	var newID int64
+	query := `
+	insert into fee_schedules (currency, product_id, fee_type, flat_amount, percentage_bps, min_amount, max_amount, created_at)
+	values ($1, $2, $3, $4, $5, $6, $7, $8)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Currency,
+		sql.NullInt64{Int64: arg.ProductID, Valid: arg.ProductID > 0},
+		arg.FeeType,
+		arg.FlatAmount,
+		arg.PercentageBps,
+		arg.MinAmount,
+		arg.MaxAmount,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetFeeSchedule return the fee schedule of the account product in the currency, or the default
+// schedule of the currency when the product has none. It return empty schedule if neither exist.
+func (r *PostgresRepository) GetFeeSchedule(ctx context.Context, currency string, productID int64) (models.FeeSchedule, error) {
+	query := `
+	select ` + feeScheduleColumns + ` from fee_schedules
+	where currency = $1 and (product_id = $2 or product_id is null)
+	order by product_id nulls last
+	limit 1
+`
+	var a models.FeeSchedule
+
+	row := r.db.QueryRowContext(ctx, query, currency, productID)
+	err := scanFeeSchedule(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListFeeSchedules return the fee schedules of the currency, the default schedule first
+func (r *PostgresRepository) GetListFeeSchedules(ctx context.Context, currency string) ([]*models.FeeSchedule, error) {
+	query := `
+	select ` + feeScheduleColumns + ` from fee_schedules
+	where currency = $1
+	order by product_id nulls first
+`
+	items := []*models.FeeSchedule{}
+
+	rows, err := r.db.QueryContext(ctx, query, currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.FeeSchedule
+		err = scanFeeSchedule(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetListAccountLedgers return the balance of the accounts after the given id next to the sum of their entries,
+// both are read in one statement so they are consistent with each other
+func (r *PostgresRepository) GetListAccountLedgers(ctx context.Context, afterID int64, limit int) ([]*models.AccountLedger, error) {
+	query := `
+	select a.id, a.balance, coalesce(sum(e.amount), 0) from accounts a
+	left join entries e on e.account_id = a.id
+	where a.id > $1
+	group by a.id
+	order by a.id
+	limit $2
+`
+	items := []*models.AccountLedger{}
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.AccountLedger
+		err = rows.Scan(
+			&a.AccountID,
+			&a.Balance,
+			&a.EntrySum,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetListTransferLedgers return the transfers from the accounts in (fromAccountIDAfter, fromAccountIDUntil]
+// with the sums of the entries they posted
+func (r *PostgresRepository) GetListTransferLedgers(ctx context.Context, fromAccountIDAfter, fromAccountIDUntil int64) ([]*models.TransferLedger, error) {
+	query := `
+	select ` + transferColumns + `, entry_count, from_entry_sum, to_entry_sum, other_entry_sum from (
+		select t.*,
+			count(e.id) as entry_count,
+			coalesce(sum(e.amount) filter (where e.account_id = t.from_account_id), 0) as from_entry_sum,
+			coalesce(sum(e.amount) filter (where e.account_id = t.to_account_id), 0) as to_entry_sum,
+			coalesce(sum(e.amount) filter (where e.account_id not in (t.from_account_id, t.to_account_id)), 0) as other_entry_sum
+		from transfers t
+		left join entries e on e.transfer_id = t.id
+		where t.from_account_id > $1 and t.from_account_id <= $2
+		group by t.id
+	) tl
+	order by id
+`
+	items := []*models.TransferLedger{}
+
+	rows, err := r.db.QueryContext(ctx, query, fromAccountIDAfter, fromAccountIDUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.TransferLedger
+		var fxRate sql.NullString
+		var reversalOf sql.NullInt64
+		err = rows.Scan(
+			&a.ID,
+			&a.FromAccountID,
+			&a.ToAccountID,
+			&a.Amount,
+			&a.ToAmount,
+			&fxRate,
+			&a.FxSpreadBps,
+			&reversalOf,
+			&a.Fee,
+			&a.CreatedAt,
+			&a.EntryCount,
+			&a.FromEntrySum,
+			&a.ToEntrySum,
+			&a.OtherEntrySum,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.FxRate = fxRate.String
+		a.ReversalOf = reversalOf.Int64
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const reconciliationReportColumns = `id, started_at, finished_at, accounts_checked, transfers_checked, findings_count, findings, created_at`
+
+func scanReconciliationReport(row rowScanner, a *models.ReconciliationReport) error {
+	var findings []byte
+	err := row.Scan(
+		&a.ID,
+		&a.StartedAt,
+		&a.FinishedAt,
+		&a.AccountsChecked,
+		&a.TransfersChecked,
+		&a.FindingsCount,
+		&findings,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(findings, &a.Findings)
+}
+
+// InsertReconciliationReport insert new reconciliation report to database and return newID and error if exist
+func (r *PostgresRepository) InsertReconciliationReport(ctx context.Context, arg models.ReconciliationReport) (int64, error) {
+	query := `
+	insert into reconciliation_reports (started_at, finished_at, accounts_checked, transfers_checked, findings_count, findings, created_at)
+	values ($1, $2, $3, $4, $5, $6, $7)
+	returning id
+`
+	findings := arg.Findings
+	if findings == nil {
+		findings = []*models.ReconciliationFinding{}
+	}
+	jsonFindings, err := json.Marshal(findings)
+	if err != nil {
+		return 0, err
+	}
+
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.StartedAt,
+		arg.FinishedAt,
+		arg.AccountsChecked,
+		arg.TransfersChecked,
+		arg.FindingsCount,
+		jsonFindings,
+		time.Now(),
+	)
+	err = row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetReconciliationReportByID return reconciliation report by given id or empty report if not found and error if exist
+func (r *PostgresRepository) GetReconciliationReportByID(ctx context.Context, id int64) (models.ReconciliationReport, error) {
+	query := `
+	select ` + reconciliationReportColumns + ` from reconciliation_reports
+	where id = $1
+`
+	var a models.ReconciliationReport
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanReconciliationReport(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ReconciliationReport{}, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListReconciliationReports return reconciliation reports, the latest first
+func (r *PostgresRepository) GetListReconciliationReports(ctx context.Context, limit, offset int) ([]*models.ReconciliationReport, error) {
+	query := `
+	select ` + reconciliationReportColumns + ` from reconciliation_reports
+	order by id desc
+	limit $1
+	offset $2
+`
+	items := []*models.ReconciliationReport{}
+
+	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ReconciliationReport
+		err = scanReconciliationReport(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// InsertAccountBalanceSnapshots snapshot the balance at asOf of the accounts after the given id, up to limit accounts.
+// Each balance is the previous snapshot plus the entries created since, so the first snapshot of an account
+// sum all of its entries. Accounts that already have a snapshot of the day are skipped.
+// It return the id of the last account of the chunk, 0 when there is no account left, and the number of snapshots inserted.
+func (r *PostgresRepository) InsertAccountBalanceSnapshots(ctx context.Context, snapshotDate, asOf time.Time, afterID int64, limit int) (int64, int64, error) {
+	query := `
+	with chunk as (
+		select id from accounts
+		where id > $1
+		order by id
+		limit $2
+	), inserted as (
+		insert into account_balance_snapshots (account_id, snapshot_date, as_of, balance, created_at)
+		select c.id, $3, $4,
+			coalesce(s.balance, 0) + coalesce((
+				select sum(e.amount) from entries e
+				where e.account_id = c.id and e.created_at >= coalesce(s.as_of, '-infinity') and e.created_at < $4
+			), 0),
+			$5
+		from chunk c
+		left join lateral (
+			select as_of, balance from account_balance_snapshots
+			where account_id = c.id and as_of <= $4
+			order by as_of desc
+			limit 1
+		) s on true
+		on conflict (account_id, snapshot_date) do nothing
+		returning account_id
+	)
+	select coalesce(max(id), 0), (select count(*) from inserted) from chunk
+`
+	var lastID, inserted int64
+	row := r.db.QueryRowContext(ctx, query, afterID, limit, snapshotDate, asOf, time.Now())
+	err := row.Scan(&lastID, &inserted)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lastID, inserted, nil
+}
+
+// GetListAccountBalanceSnapshots return the snapshots of the account, the latest first
+func (r *PostgresRepository) GetListAccountBalanceSnapshots(ctx context.Context, accountID int64, limit, offset int) ([]*models.AccountBalanceSnapshot, error) {
+	query := `
+	select account_id, snapshot_date, as_of, balance, created_at from account_balance_snapshots
+	where account_id = $1
+	order by snapshot_date desc
+	limit $2
+	offset $3
+`
+	items := []*models.AccountBalanceSnapshot{}
+
+	rows, err := r.db.QueryContext(ctx, query, accountID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.AccountBalanceSnapshot
+		err = rows.Scan(
+			&a.AccountID,
+			&a.SnapshotDate,
+			&a.AsOf,
+			&a.Balance,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetAccountBalanceAt return the sum of the entries of the account created before the given time,
+// it start from the nearest snapshot so only the entries since it are summed
+func (r *PostgresRepository) GetAccountBalanceAt(ctx context.Context, accountID int64, at time.Time) (int64, error) {
+	query := `
+	with s as (
+		select as_of, balance from account_balance_snapshots
+		where account_id = $1 and as_of <= $2
+		order by as_of desc
+		limit 1
+	)
+	select coalesce((select balance from s), 0) + coalesce((
+		select sum(amount) from entries
+		where account_id = $1 and created_at >= coalesce((select as_of from s), '-infinity') and created_at < $2
+	), 0)
+`
+	var balance int64
+	row := r.db.QueryRowContext(ctx, query, accountID, at)
+	err := row.Scan(&balance)
+	if err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// GetListEntriesOfAccountsAfter return the entries of the given accounts posted after the given entry id,
+// in the order they were posted
+func (r *PostgresRepository) GetListEntriesOfAccountsAfter(ctx context.Context, accountIDs []int64, afterID int64, limit int) ([]*models.Entry, error) {
+	query := `
+	select id, account_id, amount, transfer_id, created_at from entries
+	where account_id = any($1) and id > $2
+	order by id
+	limit $3
+`
+	items := []*models.Entry{}
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(accountIDs), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Entry
+		var transferID sql.NullInt64
+		err = rows.Scan(
+			&a.ID,
+			&a.AccountID,
+			&a.Amount,
+			&transferID,
+			&a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.TransferID = transferID.Int64
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// GetListTransfersByIDs return the transfers from given ids ordered by id, unknown ids are ignored
+func (r *PostgresRepository) GetListTransfersByIDs(ctx context.Context, ids []int64) ([]*models.Transfer, error) {
+	query := `
+	select ` + transferColumns + ` from transfers
+	where id = any($1)
+	order by id
+`
+	items := []*models.Transfer{}
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Transfer
+		err = scanTransfer(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+const externalPayoutColumns = `id, owner, account_id, amount, currency, routing_number, account_number, account_type, beneficiary_name, status, transfer_id, ach_file_id, trace_number, return_code, return_transfer_id, created_at, updated_at`
+
+func scanExternalPayout(row rowScanner, a *models.ExternalPayout) error {
+	var achFileID, returnTransferID sql.NullInt64
+	var traceNumber sql.NullString
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.AccountID,
+		&a.Amount,
+		&a.Currency,
+		&a.RoutingNumber,
+		&a.AccountNumber,
+		&a.AccountType,
+		&a.BeneficiaryName,
+		&a.Status,
+		&a.TransferID,
+		&achFileID,
+		&traceNumber,
+		&a.ReturnCode,
+		&returnTransferID,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.ACHFileID = achFileID.Int64
+	a.TraceNumber = traceNumber.String
+	a.ReturnTransferID = returnTransferID.Int64
+	return nil
+}
+
+// InsertExternalPayout insert new external payout to database and return newID and error if exist
+func (r *PostgresRepository) InsertExternalPayout(ctx context.Context, arg models.ExternalPayout) (int64, error) {
+	var newID int64
+	query := `
+	insert into external_payouts (owner, account_id, amount, currency, routing_number, account_number, account_type, beneficiary_name, status, transfer_id, created_at, updated_at)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.AccountID,
+		arg.Amount,
+		arg.Currency,
+		arg.RoutingNumber,
+		arg.AccountNumber,
+		arg.AccountType,
+		arg.BeneficiaryName,
+		arg.Status,
+		arg.TransferID,
+		time.Now(),
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetExternalPayoutByID return external payout from given id or empty external payout if not found and error if exist
+func (r *PostgresRepository) GetExternalPayoutByID(ctx context.Context, id int64) (models.ExternalPayout, error) {
+	query := `
+	select ` + externalPayoutColumns + ` from external_payouts
+	where id = $1
+`
+	var a models.ExternalPayout
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanExternalPayout(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetExternalPayoutByTraceNumberForUpdate return the newest submitted or returned external payout with the
+// trace number and lock it until the transaction end, empty external payout if not found.
+// The sequence of the trace numbers wrap so an old payout may have the same trace number.
+func (r *PostgresRepository) GetExternalPayoutByTraceNumberForUpdate(ctx context.Context, traceNumber string) (models.ExternalPayout, error) {
+	query := `
+	select ` + externalPayoutColumns + ` from external_payouts
+	where trace_number = $1
+	order by id desc
+	limit 1
+	for no key update
+`
+	var a models.ExternalPayout
+
+	row := r.db.QueryRowContext(ctx, query, traceNumber)
+	err := scanExternalPayout(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListExternalPayouts return list external payouts of the given owner, newest first
+func (r *PostgresRepository) GetListExternalPayouts(ctx context.Context, owner string, limit, offset int) ([]*models.ExternalPayout, error) {
+	query := `
+	select ` + externalPayoutColumns + ` from external_payouts
+	where owner = $1
+	order by id desc
+	limit $2
+	offset $3
+`
+	return r.queryExternalPayouts(ctx, query, owner, limit, offset)
+}
+
+// GetListQueuedExternalPayoutsForUpdate return the oldest queued external payouts of the currency and lock them
+// until the transaction end
+func (r *PostgresRepository) GetListQueuedExternalPayoutsForUpdate(ctx context.Context, currency string, limit int) ([]*models.ExternalPayout, error) {
+	query := `
+	select ` + externalPayoutColumns + ` from external_payouts
+	where status = $1 and currency = $2
+	order by id
+	limit $3
+	for no key update
+`
+	return r.queryExternalPayouts(ctx, query, models.PayoutStatusQueued, currency, limit)
+}
+
+func (r *PostgresRepository) queryExternalPayouts(ctx context.Context, query string, args ...interface{}) ([]*models.ExternalPayout, error) {
+	items := []*models.ExternalPayout{}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ExternalPayout
+		err = scanExternalPayout(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateExternalPayoutSubmitted mark external payout from given id submitted in the ach file with the trace number
+func (r *PostgresRepository) UpdateExternalPayoutSubmitted(ctx context.Context, id, achFileID int64, traceNumber string) error {
+	query := `
+	update external_payouts
+	set status = $1, ach_file_id = $2, trace_number = $3, updated_at = $4
+	where id = $5
+`
+	_, err := r.db.ExecContext(ctx, query, models.PayoutStatusSubmitted, achFileID, traceNumber, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateExternalPayoutReturned mark external payout from given id returned with the return reason code and its reversal
+func (r *PostgresRepository) UpdateExternalPayoutReturned(ctx context.Context, id int64, returnCode string, returnTransferID int64) error {
+	query := `
+	update external_payouts
+	set status = $1, return_code = $2, return_transfer_id = $3, updated_at = $4
+	where id = $5
+`
+	_, err := r.db.ExecContext(ctx, query, models.PayoutStatusReturned, returnCode, returnTransferID, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateExternalPayoutReturnException mark external payout from given id returned to a closed account with the return reason code
+func (r *PostgresRepository) UpdateExternalPayoutReturnException(ctx context.Context, id int64, returnCode string) error {
+	query := `
+	update external_payouts
+	set status = $1, return_code = $2, updated_at = $3
+	where id = $4
+`
+	_, err := r.db.ExecContext(ctx, query, models.PayoutStatusReturnException, returnCode, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const achFileColumns = `id, file_name, file_id_modifier, content, entry_count, total_amount, written_at, created_at`
+
+func scanACHFile(row rowScanner, a *models.ACHFile) error {
+	var writtenAt sql.NullTime
+	err := row.Scan(
+		&a.ID,
+		&a.FileName,
+		&a.FileIDModifier,
+		&a.Content,
+		&a.EntryCount,
+		&a.TotalAmount,
+		&writtenAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.WrittenAt = writtenAt.Time
+	return nil
+}
+
+// InsertACHFile insert new ach file to database and return newID and error if exist
+func (r *PostgresRepository) InsertACHFile(ctx context.Context, arg models.ACHFile) (int64, error) {
+	var newID int64
+	query := `
+	insert into ach_files (file_name, file_id_modifier, content, entry_count, total_amount, created_at)
+	values ($1, $2, $3, $4, $5, $6)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.FileName,
+		arg.FileIDModifier,
+		arg.Content,
+		arg.EntryCount,
+		arg.TotalAmount,
+		arg.CreatedAt,
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// CountACHFilesCreatedSince return the number of ach files created at or after since
+func (r *PostgresRepository) CountACHFilesCreatedSince(ctx context.Context, since time.Time) (int, error) {
+	query := `
+	select count(*) from ach_files
+	where created_at >= $1
+`
+	var n int
+
+	err := r.db.QueryRowContext(ctx, query, since).Scan(&n)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// GetListUnwrittenACHFiles return the ach files not written to the output directory yet, oldest first
+func (r *PostgresRepository) GetListUnwrittenACHFiles(ctx context.Context, limit int) ([]*models.ACHFile, error) {
+	query := `
+	select ` + achFileColumns + ` from ach_files
+	where written_at is null
+	order by id
+	limit $1
+`
+	items := []*models.ACHFile{}
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.ACHFile
+		err = scanACHFile(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateACHFileWritten record when ach file from given id was written to the output directory
+func (r *PostgresRepository) UpdateACHFileWritten(ctx context.Context, id int64, writtenAt time.Time) error {
+	query := `
+	update ach_files
+	set written_at = $1
+	where id = $2
+`
+	_, err := r.db.ExecContext(ctx, query, writtenAt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const payeeColumns = `id, owner, nickname, account_id, active_at, created_at`
+
+func scanPayee(row rowScanner, a *models.Payee) error {
+	return row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.Nickname,
+		&a.AccountID,
+		&a.ActiveAt,
+		&a.CreatedAt,
+	)
+}
+
+// InsertPayee insert new payee to database and return newID and error if exist
+func (r *PostgresRepository) InsertPayee(ctx context.Context, arg models.Payee) (int64, error) {
+	var newID int64
+	query := `
+	insert into payees (owner, nickname, account_id, active_at, created_at)
+	values ($1, $2, $3, $4, $5)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.Nickname,
+		arg.AccountID,
+		arg.ActiveAt,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetPayeeByID return payee from given id or empty payee if not found and error if exist
+func (r *PostgresRepository) GetPayeeByID(ctx context.Context, id int64) (models.Payee, error) {
+	query := `
+	select ` + payeeColumns + ` from payees
+	where id = $1
+`
+	var a models.Payee
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanPayee(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetPayeeByOwnerAndAccount return the payee of the owner for the account or empty payee if not found and error if exist
+func (r *PostgresRepository) GetPayeeByOwnerAndAccount(ctx context.Context, owner string, accountID int64) (models.Payee, error) {
+	query := `
+	select ` + payeeColumns + ` from payees
+	where owner = $1 and account_id = $2
+`
+	var a models.Payee
+
+	row := r.db.QueryRowContext(ctx, query, owner, accountID)
+	err := scanPayee(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetPayeeSentAmount return the amount sent since the given time from the accounts of the owner in the currency to
+// the account, counting the transfers and the holds still authorized. Reversals are not counted.
+func (r *PostgresRepository) GetPayeeSentAmount(ctx context.Context, owner, currency string, accountID int64, since time.Time) (int64, error) {
+	query := `
+	select coalesce(sum(sent.amount), 0) from (
+		select t.amount from transfers t
+		join accounts a on a.id = t.from_account_id
+		where a.owner = $1 and a.currency = $2 and t.to_account_id = $3
+			and t.created_at >= $4
+			and t.reversal_of is null
+		union all
+		select h.amount from holds h
+		join accounts a on a.id = h.account_id
+		where a.owner = $1 and a.currency = $2 and h.to_account_id = $3
+			and h.created_at >= $4
+			and h.status = 'authorized'
+	) sent
+`
+	var amount int64
+
+	row := r.db.QueryRowContext(ctx, query, owner, currency, accountID, since)
+	err := row.Scan(&amount)
+	if err != nil {
+		return amount, err
+	}
+
+	return amount, nil
+}
+
+// GetListPayees return list payees of the given owner ordered by nickname
+func (r *PostgresRepository) GetListPayees(ctx context.Context, owner string, limit, offset int) ([]*models.Payee, error) {
+	query := `
+	select ` + payeeColumns + ` from payees
+	where owner = $1
+	order by nickname, id
+	limit $2
+	offset $3
+`
+	items := []*models.Payee{}
+
+	rows, err := r.db.QueryContext(ctx, query, owner, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.Payee
+		err = scanPayee(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// DeletePayee delete payee from given id
+func (r *PostgresRepository) DeletePayee(ctx context.Context, id int64) error {
+	query := `
+	delete from payees
+	where id = $1
+`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+const transferLimitColumns = `id, owner, account_id, currency, per_transaction, daily, monthly, updated_by, created_at, updated_at`
+
+func scanTransferLimit(row rowScanner, a *models.TransferLimit) error {
+	var owner sql.NullString
+	var accountID sql.NullInt64
+	err := row.Scan(
+		&a.ID,
+		&owner,
+		&accountID,
+		&a.Currency,
+		&a.PerTransaction,
+		&a.Daily,
+		&a.Monthly,
+		&a.UpdatedBy,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.Owner = owner.String
+	a.AccountID = accountID.Int64
+	return nil
+}
+
+func scanTransferLimits(rows *sql.Rows) ([]*models.TransferLimit, error) {
+	items := []*models.TransferLimit{}
+	for rows.Next() {
+		var a models.TransferLimit
+		err := scanTransferLimit(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err := rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// InsertTransferLimit insert new transfer limit policy to database and return newID and error if exist
+func (r *PostgresRepository) InsertTransferLimit(ctx context.Context, arg models.TransferLimit) (int64, error) {
+	var newID int64
+	query := `
+	insert into transfer_limits (owner, account_id, currency, per_transaction, daily, monthly, updated_by, created_at, updated_at)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+	returning id
+`
+	row := r.db.QueryRowContext(ctx, query,
+		sql.NullString{String: arg.Owner, Valid: arg.Owner != ""},
+		sql.NullInt64{Int64: arg.AccountID, Valid: arg.AccountID > 0},
+		arg.Currency,
+		arg.PerTransaction,
+		arg.Daily,
+		arg.Monthly,
+		arg.UpdatedBy,
+		time.Now(),
+	)
+
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetTransferLimitByID return transfer limit policy from given id or empty policy if not found and error if exist
+func (r *PostgresRepository) GetTransferLimitByID(ctx context.Context, id int64) (models.TransferLimit, error) {
+	query := `
+	select ` + transferLimitColumns + ` from transfer_limits
+	where id = $1
+`
+	var a models.TransferLimit
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanTransferLimit(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetTransferLimitForUpdate lock and return the policy of exactly the given scope: the default of the currency
+// when owner and accountID are empty, the policy of the owner or the policy of the account.
+// It return empty policy if not found and error if exist
+func (r *PostgresRepository) GetTransferLimitForUpdate(ctx context.Context, owner, currency string, accountID int64) (models.TransferLimit, error) {
+	query := `
+	select ` + transferLimitColumns + ` from transfer_limits
+	where coalesce(owner, '') = $1 and currency = $2 and coalesce(account_id, 0) = $3
+	for no key update
+`
+	var a models.TransferLimit
+
+	row := r.db.QueryRowContext(ctx, query, owner, currency, accountID)
+	err := scanTransferLimit(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetTransferLimitsOfAccount return the policies that may apply to a transfer from the account: the default of
+// its currency, the policy of its owner in the currency and the policy of the account, see util.ResolveTransferLimits
+func (r *PostgresRepository) GetTransferLimitsOfAccount(ctx context.Context, owner, currency string, accountID int64) ([]*models.TransferLimit, error) {
+	query := `
+	select ` + transferLimitColumns + ` from transfer_limits
+	where currency = $2 and (
+		(owner is null and account_id is null)
+		or owner = $1
+		or account_id = $3
+	)
+	order by id
+`
+	rows, err := r.db.QueryContext(ctx, query, owner, currency, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTransferLimits(rows)
+}
+
+// GetListTransferLimits return list transfer limit policies, of the given owner and its accounts when owner is not empty
+func (r *PostgresRepository) GetListTransferLimits(ctx context.Context, owner string, limit, offset int) ([]*models.TransferLimit, error) {
+	query := `
+	select ` + transferLimitColumns + ` from transfer_limits l
+	where $1 = ''
+		or l.owner = $1
+		or l.account_id in (select id from accounts where owner = $1)
+	order by l.currency, l.owner nulls first, l.account_id nulls first
+	limit $2
+	offset $3
+`
+	rows, err := r.db.QueryContext(ctx, query, owner, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanTransferLimits(rows)
+}
+
+// UpdateTransferLimit update the limits of the policy and the admin who set them
+func (r *PostgresRepository) UpdateTransferLimit(ctx context.Context, arg models.TransferLimit) error {
+	query := `
+	update transfer_limits
+	set per_transaction = $1, daily = $2, monthly = $3, updated_by = $4, updated_at = $5
+	where id = $6
+`
+	_, err := r.db.ExecContext(ctx, query,
+		arg.PerTransaction,
+		arg.Daily,
+		arg.Monthly,
+		arg.UpdatedBy,
+		time.Now(),
+		arg.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DeleteTransferLimit delete transfer limit policy from given id
+func (r *PostgresRepository) DeleteTransferLimit(ctx context.Context, id int64) error {
+	query := `
+	delete from transfer_limits
+	where id = $1
+`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetTransferLimitUsage return the amount sent since dailySince and since monthlySince from the accounts of the
+// owner in the currency, or only from accountID when it is not 0. The holds still authorized count as sent,
+// a captured hold is counted by its transfer. Reversals are not counted.
+func (r *PostgresRepository) GetTransferLimitUsage(ctx context.Context, owner, currency string, accountID int64, dailySince, monthlySince time.Time) (models.TransferLimitUsage, error) {
+	query := `
+	select
+		coalesce(sum(sent.amount) filter (where sent.created_at >= $4), 0),
+		coalesce(sum(sent.amount), 0)
+	from (
+		select t.amount, t.created_at from transfers t
+		join accounts a on a.id = t.from_account_id
+		where a.owner = $1 and a.currency = $2 and ($3::bigint = 0 or a.id = $3)
+			and t.created_at >= $5
+			and t.reversal_of is null
+		union all
+		select h.amount, h.created_at from holds h
+		join accounts a on a.id = h.account_id
+		where a.owner = $1 and a.currency = $2 and ($3::bigint = 0 or a.id = $3)
+			and h.created_at >= $5
+			and h.status = 'authorized'
+	) sent
+`
+	var a models.TransferLimitUsage
+
+	row := r.db.QueryRowContext(ctx, query, owner, currency, accountID, dailySince, monthlySince)
+	err := row.Scan(&a.Daily, &a.Monthly)
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetUsersByUsernameForUpdate lock and return users from given username or empty users if not found and error if exist
+func (r *PostgresRepository) GetUsersByUsernameForUpdate(ctx context.Context, username string) (models.Users, error) {
+	query := `
+	select username, hashed_password, full_name, email, created_at, updated_at, is_email_verify, role from users
+	where username = $1
+	for no key update
+`
+	var a models.Users
+	row := r.db.QueryRowContext(ctx, query, username)
+	err := row.Scan(
+		&a.Username,
+		&a.HashedPassword,
+		&a.FullName,
+		&a.Email,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+		&a.IsEmailVerify,
+		&a.Role,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+const transferReviewColumns = `id, owner, from_account_id, to_account_id, amount, to_currency, idempotency_key, status, findings, reviewed_by, transfer_id, created_at, reviewed_at`
+
+func scanTransferReview(row rowScanner, a *models.TransferReview) error {
+	var idempotencyKey sql.NullString
+	var findings []byte
+	var transferID sql.NullInt64
+	var reviewedAt sql.NullTime
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.FromAccountID,
+		&a.ToAccountID,
+		&a.Amount,
+		&a.ToCurrency,
+		&idempotencyKey,
+		&a.Status,
+		&findings,
+		&a.ReviewedBy,
+		&transferID,
+		&a.CreatedAt,
+		&reviewedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.IdempotencyKey = idempotencyKey.String
+	a.TransferID = transferID.Int64
+	a.ReviewedAt = reviewedAt.Time
+	return json.Unmarshal(findings, &a.Findings)
+}
+
+// InsertTransferReview insert new pending transfer review to database and return newID and error if exist
+func (r *PostgresRepository) InsertTransferReview(ctx context.Context, arg models.TransferReview) (int64, error) {
+	query := `
+	insert into transfer_reviews (owner, from_account_id, to_account_id, amount, to_currency, idempotency_key, status, findings, created_at)
+	values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	returning id
+`
+	findings := arg.Findings
+	if findings == nil {
+		findings = []*models.RiskFinding{}
+	}
+	jsonFindings, err := json.Marshal(findings)
+	if err != nil {
+		return 0, err
+	}
+
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToCurrency,
+		sql.NullString{String: arg.IdempotencyKey, Valid: arg.IdempotencyKey != ""},
+		models.TransferReviewStatusPending,
+		jsonFindings,
+		time.Now(),
+	)
+	err = row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetTransferReviewByID return transfer review from given id or empty review if not found and error if exist
+func (r *PostgresRepository) GetTransferReviewByID(ctx context.Context, id int64) (models.TransferReview, error) {
+	query := `
+	select ` + transferReviewColumns + ` from transfer_reviews
+	where id = $1
+`
+	var a models.TransferReview
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanTransferReview(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetTransferReviewByIDForUpdate lock and return transfer review from given id or empty review if not found and error if exist
+func (r *PostgresRepository) GetTransferReviewByIDForUpdate(ctx context.Context, id int64) (models.TransferReview, error) {
+	query := `
+	select ` + transferReviewColumns + ` from transfer_reviews
+	where id = $1
+	for no key update
+`
+	var a models.TransferReview
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanTransferReview(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetTransferReviewByIdempotencyKey return the transfer review parked by the request of the owner with the key
+// or empty review if not found and error if exist
+func (r *PostgresRepository) GetTransferReviewByIdempotencyKey(ctx context.Context, owner, key string) (models.TransferReview, error) {
+	query := `
+	select ` + transferReviewColumns + ` from transfer_reviews
+	where owner = $1 and idempotency_key = $2
+`
+	var a models.TransferReview
+
+	row := r.db.QueryRowContext(ctx, query, owner, key)
+	err := scanTransferReview(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListTransferReviews return the transfer reviews with the given status after the given review id ordered by id,
+// the oldest first
+func (r *PostgresRepository) GetListTransferReviews(ctx context.Context, status string, afterID int64, limit int) ([]*models.TransferReview, error) {
+	query := `
+	select ` + transferReviewColumns + ` from transfer_reviews
+	where status = $1 and id > $2
+	order by id
+	limit $3
+`
+	items := []*models.TransferReview{}
+
+	rows, err := r.db.QueryContext(ctx, query, status, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.TransferReview
+		err = scanTransferReview(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateTransferReviewStatus record the decision of the admin on the review and the transfer executed if approved
+func (r *PostgresRepository) UpdateTransferReviewStatus(ctx context.Context, id int64, status, reviewedBy string, transferID int64) error {
+	query := `
+	update transfer_reviews
+	set status = $1, reviewed_by = $2, transfer_id = $3, reviewed_at = $4
+	where id = $5
+`
+	_, err := r.db.ExecContext(ctx, query,
+		status,
+		reviewedBy,
+		sql.NullInt64{Int64: transferID, Valid: transferID > 0},
+		time.Now(),
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetIdempotencyKey return the idempotency key without locking it or empty key if not found and error if exist
+func (r *PostgresRepository) GetIdempotencyKey(ctx context.Context, username, key string) (models.IdempotencyKey, error) {
+	query := `
+	select username, idempotency_key, request_hash, response, created_at
+	from idempotency_keys
+	where username = $1 and idempotency_key = $2
+`
+	var a models.IdempotencyKey
+
+	row := r.db.QueryRowContext(ctx, query, username, key)
+	err := row.Scan(
+		&a.Username,
+		&a.IdempotencyKey,
+		&a.RequestHash,
+		&a.Response,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// CountTransfersByOwnerSince count the transfers sent from the accounts of the owner since the time, reversals excluded
+func (r *PostgresRepository) CountTransfersByOwnerSince(ctx context.Context, owner string, since time.Time) (int, error) {
+	query := `
+	select count(*) from transfers t
+	join accounts a on a.id = t.from_account_id
+	where a.owner = $1 and t.created_at >= $2 and t.reversal_of is null
+`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, owner, since).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// CountSessionsFromDevice count the sessions of the user created before the time from the user agent and client ip
+func (r *PostgresRepository) CountSessionsFromDevice(ctx context.Context, username, userAgent, clientIP string, before time.Time) (int, error) {
+	query := `
+	select count(*) from sessions
+	where username = $1 and user_agent = $2 and client_ip = $3 and created_at < $4
+`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, username, userAgent, clientIP, before).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetTransferAmountStats return the count, average and max amount of the transfers sent from the account since
+// the time, reversals excluded
+func (r *PostgresRepository) GetTransferAmountStats(ctx context.Context, accountID int64, since time.Time) (models.TransferAmountStats, error) {
+	query := `
+	select count(*), coalesce(avg(amount), 0)::bigint, coalesce(max(amount), 0)
+	from transfers
+	where from_account_id = $1 and created_at >= $2 and reversal_of is null
+`
+	var a models.TransferAmountStats
+	err := r.db.QueryRowContext(ctx, query, accountID, since).Scan(&a.Count, &a.Average, &a.Max)
+	if err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// CountTransfersFromOwnerToAccount count the transfers sent from the accounts of the owner to the account
+func (r *PostgresRepository) CountTransfersFromOwnerToAccount(ctx context.Context, owner string, toAccountID int64) (int, error) {
+	query := `
+	select count(*) from transfers t
+	join accounts a on a.id = t.from_account_id
+	where a.owner = $1 and t.to_account_id = $2
+`
+	var count int
+	err := r.db.QueryRowContext(ctx, query, owner, toAccountID).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+const webhookEndpointColumns = `id, owner, url, secret, event_types, created_at`
+
+func scanWebhookEndpoint(row rowScanner, a *models.WebhookEndpoint) error {
+	var eventTypes pq.StringArray
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.URL,
+		&a.Secret,
+		&eventTypes,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.EventTypes = eventTypes
+	return nil
+}
+
+// InsertWebhookEndpoint insert new webhook endpoint to database and return newID and error if exist
+func (r *PostgresRepository) InsertWebhookEndpoint(ctx context.Context, arg models.WebhookEndpoint) (int64, error) {
+	query := `
+	insert into webhook_endpoints (owner, url, secret, event_types, created_at)
+	values ($1, $2, $3, $4, $5)
+	returning id
+`
+	eventTypes := arg.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.URL,
+		arg.Secret,
+		pq.Array(eventTypes),
+		time.Now(),
+	)
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetWebhookEndpointByID return webhook endpoint from given id or empty endpoint if not found and error if exist
+func (r *PostgresRepository) GetWebhookEndpointByID(ctx context.Context, id int64) (models.WebhookEndpoint, error) {
+	query := `
+	select ` + webhookEndpointColumns + ` from webhook_endpoints
+	where id = $1
+`
+	var a models.WebhookEndpoint
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanWebhookEndpoint(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListWebhookEndpoints return list webhook endpoints of the owner
+func (r *PostgresRepository) GetListWebhookEndpoints(ctx context.Context, owner string, limit, offset int) ([]*models.WebhookEndpoint, error) {
+	query := `
+	select ` + webhookEndpointColumns + ` from webhook_endpoints
+	where owner = $1
+	order by id
+	limit $2
+	offset $3
+`
+	return r.queryWebhookEndpoints(ctx, query, owner, limit, offset)
+}
+
+// GetWebhookEndpointsForEvent return the webhook endpoints of the owner subscribed to the event type
+func (r *PostgresRepository) GetWebhookEndpointsForEvent(ctx context.Context, owner, eventType string) ([]*models.WebhookEndpoint, error) {
+	query := `
+	select ` + webhookEndpointColumns + ` from webhook_endpoints
+	where owner = $1 and (cardinality(event_types) = 0 or $2 = any(event_types))
+	order by id
+`
+	return r.queryWebhookEndpoints(ctx, query, owner, eventType)
+}
+
+func (r *PostgresRepository) queryWebhookEndpoints(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookEndpoint, error) {
+	items := []*models.WebhookEndpoint{}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.WebhookEndpoint
+		err = scanWebhookEndpoint(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// DeleteWebhookEndpoint delete webhook endpoint from given id with its deliveries
+func (r *PostgresRepository) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	query := `
+	delete from webhook_endpoints
+	where id = $1
+`
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// InsertWebhookEvent insert new webhook event to database and return newID and error if exist
+func (r *PostgresRepository) InsertWebhookEvent(ctx context.Context, arg models.WebhookEvent) (int64, error) {
+	query := `
+	insert into webhook_events (owner, event_type, payload, created_at)
+	values ($1, $2, $3, $4)
+	returning id
+`
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.Owner,
+		arg.Type,
+		[]byte(arg.Payload),
+		time.Now(),
+	)
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetWebhookEventByID return webhook event from given id or empty event if not found and error if exist
+func (r *PostgresRepository) GetWebhookEventByID(ctx context.Context, id int64) (models.WebhookEvent, error) {
+	query := `
+	select id, owner, event_type, payload, created_at from webhook_events
+	where id = $1
+`
+	var a models.WebhookEvent
+	var payload []byte
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := row.Scan(
+		&a.ID,
+		&a.Owner,
+		&a.Type,
+		&payload,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	a.Payload = payload
+	return a, nil
+}
+
+const webhookDeliveryColumns = `id, event_id, endpoint_id, status, attempts, last_status_code, last_error, next_attempt_at, delivered_at, created_at`
+
+func scanWebhookDelivery(row rowScanner, a *models.WebhookDelivery) error {
+	var deliveredAt sql.NullTime
+	err := row.Scan(
+		&a.ID,
+		&a.EventID,
+		&a.EndpointID,
+		&a.Status,
+		&a.Attempts,
+		&a.LastStatusCode,
+		&a.LastError,
+		&a.NextAttemptAt,
+		&deliveredAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.DeliveredAt = deliveredAt.Time
+	return nil
+}
+
+// InsertWebhookDelivery insert new pending webhook delivery to database and return newID and error if exist
+func (r *PostgresRepository) InsertWebhookDelivery(ctx context.Context, arg models.WebhookDelivery) (int64, error) {
+	query := `
+	insert into webhook_deliveries (event_id, endpoint_id, status, next_attempt_at, created_at)
+	values ($1, $2, $3, $4, $5)
+	returning id
+`
+	now := time.Now()
+	nextAttemptAt := arg.NextAttemptAt
+	if nextAttemptAt.IsZero() {
+		nextAttemptAt = now
+	}
+
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.EventID,
+		arg.EndpointID,
+		models.WebhookDeliveryStatusPending,
+		nextAttemptAt,
+		now,
+	)
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetWebhookDeliveryByID return webhook delivery from given id or empty delivery if not found and error if exist
+func (r *PostgresRepository) GetWebhookDeliveryByID(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	query := `
+	select ` + webhookDeliveryColumns + ` from webhook_deliveries
+	where id = $1
+`
+	var a models.WebhookDelivery
+
+	row := r.db.QueryRowContext(ctx, query, id)
+	err := scanWebhookDelivery(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+// GetListWebhookDeliveries return the deliveries to the endpoint with the given status, every status when empty,
+// before the given delivery id, every delivery when 0, the newest first
+func (r *PostgresRepository) GetListWebhookDeliveries(ctx context.Context, endpointID int64, status string, beforeID int64, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+	select ` + webhookDeliveryColumns + ` from webhook_deliveries
+	where endpoint_id = $1 and ($2::varchar = '' or status = $2::varchar)
+	and ($3::bigint = 0 or id < $3::bigint)
+	order by id desc
+	limit $4
+`
+	return r.queryWebhookDeliveries(ctx, query, endpointID, status, beforeID, limit)
+}
+
+// GetListDueWebhookDeliveries return pending webhook deliveries that should be attempted at or before the given
+// time, oldest first
+func (r *PostgresRepository) GetListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	query := `
+	select ` + webhookDeliveryColumns + ` from webhook_deliveries
+	where status = $1 and next_attempt_at <= $2
+	order by next_attempt_at
+	limit $3
+`
+	return r.queryWebhookDeliveries(ctx, query, models.WebhookDeliveryStatusPending, now, limit)
+}
+
+func (r *PostgresRepository) queryWebhookDeliveries(ctx context.Context, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	items := []*models.WebhookDelivery{}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.WebhookDelivery
+		err = scanWebhookDelivery(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateWebhookDeliveryAttempt record the outcome of an attempt of the delivery: its status, attempts, last
+// response and next attempt
+func (r *PostgresRepository) UpdateWebhookDeliveryAttempt(ctx context.Context, arg models.WebhookDelivery) error {
+	query := `
+	update webhook_deliveries
+	set status = $1, attempts = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6
+	where id = $7
+`
+	_, err := r.db.ExecContext(ctx, query,
+		arg.Status,
+		arg.Attempts,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.NextAttemptAt,
+		sql.NullTime{Time: arg.DeliveredAt, Valid: !arg.DeliveredAt.IsZero()},
+		arg.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReplayWebhookDelivery make the delivery pending again with its attempts reset so it is attempted right away,
+// the delivery is returned or empty delivery if not found
+func (r *PostgresRepository) ReplayWebhookDelivery(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	query := `
+	update webhook_deliveries
+	set status = $1, attempts = 0, last_status_code = 0, last_error = '', next_attempt_at = $2, delivered_at = null
+	where id = $3
+	returning ` + webhookDeliveryColumns + `
+`
+	var a models.WebhookDelivery
+
+	row := r.db.QueryRowContext(ctx, query, models.WebhookDeliveryStatusPending, time.Now(), id)
+	err := scanWebhookDelivery(row, &a)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return a, nil
+		}
+		return a, err
+	}
+
+	return a, nil
+}
+
+const outboxMessageColumns = `id, task_type, payload, queue, max_retry, relayed_at, created_at`
+
+func scanOutboxMessage(row rowScanner, a *models.OutboxMessage) error {
+	var payload []byte
+	var relayedAt sql.NullTime
+	err := row.Scan(
+		&a.ID,
+		&a.TaskType,
+		&payload,
+		&a.Queue,
+		&a.MaxRetry,
+		&relayedAt,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	a.Payload = payload
+	a.RelayedAt = relayedAt.Time
+	return nil
+}
+
+// InsertOutboxMessage insert new unrelayed outbox message to database and return newID and error if exist
+func (r *PostgresRepository) InsertOutboxMessage(ctx context.Context, arg models.OutboxMessage) (int64, error) {
+	query := `
+	insert into outbox (task_type, payload, queue, max_retry, created_at)
+	values ($1, $2, $3, $4, $5)
+	returning id
+`
+	var newID int64
+	row := r.db.QueryRowContext(ctx, query,
+		arg.TaskType,
+		[]byte(arg.Payload),
+		arg.Queue,
+		arg.MaxRetry,
+		time.Now(),
+	)
+	err := row.Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+
+	return newID, nil
+}
+
+// GetListUnrelayedOutboxMessages return outbox messages that are not relayed yet, oldest first
+func (r *PostgresRepository) GetListUnrelayedOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	query := `
+	select ` + outboxMessageColumns + ` from outbox
+	where relayed_at is null
+	order by id
+	limit $1
+`
+	items := []*models.OutboxMessage{}
+
+	rows, err := r.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a models.OutboxMessage
+		err = scanOutboxMessage(rows, &a)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, &a)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// UpdateOutboxMessageRelayed record when the outbox message was enqueued
+func (r *PostgresRepository) UpdateOutboxMessageRelayed(ctx context.Context, id int64, relayedAt time.Time) error {
+	query := `
+	update outbox set relayed_at = $1
+	where id = $2
+`
+	_, err := r.db.ExecContext(ctx, query, relayedAt, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
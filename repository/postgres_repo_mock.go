@@ -43,6 +43,28 @@ func (r *PostgresRepositoryMock) GetAccountByID(ctx context.Context, id int64) (
 			Owner:     "some-user",
 			Balance:   100,
 			Currency:  "USD",
+			Status:    models.AccountStatusActive,
+			CreatedAt: time.Now(),
+		}
+	}
+	if id == 4 {
+		a = models.Account{
+			ID:        id,
+			Owner:     "other-user",
+			Balance:   100,
+			Currency:  "EUR",
+			Status:    models.AccountStatusActive,
+			CreatedAt: time.Now(),
+		}
+	}
+	// account of another user in the same currency, not a payee of anyone
+	if id == 5 {
+		a = models.Account{
+			ID:        id,
+			Owner:     "other-user",
+			Balance:   100,
+			Currency:  "USD",
+			Status:    models.AccountStatusActive,
 			CreatedAt: time.Now(),
 		}
 	}
@@ -52,17 +74,37 @@ func (r *PostgresRepositoryMock) GetAccountByID(ctx context.Context, id int64) (
 	return a, nil
 }
 
+// GetAccountByOwnerAndCurrency return the open account of the owner in the currency. some-user has an open CAD
+// account with id 1 and a closed JPY account, the closed account is not returned
 func (r *PostgresRepositoryMock) GetAccountByOwnerAndCurrency(ctx context.Context, username, currency string) (models.Account, error) {
 	var a models.Account
+	if username == "some-user" && currency == "CAD" {
+		a = models.Account{
+			ID:        1,
+			Owner:     username,
+			Currency:  currency,
+			Status:    models.AccountStatusActive,
+			CreatedAt: time.Now(),
+		}
+	}
 	return a, nil
 }
 
-// GetListAccounts return list accounts from database and error if exist
-func (r *PostgresRepositoryMock) GetListAccounts(ctx context.Context, owner string, limit, offset int) ([]*models.Account, error) {
+// GetListAccounts return the accounts of the owner after the given account id ordered by id and error if exist
+func (r *PostgresRepositoryMock) GetListAccounts(ctx context.Context, owner string, afterID int64, limit int) ([]*models.Account, error) {
 	items := []*models.Account{}
-	if offset > 1000 {
+	if afterID > 1000 {
 		return items, sql.ErrConnDone
 	}
+	for id := afterID + 1; id <= 3 && len(items) < limit; id++ {
+		if id < 2 {
+			continue
+		}
+		a, _ := r.GetAccountByID(ctx, id)
+		if a.Owner == owner {
+			items = append(items, &a)
+		}
+	}
 	return items, nil
 }
 
@@ -74,6 +116,14 @@ func (r *PostgresRepositoryMock) UpdateAccount(ctx context.Context, arg models.A
 	return nil
 }
 
+func (r *PostgresRepositoryMock) UpdateAccountOverdraftLimit(ctx context.Context, id, limit int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) UpdateAccountStatus(ctx context.Context, id int64, status string) error {
+	return nil
+}
+
 // DeleteAccount delete account from given id return error if exist
 func (r *PostgresRepositoryMock) DeleteAccount(ctx context.Context, id int64) error {
 	if id == 3 {
@@ -105,12 +155,19 @@ func (r *PostgresRepositoryMock) GetEntryByID(ctx context.Context, id int64) (mo
 	return a, nil
 }
 
-// GetListEntries return list entry from given account_id and error if exist
-func (r *PostgresRepositoryMock) GetListEntries(ctx context.Context, accountID int64, limit, offset int) ([]*models.Entry, error) {
+// GetListEntries return the entries matching the filter after the given entry id ordered by id and error if exist
+func (r *PostgresRepositoryMock) GetListEntries(ctx context.Context, arg models.EntryFilter, afterID int64, limit int) ([]*models.Entry, error) {
 	items := []*models.Entry{}
-	if offset > 1000 {
+	if afterID > 1000 {
 		return items, sql.ErrConnDone
 	}
+	// a statement period has one credit and one debit
+	if afterID == 0 && !arg.From.IsZero() {
+		items = append(items,
+			&models.Entry{ID: 1, AccountID: arg.AccountID, Amount: 50, TransferID: 1, CreatedAt: arg.From.Add(time.Hour)},
+			&models.Entry{ID: 2, AccountID: arg.AccountID, Amount: -20, TransferID: 2, CreatedAt: arg.From.Add(2 * time.Hour)},
+		)
+	}
 	return items, nil
 }
 
@@ -138,10 +195,11 @@ func (r *PostgresRepositoryMock) GetTransferByID(ctx context.Context, id int64)
 	return a, nil
 }
 
-// GetListTransfers return list transfers from given from_account_id or to_account_id and error if exist
-func (r *PostgresRepositoryMock) GetListTransfers(ctx context.Context, fromAccountID, toAccountID int64, limit, offset int) ([]*models.Transfer, error) {
+// GetListTransfers return the transfers sent or received by the account of the filter after the given transfer id
+// ordered by id and error if exist
+func (r *PostgresRepositoryMock) GetListTransfers(ctx context.Context, arg models.TransferFilter, afterID int64, limit int) ([]*models.Transfer, error) {
 	items := []*models.Transfer{}
-	if offset > 1000 {
+	if afterID > 1000 {
 		return items, sql.ErrConnDone
 	}
 	return items, nil
@@ -171,9 +229,13 @@ func (r *PostgresRepositoryMock) GetUsersByUsername(ctx context.Context, usernam
 		HashedPassword: hashedPassword,
 		FullName:       "some name",
 		Email:          "some@email.com",
+		Role:           models.UserRoleDepositor,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
+	if username == "admin-user" {
+		a.Role = models.UserRoleAdmin
+	}
 	if username == "user" || username == "user2" {
 		a = models.Users{}
 	}
@@ -237,3 +299,843 @@ func (r *PostgresRepositoryMock) GetVerifyEmailByID(ctx context.Context, id int6
 func (r *PostgresRepositoryMock) UpdateVerifyEmailIsUsed(ctx context.Context, id int64, secretCode string) error {
 	return nil
 }
+
+func (r *PostgresRepositoryMock) InsertIdempotencyKey(ctx context.Context, arg models.IdempotencyKey) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) GetIdempotencyKeyForUpdate(ctx context.Context, username, key string) (models.IdempotencyKey, error) {
+	var a models.IdempotencyKey
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateIdempotencyKeyResponse(ctx context.Context, username, key string, response []byte) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) AddAccountHeldBalanceByID(ctx context.Context, amount, id int64) (models.Account, error) {
+	var a models.Account
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) InsertHold(ctx context.Context, arg models.Hold) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetHoldByID(ctx context.Context, id int64) (models.Hold, error) {
+	var a models.Hold
+	if id == 2 {
+		a = models.Hold{
+			ID:          id,
+			AccountID:   2,
+			ToAccountID: 3,
+			Amount:      10,
+			Status:      models.HoldStatusAuthorized,
+			ExpiredAt:   time.Now().Add(time.Hour),
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetHoldByIDForUpdate(ctx context.Context, id int64) (models.Hold, error) {
+	return r.GetHoldByID(ctx, id)
+}
+
+func (r *PostgresRepositoryMock) UpdateHoldStatus(ctx context.Context, id int64, status string, transferID int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) GetListExpiredHolds(ctx context.Context, limit int) ([]*models.Hold, error) {
+	items := []*models.Hold{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferByIDForUpdate(ctx context.Context, id int64) (models.Transfer, error) {
+	return r.GetTransferByID(ctx, id)
+}
+
+func (r *PostgresRepositoryMock) GetListReversals(ctx context.Context, transferID int64) ([]*models.Transfer, error) {
+	items := []*models.Transfer{}
+	if transferID == 2 {
+		items = append(items, &models.Transfer{
+			ID:            3,
+			FromAccountID: 2,
+			ToAccountID:   1,
+			Amount:        5,
+			ReversalOf:    transferID,
+			CreatedAt:     time.Now(),
+		})
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertAccountStatusHistory(ctx context.Context, arg models.AccountStatusHistory) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetListAccountStatusHistory(ctx context.Context, accountID int64) ([]*models.AccountStatusHistory, error) {
+	items := []*models.AccountStatusHistory{}
+	if accountID > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetCurrency(ctx context.Context, code string) (models.Currency, error) {
+	var a models.Currency
+	switch code {
+	case "USD", "EUR", "CAD":
+		a = models.Currency{Code: code, MinorUnit: 2, CreatedAt: time.Now()}
+	case "JPY":
+		a = models.Currency{Code: code, MinorUnit: 0, CreatedAt: time.Now()}
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListCurrencies(ctx context.Context) ([]*models.Currency, error) {
+	items := []*models.Currency{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertFxRate(ctx context.Context, arg models.FxRate) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetFxRateAt(ctx context.Context, base, quote string, at time.Time) (models.FxRate, error) {
+	var a models.FxRate
+	if base == "USD" && quote == "EUR" {
+		a = models.FxRate{
+			ID:            1,
+			BaseCurrency:  base,
+			QuoteCurrency: quote,
+			Rate:          "0.92",
+			SpreadBps:     50,
+			EffectiveAt:   time.Now(),
+			CreatedAt:     time.Now(),
+		}
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) InsertScheduledTransfer(ctx context.Context, arg models.ScheduledTransfer) (int64, error) {
+	var newID int64
+	if arg.Amount > 1000 {
+		return newID, sql.ErrConnDone
+	}
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetScheduledTransferByID(ctx context.Context, id int64) (models.ScheduledTransfer, error) {
+	var a models.ScheduledTransfer
+	if id == 2 {
+		a = models.ScheduledTransfer{
+			ID:            id,
+			Owner:         "some-user",
+			FromAccountID: 2,
+			ToAccountID:   3,
+			Amount:        10,
+			CronSpec:      "0 9 1 * *",
+			NextRunAt:     time.Now().Add(time.Hour),
+			FailurePolicy: models.FailurePolicySkip,
+			Status:        models.ScheduledTransferStatusActive,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetScheduledTransferByIDForUpdate(ctx context.Context, id int64) (models.ScheduledTransfer, error) {
+	return r.GetScheduledTransferByID(ctx, id)
+}
+
+func (r *PostgresRepositoryMock) GetListScheduledTransfers(ctx context.Context, owner string, limit, offset int) ([]*models.ScheduledTransfer, error) {
+	items := []*models.ScheduledTransfer{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]*models.ScheduledTransfer, error) {
+	items := []*models.ScheduledTransfer{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateScheduledTransfer(ctx context.Context, arg models.ScheduledTransfer) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertScheduledTransferExecution(ctx context.Context, arg models.ScheduledTransferExecution) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetListScheduledTransferExecutions(ctx context.Context, scheduledTransferID int64, limit, offset int) ([]*models.ScheduledTransferExecution, error) {
+	items := []*models.ScheduledTransferExecution{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertTransferBatch(ctx context.Context, arg models.TransferBatch) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferBatchByID(ctx context.Context, id int64) (models.TransferBatch, error) {
+	var a models.TransferBatch
+	if id == 2 {
+		a = models.TransferBatch{
+			ID:         id,
+			Owner:      "some-user",
+			Mode:       models.BatchModeBestEffort,
+			Status:     models.BatchStatusPending,
+			TotalItems: 1,
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateTransferBatchStatus(ctx context.Context, id int64, status string) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertTransferBatchItem(ctx context.Context, arg models.TransferBatchItem) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferBatchItemByIDForUpdate(ctx context.Context, id int64) (models.TransferBatchItem, error) {
+	var a models.TransferBatchItem
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateTransferBatchItemResult(ctx context.Context, id int64, status string, transferID int64, errMessage string) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) GetListTransferBatchItems(ctx context.Context, batchID int64) ([]*models.TransferBatchItem, error) {
+	items := []*models.TransferBatchItem{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertAccountProduct(ctx context.Context, arg models.AccountProduct) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetAccountProduct(ctx context.Context, id int64) (models.AccountProduct, error) {
+	var a models.AccountProduct
+	if id == 2 {
+		a = models.AccountProduct{
+			ID:         id,
+			Name:       "USD Savings",
+			Currency:   "USD",
+			AnnualRate: "0.0425",
+			DayCount:   models.DayCountAct365,
+			CreatedAt:  time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListAccountProducts(ctx context.Context) ([]*models.AccountProduct, error) {
+	items := []*models.AccountProduct{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListInterestBearingAccounts(ctx context.Context, afterID int64, limit int) ([]*models.Account, error) {
+	items := []*models.Account{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertHouseAccount(ctx context.Context, arg models.HouseAccount) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) GetHouseAccount(ctx context.Context, purpose, currency string) (models.HouseAccount, error) {
+	var a models.HouseAccount
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) InsertInterestAccrual(ctx context.Context, arg models.InterestAccrual) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetListUnpostedInterestAccruals(ctx context.Context, accountID int64, until time.Time) ([]*models.InterestAccrual, error) {
+	items := []*models.InterestAccrual{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateInterestAccrualsPosting(ctx context.Context, ids []int64, postingID int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertInterestPosting(ctx context.Context, arg models.InterestPosting) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetLastInterestPosting(ctx context.Context, accountID int64) (models.InterestPosting, error) {
+	var a models.InterestPosting
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListInterestPostings(ctx context.Context, accountID int64, limit, offset int) ([]*models.InterestPosting, error) {
+	items := []*models.InterestPosting{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertFeeSchedule(ctx context.Context, arg models.FeeSchedule) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetFeeSchedule(ctx context.Context, currency string, productID int64) (models.FeeSchedule, error) {
+	var a models.FeeSchedule
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListFeeSchedules(ctx context.Context, currency string) ([]*models.FeeSchedule, error) {
+	items := []*models.FeeSchedule{}
+	if currency == "CAD" {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListAccountLedgers(ctx context.Context, afterID int64, limit int) ([]*models.AccountLedger, error) {
+	items := []*models.AccountLedger{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListTransferLedgers(ctx context.Context, fromAccountIDAfter, fromAccountIDUntil int64) ([]*models.TransferLedger, error) {
+	items := []*models.TransferLedger{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertReconciliationReport(ctx context.Context, arg models.ReconciliationReport) (int64, error) {
+	return 1, nil
+}
+
+func (r *PostgresRepositoryMock) GetReconciliationReportByID(ctx context.Context, id int64) (models.ReconciliationReport, error) {
+	var a models.ReconciliationReport
+	if id == 2 {
+		a = models.ReconciliationReport{
+			ID:               id,
+			StartedAt:        time.Now(),
+			FinishedAt:       time.Now(),
+			AccountsChecked:  2,
+			TransfersChecked: 1,
+			FindingsCount:    1,
+			Findings: []*models.ReconciliationFinding{
+				{Kind: models.FindingBalanceMismatch, AccountID: 2, Detail: "balance 100, entries sum to 90"},
+			},
+			CreatedAt: time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListReconciliationReports(ctx context.Context, limit, offset int) ([]*models.ReconciliationReport, error) {
+	items := []*models.ReconciliationReport{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertAccountBalanceSnapshots(ctx context.Context, snapshotDate, asOf time.Time, afterID int64, limit int) (int64, int64, error) {
+	return 0, 0, nil
+}
+
+func (r *PostgresRepositoryMock) GetListAccountBalanceSnapshots(ctx context.Context, accountID int64, limit, offset int) ([]*models.AccountBalanceSnapshot, error) {
+	items := []*models.AccountBalanceSnapshot{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetAccountBalanceAt(ctx context.Context, accountID int64, at time.Time) (int64, error) {
+	if at.Year() < 2000 {
+		return 0, sql.ErrConnDone
+	}
+	return 100, nil
+}
+
+func (r *PostgresRepositoryMock) GetListEntriesOfAccountsAfter(ctx context.Context, accountIDs []int64, afterID int64, limit int) ([]*models.Entry, error) {
+	items := []*models.Entry{}
+	if afterID > 1000 {
+		return items, sql.ErrConnDone
+	}
+	// entries 1 to 3 are posted to the first account
+	for id := afterID + 1; id <= 3 && len(items) < limit && len(accountIDs) > 0; id++ {
+		items = append(items, &models.Entry{ID: id, AccountID: accountIDs[0], Amount: 10, CreatedAt: time.Now()})
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListTransfersByIDs(ctx context.Context, ids []int64) ([]*models.Transfer, error) {
+	items := []*models.Transfer{}
+	for _, id := range ids {
+		if id > 1000 {
+			return nil, sql.ErrConnDone
+		}
+		items = append(items, &models.Transfer{
+			ID:            id,
+			FromAccountID: 4,
+			ToAccountID:   2,
+			Amount:        50,
+			ToAmount:      50,
+			CreatedAt:     time.Now(),
+		})
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) InsertExternalPayout(ctx context.Context, arg models.ExternalPayout) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetExternalPayoutByID(ctx context.Context, id int64) (models.ExternalPayout, error) {
+	var a models.ExternalPayout
+	if id == 2 {
+		a = models.ExternalPayout{
+			ID:              id,
+			Owner:           "some-user",
+			AccountID:       2,
+			Amount:          10,
+			Currency:        "USD",
+			RoutingNumber:   "021000021",
+			AccountNumber:   "123456789",
+			AccountType:     models.PayoutAccountChecking,
+			BeneficiaryName: "John Doe",
+			Status:          models.PayoutStatusQueued,
+			TransferID:      1,
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetExternalPayoutByTraceNumberForUpdate(ctx context.Context, traceNumber string) (models.ExternalPayout, error) {
+	var a models.ExternalPayout
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListExternalPayouts(ctx context.Context, owner string, limit, offset int) ([]*models.ExternalPayout, error) {
+	items := []*models.ExternalPayout{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListQueuedExternalPayoutsForUpdate(ctx context.Context, currency string, limit int) ([]*models.ExternalPayout, error) {
+	items := []*models.ExternalPayout{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateExternalPayoutSubmitted(ctx context.Context, id, achFileID int64, traceNumber string) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) UpdateExternalPayoutReturned(ctx context.Context, id int64, returnCode string, returnTransferID int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) UpdateExternalPayoutReturnException(ctx context.Context, id int64, returnCode string) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertACHFile(ctx context.Context, arg models.ACHFile) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) CountACHFilesCreatedSince(ctx context.Context, since time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *PostgresRepositoryMock) GetListUnwrittenACHFiles(ctx context.Context, limit int) ([]*models.ACHFile, error) {
+	items := []*models.ACHFile{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateACHFileWritten(ctx context.Context, id int64, writtenAt time.Time) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertPayee(ctx context.Context, arg models.Payee) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetPayeeByID(ctx context.Context, id int64) (models.Payee, error) {
+	var a models.Payee
+	if id == 2 {
+		a = models.Payee{
+			ID:        id,
+			Owner:     "some-user",
+			Nickname:  "other",
+			AccountID: 4,
+			ActiveAt:  time.Now().Add(time.Hour),
+			CreatedAt: time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetPayeeByOwnerAndAccount(ctx context.Context, owner string, accountID int64) (models.Payee, error) {
+	if owner == "some-user" && accountID == 4 {
+		return r.GetPayeeByID(ctx, 2)
+	}
+	var a models.Payee
+	if accountID > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListPayees(ctx context.Context, owner string, limit, offset int) ([]*models.Payee, error) {
+	items := []*models.Payee{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+// GetPayeeSentAmount return 3 sent by some-user in USD to account 4 and 0 otherwise
+func (r *PostgresRepositoryMock) GetPayeeSentAmount(ctx context.Context, owner, currency string, accountID int64, since time.Time) (int64, error) {
+	if accountID > 1000 {
+		return 0, sql.ErrConnDone
+	}
+	if owner == "some-user" && currency == "USD" && accountID == 4 {
+		return 3, nil
+	}
+	return 0, nil
+}
+
+func (r *PostgresRepositoryMock) DeletePayee(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertTransferLimit(ctx context.Context, arg models.TransferLimit) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferLimitByID(ctx context.Context, id int64) (models.TransferLimit, error) {
+	var a models.TransferLimit
+	if id == 2 {
+		a = models.TransferLimit{
+			ID:        id,
+			Owner:     "some-user",
+			Currency:  "USD",
+			Daily:     1000,
+			UpdatedBy: "admin-user",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferLimitForUpdate(ctx context.Context, owner, currency string, accountID int64) (models.TransferLimit, error) {
+	var a models.TransferLimit
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferLimitsOfAccount(ctx context.Context, owner, currency string, accountID int64) ([]*models.TransferLimit, error) {
+	items := []*models.TransferLimit{}
+	if currency == "USD" {
+		items = append(items, &models.TransferLimit{
+			ID:        1,
+			Currency:  currency,
+			Daily:     1000,
+			UpdatedBy: "admin-user",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		})
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListTransferLimits(ctx context.Context, owner string, limit, offset int) ([]*models.TransferLimit, error) {
+	items := []*models.TransferLimit{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateTransferLimit(ctx context.Context, arg models.TransferLimit) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) DeleteTransferLimit(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferLimitUsage(ctx context.Context, owner, currency string, accountID int64, dailySince, monthlySince time.Time) (models.TransferLimitUsage, error) {
+	return models.TransferLimitUsage{Daily: 300, Monthly: 300}, nil
+}
+
+func (r *PostgresRepositoryMock) GetUsersByUsernameForUpdate(ctx context.Context, username string) (models.Users, error) {
+	return r.GetUsersByUsername(ctx, username)
+}
+
+func (r *PostgresRepositoryMock) InsertTransferReview(ctx context.Context, arg models.TransferReview) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferReviewByID(ctx context.Context, id int64) (models.TransferReview, error) {
+	var a models.TransferReview
+	if id == 2 {
+		a = models.TransferReview{
+			ID:            id,
+			Owner:         "some-user",
+			FromAccountID: 2,
+			ToAccountID:   4,
+			Amount:        60,
+			ToCurrency:    "EUR",
+			Status:        models.TransferReviewStatusPending,
+			Findings: []*models.RiskFinding{
+				{Rule: "first_time_payee", Decision: models.RiskDecisionReview, Reason: "first transfer to account 4"},
+			},
+			CreatedAt: time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferReviewByIDForUpdate(ctx context.Context, id int64) (models.TransferReview, error) {
+	return r.GetTransferReviewByID(ctx, id)
+}
+
+func (r *PostgresRepositoryMock) GetTransferReviewByIdempotencyKey(ctx context.Context, owner, key string) (models.TransferReview, error) {
+	if owner == "some-user" && key == "parked-key" {
+		return r.GetTransferReviewByID(ctx, 2)
+	}
+	var a models.TransferReview
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListTransferReviews(ctx context.Context, status string, afterID int64, limit int) ([]*models.TransferReview, error) {
+	items := []*models.TransferReview{}
+	if afterID > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateTransferReviewStatus(ctx context.Context, id int64, status, reviewedBy string, transferID int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) GetIdempotencyKey(ctx context.Context, username, key string) (models.IdempotencyKey, error) {
+	var a models.IdempotencyKey
+	if key == "replayed-key" {
+		a = models.IdempotencyKey{
+			Username:       username,
+			IdempotencyKey: key,
+			Response:       []byte(`{}`),
+			CreatedAt:      time.Now(),
+		}
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) CountTransfersByOwnerSince(ctx context.Context, owner string, since time.Time) (int, error) {
+	if owner == "other-user" {
+		return 10, nil
+	}
+	return 0, nil
+}
+
+func (r *PostgresRepositoryMock) CountSessionsFromDevice(ctx context.Context, username, userAgent, clientIP string, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *PostgresRepositoryMock) GetTransferAmountStats(ctx context.Context, accountID int64, since time.Time) (models.TransferAmountStats, error) {
+	var a models.TransferAmountStats
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) CountTransfersFromOwnerToAccount(ctx context.Context, owner string, toAccountID int64) (int, error) {
+	return 0, nil
+}
+
+func (r *PostgresRepositoryMock) InsertWebhookEndpoint(ctx context.Context, arg models.WebhookEndpoint) (int64, error) {
+	var newID int64
+	if arg.Owner == "test-error-db" {
+		return newID, sql.ErrConnDone
+	}
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetWebhookEndpointByID(ctx context.Context, id int64) (models.WebhookEndpoint, error) {
+	var a models.WebhookEndpoint
+	if id == 2 {
+		a = models.WebhookEndpoint{
+			ID:         id,
+			Owner:      "some-user",
+			URL:        "https://example.com/webhooks",
+			Secret:     "whsec_test",
+			EventTypes: []string{models.WebhookEventTransferPosted},
+			CreatedAt:  time.Now(),
+		}
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListWebhookEndpoints(ctx context.Context, owner string, limit, offset int) ([]*models.WebhookEndpoint, error) {
+	items := []*models.WebhookEndpoint{}
+	if offset > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetWebhookEndpointsForEvent(ctx context.Context, owner, eventType string) ([]*models.WebhookEndpoint, error) {
+	items := []*models.WebhookEndpoint{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) DeleteWebhookEndpoint(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) InsertWebhookEvent(ctx context.Context, arg models.WebhookEvent) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetWebhookEventByID(ctx context.Context, id int64) (models.WebhookEvent, error) {
+	var a models.WebhookEvent
+	if id == 2 {
+		a = models.WebhookEvent{
+			ID:        id,
+			Owner:     "some-user",
+			Type:      models.WebhookEventTransferPosted,
+			Payload:   []byte(`{}`),
+			CreatedAt: time.Now(),
+		}
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) InsertWebhookDelivery(ctx context.Context, arg models.WebhookDelivery) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetWebhookDeliveryByID(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	var a models.WebhookDelivery
+	if id == 2 || id == 3 {
+		a = models.WebhookDelivery{
+			ID:             id,
+			EventID:        2,
+			EndpointID:     2,
+			Status:         models.WebhookDeliveryStatusDead,
+			Attempts:       10,
+			LastStatusCode: 500,
+			NextAttemptAt:  time.Now(),
+			CreatedAt:      time.Now(),
+		}
+	}
+	if id == 3 {
+		a.Status = models.WebhookDeliveryStatusPending
+		a.Attempts = 1
+	}
+	if id > 1000 {
+		return a, sql.ErrConnDone
+	}
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) GetListWebhookDeliveries(ctx context.Context, endpointID int64, status string, beforeID int64, limit int) ([]*models.WebhookDelivery, error) {
+	items := []*models.WebhookDelivery{}
+	if beforeID > 1000 {
+		return items, sql.ErrConnDone
+	}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) GetListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	items := []*models.WebhookDelivery{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateWebhookDeliveryAttempt(ctx context.Context, arg models.WebhookDelivery) error {
+	return nil
+}
+
+func (r *PostgresRepositoryMock) ReplayWebhookDelivery(ctx context.Context, id int64) (models.WebhookDelivery, error) {
+	a, err := r.GetWebhookDeliveryByID(ctx, id)
+	if err != nil {
+		return a, err
+	}
+	a.Status = models.WebhookDeliveryStatusPending
+	a.Attempts = 0
+	a.LastStatusCode = 0
+	return a, nil
+}
+
+func (r *PostgresRepositoryMock) InsertOutboxMessage(ctx context.Context, arg models.OutboxMessage) (int64, error) {
+	var newID int64
+	return newID, nil
+}
+
+func (r *PostgresRepositoryMock) GetListUnrelayedOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	items := []*models.OutboxMessage{}
+	return items, nil
+}
+
+func (r *PostgresRepositoryMock) UpdateOutboxMessageRelayed(ctx context.Context, id int64, relayedAt time.Time) error {
+	return nil
+}
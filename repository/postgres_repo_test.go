@@ -63,6 +63,57 @@ func TestInsertAccount(t *testing.T) {
 	}
 }
 
+func TestInsertAccountSameCurrency(t *testing.T) {
+	hashedPassword, _ := util.HashedPassword(util.RandomString(6))
+
+	user := models.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	err := testRepo.InsertUsers(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed insert users error:%s", err)
+	}
+
+	dataTest := createRandomAccount(user.Username)
+	firstID, err := testRepo.InsertAccount(context.Background(), dataTest)
+	if err != nil {
+		t.Fatalf("failed insert account error:%s", err)
+	}
+
+	// only one open account per currency
+	_, err = testRepo.InsertAccount(context.Background(), dataTest)
+	if err == nil {
+		t.Fatalf("failed insert second open account of the same currency want error got nil")
+	}
+
+	// a closed account does not take the currency
+	err = testRepo.UpdateAccountStatus(context.Background(), firstID, models.AccountStatusClosed)
+	if err != nil {
+		t.Fatalf("failed close account error:%s", err)
+	}
+	newID, err := testRepo.InsertAccount(context.Background(), dataTest)
+	if err != nil {
+		t.Fatalf("failed insert account after close error:%s", err)
+	}
+	if newID == firstID {
+		t.Errorf("failed same id of the new account %d", newID)
+	}
+
+	// the lookup of the owner currency return the open account, not the closed one
+	a, err := testRepo.GetAccountByOwnerAndCurrency(context.Background(), user.Username, dataTest.Currency)
+	if err != nil {
+		t.Fatalf("failed get account by owner and currency error:%s", err)
+	}
+	if a.ID != newID {
+		t.Errorf("failed wrong account of owner currency, want %d got %d", newID, a.ID)
+	}
+}
+
 func TestGetAccountByID(t *testing.T) {
 	hashedPassword, _ := util.HashedPassword(util.RandomString(6))
 
@@ -290,7 +341,7 @@ func TestGetListAccounts(t *testing.T) {
 		lastAccount = dataTest
 	}
 
-	listData, err := testRepo.GetListAccounts(context.Background(), lastAccount.Owner, 10, 0)
+	listData, err := testRepo.GetListAccounts(context.Background(), lastAccount.Owner, 0, 10)
 	if err != nil {
 		t.Errorf("failed get list accounts error:%s", err)
 	}
@@ -464,7 +515,7 @@ func TestGetListEntries(t *testing.T) {
 		}
 	}
 
-	listEntries, err := testRepo.GetListEntries(context.Background(), acc.ID, 10, 0)
+	listEntries, err := testRepo.GetListEntries(context.Background(), models.EntryFilter{AccountID: acc.ID}, 0, 10)
 	if err != nil {
 		t.Errorf("failed get list entries error:%s", err)
 	}
@@ -472,6 +523,53 @@ func TestGetListEntries(t *testing.T) {
 	if len(listEntries) < 10 {
 		t.Errorf("failed len entries not 10 len:%d", len(listEntries))
 	}
+
+	// next page start after the last entry of the previous one
+	page, err := testRepo.GetListEntries(context.Background(), models.EntryFilter{AccountID: acc.ID}, listEntries[6].ID, 10)
+	if err != nil {
+		t.Errorf("failed get next page of entries error:%s", err)
+	}
+	if len(page) != 3 || page[0].ID != listEntries[7].ID {
+		t.Errorf("failed next page of entries want 3 got %d", len(page))
+	}
+
+	// RandomBalance is positive so every entry is a credit
+	debits, err := testRepo.GetListEntries(context.Background(), models.EntryFilter{AccountID: acc.ID, Direction: models.EntryDirectionDebit}, 0, 10)
+	if err != nil {
+		t.Errorf("failed get debit entries error:%s", err)
+	}
+	if len(debits) != 0 {
+		t.Errorf("failed debit entries want 0 got %d", len(debits))
+	}
+
+	amount := listEntries[0].Amount
+	byAmount, err := testRepo.GetListEntries(context.Background(), models.EntryFilter{AccountID: acc.ID, MinAmount: amount, MaxAmount: amount}, 0, 10)
+	if err != nil {
+		t.Errorf("failed get entries by amount error:%s", err)
+	}
+	for _, e := range byAmount {
+		if e.Amount != amount {
+			t.Errorf("failed entries by amount want %d got %d", amount, e.Amount)
+		}
+	}
+
+	future, err := testRepo.GetListEntries(context.Background(), models.EntryFilter{AccountID: acc.ID, From: time.Now().Add(time.Hour)}, 0, 10)
+	if err != nil {
+		t.Errorf("failed get entries by date error:%s", err)
+	}
+	if len(future) != 0 {
+		t.Errorf("failed entries from the future want 0 got %d", len(future))
+	}
+
+	// resume after the 5th entry
+	afterID := listEntries[4].ID
+	after, err := testRepo.GetListEntriesOfAccountsAfter(context.Background(), []int64{acc.ID}, afterID, 10)
+	if err != nil {
+		t.Errorf("failed get list entries after error:%s", err)
+	}
+	if len(after) != 5 || after[0].ID <= afterID {
+		t.Errorf("failed list entries after %d got %d entries", afterID, len(after))
+	}
 }
 
 func TestInsertTransfer(t *testing.T) {
@@ -685,6 +783,7 @@ func TestGetListTransfers(t *testing.T) {
 		acc.ID = newID
 	}
 
+	var ids []int64
 	for i := 0; i < 10; i++ {
 		tf := models.Transfer{
 			FromAccountID: acc1.ID,
@@ -692,13 +791,14 @@ func TestGetListTransfers(t *testing.T) {
 			Amount:        util.RandomBalance(),
 		}
 
-		_, err := testRepo.InsertTransfer(context.Background(), tf)
+		id, err := testRepo.InsertTransfer(context.Background(), tf)
 		if err != nil {
 			t.Errorf("failed insert transfer error:%s", err)
 		}
+		ids = append(ids, id)
 	}
 
-	listTf, err := testRepo.GetListTransfers(context.Background(), acc1.ID, acc2.ID, 10, 0)
+	listTf, err := testRepo.GetListTransfers(context.Background(), models.TransferFilter{AccountID: acc1.ID}, 0, 10)
 	if err != nil {
 		t.Errorf("failed to get list transfers error:%s", err)
 	}
@@ -706,6 +806,51 @@ func TestGetListTransfers(t *testing.T) {
 	if len(listTf) < 10 {
 		t.Errorf("fialed len list transefer want %d got %d", 10, len(listTf))
 	}
+
+	// reverse the first transfer
+	_, err = testRepo.InsertTransfer(context.Background(), models.Transfer{
+		FromAccountID: acc2.ID,
+		ToAccountID:   acc1.ID,
+		Amount:        1,
+		ReversalOf:    ids[0],
+	})
+	if err != nil {
+		t.Errorf("failed insert reversal error:%s", err)
+	}
+
+	statusCount := map[string]int{
+		models.TransferStatusPosted:   9,
+		models.TransferStatusReversed: 1,
+		models.TransferStatusReversal: 1,
+		"":                            11,
+	}
+	for status, want := range statusCount {
+		filter := models.TransferFilter{AccountID: acc2.ID, CounterpartyAccountID: acc1.ID, Status: status}
+		byStatus, err := testRepo.GetListTransfers(context.Background(), filter, 0, 20)
+		if err != nil {
+			t.Errorf("failed to get %q transfers error:%s", status, err)
+		}
+		if len(byStatus) != want {
+			t.Errorf("failed %q transfers want %d got %d", status, want, len(byStatus))
+		}
+	}
+
+	page, err := testRepo.GetListTransfers(context.Background(), models.TransferFilter{AccountID: acc1.ID}, ids[4], 20)
+	if err != nil {
+		t.Errorf("failed to get next page of transfers error:%s", err)
+	}
+	if len(page) != 6 || page[0].ID != ids[5] {
+		t.Errorf("failed next page of transfers want 6 got %d", len(page))
+	}
+
+	// unknown ids are ignored
+	byIDs, err := testRepo.GetListTransfersByIDs(context.Background(), append(ids[:3], -1))
+	if err != nil {
+		t.Errorf("failed to get list transfers by ids error:%s", err)
+	}
+	if len(byIDs) != 3 || byIDs[0].ID != ids[0] {
+		t.Errorf("failed list transfers by ids want 3 got %d", len(byIDs))
+	}
 }
 
 func TestInsertUsers(t *testing.T) {
@@ -932,3 +1077,88 @@ func TestGetListUsers(t *testing.T) {
 		t.Errorf("failed len data not 10 len:%d", len(listData))
 	}
 }
+
+func TestAccountBalanceSnapshots(t *testing.T) {
+	user := models.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	err := testRepo.InsertUsers(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed insert users error:%s", err)
+	}
+	acc := createRandomAccount(user.Username)
+	acc.ID, err = testRepo.InsertAccount(context.Background(), acc)
+	if err != nil {
+		t.Fatalf("failed insert account error:%s", err)
+	}
+
+	day1 := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	entries := []struct {
+		amount    int64
+		createdAt time.Time
+	}{
+		{amount: 100, createdAt: day1.Add(9 * time.Hour)},
+		{amount: -30, createdAt: day1.Add(23 * time.Hour)},
+		{amount: 50, createdAt: day2.Add(10 * time.Hour)},
+		{amount: -5, createdAt: day2.Add(30 * time.Hour)},
+	}
+	for _, e := range entries {
+		_, err = testDB.Exec(`INSERT INTO "entries" ("account_id", "amount", "created_at") VALUES ($1, $2, $3)`, acc.ID, e.amount, e.createdAt)
+		if err != nil {
+			t.Fatalf("failed insert entry error:%s", err)
+		}
+	}
+
+	// snapshot of each day, the second one build on the first
+	for _, day := range []time.Time{day1, day2} {
+		lastID, inserted, err := testRepo.InsertAccountBalanceSnapshots(context.Background(), day, day.AddDate(0, 0, 1), acc.ID-1, 1)
+		if err != nil {
+			t.Fatalf("failed insert snapshots error:%s", err)
+		}
+		if lastID != acc.ID || inserted != 1 {
+			t.Fatalf("failed insert snapshots want last id %d inserted 1 got %d %d", acc.ID, lastID, inserted)
+		}
+	}
+
+	// snapshot of the same day again is skipped
+	_, inserted, err := testRepo.InsertAccountBalanceSnapshots(context.Background(), day2, day2.AddDate(0, 0, 1), acc.ID-1, 1)
+	if err != nil {
+		t.Fatalf("failed insert snapshots error:%s", err)
+	}
+	if inserted != 0 {
+		t.Errorf("failed insert snapshots want 0 inserted got %d", inserted)
+	}
+
+	snapshots, err := testRepo.GetListAccountBalanceSnapshots(context.Background(), acc.ID, 5, 0)
+	if err != nil {
+		t.Fatalf("failed get snapshots error:%s", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Balance != 120 || snapshots[1].Balance != 70 {
+		t.Fatalf("failed snapshots want balances 120 and 70 got %+v", snapshots)
+	}
+
+	testCases := []struct {
+		at   time.Time
+		want int64
+	}{
+		{at: day1, want: 0},
+		{at: day1.Add(12 * time.Hour), want: 100},
+		{at: day2, want: 70},
+		{at: day2.Add(12 * time.Hour), want: 120},
+		{at: day2.AddDate(0, 0, 1), want: 120},
+		{at: day2.AddDate(0, 0, 2), want: 115},
+	}
+	for _, tc := range testCases {
+		got, err := testRepo.GetAccountBalanceAt(context.Background(), acc.ID, tc.at)
+		if err != nil {
+			t.Fatalf("failed get balance at error:%s", err)
+		}
+		if got != tc.want {
+			t.Errorf("failed balance at %s want %d got %d", tc.at, tc.want, got)
+		}
+	}
+}
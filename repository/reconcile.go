@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+// maxReconciliationFindings is the number of findings kept in a report, a broken migration could
+// otherwise produce one finding for every transfer
+const maxReconciliationFindings = 1000
+
+// ReconcileLedger check the whole ledger chunk by chunk of accounts and save the report. The balance of every
+// account must be the sum of its entries and every transfer must have posted a balanced pair of entries,
+// plus a second pair when it charged a fee. The report is saved even when findings are found,
+// the returned error is only for failing to read or save.
+func (s *SQLStore) ReconcileLedger(ctx context.Context, chunkSize int) (models.ReconciliationReport, error) {
+	report := models.ReconciliationReport{
+		StartedAt: time.Now(),
+		Findings:  []*models.ReconciliationFinding{},
+	}
+	addFinding := func(f *models.ReconciliationFinding) {
+		report.FindingsCount++
+		if len(report.Findings) < maxReconciliationFindings {
+			report.Findings = append(report.Findings, f)
+		}
+	}
+
+	var afterID int64
+	for {
+		accounts, err := s.GetListAccountLedgers(ctx, afterID, chunkSize)
+		if err != nil {
+			return report, fmt.Errorf("failed to get account ledgers after %d: %w", afterID, err)
+		}
+		if len(accounts) == 0 {
+			break
+		}
+		for _, a := range accounts {
+			if f := checkAccountLedger(a); f != nil {
+				addFinding(f)
+			}
+		}
+
+		// transfers are checked with the chunk of their from account so each one is checked once
+		untilID := accounts[len(accounts)-1].AccountID
+		transfers, err := s.GetListTransferLedgers(ctx, afterID, untilID)
+		if err != nil {
+			return report, fmt.Errorf("failed to get transfer ledgers of accounts (%d, %d]: %w", afterID, untilID, err)
+		}
+		for _, t := range transfers {
+			if f := checkTransferLedger(t); f != nil {
+				addFinding(f)
+			}
+		}
+
+		report.AccountsChecked += int64(len(accounts))
+		report.TransfersChecked += int64(len(transfers))
+		afterID = untilID
+		if len(accounts) < chunkSize {
+			break
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	var err error
+	report.ID, err = s.InsertReconciliationReport(ctx, report)
+	if err != nil {
+		return report, fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+
+	return report, nil
+}
+
+func checkAccountLedger(a *models.AccountLedger) *models.ReconciliationFinding {
+	if a.Balance == a.EntrySum {
+		return nil
+	}
+	return &models.ReconciliationFinding{
+		Kind:      models.FindingBalanceMismatch,
+		AccountID: a.AccountID,
+		Detail:    fmt.Sprintf("balance %d, entries sum to %d", a.Balance, a.EntrySum),
+	}
+}
+
+func checkTransferLedger(t *models.TransferLedger) *models.ReconciliationFinding {
+	wantCount := int64(2)
+	if t.Fee > 0 {
+		wantCount = 4
+	}
+
+	var detail string
+	switch {
+	case t.EntryCount != wantCount:
+		detail = fmt.Sprintf("%d entries, want %d", t.EntryCount, wantCount)
+	case t.FromEntrySum != -(t.Amount + t.Fee):
+		detail = fmt.Sprintf("from account entries sum to %d, want %d", t.FromEntrySum, -(t.Amount + t.Fee))
+	case t.ToEntrySum != t.ToAmount:
+		detail = fmt.Sprintf("to account entries sum to %d, want %d", t.ToEntrySum, t.ToAmount)
+	case t.OtherEntrySum != t.Fee:
+		detail = fmt.Sprintf("fee revenue entries sum to %d, want %d", t.OtherEntrySum, t.Fee)
+	default:
+		return nil
+	}
+
+	return &models.ReconciliationFinding{
+		Kind:       models.FindingTransferEntries,
+		AccountID:  t.FromAccountID,
+		TransferID: t.ID,
+		Detail:     detail,
+	}
+}
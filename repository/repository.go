@@ -5,20 +5,23 @@ import (
 	"database/sql"
 	"github.com/google/uuid"
 	"github.com/ismail118/simple-bank/models"
+	"time"
 )
 
 type Repository interface {
 	InsertAccount(ctx context.Context, arg models.Account) (int64, error)
 	GetAccountByID(ctx context.Context, id int64) (models.Account, error)
-	GetListAccounts(ctx context.Context, owner string, limit, offset int) ([]*models.Account, error)
+	GetListAccounts(ctx context.Context, owner string, afterID int64, limit int) ([]*models.Account, error)
 	UpdateAccount(ctx context.Context, arg models.Account) error
+	UpdateAccountOverdraftLimit(ctx context.Context, id, limit int64) error
+	UpdateAccountStatus(ctx context.Context, id int64, status string) error
 	DeleteAccount(ctx context.Context, id int64) error
 	InsertEntry(ctx context.Context, arg models.Entry) (int64, error)
 	GetEntryByID(ctx context.Context, id int64) (models.Entry, error)
-	GetListEntries(ctx context.Context, accountID int64, limit, offset int) ([]*models.Entry, error)
+	GetListEntries(ctx context.Context, arg models.EntryFilter, afterID int64, limit int) ([]*models.Entry, error)
 	InsertTransfer(ctx context.Context, arg models.Transfer) (int64, error)
 	GetTransferByID(ctx context.Context, id int64) (models.Transfer, error)
-	GetListTransfers(ctx context.Context, fromAccountID, toAccountID int64, limit, offset int) ([]*models.Transfer, error)
+	GetListTransfers(ctx context.Context, arg models.TransferFilter, afterID int64, limit int) ([]*models.Transfer, error)
 	GetAccountByIdForUpdate(ctx context.Context, id int64) (models.Account, error)
 	AddAccountBalanceByID(ctx context.Context, amount, id int64) (models.Account, error)
 	InsertUsers(ctx context.Context, arg models.Users) error
@@ -33,6 +36,117 @@ type Repository interface {
 	InsertVerifyEmail(ctx context.Context, arg models.VerifyEmail) (int64, error)
 	GetVerifyEmailByID(ctx context.Context, id int64) (models.VerifyEmail, error)
 	UpdateVerifyEmailIsUsed(ctx context.Context, id int64, secretCode string) error
+	InsertIdempotencyKey(ctx context.Context, arg models.IdempotencyKey) error
+	GetIdempotencyKeyForUpdate(ctx context.Context, username, key string) (models.IdempotencyKey, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, username, key string, response []byte) error
+	AddAccountHeldBalanceByID(ctx context.Context, amount, id int64) (models.Account, error)
+	InsertHold(ctx context.Context, arg models.Hold) (int64, error)
+	GetHoldByID(ctx context.Context, id int64) (models.Hold, error)
+	GetHoldByIDForUpdate(ctx context.Context, id int64) (models.Hold, error)
+	UpdateHoldStatus(ctx context.Context, id int64, status string, transferID int64) error
+	GetListExpiredHolds(ctx context.Context, limit int) ([]*models.Hold, error)
+	GetTransferByIDForUpdate(ctx context.Context, id int64) (models.Transfer, error)
+	GetListReversals(ctx context.Context, transferID int64) ([]*models.Transfer, error)
+	InsertAccountStatusHistory(ctx context.Context, arg models.AccountStatusHistory) (int64, error)
+	GetListAccountStatusHistory(ctx context.Context, accountID int64) ([]*models.AccountStatusHistory, error)
+	GetCurrency(ctx context.Context, code string) (models.Currency, error)
+	GetListCurrencies(ctx context.Context) ([]*models.Currency, error)
+	InsertFxRate(ctx context.Context, arg models.FxRate) (int64, error)
+	GetFxRateAt(ctx context.Context, base, quote string, at time.Time) (models.FxRate, error)
+	InsertScheduledTransfer(ctx context.Context, arg models.ScheduledTransfer) (int64, error)
+	GetScheduledTransferByID(ctx context.Context, id int64) (models.ScheduledTransfer, error)
+	GetScheduledTransferByIDForUpdate(ctx context.Context, id int64) (models.ScheduledTransfer, error)
+	GetListScheduledTransfers(ctx context.Context, owner string, limit, offset int) ([]*models.ScheduledTransfer, error)
+	GetListDueScheduledTransfers(ctx context.Context, now time.Time, limit int) ([]*models.ScheduledTransfer, error)
+	UpdateScheduledTransfer(ctx context.Context, arg models.ScheduledTransfer) error
+	InsertScheduledTransferExecution(ctx context.Context, arg models.ScheduledTransferExecution) (int64, error)
+	GetListScheduledTransferExecutions(ctx context.Context, scheduledTransferID int64, limit, offset int) ([]*models.ScheduledTransferExecution, error)
+	InsertTransferBatch(ctx context.Context, arg models.TransferBatch) (int64, error)
+	GetTransferBatchByID(ctx context.Context, id int64) (models.TransferBatch, error)
+	UpdateTransferBatchStatus(ctx context.Context, id int64, status string) error
+	InsertTransferBatchItem(ctx context.Context, arg models.TransferBatchItem) (int64, error)
+	GetTransferBatchItemByIDForUpdate(ctx context.Context, id int64) (models.TransferBatchItem, error)
+	UpdateTransferBatchItemResult(ctx context.Context, id int64, status string, transferID int64, errMessage string) error
+	GetListTransferBatchItems(ctx context.Context, batchID int64) ([]*models.TransferBatchItem, error)
+	InsertAccountProduct(ctx context.Context, arg models.AccountProduct) (int64, error)
+	GetAccountProduct(ctx context.Context, id int64) (models.AccountProduct, error)
+	GetListAccountProducts(ctx context.Context) ([]*models.AccountProduct, error)
+	GetListInterestBearingAccounts(ctx context.Context, afterID int64, limit int) ([]*models.Account, error)
+	InsertHouseAccount(ctx context.Context, arg models.HouseAccount) error
+	GetHouseAccount(ctx context.Context, purpose, currency string) (models.HouseAccount, error)
+	InsertInterestAccrual(ctx context.Context, arg models.InterestAccrual) (int64, error)
+	GetListUnpostedInterestAccruals(ctx context.Context, accountID int64, until time.Time) ([]*models.InterestAccrual, error)
+	UpdateInterestAccrualsPosting(ctx context.Context, ids []int64, postingID int64) error
+	InsertInterestPosting(ctx context.Context, arg models.InterestPosting) (int64, error)
+	GetLastInterestPosting(ctx context.Context, accountID int64) (models.InterestPosting, error)
+	GetListInterestPostings(ctx context.Context, accountID int64, limit, offset int) ([]*models.InterestPosting, error)
+	InsertFeeSchedule(ctx context.Context, arg models.FeeSchedule) (int64, error)
+	GetFeeSchedule(ctx context.Context, currency string, productID int64) (models.FeeSchedule, error)
+	GetListFeeSchedules(ctx context.Context, currency string) ([]*models.FeeSchedule, error)
+	GetListAccountLedgers(ctx context.Context, afterID int64, limit int) ([]*models.AccountLedger, error)
+	GetListTransferLedgers(ctx context.Context, fromAccountIDAfter, fromAccountIDUntil int64) ([]*models.TransferLedger, error)
+	InsertReconciliationReport(ctx context.Context, arg models.ReconciliationReport) (int64, error)
+	GetReconciliationReportByID(ctx context.Context, id int64) (models.ReconciliationReport, error)
+	GetListReconciliationReports(ctx context.Context, limit, offset int) ([]*models.ReconciliationReport, error)
+	InsertAccountBalanceSnapshots(ctx context.Context, snapshotDate, asOf time.Time, afterID int64, limit int) (int64, int64, error)
+	GetListAccountBalanceSnapshots(ctx context.Context, accountID int64, limit, offset int) ([]*models.AccountBalanceSnapshot, error)
+	GetAccountBalanceAt(ctx context.Context, accountID int64, at time.Time) (int64, error)
+	GetListEntriesOfAccountsAfter(ctx context.Context, accountIDs []int64, afterID int64, limit int) ([]*models.Entry, error)
+	GetListTransfersByIDs(ctx context.Context, ids []int64) ([]*models.Transfer, error)
+	InsertExternalPayout(ctx context.Context, arg models.ExternalPayout) (int64, error)
+	GetExternalPayoutByID(ctx context.Context, id int64) (models.ExternalPayout, error)
+	GetExternalPayoutByTraceNumberForUpdate(ctx context.Context, traceNumber string) (models.ExternalPayout, error)
+	GetListExternalPayouts(ctx context.Context, owner string, limit, offset int) ([]*models.ExternalPayout, error)
+	GetListQueuedExternalPayoutsForUpdate(ctx context.Context, currency string, limit int) ([]*models.ExternalPayout, error)
+	UpdateExternalPayoutSubmitted(ctx context.Context, id, achFileID int64, traceNumber string) error
+	UpdateExternalPayoutReturned(ctx context.Context, id int64, returnCode string, returnTransferID int64) error
+	UpdateExternalPayoutReturnException(ctx context.Context, id int64, returnCode string) error
+	InsertACHFile(ctx context.Context, arg models.ACHFile) (int64, error)
+	CountACHFilesCreatedSince(ctx context.Context, since time.Time) (int, error)
+	GetListUnwrittenACHFiles(ctx context.Context, limit int) ([]*models.ACHFile, error)
+	UpdateACHFileWritten(ctx context.Context, id int64, writtenAt time.Time) error
+	InsertPayee(ctx context.Context, arg models.Payee) (int64, error)
+	GetPayeeByID(ctx context.Context, id int64) (models.Payee, error)
+	GetPayeeByOwnerAndAccount(ctx context.Context, owner string, accountID int64) (models.Payee, error)
+	GetListPayees(ctx context.Context, owner string, limit, offset int) ([]*models.Payee, error)
+	GetPayeeSentAmount(ctx context.Context, owner, currency string, accountID int64, since time.Time) (int64, error)
+	DeletePayee(ctx context.Context, id int64) error
+	InsertTransferLimit(ctx context.Context, arg models.TransferLimit) (int64, error)
+	GetTransferLimitByID(ctx context.Context, id int64) (models.TransferLimit, error)
+	GetTransferLimitForUpdate(ctx context.Context, owner, currency string, accountID int64) (models.TransferLimit, error)
+	GetTransferLimitsOfAccount(ctx context.Context, owner, currency string, accountID int64) ([]*models.TransferLimit, error)
+	GetListTransferLimits(ctx context.Context, owner string, limit, offset int) ([]*models.TransferLimit, error)
+	UpdateTransferLimit(ctx context.Context, arg models.TransferLimit) error
+	DeleteTransferLimit(ctx context.Context, id int64) error
+	GetTransferLimitUsage(ctx context.Context, owner, currency string, accountID int64, dailySince, monthlySince time.Time) (models.TransferLimitUsage, error)
+	GetUsersByUsernameForUpdate(ctx context.Context, username string) (models.Users, error)
+	InsertTransferReview(ctx context.Context, arg models.TransferReview) (int64, error)
+	GetTransferReviewByID(ctx context.Context, id int64) (models.TransferReview, error)
+	GetTransferReviewByIDForUpdate(ctx context.Context, id int64) (models.TransferReview, error)
+	GetTransferReviewByIdempotencyKey(ctx context.Context, owner, key string) (models.TransferReview, error)
+	GetListTransferReviews(ctx context.Context, status string, afterID int64, limit int) ([]*models.TransferReview, error)
+	UpdateTransferReviewStatus(ctx context.Context, id int64, status, reviewedBy string, transferID int64) error
+	GetIdempotencyKey(ctx context.Context, username, key string) (models.IdempotencyKey, error)
+	CountTransfersByOwnerSince(ctx context.Context, owner string, since time.Time) (int, error)
+	CountSessionsFromDevice(ctx context.Context, username, userAgent, clientIP string, before time.Time) (int, error)
+	GetTransferAmountStats(ctx context.Context, accountID int64, since time.Time) (models.TransferAmountStats, error)
+	CountTransfersFromOwnerToAccount(ctx context.Context, owner string, toAccountID int64) (int, error)
+	InsertWebhookEndpoint(ctx context.Context, arg models.WebhookEndpoint) (int64, error)
+	GetWebhookEndpointByID(ctx context.Context, id int64) (models.WebhookEndpoint, error)
+	GetListWebhookEndpoints(ctx context.Context, owner string, limit, offset int) ([]*models.WebhookEndpoint, error)
+	GetWebhookEndpointsForEvent(ctx context.Context, owner, eventType string) ([]*models.WebhookEndpoint, error)
+	DeleteWebhookEndpoint(ctx context.Context, id int64) error
+	InsertWebhookEvent(ctx context.Context, arg models.WebhookEvent) (int64, error)
+	GetWebhookEventByID(ctx context.Context, id int64) (models.WebhookEvent, error)
+	InsertWebhookDelivery(ctx context.Context, arg models.WebhookDelivery) (int64, error)
+	GetWebhookDeliveryByID(ctx context.Context, id int64) (models.WebhookDelivery, error)
+	GetListWebhookDeliveries(ctx context.Context, endpointID int64, status string, beforeID int64, limit int) ([]*models.WebhookDelivery, error)
+	GetListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg models.WebhookDelivery) error
+	ReplayWebhookDelivery(ctx context.Context, id int64) (models.WebhookDelivery, error)
+	InsertOutboxMessage(ctx context.Context, arg models.OutboxMessage) (int64, error)
+	GetListUnrelayedOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error)
+	UpdateOutboxMessageRelayed(ctx context.Context, id int64, relayedAt time.Time) error
 }
 
 type DBTX interface {
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"github.com/ismail118/simple-bank/util"
 	_ "github.com/lib/pq"
@@ -16,6 +17,7 @@ const (
 
 var testRepo Repository
 var testStore Store
+var testDB *sql.DB
 
 func TestMain(m *testing.M) {
 	conf, err := util.LoadConfig("../.")
@@ -27,11 +29,24 @@ func TestMain(m *testing.M) {
 		log.Fatal().Err(err)
 	}
 
+	testDB = conn
+
 	repo := NewPostgresRepo(conn)
 	testRepo = repo
 
 	store := NewStore(conn)
 	testStore = store
 
+	// pick random currencies among the currencies seeded by the migration
+	currencies, err := repo.GetListCurrencies(context.Background())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to get currencies")
+	}
+	codes := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		codes = append(codes, c.Code)
+	}
+	util.SetRandomCurrencies(codes)
+
 	os.Exit(m.Run())
 }
@@ -3,32 +3,110 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/ismail118/simple-bank/models"
+	"github.com/lib/pq"
+	"math/rand"
+	"strings"
+	"time"
 )
 
 type Store interface {
 	Repository
 	execTx(ctx context.Context, fn func(Repository) error) error
 	TransferTx(ctx context.Context, arg models.Transfer) (TransferTxResult, error)
-	CreateUserTx(ctx context.Context, arg models.Users, afterCreate func(user models.Users) error) (CreateUserTxResult, error)
+	CrossCurrencyTransferTx(ctx context.Context, arg models.Transfer) (TransferTxResult, error)
+	IdempotentTransferTx(ctx context.Context, key models.IdempotencyKey, arg models.Transfer) (IdempotentTransferTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg models.Hold) (HoldTxResult, error)
+	CaptureHoldTx(ctx context.Context, id int64, amount int64) (CaptureHoldTxResult, error)
+	VoidHoldTx(ctx context.Context, id int64) (HoldTxResult, error)
+	ExpireHoldTx(ctx context.Context, id int64) (HoldTxResult, error)
+	ReverseTransferTx(ctx context.Context, transferID int64, amount int64) (ReverseTransferTxResult, error)
+	ChangeAccountStatusTx(ctx context.Context, arg models.AccountStatusHistory) (ChangeAccountStatusTxResult, error)
+	ExecuteScheduledTransferTx(ctx context.Context, id int64, scheduledAt time.Time, rules PayeeRules, newFailedTask func(execution models.ScheduledTransferExecution) (models.OutboxMessage, error)) (ExecuteScheduledTransferTxResult, error)
+	AtomicTransferBatchTx(ctx context.Context, batch models.TransferBatch, items []models.TransferBatchItem) (TransferBatchTxResult, error)
+	CreateTransferBatchTx(ctx context.Context, batch models.TransferBatch, items []models.TransferBatchItem, newTask func(batch models.TransferBatch) (models.OutboxMessage, error)) (TransferBatchTxResult, error)
+	ExecuteTransferBatchItemTx(ctx context.Context, itemID int64) (ExecuteTransferBatchItemTxResult, error)
+	AccrueInterestTx(ctx context.Context, accountID int64, date time.Time) (models.InterestAccrual, error)
+	PostInterestTx(ctx context.Context, accountID int64, periodEnd time.Time) (PostInterestTxResult, error)
+	CreateUserTx(ctx context.Context, arg models.Users, newTask func(user models.Users) (models.OutboxMessage, error)) (CreateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, id int64, secretCode string) (VerifyEmailTxResult, error)
+	ReconcileLedger(ctx context.Context, chunkSize int) (models.ReconciliationReport, error)
+	CreateExternalPayoutTx(ctx context.Context, arg models.ExternalPayout) (ExternalPayoutTxResult, error)
+	CreateACHFileTx(ctx context.Context, currency string, limit int, render func(file *models.ACHFile, sameDayFiles int, payouts []*models.ExternalPayout) error) (CreateACHFileTxResult, error)
+	ReturnExternalPayoutTx(ctx context.Context, traceNumber, returnCode string) (ReturnExternalPayoutTxResult, error)
+	CreatePayeeTx(ctx context.Context, arg models.Payee, newTask func(payee models.Payee) (models.OutboxMessage, error)) (CreatePayeeTxResult, error)
+	SetTransferLimitTx(ctx context.Context, arg models.TransferLimit) (models.TransferLimit, error)
+	ApproveTransferReviewTx(ctx context.Context, id int64, reviewedBy string, rules PayeeRules) (TransferReviewTxResult, error)
+	RejectTransferReviewTx(ctx context.Context, id int64, reviewedBy string) (TransferReviewTxResult, error)
 }
 
 type SQLStore struct {
 	Repository
-	db *sql.DB
+	db        *sql.DB
+	txOptions TxOptions
+}
+
+// TxOptions configure how the store run its transactions
+type TxOptions struct {
+	// isolation level of every transaction, sql.LevelDefault use the database default
+	Isolation sql.IsolationLevel
+	// how many times a transaction is run when it keep failing with a serialization failure or a deadlock
+	MaxAttempts int
+	// delay before the first retry, it is doubled on every next retry
+	RetryBackoff time.Duration
+}
+
+var DefaultTxOptions = TxOptions{
+	Isolation:    sql.LevelReadCommitted,
+	MaxAttempts:  3,
+	RetryBackoff: 20 * time.Millisecond,
 }
 
 func NewStore(db *sql.DB) Store {
+	return NewStoreWithTxOptions(db, DefaultTxOptions)
+}
+
+func NewStoreWithTxOptions(db *sql.DB, opts TxOptions) Store {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
 	return &SQLStore{
 		db:         db,
 		Repository: NewPostgresRepo(db),
+		txOptions:  opts,
 	}
 }
 
+// execTx run fn in a transaction with a tx scoped repository, every read and write of fn must go through it.
+// When the transaction fail with a serialization failure or a deadlock it is run again from the start
+// after a backoff, so fn must not have side effects outside the transaction and must reset the state
+// it share with the caller.
 func (s *SQLStore) execTx(ctx context.Context, fn func(Repository) error) error {
-	tx, err := s.db.BeginTx(ctx, nil)
+	backoff := s.txOptions.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.runTx(ctx, fn)
+		if err == nil || !isRetryableTxError(err) || attempt >= s.txOptions.MaxAttempts {
+			return err
+		}
+
+		// jitter keep the conflicting transactions from retrying at the same time again
+		delay := backoff
+		if backoff > 0 {
+			delay += time.Duration(rand.Int63n(int64(backoff)))
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *SQLStore) runTx(ctx context.Context, fn func(Repository) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: s.txOptions.Isolation})
 	if err != nil {
 		return err
 	}
@@ -38,9 +116,41 @@ func (s *SQLStore) execTx(ctx context.Context, fn func(Repository) error) error
 	if err != nil {
 		rbErr := tx.Rollback()
 		if rbErr != nil {
-			return fmt.Errorf("tx error:%s rollback error: %s", err, rbErr)
+			return fmt.Errorf("tx error:%w rollback error: %s", err, rbErr)
 		}
+		return err
 	}
 
 	return tx.Commit()
 }
+
+const (
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+)
+
+// isRetryableTxError report whether the transaction failed only because of a concurrent transaction,
+// running it again may succeed
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == pqSerializationFailure || pqErr.Code == pqDeadlockDetected
+}
+
+// ParseIsolationLevel parse the isolation level name used in the config, e.g. "repeatable read",
+// an empty name is the database default
+func ParseIsolationLevel(name string) (sql.IsolationLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "":
+		return sql.LevelDefault, nil
+	case "read committed":
+		return sql.LevelReadCommitted, nil
+	case "repeatable read":
+		return sql.LevelRepeatableRead, nil
+	case "serializable":
+		return sql.LevelSerializable, nil
+	}
+	return sql.LevelDefault, fmt.Errorf("unsupported isolation level %q", name)
+}
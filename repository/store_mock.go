@@ -26,16 +26,165 @@ func (s *SQLStoreMock) execTx(ctx context.Context, fn func(Repository) error) er
 
 func (s *SQLStoreMock) TransferTx(ctx context.Context, arg models.Transfer) (TransferTxResult, error) {
 	var result TransferTxResult
+	if arg.Amount > 100 {
+		return result, ErrInsufficientFunds
+	}
+	return result, nil
+}
+
+func (s *SQLStoreMock) CrossCurrencyTransferTx(ctx context.Context, arg models.Transfer) (TransferTxResult, error) {
+	var result TransferTxResult
+	if arg.Amount > 100 {
+		return result, ErrInsufficientFunds
+	}
+	result.Transfer = arg
+	return result, nil
+}
+
+func (s *SQLStoreMock) IdempotentTransferTx(ctx context.Context, key models.IdempotencyKey, arg models.Transfer) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+	if key.IdempotencyKey == "reused-key" {
+		return result, ErrIdempotencyKeyReused
+	}
+	if key.IdempotencyKey == "replayed-key" {
+		result.Replayed = true
+	}
+	if arg.Amount > 100 {
+		return result, ErrInsufficientFunds
+	}
+	result.Transfer = arg
+	return result, nil
+}
+
+func (s *SQLStoreMock) AuthorizeTransferTx(ctx context.Context, arg models.Hold) (HoldTxResult, error) {
+	var result HoldTxResult
+	if arg.Amount > 100 {
+		return result, ErrInsufficientFunds
+	}
+	result.Hold = arg
+	return result, nil
+}
+
+func (s *SQLStoreMock) CaptureHoldTx(ctx context.Context, id int64, amount int64) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+	if amount > 10 {
+		return result, ErrCaptureExceedsHold
+	}
+	return result, nil
+}
+
+func (s *SQLStoreMock) VoidHoldTx(ctx context.Context, id int64) (HoldTxResult, error) {
+	var result HoldTxResult
+	return result, nil
+}
+
+func (s *SQLStoreMock) ExpireHoldTx(ctx context.Context, id int64) (HoldTxResult, error) {
+	var result HoldTxResult
+	return result, nil
+}
+
+func (s *SQLStoreMock) ReverseTransferTx(ctx context.Context, transferID int64, amount int64) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+	if amount > 5 {
+		return result, ErrReversalExceedsAmount
+	}
+	return result, nil
+}
+
+func (s *SQLStoreMock) ChangeAccountStatusTx(ctx context.Context, arg models.AccountStatusHistory) (ChangeAccountStatusTxResult, error) {
+	var result ChangeAccountStatusTxResult
+	if arg.AccountID == 3 {
+		return result, sql.ErrConnDone
+	}
+	if arg.AccountID == 1 {
+		return result, ErrAccountNotFound
+	}
+	// account 5 is frozen by the bank
+	if arg.AccountID == 5 && arg.ToStatus == models.AccountStatusActive {
+		result.Account.ID = arg.AccountID
+		result.Account.Status = arg.ToStatus
+		result.History = arg
+		return result, nil
+	}
+	// mock accounts are active already
+	if arg.ToStatus == models.AccountStatusActive {
+		return result, ErrInvalidStatusChange
+	}
+	result.Account.ID = arg.AccountID
+	result.Account.Status = arg.ToStatus
+	result.History = arg
+	return result, nil
+}
+
+func (s *SQLStoreMock) ExecuteScheduledTransferTx(ctx context.Context, id int64, scheduledAt time.Time, rules PayeeRules, newFailedTask func(execution models.ScheduledTransferExecution) (models.OutboxMessage, error)) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+	if id != 2 {
+		return result, ErrScheduledTransferNotFound
+	}
+	result.Execution = models.ScheduledTransferExecution{
+		ScheduledTransferID: id,
+		ScheduledAt:         scheduledAt,
+		Status:              models.ExecutionStatusSucceeded,
+	}
+	return result, nil
+}
+
+func (s *SQLStoreMock) AtomicTransferBatchTx(ctx context.Context, batch models.TransferBatch, items []models.TransferBatchItem) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+	batch.Mode = models.BatchModeAtomic
+	batch.Status = models.BatchStatusCompleted
+	for i := range items {
+		items[i].Status = models.BatchItemStatusSucceeded
+		if items[i].Amount > 100 {
+			batch.Status = models.BatchStatusFailed
+			items[i].Status = models.BatchItemStatusFailed
+			items[i].Error = ErrInsufficientFunds.Error()
+		}
+		result.Items = append(result.Items, &items[i])
+	}
+	result.Batch = batch
+	return result, nil
+}
+
+func (s *SQLStoreMock) CreateTransferBatchTx(ctx context.Context, batch models.TransferBatch, items []models.TransferBatchItem, newTask func(batch models.TransferBatch) (models.OutboxMessage, error)) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+	batch.Mode = models.BatchModeBestEffort
+	batch.Status = models.BatchStatusPending
+	_, err := newTask(batch)
+	if err != nil {
+		return result, err
+	}
+	for i := range items {
+		items[i].Status = models.BatchItemStatusPending
+		result.Items = append(result.Items, &items[i])
+	}
+	result.Batch = batch
 	return result, nil
 }
 
-func (s *SQLStoreMock) CreateUserTx(ctx context.Context, arg models.Users, afterCreate func(user models.Users) error) (CreateUserTxResult, error) {
+func (s *SQLStoreMock) ExecuteTransferBatchItemTx(ctx context.Context, itemID int64) (ExecuteTransferBatchItemTxResult, error) {
+	var result ExecuteTransferBatchItemTxResult
+	return result, nil
+}
+
+func (s *SQLStoreMock) AccrueInterestTx(ctx context.Context, accountID int64, date time.Time) (models.InterestAccrual, error) {
+	var result models.InterestAccrual
+	return result, nil
+}
+
+func (s *SQLStoreMock) PostInterestTx(ctx context.Context, accountID int64, periodEnd time.Time) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+	return result, nil
+}
+
+func (s *SQLStoreMock) CreateUserTx(ctx context.Context, arg models.Users, newTask func(user models.Users) (models.OutboxMessage, error)) (CreateUserTxResult, error) {
 	var result CreateUserTxResult
 
-	err := afterCreate(arg)
+	_, err := newTask(arg)
 	if err != nil {
 		return result, err
 	}
+	result.User = arg
 	return result, nil
 }
 
@@ -65,3 +214,88 @@ func (s *SQLStoreMock) VerifyEmailTx(ctx context.Context, id int64, secretCode s
 	}
 	return result, nil
 }
+
+func (s *SQLStoreMock) ReconcileLedger(ctx context.Context, chunkSize int) (models.ReconciliationReport, error) {
+	report := models.ReconciliationReport{
+		ID:         1,
+		StartedAt:  time.Now(),
+		FinishedAt: time.Now(),
+		Findings:   []*models.ReconciliationFinding{},
+	}
+	return report, nil
+}
+
+func (s *SQLStoreMock) CreateExternalPayoutTx(ctx context.Context, arg models.ExternalPayout) (ExternalPayoutTxResult, error) {
+	var result ExternalPayoutTxResult
+	if arg.Amount > 100 {
+		return result, ErrInsufficientFunds
+	}
+	arg.Status = models.PayoutStatusQueued
+	result.Payout = arg
+	return result, nil
+}
+
+func (s *SQLStoreMock) CreateACHFileTx(ctx context.Context, currency string, limit int, render func(file *models.ACHFile, sameDayFiles int, payouts []*models.ExternalPayout) error) (CreateACHFileTxResult, error) {
+	var result CreateACHFileTxResult
+	return result, nil
+}
+
+func (s *SQLStoreMock) ReturnExternalPayoutTx(ctx context.Context, traceNumber, returnCode string) (ReturnExternalPayoutTxResult, error) {
+	var result ReturnExternalPayoutTxResult
+	return result, ErrExternalPayoutNotFound
+}
+
+func (s *SQLStoreMock) CreatePayeeTx(ctx context.Context, arg models.Payee, newTask func(payee models.Payee) (models.OutboxMessage, error)) (CreatePayeeTxResult, error) {
+	var result CreatePayeeTxResult
+
+	_, err := newTask(arg)
+	if err != nil {
+		return result, err
+	}
+	result.Payee = arg
+	return result, nil
+}
+
+func (s *SQLStoreMock) SetTransferLimitTx(ctx context.Context, arg models.TransferLimit) (models.TransferLimit, error) {
+	if arg.AccountID > 1000 {
+		return arg, ErrAccountNotFound
+	}
+	if arg.AccountID > 0 {
+		arg.Owner = ""
+		arg.Currency = "USD"
+	}
+	arg.ID = 2
+	return arg, nil
+}
+
+func (s *SQLStoreMock) ApproveTransferReviewTx(ctx context.Context, id int64, reviewedBy string, rules PayeeRules) (TransferReviewTxResult, error) {
+	var result TransferReviewTxResult
+	if id > 1000 {
+		return result, ErrTransferReviewNotFound
+	}
+	if id == 3 {
+		return result, ErrTransferReviewNotPending
+	}
+	result.Review = models.TransferReview{
+		ID:         id,
+		Status:     models.TransferReviewStatusApproved,
+		ReviewedBy: reviewedBy,
+	}
+	return result, nil
+}
+
+func (s *SQLStoreMock) RejectTransferReviewTx(ctx context.Context, id int64, reviewedBy string) (TransferReviewTxResult, error) {
+	var result TransferReviewTxResult
+	if id > 1000 {
+		return result, ErrTransferReviewNotFound
+	}
+	if id == 3 {
+		return result, ErrTransferReviewNotPending
+	}
+	result.Review = models.TransferReview{
+		ID:         id,
+		Status:     models.TransferReviewStatusRejected,
+		ReviewedBy: reviewedBy,
+	}
+	return result, nil
+}
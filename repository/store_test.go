@@ -1,10 +1,17 @@
 package repository
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/ismail118/simple-bank/models"
 	"github.com/ismail118/simple-bank/util"
+	"github.com/lib/pq"
 	"log"
+	"math/rand"
 	"testing"
 	"time"
 )
@@ -26,6 +33,8 @@ func TestTransferTx(t *testing.T) {
 	}
 
 	acc1 := createRandomAccount(user1.Username)
+	// enough balance for every transfer in this test, transfer can't overdraw the account
+	acc1.Balance = util.RandomInt(100, 1000)
 
 	user2 := models.Users{
 		Username:       util.RandomOwner(),
@@ -205,6 +214,8 @@ func TestTransferTxDeadlock(t *testing.T) {
 	}
 
 	acc1 := createRandomAccount(user1.Username)
+	// enough balance for every transfer in this test, transfer can't overdraw the account
+	acc1.Balance = util.RandomInt(100, 1000)
 
 	user2 := models.Users{
 		Username:       util.RandomOwner(),
@@ -222,6 +233,7 @@ func TestTransferTxDeadlock(t *testing.T) {
 	}
 
 	acc2 := createRandomAccount(user2.Username)
+	acc2.Balance = util.RandomInt(100, 1000)
 
 	log.Println(">> before:", acc1.Balance, acc2.Balance)
 
@@ -297,3 +309,1890 @@ func TestTransferTxDeadlock(t *testing.T) {
 	}
 
 }
+
+// createTestAccount insert a random user with one account that has enough balance for small transfers and return the account
+func createTestAccount(t *testing.T) models.Account {
+	user := models.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	err := testRepo.InsertUsers(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed insert users error:%s", err)
+	}
+
+	acc := createRandomAccount(user.Username)
+	acc.Balance = util.RandomInt(100, 1000)
+	acc.ID, err = testRepo.InsertAccount(context.Background(), acc)
+	if err != nil {
+		t.Fatalf("failed insert account error:%s", err)
+	}
+
+	return acc
+}
+
+func TestIdempotentTransferTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+
+	tf := models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	}
+	key := models.IdempotencyKey{
+		Username:       acc1.Owner,
+		IdempotencyKey: util.RandomString(32),
+		RequestHash:    util.RandomString(64),
+	}
+
+	// run n concurrent requests with the same key, only one of them may move the money
+	n := 5
+	chErr := make(chan error)
+	chRes := make(chan IdempotentTransferTxResult)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			res, err := testStore.IdempotentTransferTx(context.Background(), key, tf)
+
+			chErr <- err
+			chRes <- res
+		}()
+	}
+
+	var transferID int64
+	replayed := 0
+	for i := 0; i < n; i++ {
+		err := <-chErr
+		res := <-chRes
+		if err != nil {
+			t.Fatalf("failed idempotent transfer error:%s", err)
+		}
+		if res.Transfer.ID < 1 {
+			t.Fatalf("failed transfer id is 0")
+		}
+		if transferID == 0 {
+			transferID = res.Transfer.ID
+		}
+		if res.Transfer.ID != transferID {
+			t.Fatalf("failed transfer id want %d got %d", transferID, res.Transfer.ID)
+		}
+		if res.Replayed {
+			replayed++
+		}
+	}
+
+	if replayed != n-1 {
+		t.Fatalf("failed replayed responses want %d got %d", n-1, replayed)
+	}
+
+	updatedAcc1, err := testRepo.GetAccountByID(context.Background(), acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get updated account 1 error:%s", err)
+	}
+	if updatedAcc1.Balance != acc1.Balance-tf.Amount {
+		t.Fatalf("failed updated balance account 1 want %d got %d", acc1.Balance-tf.Amount, updatedAcc1.Balance)
+	}
+
+	// same key with different request must be rejected
+	key.RequestHash = util.RandomString(64)
+	_, err = testStore.IdempotentTransferTx(context.Background(), key, tf)
+	if err != ErrIdempotencyKeyReused {
+		t.Fatalf("failed reused key error want %s got %v", ErrIdempotencyKeyReused, err)
+	}
+}
+
+func TestHoldTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+	acc2.Currency = acc1.Currency
+	err := testRepo.UpdateAccount(context.Background(), acc2)
+	if err != nil {
+		t.Fatalf("failed update account 2 error:%s", err)
+	}
+
+	// fund the account so the hold can be authorized
+	acc1, err = testRepo.AddAccountBalanceByID(context.Background(), 100, acc1.ID)
+	if err != nil {
+		t.Fatalf("failed add balance account 1 error:%s", err)
+	}
+
+	amount := int64(30)
+	res, err := testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      amount,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed authorize hold error:%s", err)
+	}
+	if res.Hold.Status != models.HoldStatusAuthorized {
+		t.Fatalf("failed hold status want %s got %s", models.HoldStatusAuthorized, res.Hold.Status)
+	}
+	if res.Account.Balance != acc1.Balance {
+		t.Fatalf("failed ledger balance must not change want %d got %d", acc1.Balance, res.Account.Balance)
+	}
+	if res.Account.AvailableBalance != acc1.AvailableBalance-amount {
+		t.Fatalf("failed available balance want %d got %d", acc1.AvailableBalance-amount, res.Account.AvailableBalance)
+	}
+
+	// the held funds can't be held twice
+	_, err = testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      res.Account.AvailableBalance + 1,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("failed authorize over available balance want %s got %v", ErrInsufficientFunds, err)
+	}
+
+	// partial capture move the captured amount and release the rest
+	captured := int64(20)
+	capRes, err := testStore.CaptureHoldTx(context.Background(), res.Hold.ID, captured)
+	if err != nil {
+		t.Fatalf("failed capture hold error:%s", err)
+	}
+	if capRes.Hold.Status != models.HoldStatusCaptured {
+		t.Fatalf("failed hold status want %s got %s", models.HoldStatusCaptured, capRes.Hold.Status)
+	}
+	if capRes.Hold.TransferID != capRes.Transfer.ID {
+		t.Fatalf("failed hold transfer id want %d got %d", capRes.Transfer.ID, capRes.Hold.TransferID)
+	}
+	if capRes.FromAccount.Balance != acc1.Balance-captured {
+		t.Fatalf("failed balance after capture want %d got %d", acc1.Balance-captured, capRes.FromAccount.Balance)
+	}
+	if capRes.FromAccount.HeldBalance != acc1.HeldBalance {
+		t.Fatalf("failed held balance after capture want %d got %d", acc1.HeldBalance, capRes.FromAccount.HeldBalance)
+	}
+
+	_, err = testStore.VoidHoldTx(context.Background(), res.Hold.ID)
+	if !errors.Is(err, ErrHoldNotAuthorized) {
+		t.Fatalf("failed void captured hold want %s got %v", ErrHoldNotAuthorized, err)
+	}
+
+	// expired hold is released by ExpireHoldTx and can't be captured
+	res, err = testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      amount,
+		ExpiredAt:   time.Now().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("failed authorize hold error:%s", err)
+	}
+
+	_, err = testStore.CaptureHoldTx(context.Background(), res.Hold.ID, 0)
+	if !errors.Is(err, ErrHoldExpired) {
+		t.Fatalf("failed capture expired hold want %s got %v", ErrHoldExpired, err)
+	}
+
+	expRes, err := testStore.ExpireHoldTx(context.Background(), res.Hold.ID)
+	if err != nil {
+		t.Fatalf("failed expire hold error:%s", err)
+	}
+	if expRes.Hold.Status != models.HoldStatusExpired {
+		t.Fatalf("failed hold status want %s got %s", models.HoldStatusExpired, expRes.Hold.Status)
+	}
+	if expRes.Account.HeldBalance != acc1.HeldBalance {
+		t.Fatalf("failed held balance after expire want %d got %d", acc1.HeldBalance, expRes.Account.HeldBalance)
+	}
+}
+
+func TestReverseTransferTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+
+	amount := int64(10)
+	tRes, err := testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        amount,
+	})
+	if err != nil {
+		t.Fatalf("failed transfer error:%s", err)
+	}
+
+	// partial reversal
+	res, err := testStore.ReverseTransferTx(context.Background(), tRes.Transfer.ID, 4)
+	if err != nil {
+		t.Fatalf("failed reverse transfer error:%s", err)
+	}
+	if res.Transfer.ReversalOf != tRes.Transfer.ID {
+		t.Fatalf("failed reversal_of want %d got %d", tRes.Transfer.ID, res.Transfer.ReversalOf)
+	}
+	if res.Transfer.FromAccountID != acc2.ID || res.Transfer.ToAccountID != acc1.ID {
+		t.Fatalf("failed reversal must go from account %d to %d", acc2.ID, acc1.ID)
+	}
+
+	// can't reverse more than the remaining amount
+	_, err = testStore.ReverseTransferTx(context.Background(), tRes.Transfer.ID, amount)
+	if !errors.Is(err, ErrReversalExceedsAmount) {
+		t.Fatalf("failed reverse over amount want %s got %v", ErrReversalExceedsAmount, err)
+	}
+
+	// a reversal can't be reversed
+	_, err = testStore.ReverseTransferTx(context.Background(), res.Transfer.ID, 0)
+	if !errors.Is(err, ErrReverseReversal) {
+		t.Fatalf("failed reverse reversal want %s got %v", ErrReverseReversal, err)
+	}
+
+	// concurrent full reversals of the rest, only one can succeed
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.ReverseTransferTx(context.Background(), tRes.Transfer.ID, 0)
+			errs <- err
+		}()
+	}
+
+	var success int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			success++
+			continue
+		}
+		if !errors.Is(err, ErrReversalExceedsAmount) {
+			t.Fatalf("failed concurrent reversal error:%s", err)
+		}
+	}
+	if success != 1 {
+		t.Fatalf("failed concurrent reversal want 1 success got %d", success)
+	}
+
+	reversals, err := testRepo.GetListReversals(context.Background(), tRes.Transfer.ID)
+	if err != nil {
+		t.Fatalf("failed get list reversals error:%s", err)
+	}
+	var reversed int64
+	for _, rv := range reversals {
+		reversed += rv.Amount
+	}
+	if reversed != amount {
+		t.Fatalf("failed total reversed want %d got %d", amount, reversed)
+	}
+
+	acc1After, err := testRepo.GetAccountByID(context.Background(), acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get account 1 error:%s", err)
+	}
+	if acc1After.Balance != acc1.Balance {
+		t.Fatalf("failed balance after full reversal want %d got %d", acc1.Balance, acc1After.Balance)
+	}
+}
+
+func TestTransferTxOverdraft(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+
+	// spending more than the balance without overdraft limit must fail
+	_, err := testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        acc1.Balance + 1,
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("failed transfer over balance want %s got %v", ErrInsufficientFunds, err)
+	}
+
+	limit := int64(50)
+	err = testRepo.UpdateAccountOverdraftLimit(context.Background(), acc1.ID, limit)
+	if err != nil {
+		t.Fatalf("failed update overdraft limit error:%s", err)
+	}
+
+	// the balance can go below zero up to the overdraft limit
+	res, err := testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        acc1.Balance + limit,
+	})
+	if err != nil {
+		t.Fatalf("failed transfer within overdraft limit error:%s", err)
+	}
+	if res.FromAccount.Balance != -limit {
+		t.Fatalf("failed balance want %d got %d", -limit, res.FromAccount.Balance)
+	}
+
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        1,
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("failed transfer over overdraft limit want %s got %v", ErrInsufficientFunds, err)
+	}
+
+	// concurrent transfers must not overdraw the account together
+	acc3 := createTestAccount(t)
+	n := 5
+	amount := acc3.Balance/int64(n-1) + 1
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.TransferTx(context.Background(), models.Transfer{
+				FromAccountID: acc3.ID,
+				ToAccountID:   acc2.ID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	var failed int
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrInsufficientFunds) {
+			t.Fatalf("failed concurrent transfer error:%s", err)
+		}
+		failed++
+	}
+	if failed < 1 {
+		t.Fatalf("failed concurrent transfers must reject at least one transfer")
+	}
+
+	acc3After, err := testRepo.GetAccountByID(context.Background(), acc3.ID)
+	if err != nil {
+		t.Fatalf("failed get account 3 error:%s", err)
+	}
+	if acc3After.Balance < 0 {
+		t.Fatalf("failed account overdrawn balance %d", acc3After.Balance)
+	}
+}
+
+func TestChangeAccountStatusTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+
+	res, err := testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc1.ID,
+		ToStatus:  models.AccountStatusFrozen,
+		Reason:    "suspicious activity",
+		ChangedBy: acc1.Owner,
+	})
+	if err != nil {
+		t.Fatalf("failed freeze account error:%s", err)
+	}
+	if res.Account.Status != models.AccountStatusFrozen {
+		t.Fatalf("failed account status want %s got %s", models.AccountStatusFrozen, res.Account.Status)
+	}
+	if res.History.FromStatus != models.AccountStatusActive {
+		t.Fatalf("failed history from status want %s got %s", models.AccountStatusActive, res.History.FromStatus)
+	}
+
+	// frozen account can't send or receive money
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        1,
+	})
+	if !errors.Is(err, ErrAccountNotActive) {
+		t.Fatalf("failed transfer from frozen account want %s got %v", ErrAccountNotActive, err)
+	}
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc2.ID,
+		ToAccountID:   acc1.ID,
+		Amount:        1,
+	})
+	if !errors.Is(err, ErrAccountNotActive) {
+		t.Fatalf("failed transfer to frozen account want %s got %v", ErrAccountNotActive, err)
+	}
+
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc1.ID,
+		ToStatus:  models.AccountStatusDormant,
+		Reason:    "no activity",
+		ChangedBy: "system",
+	})
+	if !errors.Is(err, ErrInvalidStatusChange) {
+		t.Fatalf("failed frozen to dormant want %s got %v", ErrInvalidStatusChange, err)
+	}
+
+	// closing need zero balance
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc1.ID,
+		ToStatus:  models.AccountStatusClosed,
+		Reason:    "closed by owner",
+		ChangedBy: acc1.Owner,
+	})
+	if !errors.Is(err, ErrAccountNotEmpty) {
+		t.Fatalf("failed close account with balance want %s got %v", ErrAccountNotEmpty, err)
+	}
+
+	_, err = testRepo.AddAccountBalanceByID(context.Background(), -acc1.Balance, acc1.ID)
+	if err != nil {
+		t.Fatalf("failed empty account error:%s", err)
+	}
+
+	res, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc1.ID,
+		ToStatus:  models.AccountStatusClosed,
+		Reason:    "closed by owner",
+		ChangedBy: acc1.Owner,
+	})
+	if err != nil {
+		t.Fatalf("failed close account error:%s", err)
+	}
+	if res.Account.Status != models.AccountStatusClosed {
+		t.Fatalf("failed account status want %s got %s", models.AccountStatusClosed, res.Account.Status)
+	}
+
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc1.ID,
+		ToStatus:  models.AccountStatusActive,
+		Reason:    "reopen",
+		ChangedBy: acc1.Owner,
+	})
+	if !errors.Is(err, ErrInvalidStatusChange) {
+		t.Fatalf("failed reopen closed account want %s got %v", ErrInvalidStatusChange, err)
+	}
+
+	// the owner can lift its own freeze only, a freeze or dormancy set by the bank is lifted by an admin
+	for _, arg := range []models.AccountStatusHistory{
+		{AccountID: acc2.ID, ToStatus: models.AccountStatusFrozen, Reason: "lost card", ChangedBy: acc2.Owner},
+		{AccountID: acc2.ID, ToStatus: models.AccountStatusActive, Reason: "found card", ChangedBy: acc2.Owner},
+		{AccountID: acc2.ID, ToStatus: models.AccountStatusDormant, Reason: "no activity", ChangedBy: "system"},
+	} {
+		_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+		if err != nil {
+			t.Fatalf("failed change account status to %s error:%s", arg.ToStatus, err)
+		}
+	}
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc2.ID,
+		ToStatus:  models.AccountStatusActive,
+		Reason:    "still here",
+		ChangedBy: acc2.Owner,
+	})
+	if !errors.Is(err, ErrReactivationNotAllowed) {
+		t.Fatalf("failed owner reactivate dormant account want %s got %v", ErrReactivationNotAllowed, err)
+	}
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc2.ID,
+		ToStatus:  models.AccountStatusFrozen,
+		Reason:    "fraud investigation",
+		ChangedBy: "bank-admin",
+	})
+	if err != nil {
+		t.Fatalf("failed bank freeze account error:%s", err)
+	}
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc2.ID,
+		ToStatus:  models.AccountStatusActive,
+		Reason:    "found card",
+		ChangedBy: acc2.Owner,
+	})
+	if !errors.Is(err, ErrReactivationNotAllowed) {
+		t.Fatalf("failed owner lift bank freeze want %s got %v", ErrReactivationNotAllowed, err)
+	}
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc2.ID,
+		ToStatus:  models.AccountStatusActive,
+		Reason:    "investigation closed",
+		ChangedBy: "bank-admin",
+	})
+	if err != nil {
+		t.Fatalf("failed admin lift freeze error:%s", err)
+	}
+
+	history, err := testRepo.GetListAccountStatusHistory(context.Background(), acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get status history error:%s", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("failed status history length want %d got %d", 2, len(history))
+	}
+	if history[1].FromStatus != models.AccountStatusFrozen || history[1].ToStatus != models.AccountStatusClosed {
+		t.Fatalf("failed status history want %s to %s got %s to %s", models.AccountStatusFrozen, models.AccountStatusClosed, history[1].FromStatus, history[1].ToStatus)
+	}
+}
+
+func TestCrossCurrencyTransferTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc1.Currency = "USD"
+	err := testRepo.UpdateAccount(context.Background(), acc1)
+	if err != nil {
+		t.Fatalf("failed update account 1 error:%s", err)
+	}
+	acc2 := createTestAccount(t)
+	acc2.Currency = "JPY"
+	err = testRepo.UpdateAccount(context.Background(), acc2)
+	if err != nil {
+		t.Fatalf("failed update account 2 error:%s", err)
+	}
+
+	// plain transfer can't move money between currencies
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("failed transfer between currencies want %s got %v", ErrCurrencyMismatch, err)
+	}
+
+	_, err = testRepo.InsertFxRate(context.Background(), models.FxRate{
+		BaseCurrency:  "USD",
+		QuoteCurrency: "JPY",
+		Rate:          "150",
+		SpreadBps:     100,
+		EffectiveAt:   time.Now().Add(-time.Second),
+	})
+	if err != nil {
+		t.Fatalf("failed insert fx rate error:%s", err)
+	}
+
+	amount := int64(50) // 0.50 USD
+	res, err := testStore.CrossCurrencyTransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        amount,
+	})
+	if err != nil {
+		t.Fatalf("failed cross currency transfer error:%s", err)
+	}
+
+	// 0.50 USD * 150 - 1% = 74.25 JPY rounded down
+	toAmount := int64(74)
+	if res.Transfer.ToAmount != toAmount {
+		t.Fatalf("failed to amount want %d got %d", toAmount, res.Transfer.ToAmount)
+	}
+	if res.FromEntry.Amount != -amount {
+		t.Fatalf("failed from entry want %d got %d", -amount, res.FromEntry.Amount)
+	}
+	if res.ToEntry.Amount != toAmount {
+		t.Fatalf("failed to entry want %d got %d", toAmount, res.ToEntry.Amount)
+	}
+	if res.ToAccount.Balance != acc2.Balance+toAmount {
+		t.Fatalf("failed to account balance want %d got %d", acc2.Balance+toAmount, res.ToAccount.Balance)
+	}
+
+	tf, err := testRepo.GetTransferByID(context.Background(), res.Transfer.ID)
+	if err != nil {
+		t.Fatalf("failed get transfer error:%s", err)
+	}
+	if tf.FxRate == "" || tf.FxSpreadBps != 100 || tf.ToAmount != toAmount {
+		t.Fatalf("failed quoted rate is not recorded on transfer %+v", tf)
+	}
+
+	// reversal give back the original amount at the original rate
+	rev, err := testStore.ReverseTransferTx(context.Background(), tf.ID, 0)
+	if err != nil {
+		t.Fatalf("failed reverse cross currency transfer error:%s", err)
+	}
+	if rev.Transfer.Amount != toAmount || rev.Transfer.ToAmount != amount {
+		t.Fatalf("failed reversal amount want %d to %d got %d to %d", toAmount, amount, rev.Transfer.Amount, rev.Transfer.ToAmount)
+	}
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+	acc2.Currency = acc1.Currency
+	err := testRepo.UpdateAccount(context.Background(), acc2)
+	if err != nil {
+		t.Fatalf("failed update account 2 error:%s", err)
+	}
+
+	st := models.ScheduledTransfer{
+		Owner:         acc1.Owner,
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+		CronSpec:      "0 9 1 * *",
+		NextRunAt:     time.Now().Add(-time.Minute).Truncate(time.Microsecond),
+		FailurePolicy: models.FailurePolicyPause,
+		Status:        models.ScheduledTransferStatusActive,
+	}
+	st.ID, err = testRepo.InsertScheduledTransfer(context.Background(), st)
+	if err != nil {
+		t.Fatalf("failed insert scheduled transfer error:%s", err)
+	}
+
+	// the failure task carry the id of the scheduled transfer so each one can be found in the outbox
+	newFailedTask := func(execution models.ScheduledTransferExecution) (models.OutboxMessage, error) {
+		payload := []byte(fmt.Sprintf(`{"scheduled_transfer_id":%d}`, execution.ScheduledTransferID))
+		return models.OutboxMessage{TaskType: "task:send_scheduled_transfer_failed_email", Payload: payload, Queue: "default", MaxRetry: 10}, nil
+	}
+	failedPayload := func(id int64) []byte {
+		return []byte(fmt.Sprintf(`{"scheduled_transfer_id":%d}`, id))
+	}
+
+	res, err := testStore.ExecuteScheduledTransferTx(context.Background(), st.ID, st.NextRunAt, PayeeRules{}, newFailedTask)
+	if err != nil {
+		t.Fatalf("failed execute scheduled transfer error:%s", err)
+	}
+	if getTestOutboxMessage(t, failedPayload(st.ID)) != nil {
+		t.Fatalf("failed succeeded execution must not write the failure task")
+	}
+	if res.Execution.Status != models.ExecutionStatusSucceeded {
+		t.Fatalf("failed execution status want %s got %s", models.ExecutionStatusSucceeded, res.Execution.Status)
+	}
+	if res.Execution.TransferID != res.Transfer.ID || res.Transfer.Amount != st.Amount {
+		t.Fatalf("failed execution transfer %+v", res.Transfer)
+	}
+	if !res.ScheduledTransfer.NextRunAt.After(time.Now()) {
+		t.Fatalf("failed next run must be in the future got %s", res.ScheduledTransfer.NextRunAt)
+	}
+
+	// the same run can't be executed twice
+	_, err = testStore.ExecuteScheduledTransferTx(context.Background(), st.ID, st.NextRunAt, PayeeRules{}, newFailedTask)
+	if !errors.Is(err, ErrScheduledTransferNotDue) {
+		t.Fatalf("failed execute twice want %s got %v", ErrScheduledTransferNotDue, err)
+	}
+
+	// failed run is recorded and pause the schedule
+	next := res.ScheduledTransfer
+	next.Amount = acc1.Balance + 1
+	next.NextRunAt = time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+	err = testRepo.UpdateScheduledTransfer(context.Background(), next)
+	if err != nil {
+		t.Fatalf("failed update scheduled transfer error:%s", err)
+	}
+
+	res, err = testStore.ExecuteScheduledTransferTx(context.Background(), st.ID, next.NextRunAt, PayeeRules{}, newFailedTask)
+	if err != nil {
+		t.Fatalf("failed execute scheduled transfer error:%s", err)
+	}
+	if res.Execution.Status != models.ExecutionStatusFailed || res.Execution.Error == "" {
+		t.Fatalf("failed execution want failed with error got %+v", res.Execution)
+	}
+	// the failure task is written to the outbox with the failed execution
+	msg := getTestOutboxMessage(t, failedPayload(st.ID))
+	if msg == nil || msg.TaskType != "task:send_scheduled_transfer_failed_email" {
+		t.Fatalf("failed want the failure task in the outbox got %+v", msg)
+	}
+	if res.ScheduledTransfer.Status != models.ScheduledTransferStatusPaused {
+		t.Fatalf("failed schedule status want %s got %s", models.ScheduledTransferStatusPaused, res.ScheduledTransfer.Status)
+	}
+
+	executions, err := testRepo.GetListScheduledTransferExecutions(context.Background(), st.ID, 10, 0)
+	if err != nil {
+		t.Fatalf("failed get executions error:%s", err)
+	}
+	if len(executions) != 2 {
+		t.Fatalf("failed executions length want %d got %d", 2, len(executions))
+	}
+
+	// the payee rules are checked on each run, the to account is not a payee of the owner
+	st.ID = 0
+	st.NextRunAt = time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+	st.ID, err = testRepo.InsertScheduledTransfer(context.Background(), st)
+	if err != nil {
+		t.Fatalf("failed insert scheduled transfer error:%s", err)
+	}
+	res, err = testStore.ExecuteScheduledTransferTx(context.Background(), st.ID, st.NextRunAt, PayeeRules{RequirePayee: true}, newFailedTask)
+	if err != nil {
+		t.Fatalf("failed execute scheduled transfer error:%s", err)
+	}
+	if res.Execution.Status != models.ExecutionStatusFailed || res.Transfer.ID != 0 {
+		t.Fatalf("failed execution to a non payee want failed got %+v", res.Execution)
+	}
+}
+
+func TestVerifyPayeeCoolingOff(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+	acc2.Currency = acc1.Currency
+	err := testRepo.UpdateAccount(context.Background(), acc2)
+	if err != nil {
+		t.Fatalf("failed update account 2 error:%s", err)
+	}
+
+	_, err = testRepo.InsertPayee(context.Background(), models.Payee{
+		Owner:     acc1.Owner,
+		Nickname:  util.RandomOwner(),
+		AccountID: acc2.ID,
+		ActiveAt:  time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed insert payee error:%s", err)
+	}
+
+	rules := PayeeRules{CoolingOffLimit: map[string]int64{acc1.Currency: 50}}
+	verify := func(amount int64) error {
+		return VerifyPayee(context.Background(), testRepo, rules, acc1, acc2, amount)
+	}
+
+	// a payment split in several transfers each under the limit is refused once the total go over it
+	for i := 0; i < 2; i++ {
+		err = verify(20)
+		if err != nil {
+			t.Fatalf("failed verify transfer %d under the limit error:%s", i, err)
+		}
+		_, err = testStore.TransferTx(context.Background(), models.Transfer{
+			FromAccountID: acc1.ID,
+			ToAccountID:   acc2.ID,
+			Amount:        20,
+		})
+		if err != nil {
+			t.Fatalf("failed transfer %d error:%s", i, err)
+		}
+	}
+	var ruleErr *PayeeRuleError
+	err = verify(20)
+	if !errors.As(err, &ruleErr) || !ruleErr.CoolingOff {
+		t.Fatalf("failed verify third transfer over the total want cooling-off error got %v", err)
+	}
+	err = verify(10)
+	if err != nil {
+		t.Fatalf("failed verify the rest of the limit error:%s", err)
+	}
+
+	// an authorized hold count as sent
+	_, err = testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      10,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed authorize hold error:%s", err)
+	}
+	err = verify(1)
+	if !errors.As(err, &ruleErr) || !ruleErr.CoolingOff {
+		t.Fatalf("failed verify transfer after hold want cooling-off error got %v", err)
+	}
+
+	// the limit is by currency, a currency without limit is not limited
+	rules.CoolingOffLimit = map[string]int64{"XXX": 50}
+	err = verify(100)
+	if err != nil {
+		t.Fatalf("failed verify currency without limit error:%s", err)
+	}
+}
+
+func TestTransferBatchTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+	acc2.Currency = acc1.Currency
+	err := testRepo.UpdateAccount(context.Background(), acc2)
+	if err != nil {
+		t.Fatalf("failed update account 2 error:%s", err)
+	}
+
+	batch := models.TransferBatch{Owner: acc1.Owner}
+	items := func(amounts ...int64) []models.TransferBatchItem {
+		var list []models.TransferBatchItem
+		for _, amount := range amounts {
+			list = append(list, models.TransferBatchItem{
+				FromAccountID: acc1.ID,
+				ToAccountID:   acc2.ID,
+				Amount:        amount,
+			})
+		}
+		return list
+	}
+
+	// atomic batch with a failing item doesn't move any money
+	res, err := testStore.AtomicTransferBatchTx(context.Background(), batch, items(10, acc1.Balance))
+	if err != nil {
+		t.Fatalf("failed atomic batch error:%s", err)
+	}
+	if res.Batch.Status != models.BatchStatusFailed {
+		t.Fatalf("failed batch status want %s got %s", models.BatchStatusFailed, res.Batch.Status)
+	}
+	if res.Items[0].TransferID != 0 || res.Items[1].Error == "" {
+		t.Fatalf("failed items want rolled back got %+v %+v", res.Items[0], res.Items[1])
+	}
+	account1, err := testRepo.GetAccountByID(context.Background(), acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get account 1 error:%s", err)
+	}
+	if account1.Balance != acc1.Balance {
+		t.Fatalf("failed balance want %d got %d", acc1.Balance, account1.Balance)
+	}
+
+	res, err = testStore.AtomicTransferBatchTx(context.Background(), batch, items(10, 20))
+	if err != nil {
+		t.Fatalf("failed atomic batch error:%s", err)
+	}
+	if res.Batch.Status != models.BatchStatusCompleted || res.Batch.TotalItems != 2 {
+		t.Fatalf("failed batch want completed with 2 items got %+v", res.Batch)
+	}
+	for _, item := range res.Items {
+		if item.Status != models.BatchItemStatusSucceeded || item.TransferID < 1 {
+			t.Fatalf("failed item want succeeded got %+v", item)
+		}
+	}
+
+	// best effort batch execute each item on its own
+	res, err = testStore.CreateTransferBatchTx(context.Background(), batch, items(5, acc1.Balance), func(batch models.TransferBatch) (models.OutboxMessage, error) {
+		payload := []byte(fmt.Sprintf(`{"batch_id":%d}`, batch.ID))
+		return models.OutboxMessage{TaskType: "task:process_transfer_batch", Payload: payload, Queue: "default", MaxRetry: 10}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed create batch error:%s", err)
+	}
+	if res.Batch.Status != models.BatchStatusPending {
+		t.Fatalf("failed batch status want %s got %s", models.BatchStatusPending, res.Batch.Status)
+	}
+	// the task processing the batch is written to the outbox with the batch
+	msg := getTestOutboxMessage(t, []byte(fmt.Sprintf(`{"batch_id":%d}`, res.Batch.ID)))
+	if msg == nil || msg.TaskType != "task:process_transfer_batch" {
+		t.Fatalf("failed want the batch task in the outbox got %+v", msg)
+	}
+
+	want := []string{models.BatchItemStatusSucceeded, models.BatchItemStatusFailed}
+	for i, item := range res.Items {
+		itemRes, err := testStore.ExecuteTransferBatchItemTx(context.Background(), item.ID)
+		if err != nil {
+			t.Fatalf("failed execute item error:%s", err)
+		}
+		if itemRes.Item.Status != want[i] {
+			t.Fatalf("failed item %d status want %s got %s", i, want[i], itemRes.Item.Status)
+		}
+
+		// executing again doesn't run the transfer twice
+		itemRes, err = testStore.ExecuteTransferBatchItemTx(context.Background(), item.ID)
+		if err != nil {
+			t.Fatalf("failed execute item again error:%s", err)
+		}
+		if itemRes.Transfer.ID != 0 {
+			t.Fatalf("failed item %d executed twice", i)
+		}
+	}
+
+	account1, err = testRepo.GetAccountByID(context.Background(), acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get account 1 error:%s", err)
+	}
+	if account1.Balance != acc1.Balance-35 {
+		t.Fatalf("failed balance want %d got %d", acc1.Balance-35, account1.Balance)
+	}
+}
+
+// getTestHouseAccount return the house account of the purpose and currency, it is created when missing.
+// House accounts are unique for each currency so the one registered by a previous run is kept.
+func getTestHouseAccount(t *testing.T, purpose, currency string) models.HouseAccount {
+	house, err := testRepo.GetHouseAccount(context.Background(), purpose, currency)
+	if err != nil {
+		t.Fatalf("failed get house account error:%s", err)
+	}
+	if house.AccountID < 1 {
+		acc := createTestAccount(t)
+		acc.Currency = currency
+		err = testRepo.UpdateAccount(context.Background(), acc)
+		if err != nil {
+			t.Fatalf("failed update account error:%s", err)
+		}
+
+		house = models.HouseAccount{
+			Purpose:   purpose,
+			Currency:  currency,
+			AccountID: acc.ID,
+		}
+		err = testRepo.InsertHouseAccount(context.Background(), house)
+		if err != nil {
+			t.Fatalf("failed insert house account error:%s", err)
+		}
+	}
+
+	// house accounts may go below zero, e.g. interest expense
+	err = testRepo.UpdateAccountOverdraftLimit(context.Background(), house.AccountID, 1000000000)
+	if err != nil {
+		t.Fatalf("failed update overdraft limit error:%s", err)
+	}
+
+	return house
+}
+
+// createTestProductAccount insert a random user with one account of the account product and return the account
+func createTestProductAccount(t *testing.T, product models.AccountProduct, balance int64) models.Account {
+	user := models.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	err := testRepo.InsertUsers(context.Background(), user)
+	if err != nil {
+		t.Fatalf("failed insert users error:%s", err)
+	}
+
+	acc := models.Account{
+		Owner:     user.Username,
+		Balance:   balance,
+		Currency:  product.Currency,
+		ProductID: product.ID,
+	}
+	acc.ID, err = testRepo.InsertAccount(context.Background(), acc)
+	if err != nil {
+		t.Fatalf("failed insert account error:%s", err)
+	}
+
+	return acc
+}
+
+func TestInterestTx(t *testing.T) {
+	var err error
+	currency := util.RandomCurrency()
+	houseAccount := getTestHouseAccount(t, models.HouseAccountInterestExpense, currency)
+
+	product := models.AccountProduct{
+		Name:       "Savings",
+		Currency:   currency,
+		AnnualRate: "0.05",
+		DayCount:   models.DayCountAct365,
+	}
+	product.ID, err = testRepo.InsertAccountProduct(context.Background(), product)
+	if err != nil {
+		t.Fatalf("failed insert account product error:%s", err)
+	}
+
+	acc := createTestProductAccount(t, product, 100000)
+	// interest accrue on the balance of the ledger at the end of the day, fund the account before the accrued days
+	_, err = testDB.Exec(`INSERT INTO "entries" ("account_id", "amount", "created_at") VALUES ($1, $2, $3)`,
+		acc.ID, acc.Balance, time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed insert opening entry error:%s", err)
+	}
+
+	days := []time.Time{
+		time.Date(2023, time.January, 30, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, time.January, 31, 0, 0, 0, 0, time.UTC),
+	}
+	for _, day := range days {
+		accrual, err := testStore.AccrueInterestTx(context.Background(), acc.ID, day)
+		if err != nil {
+			t.Fatalf("failed accrue interest error:%s", err)
+		}
+		// 1000.00 at 5% ACT/365 for one day
+		if accrual.ID < 1 || accrual.AmountMicros != 13698630 {
+			t.Fatalf("failed accrual want 13698630 micros got %+v", accrual)
+		}
+
+		// accruing the same day again doesn't record anything
+		accrual, err = testStore.AccrueInterestTx(context.Background(), acc.ID, day)
+		if err != nil {
+			t.Fatalf("failed accrue interest again error:%s", err)
+		}
+		if accrual.ID != 0 {
+			t.Fatalf("failed accrue the same day twice got id %d", accrual.ID)
+		}
+	}
+
+	res, err := testStore.PostInterestTx(context.Background(), acc.ID, days[1])
+	if err != nil {
+		t.Fatalf("failed post interest error:%s", err)
+	}
+	if res.Posting.Amount != 27 || res.Posting.CarryMicros != 397260 {
+		t.Fatalf("failed posting want 27 with carry 397260 got %+v", res.Posting)
+	}
+	if res.Transfer.FromAccountID != houseAccount.AccountID || res.ToAccount.Balance != acc.Balance+27 {
+		t.Fatalf("failed posting transfer %+v to account %+v", res.Transfer, res.ToAccount)
+	}
+
+	_, err = testStore.PostInterestTx(context.Background(), acc.ID, days[1])
+	if !errors.Is(err, ErrInterestAlreadyPosted) {
+		t.Fatalf("failed post twice want %s got %v", ErrInterestAlreadyPosted, err)
+	}
+
+	// carry of the previous posting is added to the next one
+	next := time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC)
+	accrual, err := testStore.AccrueInterestTx(context.Background(), acc.ID, next)
+	if err != nil {
+		t.Fatalf("failed accrue interest error:%s", err)
+	}
+	// the interest posted today is not part of the balance of the past day
+	if accrual.Balance != acc.Balance {
+		t.Fatalf("failed accrual balance want %d got %d", acc.Balance, accrual.Balance)
+	}
+	res, err = testStore.PostInterestTx(context.Background(), acc.ID, time.Date(2023, time.February, 28, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed post interest error:%s", err)
+	}
+	accrued := 397260 + accrual.AmountMicros
+	if res.Posting.AccruedMicros != accrued || res.Posting.Amount != accrued/models.InterestMicrosPerMinorUnit {
+		t.Fatalf("failed posting want %d micros got %+v", accrued, res.Posting)
+	}
+}
+
+func TestTransferTxFee(t *testing.T) {
+	var err error
+	currency := util.RandomCurrency()
+	houseAccount := getTestHouseAccount(t, models.HouseAccountFeeRevenue, currency)
+
+	// schedule of a new product so the transfers of the other tests stay free
+	product := models.AccountProduct{
+		Name:       "Checking",
+		Currency:   currency,
+		AnnualRate: "0",
+		DayCount:   models.DayCountAct365,
+	}
+	product.ID, err = testRepo.InsertAccountProduct(context.Background(), product)
+	if err != nil {
+		t.Fatalf("failed insert account product error:%s", err)
+	}
+	schedule := models.FeeSchedule{
+		Currency:      currency,
+		ProductID:     product.ID,
+		FeeType:       models.FeeTypePercentage,
+		PercentageBps: 100, // 1%
+		MinAmount:     5,
+		MaxAmount:     50,
+	}
+	schedule.ID, err = testRepo.InsertFeeSchedule(context.Background(), schedule)
+	if err != nil {
+		t.Fatalf("failed insert fee schedule error:%s", err)
+	}
+
+	acc1 := createTestProductAccount(t, product, 1000)
+	acc2 := createTestProductAccount(t, product, 0)
+	house, err := testRepo.GetAccountByID(context.Background(), houseAccount.AccountID)
+	if err != nil {
+		t.Fatalf("failed get house account error:%s", err)
+	}
+
+	res, err := testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        100,
+	})
+	if err != nil {
+		t.Fatalf("failed transfer error:%s", err)
+	}
+	if res.Transfer.Fee != 5 {
+		t.Fatalf("failed fee want min %d got %d", 5, res.Transfer.Fee)
+	}
+	if res.FeeEntry.AccountID != acc1.ID || res.FeeEntry.Amount != -5 {
+		t.Fatalf("failed fee entry %+v", res.FeeEntry)
+	}
+	if res.FeeRevenueEntry.AccountID != houseAccount.AccountID || res.FeeRevenueEntry.Amount != 5 {
+		t.Fatalf("failed fee revenue entry %+v", res.FeeRevenueEntry)
+	}
+	if res.FromAccount.Balance != 1000-105 || res.ToAccount.Balance != 100 {
+		t.Fatalf("failed balances from %d to %d", res.FromAccount.Balance, res.ToAccount.Balance)
+	}
+
+	updatedHouse, err := testRepo.GetAccountByID(context.Background(), houseAccount.AccountID)
+	if err != nil {
+		t.Fatalf("failed get house account error:%s", err)
+	}
+	if updatedHouse.Balance-house.Balance != 5 {
+		t.Fatalf("failed house balance want +%d got %+d", 5, updatedHouse.Balance-house.Balance)
+	}
+
+	// the fee must fit in the available balance too, nothing is moved otherwise
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        res.FromAccount.Balance,
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("failed transfer without fee funds want %s got %v", ErrInsufficientFunds, err)
+	}
+
+	// a captured hold is charged like a transfer of the captured amount
+	hold, err := testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+		AccountID:   acc1.ID,
+		ToAccountID: acc2.ID,
+		Amount:      100,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed authorize hold error:%s", err)
+	}
+	captured, err := testStore.CaptureHoldTx(context.Background(), hold.Hold.ID, 0)
+	if err != nil {
+		t.Fatalf("failed capture hold error:%s", err)
+	}
+	if captured.Transfer.Fee != 5 || captured.FromAccount.Balance != res.FromAccount.Balance-105 {
+		t.Fatalf("failed captured hold fee %d from account %+v", captured.Transfer.Fee, captured.FromAccount)
+	}
+
+	// the schedule of the product override the default schedule of the currency, e.g. free for premium products
+	freeProduct := product
+	freeProduct.Name = "Premium"
+	freeProduct.ID, err = testRepo.InsertAccountProduct(context.Background(), freeProduct)
+	if err != nil {
+		t.Fatalf("failed insert account product error:%s", err)
+	}
+	_, err = testRepo.InsertFeeSchedule(context.Background(), models.FeeSchedule{
+		Currency:  currency,
+		ProductID: freeProduct.ID,
+		FeeType:   models.FeeTypeFree,
+	})
+	if err != nil {
+		t.Fatalf("failed insert fee schedule error:%s", err)
+	}
+	acc3 := createTestProductAccount(t, freeProduct, 1000)
+
+	res, err = testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc3.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        1000,
+	})
+	if err != nil {
+		t.Fatalf("failed free transfer error:%s", err)
+	}
+	if res.Transfer.Fee != 0 || res.FeeEntry.ID != 0 || res.FromAccount.Balance != 0 {
+		t.Fatalf("failed free transfer charged %+v", res)
+	}
+}
+
+func TestIsRetryableTxError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, want: true},
+		{name: "deadlock", err: &pq.Error{Code: "40P01"}, want: true},
+		{name: "wrapped", err: fmt.Errorf("tx error:%w rollback error: conn closed", &pq.Error{Code: "40001"}), want: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: false},
+		{name: "business error", err: ErrInsufficientFunds, want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := isRetryableTxError(tc.err)
+			if got != tc.want {
+				t.Errorf("failed %s want %v got %v", tc.name, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestExecTxRetry(t *testing.T) {
+	store := NewStoreWithTxOptions(testDB, TxOptions{MaxAttempts: 3, RetryBackoff: time.Millisecond})
+
+	attempts := 0
+	err := store.execTx(context.Background(), func(r Repository) error {
+		attempts++
+		if attempts < 3 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed exec tx error:%s", err)
+	}
+	if attempts != 3 {
+		t.Errorf("failed attempts want 3 got %d", attempts)
+	}
+
+	attempts = 0
+	err = store.execTx(context.Background(), func(r Repository) error {
+		attempts++
+		return &pq.Error{Code: "40P01"}
+	})
+	if !isRetryableTxError(err) {
+		t.Errorf("failed exec tx want deadlock error got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("failed attempts want 3 got %d", attempts)
+	}
+
+	// business errors are not retried
+	attempts = 0
+	err = store.execTx(context.Background(), func(r Repository) error {
+		attempts++
+		return ErrInsufficientFunds
+	})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Errorf("failed exec tx want %s got %v", ErrInsufficientFunds, err)
+	}
+	if attempts != 1 {
+		t.Errorf("failed attempts want 1 got %d", attempts)
+	}
+}
+
+// TestTransferTxConservation run random concurrent transfers between a few accounts of the same currency
+// at every isolation level, no money is created or lost and every balance match the sum of its entries
+func TestTransferTxConservation(t *testing.T) {
+	levels := []sql.IsolationLevel{sql.LevelReadCommitted, sql.LevelRepeatableRead, sql.LevelSerializable}
+
+	for _, level := range levels {
+		t.Run(level.String(), func(t *testing.T) {
+			store := NewStoreWithTxOptions(testDB, TxOptions{
+				Isolation:    level,
+				MaxAttempts:  20,
+				RetryBackoff: 5 * time.Millisecond,
+			})
+
+			currency := util.RandomCurrency()
+			accounts := make([]models.Account, 4)
+			var total int64
+			for i := range accounts {
+				accounts[i] = createTestAccount(t)
+				_, err := testDB.Exec(`UPDATE "accounts" SET "currency" = $1 WHERE "id" = $2`, currency, accounts[i].ID)
+				if err != nil {
+					t.Fatalf("failed set account currency error:%s", err)
+				}
+				total += accounts[i].Balance
+			}
+
+			n := 40
+			chErr := make(chan error)
+			for i := 0; i < n; i++ {
+				from := accounts[rand.Intn(len(accounts))]
+				to := accounts[rand.Intn(len(accounts))]
+				for to.ID == from.ID {
+					to = accounts[rand.Intn(len(accounts))]
+				}
+				go func() {
+					_, err := store.TransferTx(context.Background(), models.Transfer{
+						FromAccountID: from.ID,
+						ToAccountID:   to.ID,
+						Amount:        util.RandomInt(1, 50),
+					})
+					chErr <- err
+				}()
+			}
+
+			for i := 0; i < n; i++ {
+				err := <-chErr
+				if err != nil && !errors.Is(err, ErrInsufficientFunds) {
+					t.Errorf("failed transfer error:%s", err)
+				}
+			}
+
+			var got int64
+			for _, acc := range accounts {
+				updated, err := testRepo.GetAccountByID(context.Background(), acc.ID)
+				if err != nil {
+					t.Fatalf("failed get account error:%s", err)
+				}
+				if updated.Balance < 0 {
+					t.Errorf("failed account %d overdrawn balance %d", acc.ID, updated.Balance)
+				}
+				got += updated.Balance
+
+				var entries int64
+				err = testDB.QueryRow(`SELECT COALESCE(SUM("amount"), 0) FROM "entries" WHERE "account_id" = $1`, acc.ID).Scan(&entries)
+				if err != nil {
+					t.Fatalf("failed sum entries error:%s", err)
+				}
+				if acc.Balance+entries != updated.Balance {
+					t.Errorf("failed account %d balance want %d got %d", acc.ID, acc.Balance+entries, updated.Balance)
+				}
+			}
+
+			if got != total {
+				t.Errorf("failed total balance want %d got %d", total, got)
+			}
+		})
+	}
+}
+
+func TestReconcileLedgerChecks(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+	_, err := testDB.Exec(`UPDATE "accounts" SET "currency" = $1 WHERE "id" = $2`, acc1.Currency, acc2.ID)
+	if err != nil {
+		t.Fatalf("failed set account currency error:%s", err)
+	}
+
+	// entries of the initial balance, the test accounts are inserted with a balance
+	for _, acc := range []models.Account{acc1, acc2} {
+		_, err = testRepo.InsertEntry(context.Background(), models.Entry{AccountID: acc.ID, Amount: acc.Balance})
+		if err != nil {
+			t.Fatalf("failed insert entry error:%s", err)
+		}
+	}
+
+	res, err := testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	if err != nil {
+		t.Fatalf("failed transfer error:%s", err)
+	}
+
+	ledgers, err := testRepo.GetListAccountLedgers(context.Background(), acc1.ID-1, 1)
+	if err != nil {
+		t.Fatalf("failed get account ledgers error:%s", err)
+	}
+	if len(ledgers) != 1 || checkAccountLedger(ledgers[0]) != nil {
+		t.Fatalf("failed account ledger want balanced got %+v", ledgers)
+	}
+
+	transfers, err := testRepo.GetListTransferLedgers(context.Background(), acc1.ID-1, acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get transfer ledgers error:%s", err)
+	}
+	if len(transfers) != 1 || transfers[0].ID != res.Transfer.ID {
+		t.Fatalf("failed transfer ledgers want transfer %d got %+v", res.Transfer.ID, transfers)
+	}
+	if f := checkTransferLedger(transfers[0]); f != nil {
+		t.Errorf("failed transfer ledger want balanced got %s", f.Detail)
+	}
+
+	// an entry posted without moving the balance break both the account and the transfer
+	_, err = testRepo.InsertEntry(context.Background(), models.Entry{AccountID: acc1.ID, Amount: -1, TransferID: res.Transfer.ID})
+	if err != nil {
+		t.Fatalf("failed insert entry error:%s", err)
+	}
+
+	ledgers, err = testRepo.GetListAccountLedgers(context.Background(), acc1.ID-1, 1)
+	if err != nil {
+		t.Fatalf("failed get account ledgers error:%s", err)
+	}
+	f := checkAccountLedger(ledgers[0])
+	if f == nil || f.Kind != models.FindingBalanceMismatch || f.AccountID != acc1.ID {
+		t.Errorf("failed account ledger want %s finding got %+v", models.FindingBalanceMismatch, f)
+	}
+
+	transfers, err = testRepo.GetListTransferLedgers(context.Background(), acc1.ID-1, acc1.ID)
+	if err != nil {
+		t.Fatalf("failed get transfer ledgers error:%s", err)
+	}
+	f = checkTransferLedger(transfers[0])
+	if f == nil || f.Kind != models.FindingTransferEntries || f.TransferID != res.Transfer.ID {
+		t.Errorf("failed transfer ledger want %s finding got %+v", models.FindingTransferEntries, f)
+	}
+
+	report, err := testStore.ReconcileLedger(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("failed reconcile ledger error:%s", err)
+	}
+	if report.ID < 1 || report.FindingsCount < 2 || report.TransfersChecked < 1 {
+		t.Errorf("failed reconciliation report want saved with findings got %+v", report)
+	}
+}
+
+func TestExternalPayoutTx(t *testing.T) {
+	acc := createTestAccount(t)
+	clearing := getTestHouseAccount(t, models.HouseAccountACHClearing, acc.Currency)
+
+	res, err := testStore.CreateExternalPayoutTx(context.Background(), models.ExternalPayout{
+		Owner:           acc.Owner,
+		AccountID:       acc.ID,
+		Amount:          50,
+		Currency:        acc.Currency,
+		RoutingNumber:   "021000021",
+		AccountNumber:   "123456789",
+		AccountType:     models.PayoutAccountChecking,
+		BeneficiaryName: "John Doe",
+	})
+	if err != nil {
+		t.Fatalf("failed create external payout error:%s", err)
+	}
+	if res.Payout.ID < 1 || res.Payout.Status != models.PayoutStatusQueued || res.Payout.TransferID != res.Transfer.ID {
+		t.Fatalf("failed payout got %+v", res.Payout)
+	}
+	if res.Transfer.ToAccountID != clearing.AccountID || res.FromAccount.Balance != acc.Balance-50-res.Transfer.Fee {
+		t.Fatalf("failed payout transfer %+v from account %+v", res.Transfer, res.FromAccount)
+	}
+
+	// queued payouts of previous runs are submitted in the same file
+	traceNumber := fmt.Sprintf("09100001%07d", res.Payout.ID%10000000)
+	file, err := testStore.CreateACHFileTx(context.Background(), acc.Currency, 1000, func(file *models.ACHFile, sameDayFiles int, payouts []*models.ExternalPayout) error {
+		file.FileName = fmt.Sprintf("test-%s-%d.ach", util.RandomString(8), sameDayFiles)
+		file.FileIDModifier = "A"
+		file.Content = "content"
+		for _, p := range payouts {
+			p.TraceNumber = fmt.Sprintf("09100001%07d", p.ID%10000000)
+			file.EntryCount++
+			file.TotalAmount += p.Amount
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed create ach file error:%s", err)
+	}
+	if file.File.ID < 1 || file.File.EntryCount != len(file.Payouts) {
+		t.Fatalf("failed ach file got %+v", file.File)
+	}
+
+	payout, err := testRepo.GetExternalPayoutByID(context.Background(), res.Payout.ID)
+	if err != nil {
+		t.Fatalf("failed get external payout error:%s", err)
+	}
+	if payout.Status != models.PayoutStatusSubmitted || payout.ACHFileID != file.File.ID || payout.TraceNumber != traceNumber {
+		t.Fatalf("failed submitted payout got %+v", payout)
+	}
+
+	// the return is credited even when the account was frozen since the payout
+	err = testRepo.UpdateAccountStatus(context.Background(), acc.ID, models.AccountStatusFrozen)
+	if err != nil {
+		t.Fatalf("failed freeze account error:%s", err)
+	}
+
+	ret, err := testStore.ReturnExternalPayoutTx(context.Background(), traceNumber, "R03")
+	if err != nil {
+		t.Fatalf("failed return external payout error:%s", err)
+	}
+	if ret.Transfer.ReversalOf != res.Transfer.ID || ret.ToAccount.Balance != res.FromAccount.Balance+50 {
+		t.Fatalf("failed return transfer %+v to account %+v", ret.Transfer, ret.ToAccount)
+	}
+	if ret.Payout.Status != models.PayoutStatusReturned || ret.Payout.ReturnCode != "R03" {
+		t.Fatalf("failed returned payout got %+v", ret.Payout)
+	}
+
+	// the same return ingested again doesn't credit twice
+	ret, err = testStore.ReturnExternalPayoutTx(context.Background(), traceNumber, "R03")
+	if err != nil {
+		t.Fatalf("failed return external payout again error:%s", err)
+	}
+	if ret.Transfer.ID != 0 {
+		t.Fatalf("failed return twice got transfer %d", ret.Transfer.ID)
+	}
+
+	_, err = testStore.ReturnExternalPayoutTx(context.Background(), "000000000000000", "R03")
+	if !errors.Is(err, ErrExternalPayoutNotFound) {
+		t.Fatalf("failed unknown trace number want %s got %v", ErrExternalPayoutNotFound, err)
+	}
+
+	// the return to a closed account is parked in the clearing house account
+	acc2 := createTestAccount(t)
+	acc2.Currency = acc.Currency
+	err = testRepo.UpdateAccount(context.Background(), acc2)
+	if err != nil {
+		t.Fatalf("failed update account error:%s", err)
+	}
+	res, err = testStore.CreateExternalPayoutTx(context.Background(), models.ExternalPayout{
+		Owner:           acc2.Owner,
+		AccountID:       acc2.ID,
+		Amount:          50,
+		Currency:        acc.Currency,
+		RoutingNumber:   "021000021",
+		AccountNumber:   "123456789",
+		AccountType:     models.PayoutAccountChecking,
+		BeneficiaryName: "John Doe",
+	})
+	if err != nil {
+		t.Fatalf("failed create external payout error:%s", err)
+	}
+	traceNumber = fmt.Sprintf("09100001%07d", res.Payout.ID%10000000)
+	_, err = testStore.CreateACHFileTx(context.Background(), acc.Currency, 1000, func(file *models.ACHFile, sameDayFiles int, payouts []*models.ExternalPayout) error {
+		file.FileName = fmt.Sprintf("test-%s-%d.ach", util.RandomString(8), sameDayFiles)
+		file.FileIDModifier = "A"
+		file.Content = "content"
+		for _, p := range payouts {
+			p.TraceNumber = fmt.Sprintf("09100001%07d", p.ID%10000000)
+			file.EntryCount++
+			file.TotalAmount += p.Amount
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed create ach file error:%s", err)
+	}
+	err = testRepo.UpdateAccountStatus(context.Background(), acc2.ID, models.AccountStatusClosed)
+	if err != nil {
+		t.Fatalf("failed close account error:%s", err)
+	}
+
+	ret, err = testStore.ReturnExternalPayoutTx(context.Background(), traceNumber, "R02")
+	if err != nil {
+		t.Fatalf("failed return external payout to closed account error:%s", err)
+	}
+	if ret.Transfer.ID != 0 || ret.Payout.Status != models.PayoutStatusReturnException || ret.Payout.ReturnCode != "R02" {
+		t.Fatalf("failed return to closed account got payout %+v transfer %+v", ret.Payout, ret.Transfer)
+	}
+}
+
+func TestTransferTxLimit(t *testing.T) {
+	var err error
+	product := models.AccountProduct{
+		Name:       "Checking",
+		Currency:   util.RandomCurrency(),
+		AnnualRate: "0",
+		DayCount:   models.DayCountAct365,
+	}
+	product.ID, err = testRepo.InsertAccountProduct(context.Background(), product)
+	if err != nil {
+		t.Fatalf("failed insert account product error:%s", err)
+	}
+	from := createTestProductAccount(t, product, 1000)
+	to := createTestProductAccount(t, product, 0)
+
+	policy, err := testStore.SetTransferLimitTx(context.Background(), models.TransferLimit{
+		AccountID:      from.ID,
+		PerTransaction: 60,
+		Daily:          80,
+		UpdatedBy:      "admin",
+	})
+	if err != nil {
+		t.Fatalf("failed set transfer limit error:%s", err)
+	}
+	if policy.ID < 1 || policy.Currency != from.Currency || policy.Scope() != models.TransferLimitScopeAccount {
+		t.Fatalf("failed transfer limit got %+v", policy)
+	}
+
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 50})
+	if err != nil {
+		t.Fatalf("failed transfer within limits error:%s", err)
+	}
+
+	testCases := []struct {
+		Name      string
+		Amount    int64
+		Period    string
+		Remaining int64
+	}{
+		{
+			Name:      "PerTransaction",
+			Amount:    61,
+			Period:    models.TransferLimitPeriodPerTransaction,
+			Remaining: 60,
+		},
+		{
+			Name:      "Daily",
+			Amount:    40,
+			Period:    models.TransferLimitPeriodDaily,
+			Remaining: 30,
+		},
+	}
+	for _, tc := range testCases {
+		_, err = testStore.TransferTx(context.Background(), models.Transfer{FromAccountID: from.ID, ToAccountID: to.ID, Amount: tc.Amount})
+		var limitErr *TransferLimitError
+		if !errors.As(err, &limitErr) || !errors.Is(err, ErrTransferLimitExceeded) {
+			t.Fatalf("failed %s want transfer limit error got %v", tc.Name, err)
+		}
+		if limitErr.Period != tc.Period || limitErr.Remaining != tc.Remaining || limitErr.LimitID != policy.ID {
+			t.Fatalf("failed %s got %+v", tc.Name, limitErr)
+		}
+	}
+
+	// a hold is checked against the limits when it is authorized
+	_, err = testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+		AccountID:   from.ID,
+		ToAccountID: to.ID,
+		Amount:      61,
+		ExpiredAt:   time.Now().Add(time.Hour),
+	})
+	if !errors.Is(err, ErrTransferLimitExceeded) {
+		t.Fatalf("failed authorize hold over the limit want %s got %v", ErrTransferLimitExceeded, err)
+	}
+
+	// overriding the policy replace its limits
+	policy.Daily = 0
+	updated, err := testStore.SetTransferLimitTx(context.Background(), policy)
+	if err != nil {
+		t.Fatalf("failed override transfer limit error:%s", err)
+	}
+	if updated.ID != policy.ID || updated.Daily != 0 {
+		t.Fatalf("failed override got %+v", updated)
+	}
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 40})
+	if err != nil {
+		t.Fatalf("failed transfer after override error:%s", err)
+	}
+}
+
+func TestAuthorizeTransferTxLimit(t *testing.T) {
+	var err error
+	product := models.AccountProduct{
+		Name:       "Checking",
+		Currency:   util.RandomCurrency(),
+		AnnualRate: "0",
+		DayCount:   models.DayCountAct365,
+	}
+	product.ID, err = testRepo.InsertAccountProduct(context.Background(), product)
+	if err != nil {
+		t.Fatalf("failed insert account product error:%s", err)
+	}
+	from := createTestProductAccount(t, product, 1000)
+	to := createTestProductAccount(t, product, 0)
+
+	_, err = testStore.SetTransferLimitTx(context.Background(), models.TransferLimit{
+		AccountID: from.ID,
+		Daily:     80,
+		UpdatedBy: "admin",
+	})
+	if err != nil {
+		t.Fatalf("failed set transfer limit error:%s", err)
+	}
+
+	authorize := func(amount int64) (HoldTxResult, error) {
+		return testStore.AuthorizeTransferTx(context.Background(), models.Hold{
+			AccountID:   from.ID,
+			ToAccountID: to.ID,
+			Amount:      amount,
+			ExpiredAt:   time.Now().Add(time.Hour),
+		})
+	}
+
+	// two holds each under the limit can't be authorized when together they go over it
+	first, err := authorize(50)
+	if err != nil {
+		t.Fatalf("failed authorize first hold error:%s", err)
+	}
+	_, err = authorize(50)
+	var limitErr *TransferLimitError
+	if !errors.As(err, &limitErr) || limitErr.Period != models.TransferLimitPeriodDaily || limitErr.Remaining != 30 {
+		t.Fatalf("failed authorize second hold over the limit want daily limit error got %v", err)
+	}
+
+	// the captured hold is still counted, by its transfer
+	_, err = testStore.CaptureHoldTx(context.Background(), first.Hold.ID, 0)
+	if err != nil {
+		t.Fatalf("failed capture hold error:%s", err)
+	}
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 31})
+	if !errors.As(err, &limitErr) || limitErr.Remaining != 30 {
+		t.Fatalf("failed transfer after capture want daily limit error got %v", err)
+	}
+
+	// a voided hold no longer count
+	second, err := authorize(30)
+	if err != nil {
+		t.Fatalf("failed authorize hold within the limit error:%s", err)
+	}
+	_, err = testStore.VoidHoldTx(context.Background(), second.Hold.ID)
+	if err != nil {
+		t.Fatalf("failed void hold error:%s", err)
+	}
+	_, err = testStore.TransferTx(context.Background(), models.Transfer{FromAccountID: from.ID, ToAccountID: to.ID, Amount: 30})
+	if err != nil {
+		t.Fatalf("failed transfer after void error:%s", err)
+	}
+}
+
+func TestTransferReviewTx(t *testing.T) {
+	var err error
+	product := models.AccountProduct{
+		Name:       "Checking",
+		Currency:   util.RandomCurrency(),
+		AnnualRate: "0",
+		DayCount:   models.DayCountAct365,
+	}
+	product.ID, err = testRepo.InsertAccountProduct(context.Background(), product)
+	if err != nil {
+		t.Fatalf("failed insert account product error:%s", err)
+	}
+	from := createTestProductAccount(t, product, 1000)
+	to := createTestProductAccount(t, product, 0)
+
+	review := models.TransferReview{
+		Owner:         from.Owner,
+		FromAccountID: from.ID,
+		ToAccountID:   to.ID,
+		Amount:        100,
+		Findings: []*models.RiskFinding{
+			{Rule: "first_time_payee", Decision: models.RiskDecisionReview, Reason: "first transfer"},
+		},
+	}
+	review.ID, err = testRepo.InsertTransferReview(context.Background(), review)
+	if err != nil {
+		t.Fatalf("failed insert transfer review error:%s", err)
+	}
+
+	// the to account is not a payee of the owner, the review stay pending
+	_, err = testStore.ApproveTransferReviewTx(context.Background(), review.ID, "admin", PayeeRules{RequirePayee: true})
+	var ruleErr *PayeeRuleError
+	if !errors.As(err, &ruleErr) || ruleErr.CoolingOff {
+		t.Fatalf("failed approve transfer to a non payee want payee rule error got %v", err)
+	}
+	pending, err := testRepo.GetTransferReviewByID(context.Background(), review.ID)
+	if err != nil {
+		t.Fatalf("failed get transfer review error:%s", err)
+	}
+	if pending.Status != models.TransferReviewStatusPending {
+		t.Fatalf("failed review refused by the payee rules want %s got %s", models.TransferReviewStatusPending, pending.Status)
+	}
+
+	res, err := testStore.ApproveTransferReviewTx(context.Background(), review.ID, "admin", PayeeRules{})
+	if err != nil {
+		t.Fatalf("failed approve transfer review error:%s", err)
+	}
+	if res.Review.Status != models.TransferReviewStatusApproved || res.Review.TransferID != res.Transfer.ID || res.Review.ReviewedBy != "admin" {
+		t.Fatalf("failed approved review got %+v", res.Review)
+	}
+	if len(res.Review.Findings) != 1 || res.ToAccount.Balance != 100 {
+		t.Fatalf("failed approved transfer got %+v to account %+v", res.Review, res.ToAccount)
+	}
+
+	_, err = testStore.RejectTransferReviewTx(context.Background(), review.ID, "admin")
+	if !errors.Is(err, ErrTransferReviewNotPending) {
+		t.Fatalf("failed reject approved review want %s got %v", ErrTransferReviewNotPending, err)
+	}
+
+	// the to account must still be in the currency the customer asked for
+	converted := review
+	converted.ToCurrency = "JPY"
+	if to.Currency == "JPY" {
+		converted.ToCurrency = "USD"
+	}
+	converted.ID, err = testRepo.InsertTransferReview(context.Background(), converted)
+	if err != nil {
+		t.Fatalf("failed insert transfer review error:%s", err)
+	}
+	_, err = testStore.ApproveTransferReviewTx(context.Background(), converted.ID, "admin", PayeeRules{})
+	if !errors.Is(err, ErrCurrencyMismatch) {
+		t.Fatalf("failed approve review to another currency want %s got %v", ErrCurrencyMismatch, err)
+	}
+}
+
+func TestWebhookEventTx(t *testing.T) {
+	acc1 := createTestAccount(t)
+	acc2 := createTestAccount(t)
+
+	endpoint := models.WebhookEndpoint{
+		Owner:      acc1.Owner,
+		URL:        "https://example.com/webhooks",
+		Secret:     "whsec_test",
+		EventTypes: []string{models.WebhookEventTransferPosted},
+	}
+	var err error
+	endpoint.ID, err = testRepo.InsertWebhookEndpoint(context.Background(), endpoint)
+	if err != nil {
+		t.Fatalf("failed insert webhook endpoint error:%s", err)
+	}
+
+	before := time.Now()
+	res, err := testStore.TransferTx(context.Background(), models.Transfer{
+		FromAccountID: acc1.ID,
+		ToAccountID:   acc2.ID,
+		Amount:        10,
+	})
+	if err != nil {
+		t.Fatalf("failed transfer error:%s", err)
+	}
+
+	// acc1 owner is subscribed to the transfer, acc2 owner has no endpoint
+	deliveries, err := testRepo.GetListWebhookDeliveries(context.Background(), endpoint.ID, "", 0, 10)
+	if err != nil {
+		t.Fatalf("failed get webhook deliveries error:%s", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != models.WebhookDeliveryStatusPending || deliveries[0].NextAttemptAt.Before(before.Add(-time.Second)) {
+		t.Fatalf("failed want one pending delivery got %+v", deliveries)
+	}
+
+	event, err := testRepo.GetWebhookEventByID(context.Background(), deliveries[0].EventID)
+	if err != nil {
+		t.Fatalf("failed get webhook event error:%s", err)
+	}
+	var payload TransferPostedEvent
+	err = json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		t.Fatalf("failed unmarshal webhook event error:%s", err)
+	}
+	if event.Owner != acc1.Owner || event.Type != models.WebhookEventTransferPosted || payload.Transfer.ID != res.Transfer.ID {
+		t.Fatalf("failed wrong webhook event %+v", event)
+	}
+	// the other owner's account isn't sent
+	if len(payload.Accounts) != 1 || payload.Accounts[0].ID != acc1.ID {
+		t.Fatalf("failed wrong webhook event accounts %+v", payload.Accounts)
+	}
+
+	// the endpoint isn't subscribed to the status changes
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), models.AccountStatusHistory{
+		AccountID: acc1.ID,
+		ToStatus:  models.AccountStatusFrozen,
+		ChangedBy: acc1.Owner,
+	})
+	if err != nil {
+		t.Fatalf("failed freeze account error:%s", err)
+	}
+	deliveries, err = testRepo.GetListWebhookDeliveries(context.Background(), endpoint.ID, models.WebhookDeliveryStatusPending, 0, 10)
+	if err != nil {
+		t.Fatalf("failed get webhook deliveries error:%s", err)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("failed want one pending delivery got %d", len(deliveries))
+	}
+
+	delivery := *deliveries[0]
+	delivery.Status = models.WebhookDeliveryStatusDead
+	delivery.Attempts = 10
+	delivery.LastStatusCode = 500
+	delivery.LastError = "endpoint responded 500 Internal Server Error"
+	err = testRepo.UpdateWebhookDeliveryAttempt(context.Background(), delivery)
+	if err != nil {
+		t.Fatalf("failed update webhook delivery error:%s", err)
+	}
+
+	replayed, err := testRepo.ReplayWebhookDelivery(context.Background(), delivery.ID)
+	if err != nil {
+		t.Fatalf("failed replay webhook delivery error:%s", err)
+	}
+	if replayed.Status != models.WebhookDeliveryStatusPending || replayed.Attempts != 0 || replayed.LastError != "" {
+		t.Fatalf("failed wrong replayed delivery %+v", replayed)
+	}
+
+	due, err := testRepo.GetListDueWebhookDeliveries(context.Background(), time.Now().Add(time.Second), 1000)
+	if err != nil {
+		t.Fatalf("failed get due webhook deliveries error:%s", err)
+	}
+	found := false
+	for _, d := range due {
+		found = found || d.ID == delivery.ID
+	}
+	if !found {
+		t.Fatalf("failed replayed delivery %d should be due", delivery.ID)
+	}
+}
+
+// getTestOutboxMessage return the unrelayed outbox message with the payload or nil if there is none. The payload
+// is stored as jsonb, it is compacted before comparing since postgres add spaces to it.
+func getTestOutboxMessage(t *testing.T, payload []byte) *models.OutboxMessage {
+	items, err := testRepo.GetListUnrelayedOutboxMessages(context.Background(), 1000)
+	if err != nil {
+		t.Fatalf("failed get unrelayed outbox messages error:%s", err)
+	}
+	for _, item := range items {
+		var compact bytes.Buffer
+		err = json.Compact(&compact, item.Payload)
+		if err != nil {
+			t.Fatalf("failed compact outbox message %d payload error:%s", item.ID, err)
+		}
+		if compact.String() == string(payload) {
+			return item
+		}
+	}
+	return nil
+}
+
+func TestCreateUserTxOutbox(t *testing.T) {
+	user := models.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	payload := []byte(fmt.Sprintf(`{"username":%q}`, user.Username))
+
+	_, err := testStore.CreateUserTx(context.Background(), user, func(user models.Users) (models.OutboxMessage, error) {
+		return models.OutboxMessage{TaskType: "task:send_verify_email", Payload: payload, Queue: "critical", MaxRetry: 10}, nil
+	})
+	if err != nil {
+		t.Fatalf("failed create user tx error:%s", err)
+	}
+
+	msg := getTestOutboxMessage(t, payload)
+	if msg == nil || msg.TaskType != "task:send_verify_email" || msg.Queue != "critical" || msg.MaxRetry != 10 || !msg.RelayedAt.IsZero() {
+		t.Fatalf("failed want the unrelayed outbox message got %+v", msg)
+	}
+
+	err = testRepo.UpdateOutboxMessageRelayed(context.Background(), msg.ID, time.Now())
+	if err != nil {
+		t.Fatalf("failed update outbox message relayed error:%s", err)
+	}
+	if getTestOutboxMessage(t, payload) != nil {
+		t.Fatalf("failed relayed outbox message %d is still unrelayed", msg.ID)
+	}
+
+	// the user is rolled back when the task can't be built
+	failed := models.Users{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+	_, err = testStore.CreateUserTx(context.Background(), failed, func(user models.Users) (models.OutboxMessage, error) {
+		return models.OutboxMessage{}, fmt.Errorf("some error")
+	})
+	if err == nil {
+		t.Fatalf("failed create user should fail when the task can't be built")
+	}
+	got, err := testRepo.GetUsersByUsername(context.Background(), failed.Username)
+	if err != nil {
+		t.Fatalf("failed get user error:%s", err)
+	}
+	if got.Username != "" {
+		t.Fatalf("failed user %s should be rolled back", failed.Username)
+	}
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+)
+
+// accountStatusTransitions list the statuses an account can move to from its current status,
+// closed is final so it has no transition
+var accountStatusTransitions = map[string][]string{
+	models.AccountStatusActive:  {models.AccountStatusFrozen, models.AccountStatusDormant, models.AccountStatusClosed},
+	models.AccountStatusFrozen:  {models.AccountStatusActive, models.AccountStatusClosed},
+	models.AccountStatusDormant: {models.AccountStatusActive, models.AccountStatusFrozen, models.AccountStatusClosed},
+}
+
+type ChangeAccountStatusTxResult struct {
+	Account models.Account              `json:"account"`
+	History models.AccountStatusHistory `json:"history"`
+}
+
+// ChangeAccountStatusTx move account to arg.ToStatus and record the change with its reason and actor.
+// Only the transitions in accountStatusTransitions are allowed and closing require a zero balance
+// with no held funds, the account row and its ledger history are kept. An owner can only reactivate
+// an account it froze itself, see checkOwnerReactivation.
+func (s *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg models.AccountStatusHistory) (ChangeAccountStatusTxResult, error) {
+	var result ChangeAccountStatusTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		account, err := getAccountForUpdate(ctx, r, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !canChangeAccountStatus(account.Status, arg.ToStatus) {
+			return fmt.Errorf("%w: from %s to %s", ErrInvalidStatusChange, account.Status, arg.ToStatus)
+		}
+
+		if arg.ToStatus == models.AccountStatusActive && arg.ChangedBy == account.Owner {
+			err = checkOwnerReactivation(ctx, r, account)
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.ToStatus == models.AccountStatusClosed && (account.Balance != 0 || account.HeldBalance != 0) {
+			return fmt.Errorf("%w: balance %d, held balance %d", ErrAccountNotEmpty, account.Balance, account.HeldBalance)
+		}
+
+		err = r.UpdateAccountStatus(ctx, account.ID, arg.ToStatus)
+		if err != nil {
+			return err
+		}
+
+		arg.FromStatus = account.Status
+		arg.ID, err = r.InsertAccountStatusHistory(ctx, arg)
+		if err != nil {
+			return err
+		}
+		result.History = arg
+
+		result.Account, err = r.GetAccountByID(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordWebhookEvent(ctx, r, result.Account.Owner, models.WebhookEventAccountStatusChanged, result)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// checkOwnerReactivation return ErrReactivationNotAllowed unless the account was frozen by its owner.
+// A freeze or dormancy set by the bank is lifted by an admin, so the actor of the history mean something.
+// The account must be locked so the history can't change before the reactivation is recorded.
+func checkOwnerReactivation(ctx context.Context, r Repository, account models.Account) error {
+	if account.Status != models.AccountStatusFrozen {
+		return fmt.Errorf("%w: account %d is %s", ErrReactivationNotAllowed, account.ID, account.Status)
+	}
+
+	history, err := r.GetListAccountStatusHistory(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 || history[len(history)-1].ChangedBy != account.Owner {
+		return fmt.Errorf("%w: account %d was frozen by the bank", ErrReactivationNotAllowed, account.ID)
+	}
+
+	return nil
+}
+
+func canChangeAccountStatus(from, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkAccountActive return ErrAccountNotActive when account can't send or receive money
+func checkAccountActive(account models.Account) error {
+	if account.Status != models.AccountStatusActive {
+		return fmt.Errorf("%w: account %d is %s", ErrAccountNotActive, account.ID, account.Status)
+	}
+
+	return nil
+}
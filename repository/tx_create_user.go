@@ -9,7 +9,9 @@ type CreateUserTxResult struct {
 	User models.Users
 }
 
-func (s *SQLStore) CreateUserTx(ctx context.Context, arg models.Users, afterCreate func(user models.Users) error) (CreateUserTxResult, error) {
+// CreateUserTx insert the user and the task returned by newTask to the outbox in the same transaction, the task is
+// enqueued by the outbox relay once the user is committed. The user is not created when newTask fail.
+func (s *SQLStore) CreateUserTx(ctx context.Context, arg models.Users, newTask func(user models.Users) (models.OutboxMessage, error)) (CreateUserTxResult, error) {
 	var result CreateUserTxResult
 
 	err := s.execTx(ctx, func(r Repository) error {
@@ -18,7 +20,11 @@ func (s *SQLStore) CreateUserTx(ctx context.Context, arg models.Users, afterCrea
 			return err
 		}
 
-		err = afterCreate(arg)
+		msg, err := newTask(arg)
+		if err != nil {
+			return err
+		}
+		_, err = r.InsertOutboxMessage(ctx, msg)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+// CrossCurrencyTransferTx debit arg.Amount in the currency of the from account and credit the converted
+// amount in the currency of the to account. The rate effective at the time of the transfer is read inside
+// the transaction and recorded on the transfer row together with its spread, rates are never updated
+// so the quoted rate stay locked for this transfer. Accounts with the same currency transfer as usual.
+func (s *SQLStore) CrossCurrencyTransferTx(ctx context.Context, arg models.Transfer) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		var err error
+		result, err = execCrossCurrencyTransfer(ctx, r, arg)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// execCrossCurrencyTransfer quote the fee, and the rate when the accounts use different currency,
+// then run execTransfer. It must be called inside a transaction.
+func execCrossCurrencyTransfer(ctx context.Context, r Repository, arg models.Transfer) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	fromAccount, err := r.GetAccountByID(ctx, arg.FromAccountID)
+	if err != nil {
+		return result, err
+	}
+	if fromAccount.ID < 1 {
+		return result, fmt.Errorf("%w: account %d", ErrAccountNotFound, arg.FromAccountID)
+	}
+
+	toAccount, err := r.GetAccountByID(ctx, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+	if toAccount.ID < 1 {
+		return result, fmt.Errorf("%w: account %d", ErrAccountNotFound, arg.ToAccountID)
+	}
+
+	// the fee is charged in the currency of the from account on the amount before conversion
+	arg.Fee, err = quoteTransferFee(ctx, r, fromAccount.ID, arg.Amount)
+	if err != nil {
+		return result, err
+	}
+	err = checkTransferLimits(ctx, r, fromAccount.ID, arg.Amount)
+	if err != nil {
+		return result, err
+	}
+
+	if fromAccount.Currency == toAccount.Currency {
+		return execTransfer(ctx, r, arg)
+	}
+
+	fromCurrency, err := getCurrency(ctx, r, fromAccount.Currency)
+	if err != nil {
+		return result, err
+	}
+	toCurrency, err := getCurrency(ctx, r, toAccount.Currency)
+	if err != nil {
+		return result, err
+	}
+
+	rate, err := r.GetFxRateAt(ctx, fromCurrency.Code, toCurrency.Code, time.Now())
+	if err != nil {
+		return result, err
+	}
+	if rate.ID < 1 {
+		return result, fmt.Errorf("%w: %s to %s", ErrFxRateNotFound, fromCurrency.Code, toCurrency.Code)
+	}
+
+	arg.ToAmount, err = util.ConvertAmount(arg.Amount, fromCurrency.MinorUnit, toCurrency.MinorUnit, rate.Rate, rate.SpreadBps)
+	if err != nil {
+		return result, err
+	}
+	if arg.ToAmount < 1 {
+		return result, fmt.Errorf("%w: %d %s is worth nothing in %s", ErrFxAmountTooSmall, arg.Amount, fromCurrency.Code, toCurrency.Code)
+	}
+	arg.FxRate = rate.Rate
+	arg.FxSpreadBps = rate.SpreadBps
+
+	return execTransfer(ctx, r, arg)
+}
+
+func getCurrency(ctx context.Context, r Repository, code string) (models.Currency, error) {
+	currency, err := r.GetCurrency(ctx, code)
+	if err != nil {
+		return currency, err
+	}
+	if currency.Code == "" {
+		return currency, fmt.Errorf("%w: %s", ErrCurrencyNotFound, code)
+	}
+
+	return currency, nil
+}
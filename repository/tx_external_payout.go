@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+type ExternalPayoutTxResult struct {
+	Payout models.ExternalPayout `json:"payout"`
+	TransferTxResult
+}
+
+// CreateExternalPayoutTx debit the payout amount and the transfer fee from the customer account into the
+// ach clearing house account of its currency and queue the payout for the next ach file
+func (s *SQLStore) CreateExternalPayoutTx(ctx context.Context, arg models.ExternalPayout) (ExternalPayoutTxResult, error) {
+	var result ExternalPayoutTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = ExternalPayoutTxResult{}
+		clearing, err := getHouseAccount(ctx, r, models.HouseAccountACHClearing, arg.Currency)
+		if err != nil {
+			return err
+		}
+
+		fee, err := quoteTransferFee(ctx, r, arg.AccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+		err = checkTransferLimits(ctx, r, arg.AccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = execTransfer(ctx, r, models.Transfer{
+			FromAccountID: arg.AccountID,
+			ToAccountID:   clearing.AccountID,
+			Amount:        arg.Amount,
+			Fee:           fee,
+		})
+		if err != nil {
+			return err
+		}
+
+		payout := arg
+		payout.Status = models.PayoutStatusQueued
+		payout.TransferID = result.Transfer.ID
+		payout.ID, err = r.InsertExternalPayout(ctx, payout)
+		if err != nil {
+			return err
+		}
+		result.Payout = payout
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+type CreateACHFileTxResult struct {
+	// empty when no payout is queued
+	File    models.ACHFile           `json:"file"`
+	Payouts []*models.ExternalPayout `json:"payouts"`
+}
+
+// CreateACHFileTx lock up to limit queued payouts of the currency and mark them submitted in a new ach file.
+// render get the number of files already created the same day and fill the name, file id modifier, content
+// and totals of the file and the trace number of every payout, the creation time is set before. The file is only saved in the database, it is written to the
+// output directory after the commit so a failed write can be done again from the saved content.
+func (s *SQLStore) CreateACHFileTx(ctx context.Context, currency string, limit int, render func(file *models.ACHFile, sameDayFiles int, payouts []*models.ExternalPayout) error) (CreateACHFileTxResult, error) {
+	var result CreateACHFileTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = CreateACHFileTxResult{}
+		payouts, err := r.GetListQueuedExternalPayoutsForUpdate(ctx, currency, limit)
+		if err != nil {
+			return err
+		}
+		if len(payouts) == 0 {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		y, m, d := now.Date()
+		sameDay, err := r.CountACHFilesCreatedSince(ctx, time.Date(y, m, d, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			return err
+		}
+
+		file := models.ACHFile{CreatedAt: now}
+		err = render(&file, sameDay, payouts)
+		if err != nil {
+			return err
+		}
+
+		file.ID, err = r.InsertACHFile(ctx, file)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range payouts {
+			if p.TraceNumber == "" {
+				return fmt.Errorf("payout %d has no trace number", p.ID)
+			}
+			err = r.UpdateExternalPayoutSubmitted(ctx, p.ID, file.ID, p.TraceNumber)
+			if err != nil {
+				return err
+			}
+			p.Status = models.PayoutStatusSubmitted
+			p.ACHFileID = file.ID
+		}
+
+		result.File = file
+		result.Payouts = payouts
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+type ReturnExternalPayoutTxResult struct {
+	Payout models.ExternalPayout `json:"payout"`
+	// empty when the payout was already returned
+	TransferTxResult
+}
+
+// ReturnExternalPayoutTx credit the amount of the submitted payout with the trace number back to the customer
+// with a reversal of its transfer from the ach clearing house account, the fee is not refunded.
+// A frozen or dormant account is credited too, the return to a closed account is marked
+// PayoutStatusReturnException and the amount stay in the clearing house account.
+// A payout already returned is left as is so ingesting the same returns file twice doesn't credit twice.
+func (s *SQLStore) ReturnExternalPayoutTx(ctx context.Context, traceNumber, returnCode string) (ReturnExternalPayoutTxResult, error) {
+	var result ReturnExternalPayoutTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = ReturnExternalPayoutTxResult{}
+		payout, err := r.GetExternalPayoutByTraceNumberForUpdate(ctx, traceNumber)
+		if err != nil {
+			return err
+		}
+		if payout.ID < 1 {
+			return fmt.Errorf("%w: trace number %s", ErrExternalPayoutNotFound, traceNumber)
+		}
+		result.Payout = payout
+		if payout.Status == models.PayoutStatusReturned || payout.Status == models.PayoutStatusReturnException {
+			return nil
+		}
+		if payout.Status != models.PayoutStatusSubmitted {
+			return fmt.Errorf("%w: payout %d is %s", ErrExternalPayoutNotSubmitted, payout.ID, payout.Status)
+		}
+
+		clearing, err := getHouseAccount(ctx, r, models.HouseAccountACHClearing, payout.Currency)
+		if err != nil {
+			return err
+		}
+
+		clearingAccount, account, err := lockTransferAccounts(ctx, r, clearing.AccountID, payout.AccountID)
+		if err != nil {
+			return err
+		}
+		err = checkAccountActive(clearingAccount)
+		if err != nil {
+			return err
+		}
+
+		// a closed account can't hold money anymore, the return is left to the bank
+		if account.Status == models.AccountStatusClosed {
+			err = r.UpdateExternalPayoutReturnException(ctx, payout.ID, returnCode)
+			if err != nil {
+				return err
+			}
+			result.Payout.Status = models.PayoutStatusReturnException
+			result.Payout.ReturnCode = returnCode
+			return nil
+		}
+
+		// the money belong to the customer, it is credited back even when the account was frozen or
+		// made dormant since the payout
+		result.TransferTxResult, err = postTransfer(ctx, r, models.Transfer{
+			FromAccountID: clearing.AccountID,
+			ToAccountID:   payout.AccountID,
+			Amount:        payout.Amount,
+			ReversalOf:    payout.TransferID,
+		}, clearingAccount, account)
+		if err != nil {
+			return err
+		}
+
+		err = r.UpdateExternalPayoutReturned(ctx, payout.ID, returnCode, result.Transfer.ID)
+		if err != nil {
+			return err
+		}
+		result.Payout.Status = models.PayoutStatusReturned
+		result.Payout.ReturnCode = returnCode
+		result.Payout.ReturnTransferID = result.Transfer.ID
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+type HoldTxResult struct {
+	Hold    models.Hold    `json:"hold"`
+	Account models.Account `json:"account"`
+}
+
+type CaptureHoldTxResult struct {
+	Hold models.Hold `json:"hold"`
+	TransferTxResult
+}
+
+// AuthorizeTransferTx reserve funds of the from account for a later capture.
+// The money stay in the account but it is not available to spend until the hold is captured, voided or expired.
+// The hold amount is checked against the transfer limits and count in their usage until it is captured, voided or
+// expired, so the limits are not checked again at capture. The funds must also cover the fee charged at capture.
+func (s *SQLStore) AuthorizeTransferTx(ctx context.Context, arg models.Hold) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		// the owner is locked by checkTransferLimits before the account, in the order of TransferTx
+		err := checkTransferLimits(ctx, r, arg.AccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+		fee, err := quoteTransferFee(ctx, r, arg.AccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		account, err := r.GetAccountByIdForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if account.ID < 1 {
+			return ErrAccountNotFound
+		}
+
+		toAccount, err := r.GetAccountByID(ctx, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if toAccount.ID < 1 {
+			return ErrAccountNotFound
+		}
+		if account.Currency != toAccount.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		err = checkAccountActive(account)
+		if err != nil {
+			return err
+		}
+		err = checkAccountActive(toAccount)
+		if err != nil {
+			return err
+		}
+
+		err = checkFunds(account, arg.Amount+fee)
+		if err != nil {
+			return err
+		}
+
+		arg.ID, err = r.InsertHold(ctx, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = r.AddAccountHeldBalanceByID(ctx, arg.Amount, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = r.GetHoldByID(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// CaptureHoldTx settle authorized hold by transferring the amount to the destination account.
+// amount 0 capture the whole hold, a smaller amount capture partially and release the rest.
+// The fee of the from account is charged on the captured amount.
+func (s *SQLStore) CaptureHoldTx(ctx context.Context, id int64, amount int64) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		hold, err := getAuthorizedHoldForUpdate(ctx, r, id)
+		if err != nil {
+			return err
+		}
+		if !time.Now().Before(hold.ExpiredAt) {
+			return ErrHoldExpired
+		}
+
+		// amount is not reassigned, a retried transaction must read the hold amount again
+		captureAmount := amount
+		if captureAmount == 0 {
+			captureAmount = hold.Amount
+		}
+		if captureAmount > hold.Amount {
+			return ErrCaptureExceedsHold
+		}
+
+		fee, err := quoteTransferFee(ctx, r, hold.AccountID, captureAmount)
+		if err != nil {
+			return err
+		}
+
+		// lock both accounts in the order of execTransfer before releasing the hold, updating the from account
+		// first could deadlock with a transfer locking the to account first
+		_, _, err = lockTransferAccounts(ctx, r, hold.AccountID, hold.ToAccountID)
+		if err != nil {
+			return err
+		}
+
+		_, err = r.AddAccountHeldBalanceByID(ctx, -hold.Amount, hold.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = execTransfer(ctx, r, models.Transfer{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        captureAmount,
+			Fee:           fee,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = r.UpdateHoldStatus(ctx, hold.ID, models.HoldStatusCaptured, result.Transfer.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = r.GetHoldByID(ctx, hold.ID)
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// VoidHoldTx cancel authorized hold and release the reserved funds
+func (s *SQLStore) VoidHoldTx(ctx context.Context, id int64) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		hold, err := getAuthorizedHoldForUpdate(ctx, r, id)
+		if err != nil {
+			return err
+		}
+
+		result, err = releaseHold(ctx, r, hold, models.HoldStatusVoided)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// ExpireHoldTx release the reserved funds of authorized hold that already pass its expired time
+func (s *SQLStore) ExpireHoldTx(ctx context.Context, id int64) (HoldTxResult, error) {
+	var result HoldTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		hold, err := getAuthorizedHoldForUpdate(ctx, r, id)
+		if err != nil {
+			return err
+		}
+		if time.Now().Before(hold.ExpiredAt) {
+			return ErrHoldNotExpired
+		}
+
+		result, err = releaseHold(ctx, r, hold, models.HoldStatusExpired)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func getAuthorizedHoldForUpdate(ctx context.Context, r Repository, id int64) (models.Hold, error) {
+	hold, err := r.GetHoldByIDForUpdate(ctx, id)
+	if err != nil {
+		return hold, err
+	}
+	if hold.ID < 1 {
+		return hold, ErrHoldNotFound
+	}
+	if hold.Status != models.HoldStatusAuthorized {
+		return hold, ErrHoldNotAuthorized
+	}
+
+	return hold, nil
+}
+
+func releaseHold(ctx context.Context, r Repository, hold models.Hold, status string) (HoldTxResult, error) {
+	var result HoldTxResult
+	var err error
+
+	result.Account, err = r.AddAccountHeldBalanceByID(ctx, -hold.Amount, hold.AccountID)
+	if err != nil {
+		return result, err
+	}
+
+	err = r.UpdateHoldStatus(ctx, hold.ID, status, 0)
+	if err != nil {
+		return result, err
+	}
+
+	result.Hold, err = r.GetHoldByID(ctx, hold.ID)
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ismail118/simple-bank/models"
+)
+
+type IdempotentTransferTxResult struct {
+	TransferTxResult
+	// Replayed is true when the result is the saved response of an earlier request with the same key
+	Replayed bool `json:"-"`
+}
+
+// IdempotentTransferTx run transfer only once for each idempotency key.
+// The key row is locked for the whole transaction so concurrent requests with the same key
+// wait for the first one and then replay its saved response instead of moving the money again.
+func (s *SQLStore) IdempotentTransferTx(ctx context.Context, key models.IdempotencyKey, arg models.Transfer) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = IdempotentTransferTxResult{}
+		err := r.InsertIdempotencyKey(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		saved, err := r.GetIdempotencyKeyForUpdate(ctx, key.Username, key.IdempotencyKey)
+		if err != nil {
+			return err
+		}
+
+		if saved.RequestHash != key.RequestHash {
+			return ErrIdempotencyKeyReused
+		}
+
+		if saved.Response != nil {
+			err = json.Unmarshal(saved.Response, &result.TransferTxResult)
+			if err != nil {
+				return err
+			}
+			result.Replayed = true
+			return nil
+		}
+
+		// the caller decide whether converting between currencies is allowed before using the key
+		result.TransferTxResult, err = execCrossCurrencyTransfer(ctx, r, arg)
+		if err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result.TransferTxResult)
+		if err != nil {
+			return err
+		}
+
+		return r.UpdateIdempotencyKeyResponse(ctx, key.Username, key.IdempotencyKey, response)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
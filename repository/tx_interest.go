@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+// AccrueInterestTx record the interest the account earn for the day of date from its balance at the end
+// of that day following its account product, so an accrual run late is not computed on a later balance. Each day is accrued once, accruing the same day again return
+// the accrual with ID 0 and nothing is recorded.
+func (s *SQLStore) AccrueInterestTx(ctx context.Context, accountID int64, date time.Time) (models.InterestAccrual, error) {
+	var result models.InterestAccrual
+
+	err := s.execTx(ctx, func(r Repository) error {
+		account, err := r.GetAccountByID(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if account.ID < 1 {
+			return ErrAccountNotFound
+		}
+
+		product, err := getAccountProduct(ctx, r, account.ProductID)
+		if err != nil {
+			return err
+		}
+
+		y, m, d := date.UTC().Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		endOfDay := day.AddDate(0, 0, 1)
+		balance, err := r.GetAccountBalanceAt(ctx, account.ID, endOfDay)
+		if err != nil {
+			return err
+		}
+
+		amount, err := util.AccrueInterest(balance, product.AnnualRate, product.DayCount, day, endOfDay)
+		if err != nil {
+			return err
+		}
+
+		result = models.InterestAccrual{
+			AccountID:    account.ID,
+			AccrualDate:  day,
+			Balance:      balance,
+			AnnualRate:   product.AnnualRate,
+			DayCount:     product.DayCount,
+			AmountMicros: amount,
+		}
+		result.ID, err = r.InsertInterestAccrual(ctx, result)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+type PostInterestTxResult struct {
+	Posting models.InterestPosting `json:"posting"`
+	// empty when the accrued interest is less than one minor unit
+	TransferTxResult
+}
+
+// PostInterestTx credit the interest accrued up to periodEnd to the account with a transfer from the
+// interest expense house account of its currency. Only whole minor units are credited, the fraction
+// is carried to the next posting so no interest is lost to rounding.
+func (s *SQLStore) PostInterestTx(ctx context.Context, accountID int64, periodEnd time.Time) (PostInterestTxResult, error) {
+	var result PostInterestTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = PostInterestTxResult{}
+		last, err := r.GetLastInterestPosting(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if last.ID > 0 && !last.PeriodEnd.Before(periodEnd) {
+			return fmt.Errorf("%w: account %d posted until %s", ErrInterestAlreadyPosted, accountID, last.PeriodEnd.Format("2006-01-02"))
+		}
+
+		accruals, err := r.GetListUnpostedInterestAccruals(ctx, accountID, periodEnd)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]int64, 0, len(accruals))
+		accrued := last.CarryMicros
+		for _, a := range accruals {
+			ids = append(ids, a.ID)
+			accrued += a.AmountMicros
+		}
+
+		posting := models.InterestPosting{
+			AccountID:     accountID,
+			PeriodEnd:     periodEnd,
+			AccruedMicros: accrued,
+			Amount:        accrued / models.InterestMicrosPerMinorUnit,
+			CarryMicros:   accrued % models.InterestMicrosPerMinorUnit,
+		}
+
+		if posting.Amount > 0 {
+			account, err := r.GetAccountByID(ctx, accountID)
+			if err != nil {
+				return err
+			}
+			if account.ID < 1 {
+				return ErrAccountNotFound
+			}
+
+			house, err := getHouseAccount(ctx, r, models.HouseAccountInterestExpense, account.Currency)
+			if err != nil {
+				return err
+			}
+
+			result.TransferTxResult, err = execTransfer(ctx, r, models.Transfer{
+				FromAccountID: house.AccountID,
+				ToAccountID:   account.ID,
+				Amount:        posting.Amount,
+			})
+			if err != nil {
+				return err
+			}
+			posting.TransferID = result.Transfer.ID
+		}
+
+		posting.ID, err = r.InsertInterestPosting(ctx, posting)
+		if err != nil {
+			return err
+		}
+		result.Posting = posting
+
+		return r.UpdateInterestAccrualsPosting(ctx, ids, posting.ID)
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func getAccountProduct(ctx context.Context, r Repository, id int64) (models.AccountProduct, error) {
+	if id < 1 {
+		return models.AccountProduct{}, ErrAccountProductNotFound
+	}
+
+	product, err := r.GetAccountProduct(ctx, id)
+	if err != nil {
+		return product, err
+	}
+	if product.ID < 1 {
+		return product, ErrAccountProductNotFound
+	}
+
+	return product, nil
+}
+
+// getHouseAccount return the bank account for the purpose and currency, it must be set up
+// by the operator before the postings that use it
+func getHouseAccount(ctx context.Context, r Repository, purpose, currency string) (models.HouseAccount, error) {
+	house, err := r.GetHouseAccount(ctx, purpose, currency)
+	if err != nil {
+		return house, err
+	}
+	if house.AccountID < 1 {
+		return house, fmt.Errorf("%w: %s %s", ErrHouseAccountNotFound, purpose, currency)
+	}
+
+	return house, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+type CreatePayeeTxResult struct {
+	Payee models.Payee `json:"payee"`
+}
+
+// CreatePayeeTx insert the payee and the task returned by newTask to the outbox in the same transaction, the task is
+// enqueued by the outbox relay once the payee is committed. The payee is not created when newTask fail.
+func (s *SQLStore) CreatePayeeTx(ctx context.Context, arg models.Payee, newTask func(payee models.Payee) (models.OutboxMessage, error)) (CreatePayeeTxResult, error) {
+	var result CreatePayeeTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		payee := arg
+		var err error
+		payee.ID, err = r.InsertPayee(ctx, payee)
+		if err != nil {
+			return err
+		}
+
+		msg, err := newTask(payee)
+		if err != nil {
+			return err
+		}
+		_, err = r.InsertOutboxMessage(ctx, msg)
+		if err != nil {
+			return err
+		}
+
+		result.Payee = payee
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// PayeeRules is the payee rules of the bank enforced by VerifyPayee
+type PayeeRules struct {
+	// the to account of another user must be a payee of the sender
+	RequirePayee bool
+	// most a payee in its cooling-off period can receive in total by the currency sent, in minor units of the
+	// currency. A currency without limit is not limited.
+	CoolingOffLimit map[string]int64
+}
+
+// PayeeRuleError is returned by VerifyPayee when the payee rules refuse the transfer
+type PayeeRuleError struct {
+	// true when the payee is in its cooling-off period, false when the to account must be added as payee first
+	CoolingOff bool
+	Message    string
+}
+
+func (e *PayeeRuleError) Error() string {
+	return e.Message
+}
+
+// VerifyPayee enforce the payee rules on a transfer from the account to the account of another user: with
+// RequirePayee the to account must be a payee, and a payee in its cooling-off period can't receive more than
+// the limit of the currency sent, counting the transfers and the authorized holds already sent to it from
+// the accounts of the owner in that currency. A refused transfer return a *PayeeRuleError
+func VerifyPayee(ctx context.Context, r Repository, rules PayeeRules, fromAccount, toAccount models.Account, amount int64) error {
+	if toAccount.Owner == fromAccount.Owner {
+		return nil
+	}
+
+	payee, err := r.GetPayeeByOwnerAndAccount(ctx, fromAccount.Owner, toAccount.ID)
+	if err != nil {
+		return err
+	}
+	if payee.ID < 1 {
+		if rules.RequirePayee {
+			return &PayeeRuleError{Message: fmt.Sprintf("account %d must be added as payee first", toAccount.ID)}
+		}
+		return nil
+	}
+
+	limit := rules.CoolingOffLimit[fromAccount.Currency]
+	if limit <= 0 || !time.Now().Before(payee.ActiveAt) {
+		return nil
+	}
+
+	sent, err := r.GetPayeeSentAmount(ctx, fromAccount.Owner, fromAccount.Currency, toAccount.ID, payee.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if amount > limit-sent {
+		return &PayeeRuleError{
+			CoolingOff: true,
+			Message: fmt.Sprintf("payee %d is limited to %d %s until %s, %d already sent",
+				payee.ID, limit, fromAccount.Currency, payee.ActiveAt.UTC().Format(time.RFC3339), sent),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/util"
+)
+
+type ReverseTransferTxResult struct {
+	OriginalTransfer models.Transfer `json:"original_transfer"`
+	TransferTxResult
+}
+
+// ReverseTransferTx send the money of a transfer back with a compensating transfer linked by reversal_of.
+// amount 0 reverse the remaining not reversed amount. The original transfer is locked so concurrent
+// reversals can't reverse more than the original amount.
+func (s *SQLStore) ReverseTransferTx(ctx context.Context, transferID int64, amount int64) (ReverseTransferTxResult, error) {
+	var result ReverseTransferTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		original, err := r.GetTransferByIDForUpdate(ctx, transferID)
+		if err != nil {
+			return err
+		}
+		if original.ID < 1 {
+			return ErrTransferNotFound
+		}
+		if original.ReversalOf > 0 {
+			return ErrReverseReversal
+		}
+
+		reversals, err := r.GetListReversals(ctx, original.ID)
+		if err != nil {
+			return err
+		}
+
+		// amounts are in the currency of the original from account, a reversal credit it back with its ToAmount.
+		// reversedDebit is what already taken back from the original to account in its own currency.
+		var reversed, reversedDebit int64
+		for _, rv := range reversals {
+			reversed += rv.ToAmount
+			reversedDebit += rv.Amount
+		}
+
+		remaining := original.Amount - reversed
+		// amount is not reassigned, a retried transaction must compute the remaining amount again
+		reverseAmount := amount
+		if reverseAmount == 0 {
+			reverseAmount = remaining
+		}
+		if reverseAmount <= 0 || reverseAmount > remaining {
+			return fmt.Errorf("%w: remaining %d, amount %d", ErrReversalExceedsAmount, remaining, reverseAmount)
+		}
+
+		// cross currency transfer is reversed at its original rate, the last reversal take
+		// whatever is left so rounding never leave money behind
+		debit, err := util.ProrateAmount(reverseAmount, original.ToAmount, original.Amount)
+		if err != nil {
+			return err
+		}
+		if reverseAmount == remaining {
+			debit = original.ToAmount - reversedDebit
+		}
+		if debit < 1 {
+			return fmt.Errorf("%w: reversing %d of transfer %d", ErrFxAmountTooSmall, reverseAmount, original.ID)
+		}
+
+		result.OriginalTransfer = original
+		result.TransferTxResult, err = execTransfer(ctx, r, models.Transfer{
+			FromAccountID: original.ToAccountID,
+			ToAccountID:   original.FromAccountID,
+			Amount:        debit,
+			ToAmount:      reverseAmount,
+			FxRate:        original.FxRate,
+			FxSpreadBps:   original.FxSpreadBps,
+			ReversalOf:    original.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
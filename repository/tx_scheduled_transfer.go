@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+type ExecuteScheduledTransferTxResult struct {
+	ScheduledTransfer models.ScheduledTransfer          `json:"scheduled_transfer"`
+	Execution         models.ScheduledTransferExecution `json:"execution"`
+	// empty when the execution failed
+	TransferTxResult
+}
+
+// ExecuteScheduledTransferTx run the scheduled transfer that is due at scheduledAt and move it to its next run.
+// Each run is executed once, calling it again after the schedule moved on return ErrScheduledTransferNotDue.
+// The payee rules are checked again on each run, e.g. the payee may have been deleted since the transfer was scheduled.
+// When the transfer itself fail, e.g. insufficient funds, it is rolled back and the failure is recorded
+// in another transaction following the failure policy. The returned error is nil in that case,
+// the caller check result.Execution.Status. The task returned by newFailedTask, e.g. the email telling the owner,
+// is inserted to the outbox in the transaction recording the failure.
+func (s *SQLStore) ExecuteScheduledTransferTx(ctx context.Context, id int64, scheduledAt time.Time, rules PayeeRules, newFailedTask func(execution models.ScheduledTransferExecution) (models.OutboxMessage, error)) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		st, err := getDueScheduledTransferForUpdate(ctx, r, id, scheduledAt)
+		if err != nil {
+			return err
+		}
+
+		fromAccount, err := r.GetAccountByID(ctx, st.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if fromAccount.ID < 1 {
+			return fmt.Errorf("%w: account %d", ErrAccountNotFound, st.FromAccountID)
+		}
+		toAccount, err := r.GetAccountByID(ctx, st.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if toAccount.ID < 1 {
+			return fmt.Errorf("%w: account %d", ErrAccountNotFound, st.ToAccountID)
+		}
+		err = VerifyPayee(ctx, r, rules, fromAccount, toAccount, st.Amount)
+		if err != nil {
+			return err
+		}
+
+		fee, err := quoteTransferFee(ctx, r, st.FromAccountID, st.Amount)
+		if err != nil {
+			return err
+		}
+		err = checkTransferLimits(ctx, r, st.FromAccountID, st.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = execTransfer(ctx, r, models.Transfer{
+			FromAccountID: st.FromAccountID,
+			ToAccountID:   st.ToAccountID,
+			Amount:        st.Amount,
+			Fee:           fee,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ScheduledTransfer, result.Execution, err = recordScheduledTransferExecution(ctx, r, st, models.ScheduledTransferExecution{
+			ScheduledAt: scheduledAt,
+			Status:      models.ExecutionStatusSucceeded,
+			TransferID:  result.Transfer.ID,
+		})
+		return err
+	})
+	if err == nil {
+		return result, nil
+	}
+	if errors.Is(err, ErrScheduledTransferNotFound) || errors.Is(err, ErrScheduledTransferNotDue) ||
+		isRetryableTxError(err) || ctx.Err() != nil {
+		return result, err
+	}
+
+	transferErr := err
+	result = ExecuteScheduledTransferTxResult{}
+
+	err = s.execTx(ctx, func(r Repository) error {
+		st, err := getDueScheduledTransferForUpdate(ctx, r, id, scheduledAt)
+		if err != nil {
+			return err
+		}
+
+		result.ScheduledTransfer, result.Execution, err = recordScheduledTransferExecution(ctx, r, st, models.ScheduledTransferExecution{
+			ScheduledAt: scheduledAt,
+			Status:      models.ExecutionStatusFailed,
+			Error:       transferErr.Error(),
+		})
+		if err != nil {
+			return err
+		}
+
+		msg, err := newFailedTask(result.Execution)
+		if err != nil {
+			return err
+		}
+		_, err = r.InsertOutboxMessage(ctx, msg)
+		return err
+	})
+	if err != nil {
+		return result, fmt.Errorf("transfer error: %s, record failure error: %w", transferErr, err)
+	}
+
+	return result, nil
+}
+
+func getDueScheduledTransferForUpdate(ctx context.Context, r Repository, id int64, scheduledAt time.Time) (models.ScheduledTransfer, error) {
+	st, err := r.GetScheduledTransferByIDForUpdate(ctx, id)
+	if err != nil {
+		return st, err
+	}
+	if st.ID < 1 {
+		return st, ErrScheduledTransferNotFound
+	}
+	if st.Status != models.ScheduledTransferStatusActive || !st.NextRunAt.Equal(scheduledAt) {
+		return st, fmt.Errorf("%w: scheduled transfer %d is %s with next run at %s", ErrScheduledTransferNotDue, st.ID, st.Status, st.NextRunAt)
+	}
+
+	return st, nil
+}
+
+// recordScheduledTransferExecution insert the execution and move the schedule to its next run,
+// the runs missed while the worker was down are not executed
+func recordScheduledTransferExecution(ctx context.Context, r Repository, st models.ScheduledTransfer, execution models.ScheduledTransferExecution) (models.ScheduledTransfer, models.ScheduledTransferExecution, error) {
+	var err error
+
+	execution.ScheduledTransferID = st.ID
+	execution.ID, err = r.InsertScheduledTransferExecution(ctx, execution)
+	if err != nil {
+		return st, execution, err
+	}
+
+	after := time.Now()
+	if execution.ScheduledAt.After(after) {
+		after = execution.ScheduledAt
+	}
+	st.NextRunAt, err = util.NextCronTime(st.CronSpec, after)
+	if err != nil {
+		return st, execution, err
+	}
+
+	if execution.Status == models.ExecutionStatusFailed && st.FailurePolicy == models.FailurePolicyPause {
+		st.Status = models.ScheduledTransferStatusPaused
+	}
+	if !st.EndAt.IsZero() && st.NextRunAt.After(st.EndAt) {
+		st.Status = models.ScheduledTransferStatusCompleted
+	}
+
+	err = r.UpdateScheduledTransfer(ctx, st)
+	if err != nil {
+		return st, execution, err
+	}
+
+	return st, execution, nil
+}
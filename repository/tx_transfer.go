@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/util"
 )
 
 type TransferTxResult struct {
@@ -11,69 +13,250 @@ type TransferTxResult struct {
 	ToAccount   models.Account  `json:"to_account"`
 	FromEntry   models.Entry    `json:"from_entry"`
 	ToEntry     models.Entry    `json:"to_entry"`
+	// fee debited from the from account and credited to the fee revenue house account,
+	// both empty when the transfer is free
+	FeeEntry        models.Entry `json:"fee_entry"`
+	FeeRevenueEntry models.Entry `json:"fee_revenue_entry"`
 }
 
+// TransferTx move the money and charge the fee of the from account fee schedule in one transaction,
+// a transfer over the limits of the from account fail with a *TransferLimitError
 func (s *SQLStore) TransferTx(ctx context.Context, arg models.Transfer) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := s.execTx(ctx, func(r Repository) error {
-		newID, err := s.InsertTransfer(ctx, arg)
+		var err error
+		arg.Fee, err = quoteTransferFee(ctx, r, arg.FromAccountID, arg.Amount)
 		if err != nil {
 			return err
 		}
-		arg.ID = newID
+		err = checkTransferLimits(ctx, r, arg.FromAccountID, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		result, err = execTransfer(ctx, r, arg)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// execTransfer insert the transfer with its entries, move the money between accounts and record the
+// transfer.posted webhook events using the given repository, so it must be called inside a transaction.
+// arg.Fee is charged on top of the amount, see quoteTransferFee.
+func execTransfer(ctx context.Context, r Repository, arg models.Transfer) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	fromAccount, toAccount, err := lockTransferAccounts(ctx, r, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+
+	err = checkAccountActive(fromAccount)
+	if err != nil {
+		return result, err
+	}
+	err = checkAccountActive(toAccount)
+	if err != nil {
+		return result, err
+	}
+
+	return postTransfer(ctx, r, arg, fromAccount, toAccount)
+}
+
+// postTransfer is execTransfer without the status checks, the caller lock the accounts with
+// lockTransferAccounts and check their status first
+func postTransfer(ctx context.Context, r Repository, arg models.Transfer, fromAccount, toAccount models.Account) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := checkFunds(fromAccount, arg.Amount+arg.Fee)
+	if err != nil {
+		return result, err
+	}
+
+	// accounts in different currency can only be used with a quoted rate, see execCrossCurrencyTransfer
+	if fromAccount.Currency != toAccount.Currency && arg.FxRate == "" {
+		return result, fmt.Errorf("%w: account %d is %s, account %d is %s",
+			ErrCurrencyMismatch, fromAccount.ID, fromAccount.Currency, toAccount.ID, toAccount.Currency)
+	}
+	if arg.ToAmount == 0 {
+		arg.ToAmount = arg.Amount
+	}
+
+	var feeAccount models.HouseAccount
+	if arg.Fee > 0 {
+		feeAccount, err = getHouseAccount(ctx, r, models.HouseAccountFeeRevenue, fromAccount.Currency)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	newID, err := r.InsertTransfer(ctx, arg)
+	if err != nil {
+		return result, err
+	}
+	arg.ID = newID
+
+	result.Transfer = arg
+
+	fEntry := models.Entry{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: arg.ID,
+	}
+	newID, err = r.InsertEntry(ctx, fEntry)
+	if err != nil {
+		return result, err
+	}
+	fEntry.ID = newID
+
+	result.FromEntry = fEntry
+
+	tEntry := models.Entry{
+		AccountID:  arg.ToAccountID,
+		Amount:     arg.ToAmount,
+		TransferID: arg.ID,
+	}
+	newID, err = r.InsertEntry(ctx, tEntry)
+	if err != nil {
+		return result, err
+	}
+	tEntry.ID = newID
 
-		result.Transfer = arg
+	result.ToEntry = tEntry
 
-		fEntry := models.Entry{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+	if arg.Fee > 0 {
+		result.FeeEntry = models.Entry{
+			AccountID:  arg.FromAccountID,
+			Amount:     -arg.Fee,
+			TransferID: arg.ID,
 		}
-		newID, err = s.InsertEntry(ctx, fEntry)
+		result.FeeEntry.ID, err = r.InsertEntry(ctx, result.FeeEntry)
 		if err != nil {
-			return err
+			return result, err
 		}
-		fEntry.ID = newID
 
-		result.FromEntry = fEntry
+		result.FeeRevenueEntry = models.Entry{
+			AccountID:  feeAccount.AccountID,
+			Amount:     arg.Fee,
+			TransferID: arg.ID,
+		}
+		result.FeeRevenueEntry.ID, err = r.InsertEntry(ctx, result.FeeRevenueEntry)
+		if err != nil {
+			return result, err
+		}
+	}
 
-		tEntry := models.Entry{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+	// keep the same order used to lock the accounts
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, err = r.AddAccountBalanceByID(ctx, -(arg.Amount + arg.Fee), arg.FromAccountID)
+		if err != nil {
+			return result, err
 		}
-		newID, err = s.InsertEntry(ctx, tEntry)
+		result.ToAccount, err = r.AddAccountBalanceByID(ctx, arg.ToAmount, arg.ToAccountID)
 		if err != nil {
-			return err
+			return result, err
+		}
+	} else {
+		result.ToAccount, err = r.AddAccountBalanceByID(ctx, arg.ToAmount, arg.ToAccountID)
+		if err != nil {
+			return result, err
 		}
-		tEntry.ID = newID
-
-		result.ToEntry = tEntry
-
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, err = s.AddAccountBalanceByID(context.Background(), -arg.Amount, arg.FromAccountID)
-			if err != nil {
-				return err
-			}
-			result.ToAccount, err = s.AddAccountBalanceByID(context.Background(), arg.Amount, arg.ToAccountID)
-			if err != nil {
-				return err
-			}
-		} else {
-			result.ToAccount, err = s.AddAccountBalanceByID(context.Background(), arg.Amount, arg.ToAccountID)
-			if err != nil {
-				return err
-			}
-			result.FromAccount, err = s.AddAccountBalanceByID(context.Background(), -arg.Amount, arg.FromAccountID)
-			if err != nil {
-				return err
-			}
+		result.FromAccount, err = r.AddAccountBalanceByID(ctx, -(arg.Amount + arg.Fee), arg.FromAccountID)
+		if err != nil {
+			return result, err
 		}
+	}
 
-		return nil
-	})
+	// the house account is always updated last, it is not locked up front because every
+	// transfer with fee would wait on it
+	if arg.Fee > 0 {
+		_, err = r.AddAccountBalanceByID(ctx, arg.Fee, feeAccount.AccountID)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	err = recordTransferPosted(ctx, r, result, fromAccount.Owner, toAccount.Owner)
 	if err != nil {
 		return result, err
 	}
 
 	return result, nil
 }
+
+// quoteTransferFee return the fee of the fee schedule that apply to the from account, it is 0 when
+// no schedule is configured for its currency. Only transfers requested by the customer are charged,
+// system transfers like reversals and interest postings are free.
+func quoteTransferFee(ctx context.Context, r Repository, fromAccountID int64, amount int64) (int64, error) {
+	account, err := r.GetAccountByID(ctx, fromAccountID)
+	if err != nil {
+		return 0, err
+	}
+	if account.ID < 1 {
+		return 0, fmt.Errorf("%w: account %d", ErrAccountNotFound, fromAccountID)
+	}
+
+	schedule, err := r.GetFeeSchedule(ctx, account.Currency, account.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	if schedule.ID < 1 {
+		return 0, nil
+	}
+
+	return util.TransferFee(schedule, amount)
+}
+
+// lockTransferAccounts lock both accounts of a transfer with the smaller id first,
+// so concurrent transfers between the same accounts in opposite direction can't deadlock
+func lockTransferAccounts(ctx context.Context, r Repository, fromAccountID, toAccountID int64) (models.Account, models.Account, error) {
+	var fromAccount, toAccount models.Account
+	var err error
+
+	if fromAccountID < toAccountID {
+		fromAccount, err = getAccountForUpdate(ctx, r, fromAccountID)
+		if err != nil {
+			return fromAccount, toAccount, err
+		}
+		toAccount, err = getAccountForUpdate(ctx, r, toAccountID)
+	} else {
+		toAccount, err = getAccountForUpdate(ctx, r, toAccountID)
+		if err != nil {
+			return fromAccount, toAccount, err
+		}
+		fromAccount, err = getAccountForUpdate(ctx, r, fromAccountID)
+	}
+	if err != nil {
+		return fromAccount, toAccount, err
+	}
+
+	return fromAccount, toAccount, nil
+}
+
+func getAccountForUpdate(ctx context.Context, r Repository, id int64) (models.Account, error) {
+	account, err := r.GetAccountByIdForUpdate(ctx, id)
+	if err != nil {
+		return account, err
+	}
+	if account.ID < 1 {
+		return account, fmt.Errorf("%w: account %d", ErrAccountNotFound, id)
+	}
+
+	return account, nil
+}
+
+// checkFunds return ErrInsufficientFunds when spending amount would take
+// the available balance of account below its overdraft limit
+func checkFunds(account models.Account, amount int64) error {
+	if account.AvailableBalance-amount < -account.OverdraftLimit {
+		return fmt.Errorf("%w: account %d available balance %d, overdraft limit %d, amount %d",
+			ErrInsufficientFunds, account.ID, account.AvailableBalance, account.OverdraftLimit, amount)
+	}
+
+	return nil
+}
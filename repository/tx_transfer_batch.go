@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+)
+
+type TransferBatchTxResult struct {
+	Batch models.TransferBatch        `json:"batch"`
+	Items []*models.TransferBatchItem `json:"items"`
+}
+
+// AtomicTransferBatchTx run all the transfers of the batch in one transaction, the batch is completed
+// only when every transfer succeed. When one of them fail, e.g. insufficient funds, all of them are
+// rolled back and the failed batch is recorded in another transaction with the error of the failing item.
+// The returned error is nil in that case, the caller check result.Batch.Status.
+func (s *SQLStore) AtomicTransferBatchTx(ctx context.Context, batch models.TransferBatch, items []models.TransferBatchItem) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+	failedIndex := -1
+
+	err := s.execTx(ctx, func(r Repository) error {
+		failedIndex = -1
+		batch.Mode = models.BatchModeAtomic
+		batch.Status = models.BatchStatusCompleted
+
+		for i := range items {
+			fee, err := quoteTransferFee(ctx, r, items[i].FromAccountID, items[i].Amount)
+			if err != nil {
+				failedIndex = i
+				return err
+			}
+			err = checkTransferLimits(ctx, r, items[i].FromAccountID, items[i].Amount)
+			if err != nil {
+				failedIndex = i
+				return err
+			}
+
+			res, err := execTransfer(ctx, r, models.Transfer{
+				FromAccountID: items[i].FromAccountID,
+				ToAccountID:   items[i].ToAccountID,
+				Amount:        items[i].Amount,
+				Fee:           fee,
+			})
+			if err != nil {
+				failedIndex = i
+				return err
+			}
+			items[i].Status = models.BatchItemStatusSucceeded
+			items[i].TransferID = res.Transfer.ID
+		}
+
+		var err error
+		result, err = insertTransferBatch(ctx, r, batch, items)
+		return err
+	})
+	if err == nil {
+		return result, nil
+	}
+	if failedIndex < 0 || isRetryableTxError(err) || ctx.Err() != nil {
+		return result, err
+	}
+
+	transferErr := err
+	batch.Status = models.BatchStatusFailed
+	for i := range items {
+		items[i].TransferID = 0
+		items[i].Status = models.BatchItemStatusFailed
+		items[i].Error = "not executed, batch rolled back"
+	}
+	items[failedIndex].Error = transferErr.Error()
+
+	err = s.execTx(ctx, func(r Repository) error {
+		var err error
+		result, err = insertTransferBatch(ctx, r, batch, items)
+		return err
+	})
+	if err != nil {
+		return result, fmt.Errorf("transfer error: %s, record failure error: %w", transferErr, err)
+	}
+
+	return result, nil
+}
+
+// CreateTransferBatchTx save the batch and its items as pending, they are executed one by one
+// later with ExecuteTransferBatchItemTx. The task returned by newTask is inserted to the outbox in the same
+// transaction, it is enqueued by the outbox relay once the batch is committed.
+func (s *SQLStore) CreateTransferBatchTx(ctx context.Context, batch models.TransferBatch, items []models.TransferBatchItem, newTask func(batch models.TransferBatch) (models.OutboxMessage, error)) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		batch.Mode = models.BatchModeBestEffort
+		batch.Status = models.BatchStatusPending
+		for i := range items {
+			items[i].Status = models.BatchItemStatusPending
+		}
+
+		var err error
+		result, err = insertTransferBatch(ctx, r, batch, items)
+		if err != nil {
+			return err
+		}
+
+		msg, err := newTask(result.Batch)
+		if err != nil {
+			return err
+		}
+		_, err = r.InsertOutboxMessage(ctx, msg)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+type ExecuteTransferBatchItemTxResult struct {
+	Item models.TransferBatchItem `json:"item"`
+	// empty when the transfer failed or the item was already executed
+	TransferTxResult
+}
+
+// ExecuteTransferBatchItemTx run the transfer of a pending batch item. An item that is not pending anymore
+// is returned as it is, so executing it again is safe. When the transfer fail it is rolled back
+// and the failure is recorded in another transaction, the returned error is nil in that case.
+func (s *SQLStore) ExecuteTransferBatchItemTx(ctx context.Context, itemID int64) (ExecuteTransferBatchItemTxResult, error) {
+	var result ExecuteTransferBatchItemTxResult
+	executed := false
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result, executed = ExecuteTransferBatchItemTxResult{}, false
+		item, err := getTransferBatchItemForUpdate(ctx, r, itemID)
+		if err != nil {
+			return err
+		}
+		result.Item = item
+		if item.Status != models.BatchItemStatusPending {
+			return nil
+		}
+
+		executed = true
+		fee, err := quoteTransferFee(ctx, r, item.FromAccountID, item.Amount)
+		if err != nil {
+			return err
+		}
+		err = checkTransferLimits(ctx, r, item.FromAccountID, item.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = execTransfer(ctx, r, models.Transfer{
+			FromAccountID: item.FromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+			Fee:           fee,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Item.Status = models.BatchItemStatusSucceeded
+		result.Item.TransferID = result.Transfer.ID
+		return r.UpdateTransferBatchItemResult(ctx, item.ID, result.Item.Status, result.Item.TransferID, "")
+	})
+	if err == nil {
+		return result, nil
+	}
+	if !executed || isRetryableTxError(err) || ctx.Err() != nil {
+		return result, err
+	}
+
+	transferErr := err
+	result = ExecuteTransferBatchItemTxResult{}
+
+	err = s.execTx(ctx, func(r Repository) error {
+		item, err := getTransferBatchItemForUpdate(ctx, r, itemID)
+		if err != nil {
+			return err
+		}
+
+		item.Status = models.BatchItemStatusFailed
+		item.Error = transferErr.Error()
+		result.Item = item
+		return r.UpdateTransferBatchItemResult(ctx, item.ID, item.Status, 0, item.Error)
+	})
+	if err != nil {
+		return result, fmt.Errorf("transfer error: %s, record failure error: %w", transferErr, err)
+	}
+
+	return result, nil
+}
+
+func getTransferBatchItemForUpdate(ctx context.Context, r Repository, id int64) (models.TransferBatchItem, error) {
+	item, err := r.GetTransferBatchItemByIDForUpdate(ctx, id)
+	if err != nil {
+		return item, err
+	}
+	if item.ID < 1 {
+		return item, ErrTransferBatchItemNotFound
+	}
+
+	return item, nil
+}
+
+// insertTransferBatch insert the batch and its items in the submitted order
+func insertTransferBatch(ctx context.Context, r Repository, batch models.TransferBatch, items []models.TransferBatchItem) (TransferBatchTxResult, error) {
+	var result TransferBatchTxResult
+	var err error
+
+	batch.TotalItems = len(items)
+	batch.ID, err = r.InsertTransferBatch(ctx, batch)
+	if err != nil {
+		return result, err
+	}
+
+	result.Batch, err = r.GetTransferBatchByID(ctx, batch.ID)
+	if err != nil {
+		return result, err
+	}
+
+	result.Items = make([]*models.TransferBatchItem, 0, len(items))
+	for i := range items {
+		item := items[i]
+		item.BatchID = batch.ID
+		item.ItemIndex = i
+		item.ID, err = r.InsertTransferBatchItem(ctx, item)
+		if err != nil {
+			return result, err
+		}
+		result.Items = append(result.Items, &item)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+// SetTransferLimitTx create the policy of the scope of arg or replace the limits of the existing one.
+// The currency of an account policy is the currency of the account.
+func (s *SQLStore) SetTransferLimitTx(ctx context.Context, arg models.TransferLimit) (models.TransferLimit, error) {
+	var result models.TransferLimit
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = models.TransferLimit{}
+		policy := arg
+
+		if policy.AccountID > 0 {
+			account, err := r.GetAccountByID(ctx, policy.AccountID)
+			if err != nil {
+				return err
+			}
+			if account.ID < 1 {
+				return fmt.Errorf("%w: account %d", ErrAccountNotFound, policy.AccountID)
+			}
+			policy.Owner = ""
+			policy.Currency = account.Currency
+		}
+		_, err := getCurrency(ctx, r, policy.Currency)
+		if err != nil {
+			return err
+		}
+
+		saved, err := r.GetTransferLimitForUpdate(ctx, policy.Owner, policy.Currency, policy.AccountID)
+		if err != nil {
+			return err
+		}
+		if saved.ID > 0 {
+			policy.ID = saved.ID
+			err = r.UpdateTransferLimit(ctx, policy)
+		} else {
+			policy.ID, err = r.InsertTransferLimit(ctx, policy)
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err = r.GetTransferLimitByID(ctx, policy.ID)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// checkTransferLimits return a *TransferLimitError when sending amount from the account would exceed the policy
+// of its owner or its own policy, see util.ResolveTransferLimits. Like quoteTransferFee it is only called for
+// transfers requested by the customer. The owner is locked before summing its transfers so concurrent transfers
+// from any of its accounts are checked one after the other.
+func checkTransferLimits(ctx context.Context, r Repository, fromAccountID int64, amount int64) error {
+	account, err := r.GetAccountByID(ctx, fromAccountID)
+	if err != nil {
+		return err
+	}
+	if account.ID < 1 {
+		return fmt.Errorf("%w: account %d", ErrAccountNotFound, fromAccountID)
+	}
+
+	policies, err := r.GetTransferLimitsOfAccount(ctx, account.Owner, account.Currency, account.ID)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	_, err = r.GetUsersByUsernameForUpdate(ctx, account.Owner)
+	if err != nil {
+		return err
+	}
+
+	ownerLimit, accountLimit := util.ResolveTransferLimits(policies)
+	now := time.Now()
+	for _, l := range []models.TransferLimit{ownerLimit, accountLimit} {
+		if l.ID < 1 {
+			continue
+		}
+
+		usage, err := r.GetTransferLimitUsage(ctx, account.Owner, account.Currency, l.AccountID,
+			now.Add(-util.TransferLimitDay), now.Add(-util.TransferLimitMonth))
+		if err != nil {
+			return err
+		}
+
+		period, remaining := util.TransferLimitAllowance(l, usage, amount)
+		if period == "" {
+			continue
+		}
+
+		limit := l.PerTransaction
+		switch period {
+		case models.TransferLimitPeriodDaily:
+			limit = l.Daily
+		case models.TransferLimitPeriodMonthly:
+			limit = l.Monthly
+		}
+		return &TransferLimitError{
+			LimitID:   l.ID,
+			Scope:     l.Scope(),
+			Period:    period,
+			Limit:     limit,
+			Remaining: remaining,
+			Currency:  account.Currency,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+)
+
+type TransferReviewTxResult struct {
+	Review models.TransferReview `json:"review"`
+	// empty when the review is rejected
+	TransferTxResult
+}
+
+// ApproveTransferReviewTx execute the transfer parked by the risk rules and mark the review approved in one
+// transaction. The transfer is checked like a new one, including the payee rules and the currency the customer
+// asked for, since the payee may have been deleted while the transfer waited. When it fail, e.g. insufficient
+// funds, the review stay pending so the admin can reject it.
+func (s *SQLStore) ApproveTransferReviewTx(ctx context.Context, id int64, reviewedBy string, rules PayeeRules) (TransferReviewTxResult, error) {
+	var result TransferReviewTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = TransferReviewTxResult{}
+		review, err := getPendingTransferReviewForUpdate(ctx, r, id)
+		if err != nil {
+			return err
+		}
+
+		fromAccount, err := r.GetAccountByID(ctx, review.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if fromAccount.ID < 1 {
+			return fmt.Errorf("%w: account %d", ErrAccountNotFound, review.FromAccountID)
+		}
+		toAccount, err := r.GetAccountByID(ctx, review.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if toAccount.ID < 1 {
+			return fmt.Errorf("%w: account %d", ErrAccountNotFound, review.ToAccountID)
+		}
+
+		// converting to another currency must have been asked with the currency of the to account
+		toCurrency := review.ToCurrency
+		if toCurrency == "" {
+			toCurrency = fromAccount.Currency
+		}
+		if toAccount.Currency != toCurrency {
+			return fmt.Errorf("%w: account %d is %s, review %d is to %s",
+				ErrCurrencyMismatch, toAccount.ID, toAccount.Currency, review.ID, toCurrency)
+		}
+
+		err = VerifyPayee(ctx, r, rules, fromAccount, toAccount, review.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = execCrossCurrencyTransfer(ctx, r, models.Transfer{
+			FromAccountID: review.FromAccountID,
+			ToAccountID:   review.ToAccountID,
+			Amount:        review.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = r.UpdateTransferReviewStatus(ctx, review.ID, models.TransferReviewStatusApproved, reviewedBy, result.Transfer.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Review, err = r.GetTransferReviewByID(ctx, review.ID)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// RejectTransferReviewTx mark a pending review rejected, no money move
+func (s *SQLStore) RejectTransferReviewTx(ctx context.Context, id int64, reviewedBy string) (TransferReviewTxResult, error) {
+	var result TransferReviewTxResult
+
+	err := s.execTx(ctx, func(r Repository) error {
+		result = TransferReviewTxResult{}
+		review, err := getPendingTransferReviewForUpdate(ctx, r, id)
+		if err != nil {
+			return err
+		}
+
+		err = r.UpdateTransferReviewStatus(ctx, review.ID, models.TransferReviewStatusRejected, reviewedBy, 0)
+		if err != nil {
+			return err
+		}
+
+		result.Review, err = r.GetTransferReviewByID(ctx, review.ID)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+func getPendingTransferReviewForUpdate(ctx context.Context, r Repository, id int64) (models.TransferReview, error) {
+	review, err := r.GetTransferReviewByIDForUpdate(ctx, id)
+	if err != nil {
+		return review, err
+	}
+	if review.ID < 1 {
+		return review, fmt.Errorf("%w: review %d", ErrTransferReviewNotFound, id)
+	}
+	if review.Status != models.TransferReviewStatusPending {
+		return review, fmt.Errorf("%w: review %d is %s", ErrTransferReviewNotPending, review.ID, review.Status)
+	}
+
+	return review, nil
+}
@@ -15,18 +15,18 @@ func (s *SQLStore) VerifyEmailTx(ctx context.Context, id int64, secretCode strin
 	var result VerifyEmailTxResult
 
 	err := s.execTx(ctx, func(r Repository) error {
-		err := s.UpdateVerifyEmailIsUsed(ctx, id, secretCode)
+		err := r.UpdateVerifyEmailIsUsed(ctx, id, secretCode)
 		if err != nil {
 			return err
 		}
 
-		verifyEmail, err := s.GetVerifyEmailByID(ctx, id)
+		verifyEmail, err := r.GetVerifyEmailByID(ctx, id)
 		if err != nil {
 			return err
 		}
 		result.VerifyEmail = verifyEmail
 
-		err = s.UpdateUsers(ctx, UpdateUserParam{
+		err = r.UpdateUsers(ctx, UpdateUserParam{
 			Username: verifyEmail.Username,
 			IsEmailVerified: sql.NullBool{
 				Bool:  true,
@@ -37,12 +37,12 @@ func (s *SQLStore) VerifyEmailTx(ctx context.Context, id int64, secretCode strin
 			return err
 		}
 
-		result.User, err = s.GetUsersByUsername(ctx, verifyEmail.Username)
+		result.User, err = r.GetUsersByUsername(ctx, verifyEmail.Username)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return recordWebhookEvent(ctx, r, result.User.Username, models.WebhookEventEmailVerified, result.User)
 	})
 	if err != nil {
 		return result, err
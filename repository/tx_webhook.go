@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ismail118/simple-bank/models"
+)
+
+// TransferPostedEvent is the payload of the transfer.posted webhook event, the accounts and entries are only
+// the ones of the owner receiving the event
+type TransferPostedEvent struct {
+	Transfer models.Transfer  `json:"transfer"`
+	Accounts []models.Account `json:"accounts"`
+	Entries  []models.Entry   `json:"entries"`
+}
+
+// recordWebhookEvent write the event to the outbox with a pending delivery to each endpoint of the owner
+// subscribed to the event type. It use the given repository so it must be called inside the transaction of
+// the change the event describe, nothing is written when no endpoint is subscribed.
+func recordWebhookEvent(ctx context.Context, r Repository, owner, eventType string, data interface{}) error {
+	endpoints, err := r.GetWebhookEndpointsForEvent(ctx, owner, eventType)
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	eventID, err := r.InsertWebhookEvent(ctx, models.WebhookEvent{
+		Owner:   owner,
+		Type:    eventType,
+		Payload: payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		_, err = r.InsertWebhookDelivery(ctx, models.WebhookDelivery{
+			EventID:    eventID,
+			EndpointID: endpoint.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recordTransferPosted record the transfer.posted event of the owner of each account of the transfer,
+// a transfer between accounts of the same owner is one event
+func recordTransferPosted(ctx context.Context, r Repository, result TransferTxResult, fromOwner, toOwner string) error {
+	fromEvent := TransferPostedEvent{
+		Transfer: result.Transfer,
+		Accounts: []models.Account{result.FromAccount},
+		Entries:  []models.Entry{result.FromEntry},
+	}
+	if result.FeeEntry.ID > 0 {
+		fromEvent.Entries = append(fromEvent.Entries, result.FeeEntry)
+	}
+
+	if toOwner == fromOwner {
+		fromEvent.Accounts = append(fromEvent.Accounts, result.ToAccount)
+		fromEvent.Entries = append(fromEvent.Entries, result.ToEntry)
+		return recordWebhookEvent(ctx, r, fromOwner, models.WebhookEventTransferPosted, fromEvent)
+	}
+
+	err := recordWebhookEvent(ctx, r, fromOwner, models.WebhookEventTransferPosted, fromEvent)
+	if err != nil {
+		return err
+	}
+
+	toEvent := TransferPostedEvent{
+		Transfer: result.Transfer,
+		Accounts: []models.Account{result.ToAccount},
+		Entries:  []models.Entry{result.ToEntry},
+	}
+	return recordWebhookEvent(ctx, r, toOwner, models.WebhookEventTransferPosted, toEvent)
+}
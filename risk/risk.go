@@ -0,0 +1,93 @@
+// Package risk screen the transfers requested by customers before they are executed. Every Rule of the Engine
+// allow, review or deny the transfer and the most severe decision win: a reviewed transfer is parked until an
+// admin approve it, a denied transfer is refused.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+// Transfer is what the rules know about a transfer being requested
+type Transfer struct {
+	Username    string
+	FromAccount models.Account
+	ToAccount   models.Account
+	Amount      int64
+	// device of the request, compared to the devices of the sessions of the user
+	UserAgent string
+	ClientIP  string
+	At        time.Time
+}
+
+// Rule evaluate one risk of a transfer. The reason explain a review or deny decision to the admin,
+// it is ignored when the transfer is allowed.
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, t Transfer) (decision string, reason string, err error)
+}
+
+// Assessment is the decision on a transfer with the findings of the rules that didn't allow it
+type Assessment struct {
+	Decision string                `json:"decision"`
+	Findings []*models.RiskFinding `json:"findings"`
+}
+
+// Engine run its rules in order on every transfer
+type Engine struct {
+	rules []Rule
+}
+
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{rules: rules}
+}
+
+// Evaluate run all the rules so the admin see every finding of a reviewed transfer, the decision is the most
+// severe of the rules. An engine without rules allow everything.
+func (e *Engine) Evaluate(ctx context.Context, t Transfer) (Assessment, error) {
+	a := Assessment{
+		Decision: models.RiskDecisionAllow,
+		Findings: []*models.RiskFinding{},
+	}
+	if t.At.IsZero() {
+		t.At = time.Now()
+	}
+
+	for _, r := range e.rules {
+		decision, reason, err := r.Evaluate(ctx, t)
+		if err != nil {
+			return a, fmt.Errorf("risk rule %s: %w", r.Name(), err)
+		}
+		if severity(decision) < 0 {
+			return a, fmt.Errorf("risk rule %s: invalid decision %q", r.Name(), decision)
+		}
+		if decision == models.RiskDecisionAllow {
+			continue
+		}
+
+		a.Findings = append(a.Findings, &models.RiskFinding{
+			Rule:     r.Name(),
+			Decision: decision,
+			Reason:   reason,
+		})
+		if severity(decision) > severity(a.Decision) {
+			a.Decision = decision
+		}
+	}
+
+	return a, nil
+}
+
+func severity(decision string) int {
+	switch decision {
+	case models.RiskDecisionAllow:
+		return 0
+	case models.RiskDecisionReview:
+		return 1
+	case models.RiskDecisionDeny:
+		return 2
+	}
+	return -1
+}
@@ -0,0 +1,160 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"github.com/ismail118/simple-bank/models"
+	"testing"
+	"time"
+)
+
+type fakeStore struct {
+	transfers      int
+	deviceSessions int
+	stats          models.TransferAmountStats
+	payeeTransfers int
+	err            error
+}
+
+func (s fakeStore) CountTransfersByOwnerSince(ctx context.Context, owner string, since time.Time) (int, error) {
+	return s.transfers, s.err
+}
+
+func (s fakeStore) CountSessionsFromDevice(ctx context.Context, username, userAgent, clientIP string, before time.Time) (int, error) {
+	return s.deviceSessions, s.err
+}
+
+func (s fakeStore) GetTransferAmountStats(ctx context.Context, accountID int64, since time.Time) (models.TransferAmountStats, error) {
+	return s.stats, s.err
+}
+
+func (s fakeStore) CountTransfersFromOwnerToAccount(ctx context.Context, owner string, toAccountID int64) (int, error) {
+	return s.payeeTransfers, s.err
+}
+
+func testTransfer(amount int64) Transfer {
+	return Transfer{
+		Username:    "some-user",
+		FromAccount: models.Account{ID: 2, Owner: "some-user", Currency: "USD"},
+		ToAccount:   models.Account{ID: 4, Owner: "other-user", Currency: "USD"},
+		Amount:      amount,
+		UserAgent:   "curl/8.0",
+		ClientIP:    "10.0.0.1",
+	}
+}
+
+func TestRules(t *testing.T) {
+	known := fakeStore{transfers: 1, deviceSessions: 1, payeeTransfers: 1, stats: models.TransferAmountStats{Count: 10, Average: 100, Max: 300}}
+
+	testCases := []struct {
+		Name     string
+		Rule     Rule
+		Amount   int64
+		Decision string
+	}{
+		{
+			Name:     "VelocityAllow",
+			Rule:     VelocityRule{Store: known, MaxTransfers: 5, Window: time.Minute},
+			Amount:   10,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "VelocityDeny",
+			Rule:     VelocityRule{Store: fakeStore{transfers: 5}, MaxTransfers: 5, Window: time.Minute},
+			Amount:   10,
+			Decision: models.RiskDecisionDeny,
+		},
+		{
+			Name:     "NewDeviceKnown",
+			Rule:     NewDeviceRule{Store: known, MinAge: time.Hour, MinAmount: 1},
+			Amount:   10,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "NewDeviceReview",
+			Rule:     NewDeviceRule{Store: fakeStore{}, MinAge: time.Hour, MinAmount: 1},
+			Amount:   10,
+			Decision: models.RiskDecisionReview,
+		},
+		{
+			Name:     "NewDeviceSmallAmount",
+			Rule:     NewDeviceRule{Store: fakeStore{}, MinAge: time.Hour, MinAmount: 100},
+			Amount:   10,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "AmountUsual",
+			Rule:     AmountAnomalyRule{Store: known, Lookback: time.Hour, MinHistory: 5, Multiplier: 5},
+			Amount:   300,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "AmountOverMaxUnderMultiplier",
+			Rule:     AmountAnomalyRule{Store: known, Lookback: time.Hour, MinHistory: 5, Multiplier: 5},
+			Amount:   400,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "AmountAnomaly",
+			Rule:     AmountAnomalyRule{Store: known, Lookback: time.Hour, MinHistory: 5, Multiplier: 5},
+			Amount:   501,
+			Decision: models.RiskDecisionReview,
+		},
+		{
+			Name:     "AmountShortHistory",
+			Rule:     AmountAnomalyRule{Store: known, Lookback: time.Hour, MinHistory: 20, Multiplier: 5},
+			Amount:   10000,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "PayeeKnown",
+			Rule:     FirstTimePayeeRule{Store: known, MinAmount: 1},
+			Amount:   10,
+			Decision: models.RiskDecisionAllow,
+		},
+		{
+			Name:     "PayeeFirstTime",
+			Rule:     FirstTimePayeeRule{Store: fakeStore{}, MinAmount: 1},
+			Amount:   10,
+			Decision: models.RiskDecisionReview,
+		},
+	}
+
+	for _, tc := range testCases {
+		tr := testTransfer(tc.Amount)
+		tr.At = time.Now()
+		decision, _, err := tc.Rule.Evaluate(context.Background(), tr)
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if decision != tc.Decision {
+			t.Fatalf("failed %s want %s got %s", tc.Name, tc.Decision, decision)
+		}
+	}
+}
+
+func TestEngine(t *testing.T) {
+	store := fakeStore{transfers: 5}
+	engine := NewEngine(
+		FirstTimePayeeRule{Store: store, MinAmount: 1},
+		VelocityRule{Store: store, MaxTransfers: 5, Window: time.Minute},
+	)
+
+	a, err := engine.Evaluate(context.Background(), testTransfer(10))
+	if err != nil {
+		t.Fatalf("failed evaluate error:%s", err)
+	}
+	if a.Decision != models.RiskDecisionDeny || len(a.Findings) != 2 {
+		t.Fatalf("failed most severe decision must win with every finding, got %s with %d findings", a.Decision, len(a.Findings))
+	}
+
+	a, err = NewEngine().Evaluate(context.Background(), testTransfer(10))
+	if err != nil || a.Decision != models.RiskDecisionAllow {
+		t.Fatalf("failed engine without rules got %s error:%v", a.Decision, err)
+	}
+
+	_, err = NewEngine(VelocityRule{Store: fakeStore{err: errors.New("db down")}, MaxTransfers: 5}).Evaluate(context.Background(), testTransfer(10))
+	if err == nil {
+		t.Fatalf("failed want error of the rule")
+	}
+}
@@ -0,0 +1,122 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+// Store is the history the built-in rules read, it is implemented by repository.Repository
+type Store interface {
+	// CountTransfersByOwnerSince count the transfers sent from the accounts of the owner since the time
+	CountTransfersByOwnerSince(ctx context.Context, owner string, since time.Time) (int, error)
+	// CountSessionsFromDevice count the sessions of the user created before the time with the user agent and ip
+	CountSessionsFromDevice(ctx context.Context, username, userAgent, clientIP string, before time.Time) (int, error)
+	// GetTransferAmountStats summarize the amounts sent from the account since the time
+	GetTransferAmountStats(ctx context.Context, accountID int64, since time.Time) (models.TransferAmountStats, error)
+	// CountTransfersFromOwnerToAccount count the transfers sent from the accounts of the owner to the account
+	CountTransfersFromOwnerToAccount(ctx context.Context, owner string, toAccountID int64) (int, error)
+}
+
+// VelocityRule deny the transfer when the user already sent MaxTransfers transfers in the last Window
+type VelocityRule struct {
+	Store        Store
+	MaxTransfers int
+	Window       time.Duration
+}
+
+func (r VelocityRule) Name() string {
+	return "velocity"
+}
+
+func (r VelocityRule) Evaluate(ctx context.Context, t Transfer) (string, string, error) {
+	count, err := r.Store.CountTransfersByOwnerSince(ctx, t.Username, t.At.Add(-r.Window))
+	if err != nil {
+		return "", "", err
+	}
+	if count >= r.MaxTransfers {
+		return models.RiskDecisionDeny, fmt.Sprintf("%d transfers in the last %s", count, r.Window), nil
+	}
+	return models.RiskDecisionAllow, "", nil
+}
+
+// NewDeviceRule review transfers of at least MinAmount requested from a user agent and ip the user didn't log in
+// from at least MinAge ago, a session stolen from a new device can't move money right away
+type NewDeviceRule struct {
+	Store     Store
+	MinAge    time.Duration
+	MinAmount int64
+}
+
+func (r NewDeviceRule) Name() string {
+	return "new_device"
+}
+
+func (r NewDeviceRule) Evaluate(ctx context.Context, t Transfer) (string, string, error) {
+	if t.Amount < r.MinAmount {
+		return models.RiskDecisionAllow, "", nil
+	}
+
+	count, err := r.Store.CountSessionsFromDevice(ctx, t.Username, t.UserAgent, t.ClientIP, t.At.Add(-r.MinAge))
+	if err != nil {
+		return "", "", err
+	}
+	if count == 0 {
+		return models.RiskDecisionReview, fmt.Sprintf("first session from %q at %s less than %s ago", t.UserAgent, t.ClientIP, r.MinAge), nil
+	}
+	return models.RiskDecisionAllow, "", nil
+}
+
+// AmountAnomalyRule review a transfer larger than every transfer of the account over the Lookback and more than
+// Multiplier times their average. Accounts with less than MinHistory transfers are not judged.
+type AmountAnomalyRule struct {
+	Store      Store
+	Lookback   time.Duration
+	MinHistory int
+	Multiplier int64
+}
+
+func (r AmountAnomalyRule) Name() string {
+	return "amount_anomaly"
+}
+
+func (r AmountAnomalyRule) Evaluate(ctx context.Context, t Transfer) (string, string, error) {
+	stats, err := r.Store.GetTransferAmountStats(ctx, t.FromAccount.ID, t.At.Add(-r.Lookback))
+	if err != nil {
+		return "", "", err
+	}
+	if stats.Count < r.MinHistory || stats.Count == 0 {
+		return models.RiskDecisionAllow, "", nil
+	}
+	if t.Amount > stats.Max && t.Amount > r.Multiplier*stats.Average {
+		return models.RiskDecisionReview, fmt.Sprintf("amount %d is over the max %d and %d times the average %d of %d transfers",
+			t.Amount, stats.Max, r.Multiplier, stats.Average, stats.Count), nil
+	}
+	return models.RiskDecisionAllow, "", nil
+}
+
+// FirstTimePayeeRule review the first transfer of at least MinAmount from the user to an account of someone else
+type FirstTimePayeeRule struct {
+	Store     Store
+	MinAmount int64
+}
+
+func (r FirstTimePayeeRule) Name() string {
+	return "first_time_payee"
+}
+
+func (r FirstTimePayeeRule) Evaluate(ctx context.Context, t Transfer) (string, string, error) {
+	if t.Amount < r.MinAmount || t.ToAccount.Owner == t.Username {
+		return models.RiskDecisionAllow, "", nil
+	}
+
+	count, err := r.Store.CountTransfersFromOwnerToAccount(ctx, t.Username, t.ToAccount.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if count == 0 {
+		return models.RiskDecisionReview, fmt.Sprintf("first transfer to account %d", t.ToAccount.ID), nil
+	}
+	return models.RiskDecisionAllow, "", nil
+}
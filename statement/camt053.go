@@ -0,0 +1,271 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+const (
+	camtDateTimeFmt = "2006-01-02T15:04:05Z"
+	camtDateFmt     = "2006-01-02"
+)
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtAccount struct {
+	ID struct {
+		Other struct {
+			ID string `xml:"Id"`
+		} `xml:"Othr"`
+	} `xml:"Id"`
+	Currency string     `xml:"Ccy,omitempty"`
+	Owner    *camtParty `xml:"Ownr,omitempty"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+}
+
+type camtDate struct {
+	Date string `xml:"Dt,omitempty"`
+	Time string `xml:"DtTm,omitempty"`
+}
+
+type camtBalance struct {
+	Type struct {
+		Code string `xml:"CdOrPrtry>Cd"`
+	} `xml:"Tp"`
+	Amount    camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Date      camtDate   `xml:"Dt"`
+}
+
+type camtNumberAndSum struct {
+	Count string `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camtDomain struct {
+	Code   string `xml:"Cd"`
+	Family struct {
+		Code      string `xml:"Cd"`
+		SubFamily string `xml:"SubFmlyCd"`
+	} `xml:"Fmly"`
+}
+
+type camtProprietary struct {
+	Code   string `xml:"Cd"`
+	Issuer string `xml:"Issr"`
+}
+
+type camtBankTransactionCode struct {
+	Domain      *camtDomain      `xml:"Domn,omitempty"`
+	Proprietary *camtProprietary `xml:"Prtry,omitempty"`
+}
+
+type camtRelatedParties struct {
+	DebtorAccount   camtAccount `xml:"DbtrAcct"`
+	CreditorAccount camtAccount `xml:"CdtrAcct"`
+}
+
+type camtTransactionDetails struct {
+	Refs struct {
+		TxID string `xml:"TxId"`
+	} `xml:"Refs"`
+	RelatedParties *camtRelatedParties `xml:"RltdPties,omitempty"`
+	Unstructured   string              `xml:"RmtInf>Ustrd"`
+}
+
+type camtEntry struct {
+	Ref             string                   `xml:"NtryRef"`
+	Amount          camtAmount               `xml:"Amt"`
+	CdtDbtInd       string                   `xml:"CdtDbtInd"`
+	Reversal        bool                     `xml:"RvslInd,omitempty"`
+	Status          string                   `xml:"Sts"`
+	BookingDate     camtDate                 `xml:"BookgDt"`
+	ValueDate       camtDate                 `xml:"ValDt"`
+	ServicerRef     string                   `xml:"AcctSvcrRef"`
+	TransactionCode camtBankTransactionCode  `xml:"BkTxCd"`
+	Details         []camtTransactionDetails `xml:"NtryDtls>TxDtls,omitempty"`
+	Info            string                   `xml:"AddtlNtryInf"`
+}
+
+type camtDocument struct {
+	XMLName   xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:camt.053.001.02 Document"`
+	Statement struct {
+		GroupHeader struct {
+			MsgID     string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Stmt struct {
+			ID        string `xml:"Id"`
+			CreatedAt string `xml:"CreDtTm"`
+			Period    struct {
+				From string `xml:"FrDtTm"`
+				To   string `xml:"ToDtTm"`
+			} `xml:"FrToDt"`
+			Account  camtAccount   `xml:"Acct"`
+			Balances []camtBalance `xml:"Bal"`
+			Summary  struct {
+				Total struct {
+					Count     string `xml:"NbOfNtries"`
+					Sum       string `xml:"Sum"`
+					Net       string `xml:"TtlNetNtryAmt"`
+					CdtDbtInd string `xml:"CdtDbtInd"`
+				} `xml:"TtlNtries"`
+				Credits camtNumberAndSum `xml:"TtlCdtNtries"`
+				Debits  camtNumberAndSum `xml:"TtlDbtNtries"`
+			} `xml:"TxsSummry"`
+			Entries []camtEntry `xml:"Ntry"`
+		} `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+// renderCamt053 write the statement as an ISO 20022 camt.053.001.02 BankToCustomerStatement.
+// The elements are declared in the order of the schema sequences, amounts are absolute with a CRDT or DBIT
+// indicator and the entry id is the account servicer reference so ERPs can detect duplicated imports.
+func renderCamt053(st Statement) ([]byte, error) {
+	amount := func(v int64) camtAmount {
+		if v < 0 {
+			v = -v
+		}
+		return camtAmount{Currency: st.Currency.Code, Value: util.FormatAmount(v, st.Currency.MinorUnit)}
+	}
+	end := st.To.AddDate(0, 0, 1)
+
+	var doc camtDocument
+	hdr := &doc.Statement.GroupHeader
+	hdr.MsgID = fmt.Sprintf("%s-%d", st.GeneratedAt.Format("20060102150405"), st.Account.ID)
+	hdr.CreatedAt = st.GeneratedAt.Format(camtDateTimeFmt)
+
+	stmt := &doc.Statement.Stmt
+	stmt.ID = fmt.Sprintf("%s%s-%d", st.From.Format("060102"), st.To.Format("060102"), st.Account.ID)
+	stmt.CreatedAt = hdr.CreatedAt
+	stmt.Period.From = st.From.Format(camtDateTimeFmt)
+	stmt.Period.To = minTime(end.Add(-time.Second), st.GeneratedAt).Format(camtDateTimeFmt)
+	stmt.Account = camtAccountOf(st.Account.ID)
+	stmt.Account.Currency = st.Currency.Code
+	stmt.Account.Owner = &camtParty{Name: st.Account.Owner}
+
+	balance := func(code string, v int64, date string) camtBalance {
+		var b camtBalance
+		b.Type.Code = code
+		b.Amount = amount(v)
+		b.CdtDbtInd = cdtDbtInd(v)
+		b.Date.Date = date
+		return b
+	}
+	stmt.Balances = []camtBalance{
+		balance("OPBD", st.OpeningBalance, st.From.Format(camtDateFmt)),
+		balance("CLBD", st.ClosingBalance, st.To.Format(camtDateFmt)),
+	}
+
+	var credits, debits int
+	for _, l := range st.Lines {
+		if l.Amount < 0 {
+			debits++
+		} else {
+			credits++
+		}
+	}
+	sum := &stmt.Summary
+	sum.Total.Count = fmt.Sprint(len(st.Lines))
+	// sum of the absolute amounts
+	sum.Total.Sum = util.FormatAmount(st.TotalCredits-st.TotalDebits, st.Currency.MinorUnit)
+	net := st.TotalCredits + st.TotalDebits
+	sum.Total.Net = amount(net).Value
+	sum.Total.CdtDbtInd = cdtDbtInd(net)
+	sum.Credits = camtNumberAndSum{Count: fmt.Sprint(credits), Sum: amount(st.TotalCredits).Value}
+	sum.Debits = camtNumberAndSum{Count: fmt.Sprint(debits), Sum: amount(st.TotalDebits).Value}
+
+	stmt.Entries = make([]camtEntry, 0, len(st.Lines))
+	for _, l := range st.Lines {
+		e := camtEntry{
+			Ref:         fmt.Sprint(l.ID),
+			Amount:      amount(l.Amount),
+			CdtDbtInd:   cdtDbtInd(l.Amount),
+			Reversal:    l.Transfer != nil && l.Transfer.ReversalOf > 0,
+			Status:      "BOOK",
+			BookingDate: camtDate{Time: l.CreatedAt.UTC().Format(camtDateTimeFmt)},
+			ValueDate:   camtDate{Date: l.CreatedAt.UTC().Format(camtDateFmt)},
+			ServicerRef: fmt.Sprint(l.ID),
+			Info:        description(l),
+		}
+		e.TransactionCode = camtTransactionCode(l)
+
+		if l.TransferID > 0 {
+			var d camtTransactionDetails
+			d.Refs.TxID = fmt.Sprint(l.TransferID)
+			d.Unstructured = description(l)
+			if other := counterparty(l); other > 0 {
+				d.RelatedParties = &camtRelatedParties{
+					DebtorAccount:   camtAccountOf(st.Account.ID),
+					CreditorAccount: camtAccountOf(other),
+				}
+				if l.Amount > 0 {
+					d.RelatedParties = &camtRelatedParties{
+						DebtorAccount:   camtAccountOf(other),
+						CreditorAccount: camtAccountOf(st.Account.ID),
+					}
+				}
+			}
+			e.Details = []camtTransactionDetails{d}
+		}
+
+		stmt.Entries = append(stmt.Entries, e)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	err := enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+func camtAccountOf(id int64) camtAccount {
+	var a camtAccount
+	a.ID.Other.ID = fmt.Sprint(id)
+	return a
+}
+
+func cdtDbtInd(v int64) string {
+	if v < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+// camtTransactionCode return the ISO bank transaction code of the line: internal book transfers are
+// issued or received credit transfers, fees are charges and entries without transfer use a proprietary code
+func camtTransactionCode(l Line) camtBankTransactionCode {
+	var c camtBankTransactionCode
+	if l.Transfer == nil {
+		c.Proprietary = &camtProprietary{Code: "ENTRY", Issuer: ofxBankID}
+		return c
+	}
+
+	c.Domain = &camtDomain{}
+	switch {
+	case counterparty(l) == 0 && l.Amount < 0:
+		c.Domain.Code, c.Domain.Family.Code, c.Domain.Family.SubFamily = "ACMT", "MDOP", "CHRG"
+	case counterparty(l) == 0:
+		c.Domain.Code, c.Domain.Family.Code, c.Domain.Family.SubFamily = "ACMT", "MCOP", "CHRG"
+	case l.Amount < 0:
+		c.Domain.Code, c.Domain.Family.Code, c.Domain.Family.SubFamily = "PMNT", "ICDT", "BOOK"
+	default:
+		c.Domain.Code, c.Domain.Family.Code, c.Domain.Family.SubFamily = "PMNT", "RCDT", "BOOK"
+	}
+	return c
+}
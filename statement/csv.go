@@ -0,0 +1,54 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+// renderCSV write one row for each entry between the opening and closing balance rows,
+// debits and credits are in separate columns so the file sum easily in a spreadsheet
+func renderCSV(st Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	amount := func(v int64) string {
+		return util.FormatAmount(v, st.Currency.MinorUnit)
+	}
+
+	rows := [][]string{
+		{"date", "entry_id", "transfer_id", "description", "debit", "credit", "balance", "currency"},
+		{st.From.Format("2006-01-02"), "", "", "Opening balance", "", "", amount(st.OpeningBalance), st.Currency.Code},
+	}
+	for _, l := range st.Lines {
+		debit, credit := "", ""
+		if l.Amount < 0 {
+			debit = amount(-l.Amount)
+		} else {
+			credit = amount(l.Amount)
+		}
+		transferID := ""
+		if l.TransferID > 0 {
+			transferID = fmt.Sprint(l.TransferID)
+		}
+		rows = append(rows, []string{
+			l.CreatedAt.UTC().Format(time.RFC3339),
+			fmt.Sprint(l.ID),
+			transferID,
+			description(l),
+			debit,
+			credit,
+			amount(l.Balance),
+			st.Currency.Code,
+		})
+	}
+	rows = append(rows, []string{st.To.Format("2006-01-02"), "", "", "Closing balance", amount(-st.TotalDebits), amount(st.TotalCredits), amount(st.ClosingBalance), st.Currency.Code})
+
+	err := w.WriteAll(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,142 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ismail118/simple-bank/util"
+	"strings"
+)
+
+const (
+	mt940DateFmt = "060102"
+	// entries of each message, longer statements are split in several messages linked by
+	// intermediate balances and the sequence number of field 28C
+	mt940EntriesPerMessage = 50
+	// field 86 is at most 6 lines of 65 characters
+	mt940InfoLines   = 6
+	mt940InfoLineLen = 65
+)
+
+// renderMT940 write the statement as SWIFT MT940 messages (the text block only, as exchanged in files
+// with ERPs). Lines end with CRLF, every message ends with a "-" line and all free text is restricted
+// to the SWIFT X character set.
+func renderMT940(st Statement) ([]byte, error) {
+	amount := func(v int64) string {
+		if v < 0 {
+			v = -v
+		}
+		s := strings.Replace(util.FormatAmount(v, st.Currency.MinorUnit), ".", ",", 1)
+		if st.Currency.MinorUnit == 0 {
+			s += ","
+		}
+		return s
+	}
+	balance := func(tag string, v int64, date string) string {
+		mark := "C"
+		if v < 0 {
+			mark = "D"
+		}
+		return fmt.Sprintf(":%s:%s%s%s%s", tag, mark, date, st.Currency.Code, amount(v))
+	}
+
+	chunks := [][]Line{}
+	lines := st.Lines
+	for len(lines) > mt940EntriesPerMessage {
+		chunks = append(chunks, lines[:mt940EntriesPerMessage])
+		lines = lines[mt940EntriesPerMessage:]
+	}
+	chunks = append(chunks, lines)
+
+	var buf bytes.Buffer
+	write := func(s string) {
+		buf.WriteString(s)
+		buf.WriteString("\r\n")
+	}
+
+	running := st.OpeningBalance
+	for i, chunk := range chunks {
+		write(fmt.Sprintf(":20:%s%s", st.From.Format(mt940DateFmt), st.To.Format(mt940DateFmt)))
+		write(fmt.Sprintf(":25:%s/%d", ofxBankID, st.Account.ID))
+		// statements are numbered by the day of the year of their first day
+		write(fmt.Sprintf(":28C:%05d/%03d", st.From.YearDay(), i+1))
+
+		if i == 0 {
+			write(balance("60F", running, st.From.Format(mt940DateFmt)))
+		} else {
+			write(balance("60M", running, chunk[0].CreatedAt.UTC().Format(mt940DateFmt)))
+		}
+
+		for _, l := range chunk {
+			running += l.Amount
+			created := l.CreatedAt.UTC()
+			ref := "NONREF"
+			if l.TransferID > 0 {
+				ref = fmt.Sprint(l.TransferID)
+			}
+			write(fmt.Sprintf(":61:%s%s%s%s%s%s//%d",
+				created.Format(mt940DateFmt), created.Format("0102"), mt940Mark(l), amount(l.Amount), mt940TransactionType(l), ref, l.ID))
+			write(":86:" + strings.Join(mt940Info(description(l)), "\r\n"))
+		}
+
+		if i == len(chunks)-1 {
+			write(balance("62F", running, st.To.Format(mt940DateFmt)))
+		} else {
+			write(balance("62M", running, chunk[len(chunk)-1].CreatedAt.UTC().Format(mt940DateFmt)))
+		}
+		write("-")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// mt940Mark return the debit/credit mark of field 61, reversals use RD for the reversal of a debit
+// (a credit) and RC for the reversal of a credit (a debit)
+func mt940Mark(l Line) string {
+	mark := "C"
+	if l.Amount < 0 {
+		mark = "D"
+	}
+	if l.Transfer != nil && l.Transfer.ReversalOf > 0 {
+		if mark == "C" {
+			return "RD"
+		}
+		return "RC"
+	}
+	return mark
+}
+
+// mt940TransactionType return the SWIFT transaction type identification code of field 61
+func mt940TransactionType(l Line) string {
+	switch {
+	case l.Transfer == nil:
+		return "NMSC"
+	case counterparty(l) == 0:
+		return "NCHG"
+	}
+	return "NTRF"
+}
+
+// mt940Info split the text in the lines of field 86 after replacing the characters outside of the
+// SWIFT X character set
+func mt940Info(text string) []string {
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		// ':' is left out so a continuation line can't be read as a new field
+		case strings.ContainsRune("/-?().,'+ ", r):
+			return r
+		}
+		return '.'
+	}, text)
+
+	var lines []string
+	for len(clean) > mt940InfoLineLen && len(lines) < mt940InfoLines-1 {
+		lines = append(lines, clean[:mt940InfoLineLen])
+		clean = clean[mt940InfoLineLen:]
+	}
+	if len(clean) > mt940InfoLineLen {
+		clean = clean[:mt940InfoLineLen]
+	}
+	return append(lines, clean)
+}
@@ -0,0 +1,134 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"github.com/ismail118/simple-bank/util"
+	"time"
+)
+
+const (
+	ofxHeader   = `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	ofxBankID   = "SIMPLEBANK"
+	ofxTimeFmt  = "20060102150405"
+	ofxDateFmt  = "20060102"
+	ofxAcctType = "CHECKING"
+)
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	FitID  string `xml:"FITID"`
+	Name   string `xml:"NAME"`
+	RefNum string `xml:"REFNUM,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxDocument struct {
+	XMLName xml.Name `xml:"OFX"`
+	SignOn  struct {
+		Response struct {
+			Status   ofxStatus `xml:"STATUS"`
+			Server   string    `xml:"DTSERVER"`
+			Language string    `xml:"LANGUAGE"`
+		} `xml:"SONRS"`
+	} `xml:"SIGNONMSGSRSV1"`
+	Bank struct {
+		Response struct {
+			TrnUID    string    `xml:"TRNUID"`
+			Status    ofxStatus `xml:"STATUS"`
+			Statement struct {
+				Currency string `xml:"CURDEF"`
+				Account  struct {
+					BankID   string `xml:"BANKID"`
+					AcctID   string `xml:"ACCTID"`
+					AcctType string `xml:"ACCTTYPE"`
+				} `xml:"BANKACCTFROM"`
+				Transactions struct {
+					Start string           `xml:"DTSTART"`
+					End   string           `xml:"DTEND"`
+					Items []ofxTransaction `xml:"STMTTRN"`
+				} `xml:"BANKTRANLIST"`
+				LedgerBalance ofxBalance `xml:"LEDGERBAL"`
+			} `xml:"STMTRS"`
+		} `xml:"STMTTRNRS"`
+	} `xml:"BANKMSGSRSV1"`
+}
+
+// renderOFX write an OFX 2.2 bank statement response, the format imported by most personal finance software.
+// The entry id is the FITID so importing the same period twice doesn't duplicate transactions.
+func renderOFX(st Statement) ([]byte, error) {
+	amount := func(v int64) string {
+		return util.FormatAmount(v, st.Currency.MinorUnit)
+	}
+	end := st.To.AddDate(0, 0, 1)
+
+	var doc ofxDocument
+	doc.SignOn.Response.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	doc.SignOn.Response.Server = st.GeneratedAt.UTC().Format(ofxTimeFmt)
+	doc.SignOn.Response.Language = "ENG"
+
+	res := &doc.Bank.Response
+	res.TrnUID = fmt.Sprintf("%d-%s", st.Account.ID, st.From.Format(ofxDateFmt))
+	res.Status = ofxStatus{Code: 0, Severity: "INFO"}
+	res.Statement.Currency = st.Currency.Code
+	res.Statement.Account.BankID = ofxBankID
+	res.Statement.Account.AcctID = fmt.Sprint(st.Account.ID)
+	res.Statement.Account.AcctType = ofxAcctType
+	res.Statement.Transactions.Start = st.From.Format(ofxDateFmt)
+	res.Statement.Transactions.End = end.Format(ofxDateFmt)
+	res.Statement.Transactions.Items = make([]ofxTransaction, 0, len(st.Lines))
+	for _, l := range st.Lines {
+		trnType := "CREDIT"
+		if l.Amount < 0 {
+			trnType = "DEBIT"
+		}
+		t := ofxTransaction{
+			Type:   trnType,
+			Posted: l.CreatedAt.UTC().Format(ofxTimeFmt),
+			Amount: amount(l.Amount),
+			FitID:  fmt.Sprint(l.ID),
+			Name:   description(l),
+		}
+		if l.TransferID > 0 {
+			t.RefNum = fmt.Sprint(l.TransferID)
+		}
+		res.Statement.Transactions.Items = append(res.Statement.Transactions.Items, t)
+	}
+	res.Statement.LedgerBalance = ofxBalance{
+		Amount: amount(st.ClosingBalance),
+		AsOf:   minTime(end, st.GeneratedAt).UTC().Format(ofxTimeFmt),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(ofxHeader)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	err := enc.Encode(doc)
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes(), nil
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
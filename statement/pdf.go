@@ -0,0 +1,134 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ismail118/simple-bank/util"
+	"strings"
+)
+
+// page layout of the PDF statement in points, A4 portrait
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 40
+	pdfFontSize     = 9
+	pdfLeading      = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// renderPDF write the statement as a text only PDF. Every page use the Courier base font so the columns
+// line up without embedding a font or knowing its metrics.
+func renderPDF(st Statement) ([]byte, error) {
+	amount := func(v int64) string {
+		return util.FormatAmount(v, st.Currency.MinorUnit)
+	}
+	row := func(date, ref, desc, debit, credit, balance string) string {
+		return fmt.Sprintf("%-10s  %-10s  %-32s %12s %12s %12s", date, ref, desc, debit, credit, balance)
+	}
+
+	header := []string{
+		"SIMPLE BANK - ACCOUNT STATEMENT",
+		"",
+		fmt.Sprintf("Account:  %d", st.Account.ID),
+		fmt.Sprintf("Owner:    %s", st.Account.Owner),
+		fmt.Sprintf("Currency: %s", st.Currency.Code),
+		fmt.Sprintf("Period:   %s to %s", st.From.Format("2006-01-02"), st.To.Format("2006-01-02")),
+		"",
+		row("Date", "Entry", "Description", "Debit", "Credit", "Balance"),
+		strings.Repeat("-", 95),
+		row(st.From.Format("2006-01-02"), "", "Opening balance", "", "", amount(st.OpeningBalance)),
+	}
+
+	lines := header
+	for _, l := range st.Lines {
+		debit, credit := "", ""
+		if l.Amount < 0 {
+			debit = amount(-l.Amount)
+		} else {
+			credit = amount(l.Amount)
+		}
+		lines = append(lines, row(l.CreatedAt.UTC().Format("2006-01-02"), fmt.Sprint(l.ID), description(l), debit, credit, amount(l.Balance)))
+	}
+	lines = append(lines,
+		strings.Repeat("-", 95),
+		row("", "", "Total", amount(-st.TotalDebits), amount(st.TotalCredits), ""),
+		row(st.To.Format("2006-01-02"), "", "Closing balance", "", "", amount(st.ClosingBalance)),
+		"",
+		fmt.Sprintf("Generated at %s", st.GeneratedAt.UTC().Format("2006-01-02 15:04:05 MST")),
+	)
+
+	// the last line of every page is kept for the page number
+	perPage := pdfLinesPerPage - 2
+	var pages [][]string
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+	for i := range pages {
+		pages[i] = append(pages[i], "", fmt.Sprintf("Page %d of %d", i+1, len(pages)))
+	}
+
+	return writePDF(pages), nil
+}
+
+// writePDF write a PDF 1.4 document with one page of text lines for each item of pages
+func writePDF(pages [][]string) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// objects 1 to 3 are the catalog, the page tree and the font, each page is followed by its content
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	for i, lines := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, l := range lines {
+			fmt.Fprintf(&content, "(%s) '\n", pdfEscape(l))
+		}
+		content.WriteString("ET")
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfEscape escape the characters that end a PDF string, characters outside ASCII are replaced
+// because the base font encoding can't show them
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,219 @@
+// Package statement build the statement of an account for a period and render it in the formats
+// customers download or import in their accounting software
+package statement
+
+import (
+	"context"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"time"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatPDF = "pdf"
+	FormatOFX = "ofx"
+	// ISO 20022 BankToCustomerStatement camt.053.001.02
+	FormatCamt053 = "camt053"
+	// SWIFT MT940 customer statement message
+	FormatMT940 = "mt940"
+)
+
+// loadBatchSize is the number of entries read by each query of Load
+const loadBatchSize = 1000
+
+type Line struct {
+	models.Entry
+	// balance of the account after the entry
+	Balance int64 `json:"balance"`
+	// transfer that posted the entry, nil for entries without a transfer
+	Transfer *models.Transfer `json:"transfer,omitempty"`
+}
+
+type Statement struct {
+	Account  models.Account  `json:"account"`
+	Currency models.Currency `json:"currency"`
+	// first day of the period in UTC
+	From time.Time `json:"from"`
+	// last day of the period in UTC, its entries are included
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	// sum of the positive entries
+	TotalCredits int64 `json:"total_credits"`
+	// sum of the negative entries, it is negative
+	TotalDebits int64     `json:"total_debits"`
+	Lines       []Line    `json:"lines"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// New build the statement from the balance at the start of the period and the entries of the period
+// in the order they were posted, transfers are matched to the entries by id
+func New(account models.Account, currency models.Currency, from, to time.Time, openingBalance int64, entries []*models.Entry, transfers []*models.Transfer, generatedAt time.Time) Statement {
+	byID := make(map[int64]*models.Transfer, len(transfers))
+	for _, t := range transfers {
+		byID[t.ID] = t
+	}
+
+	st := Statement{
+		Account:        account,
+		Currency:       currency,
+		From:           day(from),
+		To:             day(to),
+		OpeningBalance: openingBalance,
+		ClosingBalance: openingBalance,
+		Lines:          make([]Line, 0, len(entries)),
+		GeneratedAt:    generatedAt.UTC(),
+	}
+
+	for _, e := range entries {
+		st.ClosingBalance += e.Amount
+		if e.Amount > 0 {
+			st.TotalCredits += e.Amount
+		} else {
+			st.TotalDebits += e.Amount
+		}
+		st.Lines = append(st.Lines, Line{
+			Entry:    *e,
+			Balance:  st.ClosingBalance,
+			Transfer: byID[e.TransferID],
+		})
+	}
+
+	return st
+}
+
+// Load read the opening balance and the entries of the days from and to inclusive and build the statement
+func Load(ctx context.Context, r repository.Repository, account models.Account, from, to time.Time) (Statement, error) {
+	currency, err := r.GetCurrency(ctx, account.Currency)
+	if err != nil {
+		return Statement{}, err
+	}
+	if currency.Code == "" {
+		return Statement{}, fmt.Errorf("%w: %s", repository.ErrCurrencyNotFound, account.Currency)
+	}
+
+	start := day(from)
+	end := day(to).AddDate(0, 0, 1)
+
+	opening, err := r.GetAccountBalanceAt(ctx, account.ID, start)
+	if err != nil {
+		return Statement{}, fmt.Errorf("failed to get opening balance: %w", err)
+	}
+
+	var entries []*models.Entry
+	var afterID int64
+	for {
+		items, err := r.GetListEntries(ctx, models.EntryFilter{AccountID: account.ID, From: start, To: end}, afterID, loadBatchSize)
+		if err != nil {
+			return Statement{}, fmt.Errorf("failed to get entries: %w", err)
+		}
+		entries = append(entries, items...)
+		if len(items) < loadBatchSize {
+			break
+		}
+		afterID = items[len(items)-1].ID
+	}
+
+	var transfers []*models.Transfer
+	ids := make([]int64, 0, loadBatchSize)
+	for i, e := range entries {
+		if e.TransferID > 0 {
+			ids = append(ids, e.TransferID)
+		}
+		if len(ids) == loadBatchSize || (i == len(entries)-1 && len(ids) > 0) {
+			items, err := r.GetListTransfersByIDs(ctx, ids)
+			if err != nil {
+				return Statement{}, fmt.Errorf("failed to get transfers: %w", err)
+			}
+			transfers = append(transfers, items...)
+			ids = ids[:0]
+		}
+	}
+
+	return New(account, currency, from, to, opening, entries, transfers, time.Now()), nil
+}
+
+// Render return the statement in the given format with its content type
+func Render(st Statement, format string) ([]byte, string, error) {
+	switch format {
+	case FormatCSV:
+		data, err := renderCSV(st)
+		return data, "text/csv", err
+	case FormatPDF:
+		data, err := renderPDF(st)
+		return data, "application/pdf", err
+	case FormatOFX:
+		data, err := renderOFX(st)
+		return data, "application/x-ofx", err
+	case FormatCamt053:
+		data, err := renderCamt053(st)
+		return data, "application/xml", err
+	case FormatMT940:
+		data, err := renderMT940(st)
+		return data, "text/plain", err
+	}
+	return nil, "", fmt.Errorf("unsupported statement format %q", format)
+}
+
+// fileExtensions of the formats whose extension is not the format name
+var fileExtensions = map[string]string{
+	FormatCamt053: "xml",
+	FormatMT940:   "sta",
+}
+
+// FileName return the name of the statement file in the given format
+func FileName(st Statement, format string) string {
+	ext, ok := fileExtensions[format]
+	if !ok {
+		ext = format
+	}
+	return fmt.Sprintf("statement-%d-%s-%s.%s", st.Account.ID, st.From.Format("20060102"), st.To.Format("20060102"), ext)
+}
+
+// isFee report whether the line is the fee charged to the from account of its transfer
+func isFee(l Line) bool {
+	t := l.Transfer
+	return t != nil && t.Fee > 0 && l.AccountID == t.FromAccountID && l.Amount == -t.Fee && t.Fee != t.Amount
+}
+
+// counterparty return the other account of the transfer of the line, 0 if it is unknown or the line is a fee
+func counterparty(l Line) int64 {
+	t := l.Transfer
+	switch {
+	case t == nil || isFee(l):
+		return 0
+	case l.AccountID == t.FromAccountID:
+		return t.ToAccountID
+	case l.AccountID == t.ToAccountID:
+		return t.FromAccountID
+	}
+	return 0
+}
+
+// description of the line shown to the customer
+func description(l Line) string {
+	t := l.Transfer
+	switch {
+	case t == nil && l.TransferID > 0:
+		return fmt.Sprintf("Transfer %d", l.TransferID)
+	case t == nil:
+		return fmt.Sprintf("Entry %d", l.ID)
+	case t.ReversalOf > 0:
+		return fmt.Sprintf("Reversal of transfer %d", t.ReversalOf)
+	case isFee(l):
+		return fmt.Sprintf("Fee of transfer %d", t.ID)
+	case l.AccountID == t.FromAccountID:
+		return fmt.Sprintf("Transfer %d to account %d", t.ID, t.ToAccountID)
+	case l.AccountID == t.ToAccountID:
+		return fmt.Sprintf("Transfer %d from account %d", t.ID, t.FromAccountID)
+	}
+	// revenue account credited with the fee
+	return fmt.Sprintf("Fee of transfer %d", t.ID)
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
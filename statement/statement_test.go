@@ -0,0 +1,346 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func testStatement(n int) Statement {
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := make([]*models.Entry, 0, n)
+	for i := 0; i < n; i++ {
+		amount := int64(1000 + i)
+		if i%2 == 1 {
+			amount = -amount / 2
+		}
+		entries = append(entries, &models.Entry{
+			ID:         int64(i + 1),
+			AccountID:  7,
+			Amount:     amount,
+			TransferID: int64(100 + i),
+			CreatedAt:  from.Add(time.Duration(i) * time.Hour),
+		})
+	}
+
+	account := models.Account{ID: 7, Owner: "some-user", Currency: "USD"}
+	currency := models.Currency{Code: "USD", MinorUnit: 2}
+	return New(account, currency, from, from.AddDate(0, 0, 30), 5000, entries, nil, from.AddDate(0, 1, 1))
+}
+
+// goldenStatement has a received transfer, a sent transfer with its fee, the reversal of
+// the sent transfer and an entry without transfer
+func goldenStatement() Statement {
+	from := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(d, h int) time.Time {
+		return from.AddDate(0, 0, d).Add(time.Duration(h) * time.Hour)
+	}
+	transfers := []*models.Transfer{
+		{ID: 101, FromAccountID: 3, ToAccountID: 7, Amount: 2500, ToAmount: 2500, CreatedAt: at(0, 9)},
+		{ID: 102, FromAccountID: 7, ToAccountID: 4, Amount: 1000, ToAmount: 1000, Fee: 50, CreatedAt: at(4, 14)},
+		{ID: 103, FromAccountID: 4, ToAccountID: 7, Amount: 1000, ToAmount: 1000, ReversalOf: 102, CreatedAt: at(9, 8)},
+	}
+	entries := []*models.Entry{
+		{ID: 11, AccountID: 7, Amount: 2500, TransferID: 101, CreatedAt: at(0, 9)},
+		{ID: 14, AccountID: 7, Amount: -1000, TransferID: 102, CreatedAt: at(4, 14)},
+		{ID: 15, AccountID: 7, Amount: -50, TransferID: 102, CreatedAt: at(4, 14)},
+		{ID: 17, AccountID: 7, Amount: 1000, TransferID: 103, CreatedAt: at(9, 8)},
+		{ID: 20, AccountID: 7, Amount: -300, CreatedAt: at(20, 16)},
+	}
+
+	account := models.Account{ID: 7, Owner: "some-user", Currency: "USD"}
+	currency := models.Currency{Code: "USD", MinorUnit: 2}
+	return New(account, currency, from, from.AddDate(0, 0, 30), 5000, entries, transfers, from.AddDate(0, 1, 0).Add(6*time.Hour))
+}
+
+func TestRenderGolden(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatOFX, FormatCamt053, FormatMT940} {
+		data, _, err := Render(goldenStatement(), format)
+		if err != nil {
+			t.Fatalf("failed render %s error:%s", format, err)
+		}
+
+		golden := filepath.Join("testdata", "statement."+format+".golden")
+		if *update {
+			err = os.WriteFile(golden, data, 0644)
+			if err != nil {
+				t.Fatalf("failed update %s error:%s", golden, err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatalf("failed read %s error:%s", golden, err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("failed %s doesn't match %s, run go test with -update if the change is intended\n%s", format, golden, data)
+		}
+	}
+}
+
+func TestDescription(t *testing.T) {
+	st := goldenStatement()
+	want := []string{
+		"Transfer 101 from account 3",
+		"Transfer 102 to account 4",
+		"Fee of transfer 102",
+		"Reversal of transfer 102",
+		"Entry 20",
+	}
+	for i, l := range st.Lines {
+		if got := description(l); got != want[i] {
+			t.Errorf("failed line %d description want %s got %s", i, want[i], got)
+		}
+	}
+
+	// the revenue account credited with the fee is neither side of the transfer
+	fee := Line{Entry: models.Entry{ID: 16, AccountID: 1, Amount: 50, TransferID: 102}, Transfer: st.Lines[1].Transfer}
+	if got := description(fee); got != "Fee of transfer 102" || counterparty(fee) != 0 {
+		t.Errorf("failed fee revenue description got %s", got)
+	}
+}
+
+func TestRenderCamt053(t *testing.T) {
+	st := goldenStatement()
+	data, contentType, err := Render(st, FormatCamt053)
+	if err != nil {
+		t.Fatalf("failed render error:%s", err)
+	}
+	if contentType != "application/xml" {
+		t.Errorf("failed content type want application/xml got %s", contentType)
+	}
+	if !bytes.Contains(data, []byte(`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">`)) {
+		t.Errorf("failed camt.053 namespace")
+	}
+
+	var doc camtDocument
+	err = xml.Unmarshal(data, &doc)
+	if err != nil {
+		t.Fatalf("failed parse camt.053 error:%s", err)
+	}
+	stmt := doc.Statement.Stmt
+	// Max35Text identifiers
+	for _, id := range []string{doc.Statement.GroupHeader.MsgID, stmt.ID} {
+		if len(id) == 0 || len(id) > 35 {
+			t.Errorf("failed identifier %q must contain 1-35 characters", id)
+		}
+	}
+	if len(stmt.Balances) != 2 || stmt.Balances[0].Amount.Value != "50.00" || stmt.Balances[1].Amount.Value != "71.50" {
+		t.Errorf("failed camt.053 balances got %+v", stmt.Balances)
+	}
+	sum := stmt.Summary
+	if sum.Total.Count != "5" || sum.Total.Sum != "48.50" || sum.Total.Net != "21.50" || sum.Total.CdtDbtInd != "CRDT" {
+		t.Errorf("failed camt.053 summary got %+v", sum.Total)
+	}
+	if sum.Credits.Count != "2" || sum.Credits.Sum != "35.00" || sum.Debits.Count != "3" || sum.Debits.Sum != "13.50" {
+		t.Errorf("failed camt.053 credit and debit summary got %+v %+v", sum.Credits, sum.Debits)
+	}
+
+	if len(stmt.Entries) != 5 {
+		t.Fatalf("failed camt.053 entries want 5 got %d", len(stmt.Entries))
+	}
+	sent := stmt.Entries[1]
+	if sent.CdtDbtInd != "DBIT" || sent.Amount.Value != "10.00" || sent.Amount.Currency != "USD" {
+		t.Errorf("failed camt.053 sent entry got %+v", sent)
+	}
+	parties := sent.Details[0].RelatedParties
+	if parties == nil || parties.DebtorAccount.ID.Other.ID != "7" || parties.CreditorAccount.ID.Other.ID != "4" {
+		t.Errorf("failed camt.053 related parties got %+v", parties)
+	}
+	if stmt.Entries[2].TransactionCode.Domain.Family.SubFamily != "CHRG" || stmt.Entries[2].Details[0].RelatedParties != nil {
+		t.Errorf("failed camt.053 fee entry got %+v", stmt.Entries[2])
+	}
+	if !stmt.Entries[3].Reversal {
+		t.Errorf("failed camt.053 reversal indicator")
+	}
+	if stmt.Entries[4].TransactionCode.Proprietary == nil || len(stmt.Entries[4].Details) != 0 {
+		t.Errorf("failed camt.053 entry without transfer got %+v", stmt.Entries[4])
+	}
+}
+
+func TestRenderMT940(t *testing.T) {
+	// enough lines for three messages
+	st := testStatement(120)
+	data, contentType, err := Render(st, FormatMT940)
+	if err != nil {
+		t.Fatalf("failed render error:%s", err)
+	}
+	if contentType != "text/plain" {
+		t.Errorf("failed content type want text/plain got %s", contentType)
+	}
+
+	text := string(data)
+	if !strings.HasSuffix(text, "\r\n-\r\n") || strings.Count(text, "\r\n-\r\n") != 3 {
+		t.Fatalf("failed mt940 want 3 messages")
+	}
+	if strings.Count(text, ":60F:") != 1 || strings.Count(text, ":60M:") != 2 ||
+		strings.Count(text, ":62F:") != 1 || strings.Count(text, ":62M:") != 2 {
+		t.Errorf("failed mt940 balances of the messages")
+	}
+	if strings.Count(text, ":61:") != 120 || !strings.Contains(text, ":28C:00001/003\r\n") {
+		t.Errorf("failed mt940 statement lines or sequence number")
+	}
+	wantClosing := fmt.Sprintf(":62F:C230131USD%s\r\n", strings.Replace(fmt.Sprintf("%.2f", float64(st.ClosingBalance)/100), ".", ",", 1))
+	if !strings.Contains(text, wantClosing) {
+		t.Errorf("failed mt940 closing balance want %s", wantClosing)
+	}
+
+	// SWIFT X character set only
+	invalid := regexp.MustCompile(`[^a-zA-Z0-9/\-?:().,'+ \r\n]`)
+	if loc := invalid.FindStringIndex(text); loc != nil {
+		t.Errorf("failed mt940 invalid character %q", text[loc[0]:loc[1]])
+	}
+}
+
+func TestMT940Info(t *testing.T) {
+	lines := mt940Info("Payment: rent é " + strings.Repeat("x", 500))
+	if len(lines) != mt940InfoLines {
+		t.Fatalf("failed info lines want %d got %d", mt940InfoLines, len(lines))
+	}
+	for _, l := range lines {
+		if len(l) > mt940InfoLineLen {
+			t.Errorf("failed info line longer than %d: %s", mt940InfoLineLen, l)
+		}
+	}
+	if !strings.HasPrefix(lines[0], "Payment. rent . x") {
+		t.Errorf("failed info charset got %s", lines[0])
+	}
+}
+
+func TestFileName(t *testing.T) {
+	st := goldenStatement()
+	if got := FileName(st, FormatCamt053); got != "statement-7-20230301-20230331.xml" {
+		t.Errorf("failed camt.053 file name got %s", got)
+	}
+	if got := FileName(st, FormatMT940); got != "statement-7-20230301-20230331.sta" {
+		t.Errorf("failed mt940 file name got %s", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	st := testStatement(3)
+
+	// 1000, -500, 1002 on top of 5000
+	wantBalances := []int64{6000, 5500, 6502}
+	for i, l := range st.Lines {
+		if l.Balance != wantBalances[i] {
+			t.Errorf("failed line %d balance want %d got %d", i, wantBalances[i], l.Balance)
+		}
+	}
+	if st.ClosingBalance != 6502 {
+		t.Errorf("failed closing balance want 6502 got %d", st.ClosingBalance)
+	}
+	if st.TotalCredits != 2002 || st.TotalDebits != -500 {
+		t.Errorf("failed totals want 2002 and -500 got %d and %d", st.TotalCredits, st.TotalDebits)
+	}
+	if st.OpeningBalance+st.TotalCredits+st.TotalDebits != st.ClosingBalance {
+		t.Errorf("failed totals don't add up to closing balance")
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	st := testStatement(3)
+	data, contentType, err := Render(st, FormatCSV)
+	if err != nil {
+		t.Fatalf("failed render error:%s", err)
+	}
+	if contentType != "text/csv" {
+		t.Errorf("failed content type want text/csv got %s", contentType)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed read csv error:%s", err)
+	}
+	// header, opening, 3 entries, closing
+	if len(rows) != 6 {
+		t.Fatalf("failed rows want 6 got %d", len(rows))
+	}
+	if rows[1][6] != "50.00" || rows[3][4] != "5.00" || rows[4][6] != "65.02" || rows[5][6] != "65.02" {
+		t.Errorf("failed csv amounts got %v", rows)
+	}
+}
+
+func TestRenderOFX(t *testing.T) {
+	st := testStatement(3)
+	data, _, err := Render(st, FormatOFX)
+	if err != nil {
+		t.Fatalf("failed render error:%s", err)
+	}
+
+	var doc ofxDocument
+	err = xml.Unmarshal(data, &doc)
+	if err != nil {
+		t.Fatalf("failed parse ofx error:%s", err)
+	}
+	stmt := doc.Bank.Response.Statement
+	if stmt.Currency != "USD" || stmt.Account.AcctID != "7" {
+		t.Errorf("failed ofx account got %s %s", stmt.Currency, stmt.Account.AcctID)
+	}
+	if len(stmt.Transactions.Items) != 3 || stmt.Transactions.Items[1].Type != "DEBIT" || stmt.Transactions.Items[1].Amount != "-5.00" {
+		t.Errorf("failed ofx transactions got %+v", stmt.Transactions.Items)
+	}
+	if stmt.LedgerBalance.Amount != "65.02" {
+		t.Errorf("failed ofx ledger balance want 65.02 got %s", stmt.LedgerBalance.Amount)
+	}
+}
+
+func TestRenderPDF(t *testing.T) {
+	// enough lines for more than one page
+	st := testStatement(150)
+	data, _, err := Render(st, FormatPDF)
+	if err != nil {
+		t.Fatalf("failed render error:%s", err)
+	}
+
+	if !bytes.HasPrefix(data, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("failed pdf header or trailer")
+	}
+
+	// startxref must point at the cross reference table and every entry at its object
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if m == nil {
+		t.Fatalf("failed pdf startxref not found")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(data[xref:], []byte("xref\n")) {
+		t.Fatalf("failed pdf startxref %d doesn't point at xref", xref)
+	}
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, o := range offsets {
+		off, _ := strconv.Atoi(string(o[1]))
+		if !bytes.HasPrefix(data[off:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("failed pdf offset of object %d", i+1)
+		}
+	}
+
+	if !bytes.Contains(data, []byte("/Count 3")) || !bytes.Contains(data, []byte("(Page 3 of 3) '")) {
+		t.Errorf("failed pdf want 3 pages")
+	}
+}
+
+func TestRenderUnsupported(t *testing.T) {
+	_, _, err := Render(testStatement(1), "xls")
+	if err == nil {
+		t.Errorf("failed render want error for unsupported format")
+	}
+}
+
+func TestPDFEscape(t *testing.T) {
+	got := pdfEscape(`a(b)\c é`)
+	want := `a\(b\)\\c ?`
+	if got != want {
+		t.Errorf("failed escape want %s got %s", want, got)
+	}
+}
@@ -1,13 +1,21 @@
 package util
 
 import (
+	"fmt"
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	DbDriver             string        `mapstructure:"DB_DRIVER"`
 	DbSource             string        `mapstructure:"DB_SOURCE"`
+	DbIsolationLevel     string        `mapstructure:"DB_ISOLATION_LEVEL"`
+	DbTxMaxAttempts      int           `mapstructure:"DB_TX_MAX_ATTEMPTS"`
+	DbTxRetryBackoff     time.Duration `mapstructure:"DB_TX_RETRY_BACKOFF"`
 	MigrationURL         string        `mapstructure:"MIGRATION_URL"`
 	HttpServerAddr       string        `mapstructure:"HTTP_SERVER_ADDR"`
 	GrpcServerAddr       string        `mapstructure:"GRPC_SERVER_ADDR"`
@@ -19,6 +27,29 @@ type Config struct {
 	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
 	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
 	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	HoldDuration         time.Duration `mapstructure:"HOLD_DURATION"`
+	AchOutputDir         string        `mapstructure:"ACH_OUTPUT_DIR"`
+	AchReturnsDir        string        `mapstructure:"ACH_RETURNS_DIR"`
+	AchDestination       string        `mapstructure:"ACH_IMMEDIATE_DESTINATION"`
+	AchDestinationName   string        `mapstructure:"ACH_IMMEDIATE_DESTINATION_NAME"`
+	AchOrigin            string        `mapstructure:"ACH_IMMEDIATE_ORIGIN"`
+	AchOriginName        string        `mapstructure:"ACH_IMMEDIATE_ORIGIN_NAME"`
+	AchCompanyName       string        `mapstructure:"ACH_COMPANY_NAME"`
+	AchCompanyID         string        `mapstructure:"ACH_COMPANY_ID"`
+	AchOriginatingDFI    string        `mapstructure:"ACH_ORIGINATING_DFI"`
+	PayeeCoolingOff      time.Duration `mapstructure:"PAYEE_COOLING_OFF"`
+	// most a payee in its cooling-off period can receive by currency, e.g. "USD:10000,JPY:15000"
+	PayeeCoolingOffLimit CurrencyAmounts `mapstructure:"PAYEE_COOLING_OFF_LIMIT"`
+	RequirePayee         bool            `mapstructure:"REQUIRE_PAYEE"`
+	// risk rules, a rule is disabled when its main setting is 0
+	RiskVelocityMaxTransfers int           `mapstructure:"RISK_VELOCITY_MAX_TRANSFERS"`
+	RiskVelocityWindow       time.Duration `mapstructure:"RISK_VELOCITY_WINDOW"`
+	RiskNewDeviceMinAge      time.Duration `mapstructure:"RISK_NEW_DEVICE_MIN_AGE"`
+	RiskNewDeviceMinAmount   int64         `mapstructure:"RISK_NEW_DEVICE_MIN_AMOUNT"`
+	RiskAnomalyMultiplier    int64         `mapstructure:"RISK_ANOMALY_MULTIPLIER"`
+	RiskAnomalyLookback      time.Duration `mapstructure:"RISK_ANOMALY_LOOKBACK"`
+	RiskAnomalyMinHistory    int           `mapstructure:"RISK_ANOMALY_MIN_HISTORY"`
+	RiskFirstPayeeMinAmount  int64         `mapstructure:"RISK_FIRST_PAYEE_MIN_AMOUNT"`
 }
 
 func LoadConfig(path string) (Config, error) {
@@ -35,10 +66,49 @@ func LoadConfig(path string) (Config, error) {
 		return config, err
 	}
 
-	err = viper.Unmarshal(&config)
+	err = viper.Unmarshal(&config, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToCurrencyAmountsHook,
+	)))
 	if err != nil {
 		return config, err
 	}
 
 	return config, nil
 }
+
+// CurrencyAmounts is an amount in minor units of each currency by currency code, a currency not in it has no amount
+type CurrencyAmounts map[string]int64
+
+// ParseCurrencyAmounts parse a comma separated list of currency:amount like "USD:10000,JPY:15000"
+func ParseCurrencyAmounts(s string) (CurrencyAmounts, error) {
+	amounts := CurrencyAmounts{}
+	if strings.TrimSpace(s) == "" {
+		return amounts, nil
+	}
+
+	for _, item := range strings.Split(s, ",") {
+		code, value, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok || len(code) != 3 {
+			return nil, fmt.Errorf("invalid currency amount %q", item)
+		}
+		amount, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid currency amount %q", item)
+		}
+		if _, ok := amounts[code]; ok {
+			return nil, fmt.Errorf("duplicate currency amount %q", code)
+		}
+		amounts[code] = amount
+	}
+
+	return amounts, nil
+}
+
+func stringToCurrencyAmountsHook(f reflect.Type, t reflect.Type, data interface{}) (interface{}, error) {
+	if f.Kind() != reflect.String || t != reflect.TypeOf(CurrencyAmounts{}) {
+		return data, nil
+	}
+	return ParseCurrencyAmounts(data.(string))
+}
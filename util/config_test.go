@@ -0,0 +1,71 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCurrencyAmounts(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Value    string
+		Expected CurrencyAmounts
+		IsError  bool
+	}{
+		{
+			Name:     "Empty",
+			Value:    "",
+			Expected: CurrencyAmounts{},
+		},
+		{
+			Name:     "ByCurrency",
+			Value:    "USD:10000, JPY:15000,KWD:30000",
+			Expected: CurrencyAmounts{"USD": 10000, "JPY": 15000, "KWD": 30000},
+		},
+		{
+			Name:    "MissingAmount",
+			Value:   "USD",
+			IsError: true,
+		},
+		{
+			Name:    "InvalidCurrency",
+			Value:   "US:10000",
+			IsError: true,
+		},
+		{
+			Name:    "InvalidAmount",
+			Value:   "USD:100.00",
+			IsError: true,
+		},
+		{
+			Name:    "NegativeAmount",
+			Value:   "USD:-1",
+			IsError: true,
+		},
+		{
+			Name:    "Duplicate",
+			Value:   "USD:1,USD:2",
+			IsError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		res, err := ParseCurrencyAmounts(tc.Value)
+		if (err != nil) != tc.IsError {
+			t.Fatalf("failed %s want error %v got %v", tc.Name, tc.IsError, err)
+		}
+		if !tc.IsError && !reflect.DeepEqual(res, tc.Expected) {
+			t.Fatalf("failed %s want %v got %v", tc.Name, tc.Expected, res)
+		}
+	}
+}
+
+func TestLoadConfigCurrencyAmounts(t *testing.T) {
+	config, err := LoadConfig("..")
+	if err != nil {
+		t.Fatalf("failed load config error:%s", err)
+	}
+	if config.PayeeCoolingOffLimit["USD"] != 10000 || config.PayeeCoolingOffLimit["JPY"] != 15000 {
+		t.Fatalf("failed payee cooling-off limit of app.env got %v", config.PayeeCoolingOffLimit)
+	}
+}
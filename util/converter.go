@@ -15,3 +15,69 @@ func ConvertUser(user models.Users) *pb.User {
 		CreatedAt: timestamppb.New(user.CreatedAt),
 	}
 }
+
+func ConvertAccount(account models.Account) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		HeldBalance:      account.HeldBalance,
+		AvailableBalance: account.AvailableBalance,
+		OverdraftLimit:   account.OverdraftLimit,
+		Currency:         account.Currency,
+		Status:           account.Status,
+		ProductId:        account.ProductID,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+	}
+}
+
+func ConvertEntry(entry models.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:         entry.ID,
+		AccountId:  entry.AccountID,
+		Amount:     entry.Amount,
+		TransferId: entry.TransferID,
+		CreatedAt:  timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func ConvertTransfer(transfer models.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:            transfer.ID,
+		FromAccountId: transfer.FromAccountID,
+		ToAccountId:   transfer.ToAccountID,
+		Amount:        transfer.Amount,
+		ToAmount:      transfer.ToAmount,
+		FxRate:        transfer.FxRate,
+		FxSpreadBps:   transfer.FxSpreadBps,
+		ReversalOf:    transfer.ReversalOf,
+		Fee:           transfer.Fee,
+		CreatedAt:     timestamppb.New(transfer.CreatedAt),
+	}
+}
+
+func ConvertTransferReview(review models.TransferReview) *pb.TransferReview {
+	findings := make([]*pb.RiskFinding, 0, len(review.Findings))
+	for _, f := range review.Findings {
+		findings = append(findings, &pb.RiskFinding{
+			Rule:     f.Rule,
+			Decision: f.Decision,
+			Reason:   f.Reason,
+		})
+	}
+
+	return &pb.TransferReview{
+		Id:            review.ID,
+		Owner:         review.Owner,
+		FromAccountId: review.FromAccountID,
+		ToAccountId:   review.ToAccountID,
+		Amount:        review.Amount,
+		ToCurrency:    review.ToCurrency,
+		Status:        review.Status,
+		Findings:      findings,
+		ReviewedBy:    review.ReviewedBy,
+		TransferId:    review.TransferID,
+		CreatedAt:     timestamppb.New(review.CreatedAt),
+		ReviewedAt:    timestamppb.New(review.ReviewedAt),
+	}
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	"time"
+)
+
+// NextCronTime return the first time after the given time that match the standard 5 fields cron spec,
+// e.g. "0 9 1 * *" is 09:00 on the 1st of every month. The spec is evaluated in UTC.
+func NextCronTime(spec string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron spec %q: %w", spec, err)
+	}
+
+	next := schedule.Next(after.UTC())
+	if next.IsZero() {
+		return next, fmt.Errorf("cron spec %q never run", spec)
+	}
+
+	return next, nil
+}
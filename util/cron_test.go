@@ -0,0 +1,54 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextCronTime(t *testing.T) {
+	after := time.Date(2023, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name     string
+		Spec     string
+		Expected time.Time
+		IsError  bool
+	}{
+		{
+			Name:     "Monthly",
+			Spec:     "0 9 1 * *",
+			Expected: time.Date(2023, 2, 1, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:     "Daily",
+			Spec:     "@daily",
+			Expected: time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			Name:    "Invalid",
+			Spec:    "every month",
+			IsError: true,
+		},
+		{
+			Name:    "Never",
+			Spec:    "0 0 30 2 *",
+			IsError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := NextCronTime(tc.Spec, after)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if !got.Equal(tc.Expected) {
+			t.Fatalf("failed %s want %s got %s", tc.Name, tc.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// cursor is the position after the last row of a page. It is encoded so clients pass it back as is
+// instead of building it, that let the position change without breaking them
+type cursor struct {
+	AfterID int64 `json:"after_id"`
+}
+
+// EncodeCursor return the cursor of the page starting after the row with the given id
+func EncodeCursor(afterID int64) string {
+	b, _ := json.Marshal(cursor{AfterID: afterID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor return the id the page of the cursor start after, empty cursor is the first page
+func DecodeCursor(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	var c cursor
+	err = json.Unmarshal(b, &c)
+	if err != nil || c.AfterID < 1 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+
+	return c.AfterID, nil
+}
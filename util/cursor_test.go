@@ -0,0 +1,29 @@
+package util
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	for _, id := range []int64{1, 42, 9007199254740993} {
+		got, err := DecodeCursor(EncodeCursor(id))
+		if err != nil {
+			t.Fatalf("failed decode cursor of %d error:%s", id, err)
+		}
+		if got != id {
+			t.Fatalf("failed cursor want %d got %d", id, got)
+		}
+	}
+
+	got, err := DecodeCursor("")
+	if err != nil || got != 0 {
+		t.Fatalf("empty cursor should be the first page got %d error:%v", got, err)
+	}
+
+	for _, c := range []string{"not a cursor", "bm90IGpzb24", EncodeCursor(0), EncodeCursor(-5)} {
+		_, err = DecodeCursor(c)
+		if err == nil {
+			t.Fatalf("cursor %q should be invalid", c)
+		}
+	}
+}
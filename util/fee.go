@@ -0,0 +1,40 @@
+package util
+
+import (
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"math/big"
+)
+
+// TransferFee return the fee in minor units of the schedule for a transfer of amount.
+// Percentage fee is rounded down to the whole minor unit then bounded by the min and max amount.
+func TransferFee(schedule models.FeeSchedule, amount int64) (int64, error) {
+	switch schedule.FeeType {
+	case models.FeeTypeFree:
+		return 0, nil
+	case models.FeeTypeFlat:
+		return schedule.FlatAmount, nil
+	case models.FeeTypePercentage:
+		if schedule.PercentageBps < 0 || schedule.MinAmount < 0 || schedule.MaxAmount < 0 {
+			return 0, fmt.Errorf("invalid fee schedule %d", schedule.ID)
+		}
+
+		// rounding down, big.Int.Div is euclidean division so it is floor for positive denominator
+		v := new(big.Int).Mul(big.NewInt(amount), big.NewInt(schedule.PercentageBps))
+		v.Div(v, big.NewInt(10000))
+		if !v.IsInt64() {
+			return 0, fmt.Errorf("transfer fee overflow")
+		}
+
+		fee := v.Int64()
+		if fee < schedule.MinAmount {
+			fee = schedule.MinAmount
+		}
+		if schedule.MaxAmount > 0 && fee > schedule.MaxAmount {
+			fee = schedule.MaxAmount
+		}
+		return fee, nil
+	}
+
+	return 0, fmt.Errorf("invalid fee type %q", schedule.FeeType)
+}
@@ -0,0 +1,88 @@
+package util
+
+import (
+	"github.com/ismail118/simple-bank/models"
+	"testing"
+)
+
+func TestTransferFee(t *testing.T) {
+	percentage := models.FeeSchedule{
+		FeeType:       models.FeeTypePercentage,
+		PercentageBps: 150, // 1.5%
+		MinAmount:     50,
+		MaxAmount:     1000,
+	}
+
+	testCases := []struct {
+		Name     string
+		Schedule models.FeeSchedule
+		Amount   int64
+		Expected int64
+		IsError  bool
+	}{
+		{
+			Name:     "Free",
+			Schedule: models.FeeSchedule{FeeType: models.FeeTypeFree, FlatAmount: 100},
+			Amount:   10000,
+			Expected: 0,
+		},
+		{
+			Name:     "Flat",
+			Schedule: models.FeeSchedule{FeeType: models.FeeTypeFlat, FlatAmount: 100},
+			Amount:   10000,
+			Expected: 100,
+		},
+		{
+			Name:     "Percentage",
+			Schedule: percentage,
+			Amount:   10000, // 100.00
+			Expected: 150,
+		},
+		{
+			Name:     "PercentageRoundDown",
+			Schedule: percentage,
+			Amount:   10033,
+			Expected: 150, // 150.495
+		},
+		{
+			Name:     "PercentageMin",
+			Schedule: percentage,
+			Amount:   1000,
+			Expected: 50, // 15 is below min
+		},
+		{
+			Name:     "PercentageMax",
+			Schedule: percentage,
+			Amount:   1000000,
+			Expected: 1000, // 15000 is above max
+		},
+		{
+			Name:     "PercentageNoMax",
+			Schedule: models.FeeSchedule{FeeType: models.FeeTypePercentage, PercentageBps: 150},
+			Amount:   1000000,
+			Expected: 15000,
+		},
+		{
+			Name:     "InvalidType",
+			Schedule: models.FeeSchedule{FeeType: "tiered"},
+			Amount:   10000,
+			IsError:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := TransferFee(tc.Schedule, tc.Amount)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if got != tc.Expected {
+			t.Fatalf("failed %s want %d got %d", tc.Name, tc.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package util
+
+import (
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"math/big"
+	"time"
+)
+
+// DayCount return the number of days between from and to and the number of days in a year
+// for the given convention, only the UTC date of both times is used.
+//
+// ACT/365 count the actual calendar days over a 365 days year.
+// 30/360 count every month as 30 days over a 360 days year (bond basis), a day
+// that is the 31st is counted as the 30th so e.g. 30 Jan to 31 Jan is 0 days.
+func DayCount(convention string, from, to time.Time) (int64, int64, error) {
+	y1, m1, d1 := from.UTC().Date()
+	y2, m2, d2 := to.UTC().Date()
+
+	switch convention {
+	case models.DayCountAct365:
+		start := time.Date(y1, m1, d1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(y2, m2, d2, 0, 0, 0, 0, time.UTC)
+		return int64(end.Sub(start).Hours() / 24), 365, nil
+	case models.DayCount30360:
+		if d1 == 31 {
+			d1 = 30
+		}
+		if d2 == 31 && d1 == 30 {
+			d2 = 30
+		}
+		days := 360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1)
+		return int64(days), 360, nil
+	}
+
+	return 0, 0, fmt.Errorf("invalid day count convention %q", convention)
+}
+
+// AccrueInterest return the interest of balance from the from date to the to date in millionths of
+// minor unit (models.InterestMicrosPerMinorUnit). The result is rounded down so the same input always
+// give the same output, negative or zero balance doesn't earn interest.
+func AccrueInterest(balance int64, annualRate string, convention string, from, to time.Time) (int64, error) {
+	r, ok := new(big.Rat).SetString(annualRate)
+	if !ok || r.Sign() < 0 {
+		return 0, fmt.Errorf("invalid annual rate %q", annualRate)
+	}
+
+	days, yearDays, err := DayCount(convention, from, to)
+	if err != nil {
+		return 0, err
+	}
+	if balance <= 0 || days <= 0 {
+		return 0, nil
+	}
+
+	v := new(big.Rat).SetInt64(balance)
+	v.Mul(v, r)
+	v.Mul(v, big.NewRat(days*models.InterestMicrosPerMinorUnit, yearDays))
+
+	// rounding down, big.Int.Div is euclidean division so it is floor for positive denominator
+	res := new(big.Int).Div(v.Num(), v.Denom())
+	if !res.IsInt64() {
+		return 0, fmt.Errorf("accrued interest overflow")
+	}
+
+	return res.Int64(), nil
+}
@@ -0,0 +1,231 @@
+package util
+
+import (
+	"github.com/ismail118/simple-bank/models"
+	"testing"
+	"time"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDayCount(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Convention string
+		From       time.Time
+		To         time.Time
+		Days       int64
+		YearDays   int64
+		IsError    bool
+	}{
+		{
+			Name:       "Act365OneDay",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.January, 30),
+			To:         date(2023, time.January, 31),
+			Days:       1,
+			YearDays:   365,
+		},
+		{
+			Name:       "Act365LeapFebruary",
+			Convention: models.DayCountAct365,
+			From:       date(2024, time.February, 1),
+			To:         date(2024, time.March, 1),
+			Days:       29,
+			YearDays:   365,
+		},
+		{
+			Name:       "Act365IgnoreTimeOfDay",
+			Convention: models.DayCountAct365,
+			From:       time.Date(2023, time.March, 1, 23, 59, 0, 0, time.UTC),
+			To:         time.Date(2023, time.March, 2, 0, 1, 0, 0, time.UTC),
+			Days:       1,
+			YearDays:   365,
+		},
+		{
+			Name:       "30360ThirtiethToThirtyFirst",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.January, 30),
+			To:         date(2023, time.January, 31),
+			Days:       0,
+			YearDays:   360,
+		},
+		{
+			Name:       "30360ThirtyFirstToFirst",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.January, 31),
+			To:         date(2023, time.February, 1),
+			Days:       1,
+			YearDays:   360,
+		},
+		{
+			Name:       "30360EndOfFebruary",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.February, 28),
+			To:         date(2023, time.March, 1),
+			Days:       3,
+			YearDays:   360,
+		},
+		{
+			Name:       "30360FullMonth",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.February, 1),
+			To:         date(2023, time.March, 1),
+			Days:       30,
+			YearDays:   360,
+		},
+		{
+			Name:       "30360FullYear",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.January, 1),
+			To:         date(2024, time.January, 1),
+			Days:       360,
+			YearDays:   360,
+		},
+		{
+			Name:       "InvalidConvention",
+			Convention: "ACT/ACT",
+			From:       date(2023, time.January, 1),
+			To:         date(2023, time.January, 2),
+			IsError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		days, yearDays, err := DayCount(tc.Convention, tc.From, tc.To)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if days != tc.Days || yearDays != tc.YearDays {
+			t.Fatalf("failed %s want %d/%d got %d/%d", tc.Name, tc.Days, tc.YearDays, days, yearDays)
+		}
+	}
+}
+
+func TestAccrueInterest(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Balance    int64
+		AnnualRate string
+		Convention string
+		From       time.Time
+		To         time.Time
+		Expected   int64
+		IsError    bool
+	}{
+		{
+			Name:       "Act365OneDay",
+			Balance:    100000, // 1000.00
+			AnnualRate: "0.05",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			Expected:   13698630, // 13.698630136... minor units
+		},
+		{
+			Name:       "Act365FullYear",
+			Balance:    100000,
+			AnnualRate: "0.05",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.January, 1),
+			To:         date(2024, time.January, 1),
+			Expected:   5000000000, // exactly 50.00
+		},
+		{
+			Name:       "Act365SmallBalance",
+			Balance:    1, // 0.01
+			AnnualRate: "0.01",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			Expected:   27, // 27.397... millionths
+		},
+		{
+			Name:       "30360OneDay",
+			Balance:    100000,
+			AnnualRate: "0.05",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			Expected:   13888888, // 13.888888... minor units
+		},
+		{
+			Name:       "30360EndOfFebruary",
+			Balance:    100000,
+			AnnualRate: "0.05",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.February, 28),
+			To:         date(2023, time.March, 1),
+			Expected:   41666666, // 3 days
+		},
+		{
+			Name:       "30360ThirtyFirst",
+			Balance:    100000,
+			AnnualRate: "0.05",
+			Convention: models.DayCount30360,
+			From:       date(2023, time.May, 30),
+			To:         date(2023, time.May, 31),
+			Expected:   0,
+		},
+		{
+			Name:       "NegativeBalance",
+			Balance:    -100000,
+			AnnualRate: "0.05",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			Expected:   0,
+		},
+		{
+			Name:       "ZeroRate",
+			Balance:    100000,
+			AnnualRate: "0",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			Expected:   0,
+		},
+		{
+			Name:       "InvalidRate",
+			Balance:    100000,
+			AnnualRate: "abc",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			IsError:    true,
+		},
+		{
+			Name:       "NegativeRate",
+			Balance:    100000,
+			AnnualRate: "-0.01",
+			Convention: models.DayCountAct365,
+			From:       date(2023, time.May, 10),
+			To:         date(2023, time.May, 11),
+			IsError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := AccrueInterest(tc.Balance, tc.AnnualRate, tc.Convention, tc.From, tc.To)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if got != tc.Expected {
+			t.Fatalf("failed %s want %d got %d", tc.Name, tc.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package util
+
+import (
+	"github.com/ismail118/simple-bank/models"
+	"time"
+)
+
+// rolling windows of the daily and monthly transfer limits
+const (
+	TransferLimitDay   = 24 * time.Hour
+	TransferLimitMonth = 30 * TransferLimitDay
+)
+
+// ResolveTransferLimits pick the policies that apply to an account among the default of its currency, the
+// policy of its owner and its own policy. The owner policy replace the default, both may be empty.
+func ResolveTransferLimits(policies []*models.TransferLimit) (owner models.TransferLimit, account models.TransferLimit) {
+	for _, p := range policies {
+		switch p.Scope() {
+		case models.TransferLimitScopeAccount:
+			account = *p
+		case models.TransferLimitScopeOwner:
+			owner = *p
+		case models.TransferLimitScopeDefault:
+			if owner.ID < 1 {
+				owner = *p
+			}
+		}
+	}
+	return owner, account
+}
+
+// TransferLimitRemaining return what can still be sent under limit after used, -1 when there is no limit
+func TransferLimitRemaining(limit, used int64) int64 {
+	if limit <= 0 {
+		return -1
+	}
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
+
+// TransferLimitAllowance return the first period of the policy a transfer of amount would exceed and what can
+// still be sent in that period. The period is empty when the transfer is within every limit.
+func TransferLimitAllowance(limit models.TransferLimit, usage models.TransferLimitUsage, amount int64) (string, int64) {
+	checks := []struct {
+		period string
+		limit  int64
+		used   int64
+	}{
+		{models.TransferLimitPeriodPerTransaction, limit.PerTransaction, 0},
+		{models.TransferLimitPeriodDaily, limit.Daily, usage.Daily},
+		{models.TransferLimitPeriodMonthly, limit.Monthly, usage.Monthly},
+	}
+	for _, c := range checks {
+		remaining := TransferLimitRemaining(c.limit, c.used)
+		if remaining >= 0 && amount > remaining {
+			return c.period, remaining
+		}
+	}
+	return "", 0
+}
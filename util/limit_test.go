@@ -0,0 +1,86 @@
+package util
+
+import (
+	"github.com/ismail118/simple-bank/models"
+	"testing"
+)
+
+func TestResolveTransferLimits(t *testing.T) {
+	def := &models.TransferLimit{ID: 1, Currency: "USD", Daily: 1000}
+	owner := &models.TransferLimit{ID: 2, Owner: "some-user", Currency: "USD", Daily: 5000}
+	account := &models.TransferLimit{ID: 3, AccountID: 2, Currency: "USD", PerTransaction: 100}
+
+	o, a := ResolveTransferLimits([]*models.TransferLimit{def, owner, account})
+	if o.ID != owner.ID || a.ID != account.ID {
+		t.Fatalf("failed owner policy must replace the default, got %d and %d", o.ID, a.ID)
+	}
+
+	o, a = ResolveTransferLimits([]*models.TransferLimit{def})
+	if o.ID != def.ID || a.ID != 0 {
+		t.Fatalf("failed default policy must apply without owner policy, got %d and %d", o.ID, a.ID)
+	}
+}
+
+func TestTransferLimitAllowance(t *testing.T) {
+	limit := models.TransferLimit{PerTransaction: 500, Daily: 1000, Monthly: 3000}
+
+	testCases := []struct {
+		Name      string
+		Limit     models.TransferLimit
+		Usage     models.TransferLimitUsage
+		Amount    int64
+		Period    string
+		Remaining int64
+	}{
+		{
+			Name:   "Within",
+			Limit:  limit,
+			Usage:  models.TransferLimitUsage{Daily: 500, Monthly: 2500},
+			Amount: 500,
+		},
+		{
+			Name:      "PerTransaction",
+			Limit:     limit,
+			Amount:    501,
+			Period:    models.TransferLimitPeriodPerTransaction,
+			Remaining: 500,
+		},
+		{
+			Name:      "Daily",
+			Limit:     limit,
+			Usage:     models.TransferLimitUsage{Daily: 800, Monthly: 800},
+			Amount:    300,
+			Period:    models.TransferLimitPeriodDaily,
+			Remaining: 200,
+		},
+		{
+			Name:      "Monthly",
+			Limit:     limit,
+			Usage:     models.TransferLimitUsage{Daily: 0, Monthly: 2900},
+			Amount:    200,
+			Period:    models.TransferLimitPeriodMonthly,
+			Remaining: 100,
+		},
+		{
+			Name:      "DailyAlreadyOver",
+			Limit:     limit,
+			Usage:     models.TransferLimitUsage{Daily: 1200, Monthly: 1200},
+			Amount:    1,
+			Period:    models.TransferLimitPeriodDaily,
+			Remaining: 0,
+		},
+		{
+			Name:   "NoLimit",
+			Limit:  models.TransferLimit{},
+			Usage:  models.TransferLimitUsage{Daily: 1000000, Monthly: 1000000},
+			Amount: 1000000,
+		},
+	}
+
+	for _, tc := range testCases {
+		period, remaining := TransferLimitAllowance(tc.Limit, tc.Usage, tc.Amount)
+		if period != tc.Period || remaining != tc.Remaining {
+			t.Fatalf("failed %s want %q %d got %q %d", tc.Name, tc.Period, tc.Remaining, period, remaining)
+		}
+	}
+}
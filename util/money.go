@@ -0,0 +1,124 @@
+package util
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ConvertAmount convert amount in minor units of the from currency to minor units of the to currency.
+// rate is the amount of to currency for one unit of from currency and spreadBps is the bank margin
+// in basis points taken from the converted amount. The result is rounded down to the whole minor unit,
+// so the same input always give the same output and the bank never credit more than the quoted rate.
+func ConvertAmount(amount int64, fromMinorUnit, toMinorUnit int, rate string, spreadBps int64) (int64, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return 0, fmt.Errorf("invalid fx rate %q", rate)
+	}
+	if spreadBps < 0 || spreadBps >= 10000 {
+		return 0, fmt.Errorf("invalid fx spread %d bps", spreadBps)
+	}
+	if fromMinorUnit < 0 || toMinorUnit < 0 {
+		return 0, fmt.Errorf("invalid minor unit %d to %d", fromMinorUnit, toMinorUnit)
+	}
+
+	v := new(big.Rat).SetInt64(amount)
+	v.Mul(v, r)
+	v.Mul(v, big.NewRat(10000-spreadBps, 10000))
+
+	// move from the minor unit of the from currency to the minor unit of the to currency
+	exp := toMinorUnit - fromMinorUnit
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil)
+	if exp > 0 {
+		v.Mul(v, new(big.Rat).SetInt(scale))
+	} else if exp < 0 {
+		v.Quo(v, new(big.Rat).SetInt(scale))
+	}
+
+	// rounding down, big.Int.Div is euclidean division so it is floor for positive denominator
+	res := new(big.Int).Div(v.Num(), v.Denom())
+	if !res.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflow")
+	}
+
+	return res.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ProrateAmount return amount * numerator / denominator rounded down, e.g. the part of the converted amount of a
+// transfer that a partial reversal take back. The product is computed with big.Int so it can't overflow int64.
+func ProrateAmount(amount, numerator, denominator int64) (int64, error) {
+	if amount < 0 || numerator < 0 || denominator <= 0 {
+		return 0, fmt.Errorf("invalid proration %d * %d / %d", amount, numerator, denominator)
+	}
+
+	v := new(big.Int).Mul(big.NewInt(amount), big.NewInt(numerator))
+	// rounding down, the operands are not negative so the quotient is the floor
+	v.Quo(v, big.NewInt(denominator))
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("prorated amount overflow")
+	}
+
+	return v.Int64(), nil
+}
+
+// FormatAmount format amount in minor units as a decimal of the major unit, e.g. -1234 with 2 minor units is "-12.34"
+func FormatAmount(amount int64, minorUnit int) string {
+	sign := ""
+	// negate as uint64 so the smallest int64 doesn't overflow
+	v := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		v = -v
+	}
+	if minorUnit <= 0 {
+		return fmt.Sprintf("%s%d", sign, v)
+	}
+
+	scale := uint64(1)
+	for i := 0; i < minorUnit; i++ {
+		scale *= 10
+	}
+	return fmt.Sprintf("%s%d.%0*d", sign, v/scale, minorUnit, v%scale)
+}
+
+// ParseAmount parse a positive decimal of the major unit like "12.34" in minor units, it returns an error
+// when the amount has more decimals than the currency minor unit or doesn't fit in int64
+func ParseAmount(s string, minorUnit int) (int64, error) {
+	if minorUnit < 0 {
+		return 0, fmt.Errorf("invalid minor unit %d", minorUnit)
+	}
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if whole == "" || !isDigits(whole) || !isDigits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > minorUnit {
+		return 0, fmt.Errorf("amount %q has more than %d decimals", s, minorUnit)
+	}
+
+	v, ok := new(big.Int).SetString(whole+frac+strings.Repeat("0", minorUnit-len(frac)), 10)
+	if !ok || !v.IsInt64() {
+		return 0, fmt.Errorf("amount %q overflow", s)
+	}
+
+	return v.Int64(), nil
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package util
+
+import "testing"
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Amount        int64
+		FromMinorUnit int
+		ToMinorUnit   int
+		Rate          string
+		SpreadBps     int64
+		Expected      int64
+		IsError       bool
+	}{
+		{
+			Name:          "SameMinorUnit",
+			Amount:        10000, // 100.00 EUR
+			FromMinorUnit: 2,
+			ToMinorUnit:   2,
+			Rate:          "1.0850000000",
+			Expected:      10850, // 108.50 USD
+		},
+		{
+			Name:          "WithSpread",
+			Amount:        10000,
+			FromMinorUnit: 2,
+			ToMinorUnit:   2,
+			Rate:          "1.085",
+			SpreadBps:     50,
+			Expected:      10795, // 108.50 - 0.5% = 107.9575 rounded down
+		},
+		{
+			Name:          "RoundDown",
+			Amount:        1, // 0.01 USD
+			FromMinorUnit: 2,
+			ToMinorUnit:   2,
+			Rate:          "0.9",
+			Expected:      0,
+		},
+		{
+			Name:          "ToZeroMinorUnit",
+			Amount:        12345, // 123.45 USD
+			FromMinorUnit: 2,
+			ToMinorUnit:   0,
+			Rate:          "149.5",
+			Expected:      18455, // 18455.775 JPY
+		},
+		{
+			Name:          "FromZeroMinorUnit",
+			Amount:        1000, // 1000 JPY
+			FromMinorUnit: 0,
+			ToMinorUnit:   3,
+			Rate:          "0.00205",
+			Expected:      2050, // 2.050 KWD
+		},
+		{
+			Name:          "InvalidRate",
+			Amount:        100,
+			FromMinorUnit: 2,
+			ToMinorUnit:   2,
+			Rate:          "abc",
+			IsError:       true,
+		},
+		{
+			Name:          "ZeroRate",
+			Amount:        100,
+			FromMinorUnit: 2,
+			ToMinorUnit:   2,
+			Rate:          "0",
+			IsError:       true,
+		},
+		{
+			Name:          "InvalidSpread",
+			Amount:        100,
+			FromMinorUnit: 2,
+			ToMinorUnit:   2,
+			Rate:          "1",
+			SpreadBps:     10000,
+			IsError:       true,
+		},
+	}
+
+	for _, tc := range testCases {
+		got, err := ConvertAmount(tc.Amount, tc.FromMinorUnit, tc.ToMinorUnit, tc.Rate, tc.SpreadBps)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if got != tc.Expected {
+			t.Fatalf("failed %s want %d got %d", tc.Name, tc.Expected, got)
+		}
+	}
+}
+
+func TestProrateAmount(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Amount      int64
+		Numerator   int64
+		Denominator int64
+		Expected    int64
+		IsError     bool
+	}{
+		{Name: "Half", Amount: 50, Numerator: 9000, Denominator: 100, Expected: 4500},
+		{Name: "RoundDown", Amount: 1, Numerator: 2, Denominator: 3, Expected: 0},
+		{Name: "Whole", Amount: 100, Numerator: 9001, Denominator: 100, Expected: 9001},
+		// the product overflow int64 but the result doesn't
+		{Name: "LargeProduct", Amount: 4000000000000000, Numerator: 5000000000000000, Denominator: 8000000000000000, Expected: 2500000000000000},
+		{Name: "Overflow", Amount: 9000000000000000000, Numerator: 2, Denominator: 1, IsError: true},
+		{Name: "ZeroDenominator", Amount: 1, Numerator: 1, Denominator: 0, IsError: true},
+		{Name: "Negative", Amount: -1, Numerator: 1, Denominator: 1, IsError: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ProrateAmount(tc.Amount, tc.Numerator, tc.Denominator)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got %d", tc.Name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if got != tc.Expected {
+			t.Fatalf("failed %s want %d got %d", tc.Name, tc.Expected, got)
+		}
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Amount    int64
+		MinorUnit int
+		Expected  string
+	}{
+		{Name: "TwoDecimals", Amount: 1234, MinorUnit: 2, Expected: "12.34"},
+		{Name: "Negative", Amount: -1234, MinorUnit: 2, Expected: "-12.34"},
+		{Name: "LessThanOne", Amount: -5, MinorUnit: 2, Expected: "-0.05"},
+		{Name: "Zero", Amount: 0, MinorUnit: 2, Expected: "0.00"},
+		{Name: "NoDecimals", Amount: 1500, MinorUnit: 0, Expected: "1500"},
+		{Name: "ThreeDecimals", Amount: 1500, MinorUnit: 3, Expected: "1.500"},
+		{Name: "MinInt64", Amount: -9223372036854775808, MinorUnit: 2, Expected: "-92233720368547758.08"},
+	}
+
+	for _, tc := range testCases {
+		got := FormatAmount(tc.Amount, tc.MinorUnit)
+		if got != tc.Expected {
+			t.Errorf("failed %s want %s got %s", tc.Name, tc.Expected, got)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Amount    string
+		MinorUnit int
+		Expected  int64
+		IsError   bool
+	}{
+		{Name: "TwoDecimals", Amount: "12.34", MinorUnit: 2, Expected: 1234},
+		{Name: "OneDecimal", Amount: "12.3", MinorUnit: 2, Expected: 1230},
+		{Name: "NoDecimals", Amount: "12", MinorUnit: 2, Expected: 1200},
+		{Name: "TrailingZeros", Amount: "1500.000", MinorUnit: 0, Expected: 1500},
+		{Name: "LessThanOne", Amount: "0.05", MinorUnit: 2, Expected: 5},
+		{Name: "TooManyDecimals", Amount: "1.234", MinorUnit: 2, IsError: true},
+		{Name: "Negative", Amount: "-1.00", MinorUnit: 2, IsError: true},
+		{Name: "Empty", Amount: "", MinorUnit: 2, IsError: true},
+		{Name: "NoWholePart", Amount: ".50", MinorUnit: 2, IsError: true},
+		{Name: "Comma", Amount: "1,50", MinorUnit: 2, IsError: true},
+		{Name: "Overflow", Amount: "92233720368547758.08", MinorUnit: 2, IsError: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseAmount(tc.Amount, tc.MinorUnit)
+		if tc.IsError {
+			if err == nil {
+				t.Fatalf("failed %s want error got nil", tc.Name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed %s error:%s", tc.Name, err)
+		}
+		if got != tc.Expected {
+			t.Fatalf("failed %s want %d got %d", tc.Name, tc.Expected, got)
+		}
+	}
+}
@@ -37,10 +37,20 @@ func RandomBalance() int64 {
 	return RandomInt(0, 1000)
 }
 
+// randomCurrencies is the currencies RandomCurrency pick from, set from the currencies table seeded by the migration
+var randomCurrencies []string
+
+// SetRandomCurrencies set the currency codes RandomCurrency pick from
+func SetRandomCurrencies(codes []string) {
+	randomCurrencies = codes
+}
+
 func RandomCurrency() string {
-	currencies := []string{"USD", "EUR", "CAD"}
-	n := len(currencies)
-	return currencies[rand.Intn(n)]
+	n := len(randomCurrencies)
+	if n == 0 {
+		panic("util: no currencies to pick from, call SetRandomCurrencies first")
+	}
+	return randomCurrencies[rand.Intn(n)]
 }
 
 func RandomEmail() string {
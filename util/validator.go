@@ -72,6 +72,22 @@ func ValidateID(value int64) error {
 	return nil
 }
 
+func ValidateRange(value, min, max int64) error {
+	if value < min || value > max {
+		return fmt.Errorf("must be between %d and %d", min, max)
+	}
+
+	return nil
+}
+
+func ValidateCurrency(s string) error {
+	if len(s) != 3 {
+		return fmt.Errorf("must be a 3 letters currency code")
+	}
+
+	return nil
+}
+
 func ValidateSecretCode(value string) error {
 	err := ValidateString(value, 32, 128)
 	if err != nil {
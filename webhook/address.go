@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned when an endpoint point to an address of the bank network
+var ErrBlockedAddress = errors.New("webhook address is not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, not covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsBlockedIP report whether the deliveries must not be sent to ip: loopback, private (RFC 1918 and RFC 4193),
+// link-local like the 169.254.169.254 metadata service, unspecified, multicast and shared addresses
+func IsBlockedIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// ValidateURL check the url of a new endpoint: it must be https and its host must not be localhost or a blocked ip.
+// A host name is only resolved when connecting, see DialControl.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" {
+		return errors.New("url must be https")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return errors.New("url must have a host")
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && IsBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+
+	return nil
+}
+
+// DialControl refuse to connect to a blocked ip, it is the Control of the dialer sending the deliveries. It run
+// with the resolved address of every connection, so a host name resolving to a blocked ip after the endpoint
+// was created is refused too.
+func DialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || IsBlockedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, host)
+	}
+
+	return nil
+}
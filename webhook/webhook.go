@@ -0,0 +1,118 @@
+// Package webhook sign and build the requests delivering the events of a user to its webhook endpoints.
+// Every request carry the event id, its type and an HMAC-SHA256 signature of the timestamp and the body made
+// with the secret of the endpoint, so the receiver can check the request come from the bank and isn't replayed.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/ismail118/simple-bank/models"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	EventIDHeader   = "Webhook-Id"
+	EventTypeHeader = "Webhook-Event"
+	// unix seconds of the attempt, part of the signed content
+	TimestampHeader = "Webhook-Timestamp"
+	// "v1=" followed by the hex encoded HMAC-SHA256 of "<timestamp>.<body>"
+	SignatureHeader = "Webhook-Signature"
+
+	signatureVersion = "v1="
+	secretPrefix     = "whsec_"
+)
+
+const (
+	// MaxAttempts is how many times a delivery is attempted before it is dead
+	MaxAttempts = 10
+	// first retry delay, it is doubled on every next retry up to maxRetryDelay
+	baseRetryDelay = 30 * time.Second
+	maxRetryDelay  = 6 * time.Hour
+)
+
+// Body is the json body of a delivery
+type Body struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewSecret return a random secret to sign the deliveries of a new endpoint
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return secretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign return the signature of the body sent at timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signatureVersion + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify report whether signature is the signature of the body sent at timestamp, receivers should also refuse
+// a timestamp too far from their clock
+func Verify(secret, signature string, timestamp time.Time, body []byte) bool {
+	if !strings.HasPrefix(signature, signatureVersion) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// RetryDelay return the delay before the next attempt of a delivery that failed attempts times
+func RetryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	return delay
+}
+
+// NewRequest build the signed request delivering the event to the endpoint at the given time
+func NewRequest(ctx context.Context, endpoint models.WebhookEndpoint, event models.WebhookEvent, now time.Time) (*http.Request, error) {
+	body, err := json.Marshal(Body{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "simple-bank-webhook")
+	req.Header.Set(EventIDHeader, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(EventTypeHeader, event.Type)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(endpoint.Secret, now, body))
+
+	return req, nil
+}
@@ -0,0 +1,182 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ismail118/simple-bank/models"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1}`)
+	signature := Sign("whsec_test", now, body)
+
+	if !strings.HasPrefix(signature, "v1=") || len(signature) != len("v1=")+64 {
+		t.Fatalf("wrong signature format %q", signature)
+	}
+	if !Verify("whsec_test", signature, now, body) {
+		t.Fatalf("signature should be valid")
+	}
+
+	testCases := []struct {
+		Name      string
+		Secret    string
+		Signature string
+		Timestamp time.Time
+		Body      []byte
+	}{
+		{Name: "OtherSecret", Secret: "whsec_other", Signature: signature, Timestamp: now, Body: body},
+		{Name: "OtherTimestamp", Secret: "whsec_test", Signature: signature, Timestamp: now.Add(time.Second), Body: body},
+		{Name: "OtherBody", Secret: "whsec_test", Signature: signature, Timestamp: now, Body: []byte(`{"id":2}`)},
+		{Name: "NoVersion", Secret: "whsec_test", Signature: strings.TrimPrefix(signature, "v1="), Timestamp: now, Body: body},
+	}
+
+	for _, tc := range testCases {
+		if Verify(tc.Secret, tc.Signature, tc.Timestamp, tc.Body) {
+			t.Fatalf("failed %s signature should be invalid", tc.Name)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	s1, err := NewSecret()
+	if err != nil {
+		t.Fatalf("failed new secret error:%s", err)
+	}
+	s2, _ := NewSecret()
+
+	if !strings.HasPrefix(s1, "whsec_") || len(s1) != len("whsec_")+48 || s1 == s2 {
+		t.Fatalf("wrong secrets %q %q", s1, s2)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	testCases := []struct {
+		Attempts int
+		Delay    time.Duration
+	}{
+		{Attempts: 1, Delay: 30 * time.Second},
+		{Attempts: 2, Delay: time.Minute},
+		{Attempts: 5, Delay: 8 * time.Minute},
+		{Attempts: 10, Delay: 256 * time.Minute},
+		{Attempts: 11, Delay: 6 * time.Hour},
+		{Attempts: 50, Delay: 6 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		if got := RetryDelay(tc.Attempts); got != tc.Delay {
+			t.Fatalf("failed %d attempts want delay %s got %s", tc.Attempts, tc.Delay, got)
+		}
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	endpoint := models.WebhookEndpoint{ID: 1, URL: "https://example.com/webhooks", Secret: "whsec_test"}
+	event := models.WebhookEvent{
+		ID:        7,
+		Type:      models.WebhookEventTransferPosted,
+		Payload:   []byte(`{"transfer":{"id":3}}`),
+		CreatedAt: time.Unix(1700000000, 0).UTC(),
+	}
+	now := time.Unix(1700000060, 0)
+
+	req, err := NewRequest(context.Background(), endpoint, event, now)
+	if err != nil {
+		t.Fatalf("failed new request error:%s", err)
+	}
+	if req.Method != http.MethodPost || req.URL.String() != endpoint.URL {
+		t.Fatalf("wrong request %s %s", req.Method, req.URL)
+	}
+	if req.Header.Get(EventIDHeader) != "7" || req.Header.Get(EventTypeHeader) != event.Type {
+		t.Fatalf("wrong event headers %v", req.Header)
+	}
+
+	body, _ := io.ReadAll(req.Body)
+	timestamp, err := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+	if err != nil || timestamp != now.Unix() {
+		t.Fatalf("wrong timestamp header %q", req.Header.Get(TimestampHeader))
+	}
+	if !Verify(endpoint.Secret, req.Header.Get(SignatureHeader), time.Unix(timestamp, 0), body) {
+		t.Fatalf("request signature should be valid")
+	}
+
+	var got Body
+	err = json.Unmarshal(body, &got)
+	if err != nil {
+		t.Fatalf("failed unmarshal body error:%s", err)
+	}
+	if got.ID != event.ID || got.Type != event.Type || string(got.Data) != string(event.Payload) || !got.CreatedAt.Equal(event.CreatedAt) {
+		t.Fatalf("wrong body %s", body)
+	}
+
+	_, err = NewRequest(context.Background(), models.WebhookEndpoint{URL: "://bad"}, event, now)
+	if err == nil {
+		t.Fatalf("invalid url should fail")
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	testCases := []struct {
+		URL     string
+		Blocked bool
+		OK      bool
+	}{
+		{URL: "https://example.com/webhooks", OK: true},
+		{URL: "https://93.184.216.34/webhooks", OK: true},
+		{URL: "http://example.com/webhooks"},
+		{URL: "https:///webhooks"},
+		{URL: "https://localhost/webhooks", Blocked: true},
+		{URL: "https://api.localhost./webhooks", Blocked: true},
+		{URL: "https://127.0.0.1/webhooks", Blocked: true},
+		{URL: "https://[::1]:8443/webhooks", Blocked: true},
+		{URL: "https://10.0.0.5/webhooks", Blocked: true},
+		{URL: "https://172.16.3.4/webhooks", Blocked: true},
+		{URL: "https://192.168.1.1/webhooks", Blocked: true},
+		{URL: "https://169.254.169.254/latest/meta-data", Blocked: true},
+		{URL: "https://[fe80::1]/webhooks", Blocked: true},
+		{URL: "https://0.0.0.0/webhooks", Blocked: true},
+		{URL: "https://100.64.0.1/webhooks", Blocked: true},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateURL(tc.URL)
+		if tc.OK != (err == nil) {
+			t.Fatalf("failed %s want ok %t got %v", tc.URL, tc.OK, err)
+		}
+		if tc.Blocked != errors.Is(err, ErrBlockedAddress) {
+			t.Fatalf("failed %s want blocked %t got %v", tc.URL, tc.Blocked, err)
+		}
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	testCases := []struct {
+		Address string
+		Blocked bool
+	}{
+		{Address: "93.184.216.34:443"},
+		{Address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{Address: "127.0.0.1:443", Blocked: true},
+		{Address: "10.1.2.3:443", Blocked: true},
+		{Address: "169.254.169.254:80", Blocked: true},
+		{Address: "[::ffff:192.168.0.1]:443", Blocked: true},
+		{Address: "[fd00::1]:443", Blocked: true},
+	}
+
+	for _, tc := range testCases {
+		err := DialControl("tcp", tc.Address, nil)
+		if tc.Blocked != errors.Is(err, ErrBlockedAddress) {
+			t.Fatalf("failed %s want blocked %t got %v", tc.Address, tc.Blocked, err)
+		}
+		if !tc.Blocked && err != nil {
+			t.Fatalf("failed %s error:%s", tc.Address, err)
+		}
+	}
+}
@@ -2,11 +2,22 @@ package worker
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/rs/zerolog/log"
 )
 
 type TaskDistributor interface {
 	DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail, opts ...asynq.Option) error
+	DistributeTaskExecuteScheduledTransfer(ctx context.Context, payload *PayloadExecuteScheduledTransfer, opts ...asynq.Option) error
+	DistributeTaskSendScheduledTransferFailedEmail(ctx context.Context, payload *PayloadSendScheduledTransferFailedEmail, opts ...asynq.Option) error
+	DistributeTaskProcessTransferBatch(ctx context.Context, payload *PayloadProcessTransferBatch, opts ...asynq.Option) error
+	DistributeTaskAccrueInterest(ctx context.Context, payload *PayloadAccrueInterest, opts ...asynq.Option) error
+	DistributeTaskSendStatement(ctx context.Context, payload *PayloadSendStatement, opts ...asynq.Option) error
+	DistributeTaskSendPayeeAddedEmail(ctx context.Context, payload *PayloadSendPayeeAddedEmail, opts ...asynq.Option) error
+	DistributeTaskDeliverWebhook(ctx context.Context, payload *PayloadDeliverWebhook, opts ...asynq.Option) error
+	DistributeOutboxMessage(ctx context.Context, msg models.OutboxMessage) error
 }
 
 type RedisTaskDistributor struct {
@@ -20,3 +31,26 @@ func NewRedisTaskDistributor(redisOpt asynq.RedisClientOpt) TaskDistributor {
 		client: client,
 	}
 }
+
+// enqueue marshal the payload to json and enqueue it as a task of the given type
+func (d *RedisTaskDistributor) enqueue(ctx context.Context, typename string, payload interface{}, opts ...asynq.Option) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	task := asynq.NewTask(typename, jsonPayload)
+	info, err := d.client.EnqueueContext(ctx, task, opts...)
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Str("queue", info.Queue).
+		Bytes("payload", jsonPayload).
+		Int("max_retry", info.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
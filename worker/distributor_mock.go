@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
 )
 
 type RedisTaskDistributorMock struct {
@@ -19,3 +20,38 @@ func (d *RedisTaskDistributorMock) DistributeTaskSendVerifyEmail(ctx context.Con
 	}
 	return nil
 }
+
+func (d *RedisTaskDistributorMock) DistributeTaskExecuteScheduledTransfer(ctx context.Context, payload *PayloadExecuteScheduledTransfer, opts ...asynq.Option) error {
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeTaskSendScheduledTransferFailedEmail(ctx context.Context, payload *PayloadSendScheduledTransferFailedEmail, opts ...asynq.Option) error {
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeTaskProcessTransferBatch(ctx context.Context, payload *PayloadProcessTransferBatch, opts ...asynq.Option) error {
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeTaskAccrueInterest(ctx context.Context, payload *PayloadAccrueInterest, opts ...asynq.Option) error {
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeTaskSendStatement(ctx context.Context, payload *PayloadSendStatement, opts ...asynq.Option) error {
+	if payload.Format == "ofx" {
+		return fmt.Errorf("some error")
+	}
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeTaskSendPayeeAddedEmail(ctx context.Context, payload *PayloadSendPayeeAddedEmail, opts ...asynq.Option) error {
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeTaskDeliverWebhook(ctx context.Context, payload *PayloadDeliverWebhook, opts ...asynq.Option) error {
+	return nil
+}
+
+func (d *RedisTaskDistributorMock) DistributeOutboxMessage(ctx context.Context, msg models.OutboxMessage) error {
+	return nil
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	outboxRelayInterval  = time.Second
+	outboxRelayBatchSize = 100
+	// how long the task id of a relayed message stay taken once the task is completed, a message relayed again
+	// within it is not enqueued twice
+	outboxTaskRetention = 24 * time.Hour
+)
+
+// NewOutboxMessage return the task of the given type to write to the outbox in the transaction that need it
+func NewOutboxMessage(typename string, payload interface{}, queue string, maxRetry int) (models.OutboxMessage, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return models.OutboxMessage{}, err
+	}
+
+	return models.OutboxMessage{
+		TaskType: typename,
+		Payload:  jsonPayload,
+		Queue:    queue,
+		MaxRetry: maxRetry,
+	}, nil
+}
+
+// outboxTaskID is the task id of the message, asynq refuse a second task with the same id so a message relayed
+// twice is processed once
+func outboxTaskID(id int64) string {
+	return fmt.Sprintf("outbox:%d", id)
+}
+
+// DistributeOutboxMessage enqueue the task of the outbox message, a message that is already enqueued is not an error
+func (d *RedisTaskDistributor) DistributeOutboxMessage(ctx context.Context, msg models.OutboxMessage) error {
+	task := asynq.NewTask(msg.TaskType, msg.Payload)
+	info, err := d.client.EnqueueContext(ctx, task,
+		asynq.TaskID(outboxTaskID(msg.ID)),
+		asynq.Queue(msg.Queue),
+		asynq.MaxRetry(msg.MaxRetry),
+		asynq.Retention(outboxTaskRetention),
+	)
+	if err != nil {
+		if errors.Is(err, asynq.ErrTaskIDConflict) {
+			return nil
+		}
+		return err
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Str("queue", info.Queue).
+		Int64("outbox_id", msg.ID).
+		Int("max_retry", info.MaxRetry).
+		Msg("enqueued task")
+
+	return nil
+}
+
+// OutboxRelay enqueue the tasks written to the outbox once their transaction is committed. A message is marked
+// relayed after it is enqueued, so it is enqueued at least once, and the task id deduplicate the message enqueued
+// again when the relay stopped in between.
+type OutboxRelay struct {
+	store       repository.Store
+	distributor TaskDistributor
+}
+
+func NewOutboxRelay(store repository.Store, distributor TaskDistributor) *OutboxRelay {
+	return &OutboxRelay{
+		store:       store,
+		distributor: distributor,
+	}
+}
+
+// Start relay the outbox until ctx is done
+func (r *OutboxRelay) Start(ctx context.Context) error {
+	ticker := time.NewTicker(outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		relayed, err := r.Relay(ctx)
+		if err != nil {
+			// redis or the database is down, the messages are relayed on a next round
+			log.Error().Err(err).Int("relayed", relayed).Msg("failed to relay outbox")
+		}
+
+		// a full batch mean there may be more messages waiting
+		if err == nil && relayed == outboxRelayBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Relay enqueue one batch of the unrelayed messages, oldest first, and return how many were relayed
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	items, err := r.store.GetListUnrelayedOutboxMessages(ctx, outboxRelayBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get unrelayed outbox messages: %w", err)
+	}
+
+	relayed := 0
+	for _, msg := range items {
+		err = r.distributor.DistributeOutboxMessage(ctx, *msg)
+		if err != nil {
+			return relayed, fmt.Errorf("failed to enqueue outbox message %d: %w", msg.ID, err)
+		}
+
+		err = r.store.UpdateOutboxMessageRelayed(ctx, msg.ID, time.Now())
+		if err != nil {
+			return relayed, fmt.Errorf("failed to mark outbox message %d relayed: %w", msg.ID, err)
+		}
+		relayed++
+	}
+
+	return relayed, nil
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"testing"
+	"time"
+)
+
+// outboxStoreMock keep the outbox in memory, the other methods of the store are not used by the relay
+type outboxStoreMock struct {
+	repository.Store
+	messages []*models.OutboxMessage
+	getErr   error
+}
+
+func newOutboxStoreMock(n int) *outboxStoreMock {
+	s := &outboxStoreMock{}
+	for id := int64(1); id <= int64(n); id++ {
+		s.messages = append(s.messages, &models.OutboxMessage{
+			ID:       id,
+			TaskType: TaskSendVerifyEmail,
+			Payload:  []byte(`{"username":"some-user"}`),
+			Queue:    QueueCritical,
+			MaxRetry: 10,
+		})
+	}
+	return s
+}
+
+func (s *outboxStoreMock) GetListUnrelayedOutboxMessages(ctx context.Context, limit int) ([]*models.OutboxMessage, error) {
+	items := []*models.OutboxMessage{}
+	if s.getErr != nil {
+		return items, s.getErr
+	}
+	for _, msg := range s.messages {
+		if msg.RelayedAt.IsZero() && len(items) < limit {
+			items = append(items, msg)
+		}
+	}
+	return items, nil
+}
+
+func (s *outboxStoreMock) UpdateOutboxMessageRelayed(ctx context.Context, id int64, relayedAt time.Time) error {
+	for _, msg := range s.messages {
+		if msg.ID == id {
+			msg.RelayedAt = relayedAt
+		}
+	}
+	return nil
+}
+
+func (s *outboxStoreMock) unrelayed() int {
+	n := 0
+	for _, msg := range s.messages {
+		if msg.RelayedAt.IsZero() {
+			n++
+		}
+	}
+	return n
+}
+
+// outboxDistributorMock record the enqueued messages and fail to enqueue the message failID
+type outboxDistributorMock struct {
+	TaskDistributor
+	enqueued []int64
+	failID   int64
+}
+
+func (d *outboxDistributorMock) DistributeOutboxMessage(ctx context.Context, msg models.OutboxMessage) error {
+	if msg.ID == d.failID {
+		return errors.New("redis is down")
+	}
+	d.enqueued = append(d.enqueued, msg.ID)
+	return nil
+}
+
+func TestOutboxRelay(t *testing.T) {
+	store := newOutboxStoreMock(3)
+	distributor := &outboxDistributorMock{failID: 2}
+	relay := NewOutboxRelay(store, distributor)
+
+	// the message that can't be enqueued stop the batch, it and the next ones stay unrelayed
+	relayed, err := relay.Relay(context.Background())
+	if err == nil {
+		t.Fatalf("failed relay with enqueue error want error got nil")
+	}
+	if relayed != 1 || store.unrelayed() != 2 {
+		t.Fatalf("failed relay with enqueue error want 1 relayed and 2 unrelayed got %d and %d", relayed, store.unrelayed())
+	}
+
+	// the next round relay the rest, the relayed message is not enqueued again
+	distributor.failID = 0
+	relayed, err = relay.Relay(context.Background())
+	if err != nil {
+		t.Fatalf("failed relay error:%s", err)
+	}
+	if relayed != 2 || store.unrelayed() != 0 {
+		t.Fatalf("failed relay want 2 relayed and 0 unrelayed got %d and %d", relayed, store.unrelayed())
+	}
+	want := []int64{1, 2, 3}
+	if len(distributor.enqueued) != len(want) {
+		t.Fatalf("failed enqueued messages want %v got %v", want, distributor.enqueued)
+	}
+	for i := range want {
+		if distributor.enqueued[i] != want[i] {
+			t.Fatalf("failed enqueued messages want %v got %v", want, distributor.enqueued)
+		}
+	}
+
+	relayed, err = relay.Relay(context.Background())
+	if err != nil || relayed != 0 {
+		t.Fatalf("failed relay of empty outbox want 0 got %d error %v", relayed, err)
+	}
+
+	// nothing is enqueued when the outbox can't be read
+	store.getErr = errors.New("database is down")
+	relayed, err = relay.Relay(context.Background())
+	if !errors.Is(err, store.getErr) || relayed != 0 {
+		t.Fatalf("failed relay with store error want %s got %d error %v", store.getErr, relayed, err)
+	}
+}
+
+func TestOutboxRelayStart(t *testing.T) {
+	// more messages than a batch, the relay keep going without waiting while the batches are full
+	store := newOutboxStoreMock(outboxRelayBatchSize + 50)
+	distributor := &outboxDistributorMock{}
+	relay := NewOutboxRelay(store, distributor)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := relay.Start(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("failed start with cancelled context want %s got %v", context.Canceled, err)
+	}
+	if store.unrelayed() != 0 || len(distributor.enqueued) != outboxRelayBatchSize+50 {
+		t.Fatalf("failed start want every message relayed got %d unrelayed and %d enqueued", store.unrelayed(), len(distributor.enqueued))
+	}
+}
+
+func TestNewOutboxMessage(t *testing.T) {
+	msg, err := NewOutboxMessage(TaskProcessTransferBatch, &PayloadProcessTransferBatch{BatchID: 7}, QueueDefault, 10)
+	if err != nil {
+		t.Fatalf("failed new outbox message error:%s", err)
+	}
+	if msg.TaskType != TaskProcessTransferBatch || msg.Queue != QueueDefault || msg.MaxRetry != 10 {
+		t.Fatalf("failed outbox message got %+v", msg)
+	}
+
+	var payload PayloadProcessTransferBatch
+	err = json.Unmarshal(msg.Payload, &payload)
+	if err != nil || payload.BatchID != 7 {
+		t.Fatalf("failed outbox message payload got %s error %v", msg.Payload, err)
+	}
+}
@@ -17,16 +17,34 @@ const (
 type TaskProcessor interface {
 	Start() error
 	ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskReleaseExpiredHolds(ctx context.Context, task *asynq.Task) error
+	ProcessTaskEnqueueDueScheduledTransfers(ctx context.Context, task *asynq.Task) error
+	ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendScheduledTransferFailedEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskProcessTransferBatch(ctx context.Context, task *asynq.Task) error
+	ProcessTaskEnqueueInterestAccruals(ctx context.Context, task *asynq.Task) error
+	ProcessTaskAccrueInterest(ctx context.Context, task *asynq.Task) error
+	ProcessTaskReconcileLedger(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSnapshotAccountBalances(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendStatement(ctx context.Context, task *asynq.Task) error
+	ProcessTaskCreateACHFile(ctx context.Context, task *asynq.Task) error
+	ProcessTaskIngestACHReturns(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendPayeeAddedEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskEnqueueDueWebhookDeliveries(ctx context.Context, task *asynq.Task) error
+	ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error
 }
 
 type RedisTaskProcessor struct {
 	server              *asynq.Server
 	store               repository.Store
+	distributor         TaskDistributor
 	mailer              mail.SenderEmail
 	gatewaySeverAddress string
+	ach                 ACHConfig
+	payeeRules          repository.PayeeRules
 }
 
-func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store repository.Store, mailer mail.SenderEmail, gatewaySeverAddress string) TaskProcessor {
+func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store repository.Store, distributor TaskDistributor, mailer mail.SenderEmail, gatewaySeverAddress string, ach ACHConfig, payeeRules repository.PayeeRules) TaskProcessor {
 	server := asynq.NewServer(redisOpt, asynq.Config{
 		Queues: map[string]int{
 			QueueCritical: 6,
@@ -46,8 +64,11 @@ func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store repository.Store
 	return &RedisTaskProcessor{
 		server:              server,
 		store:               store,
+		distributor:         distributor,
 		mailer:              mailer,
 		gatewaySeverAddress: gatewaySeverAddress,
+		ach:                 ach,
+		payeeRules:          payeeRules,
 	}
 }
 
@@ -55,6 +76,21 @@ func (p *RedisTaskProcessor) Start() error {
 	mux := asynq.NewServeMux()
 
 	mux.HandleFunc(TaskSendVerifyEmail, p.ProcessTaskSendVerifyEmail)
+	mux.HandleFunc(TaskReleaseExpiredHolds, p.ProcessTaskReleaseExpiredHolds)
+	mux.HandleFunc(TaskEnqueueDueScheduledTransfers, p.ProcessTaskEnqueueDueScheduledTransfers)
+	mux.HandleFunc(TaskExecuteScheduledTransfer, p.ProcessTaskExecuteScheduledTransfer)
+	mux.HandleFunc(TaskSendScheduledTransferFailedEmail, p.ProcessTaskSendScheduledTransferFailedEmail)
+	mux.HandleFunc(TaskProcessTransferBatch, p.ProcessTaskProcessTransferBatch)
+	mux.HandleFunc(TaskEnqueueInterestAccruals, p.ProcessTaskEnqueueInterestAccruals)
+	mux.HandleFunc(TaskAccrueInterest, p.ProcessTaskAccrueInterest)
+	mux.HandleFunc(TaskReconcileLedger, p.ProcessTaskReconcileLedger)
+	mux.HandleFunc(TaskSnapshotAccountBalances, p.ProcessTaskSnapshotAccountBalances)
+	mux.HandleFunc(TaskSendStatement, p.ProcessTaskSendStatement)
+	mux.HandleFunc(TaskCreateACHFile, p.ProcessTaskCreateACHFile)
+	mux.HandleFunc(TaskIngestACHReturns, p.ProcessTaskIngestACHReturns)
+	mux.HandleFunc(TaskSendPayeeAddedEmail, p.ProcessTaskSendPayeeAddedEmail)
+	mux.HandleFunc(TaskEnqueueDueWebhookDeliveries, p.ProcessTaskEnqueueDueWebhookDeliveries)
+	mux.HandleFunc(TaskDeliverWebhook, p.ProcessTaskDeliverWebhook)
 
 	return p.server.Start(mux)
 }
@@ -10,7 +10,7 @@ import (
 type RedisTaskProcessorMock struct {
 }
 
-func NewRedisTaskProcessorMock(redisOpt asynq.RedisClientOpt, store repository.Store, mailer mail.SenderEmail, gatewaySeverAddress string) TaskProcessor {
+func NewRedisTaskProcessorMock(redisOpt asynq.RedisClientOpt, store repository.Store, distributor TaskDistributor, mailer mail.SenderEmail, gatewaySeverAddress string, ach ACHConfig, payeeRules repository.PayeeRules) TaskProcessor {
 	return &RedisTaskProcessorMock{}
 }
 
@@ -21,3 +21,63 @@ func (p *RedisTaskProcessorMock) Start() error {
 func (p *RedisTaskProcessorMock) ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error {
 	return nil
 }
+
+func (p *RedisTaskProcessorMock) ProcessTaskReleaseExpiredHolds(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskEnqueueDueScheduledTransfers(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskSendScheduledTransferFailedEmail(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskProcessTransferBatch(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskEnqueueInterestAccruals(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskAccrueInterest(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskReconcileLedger(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskSnapshotAccountBalances(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskSendStatement(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskCreateACHFile(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskIngestACHReturns(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskSendPayeeAddedEmail(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskEnqueueDueWebhookDeliveries(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
+
+func (p *RedisTaskProcessorMock) ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error {
+	return nil
+}
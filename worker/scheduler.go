@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+type TaskScheduler interface {
+	Start() error
+}
+
+// RedisTaskScheduler enqueue the periodic tasks, only one instance should be run for each redis
+type RedisTaskScheduler struct {
+	scheduler *asynq.Scheduler
+}
+
+func NewRedisTaskScheduler(redisOpt asynq.RedisClientOpt) TaskScheduler {
+	scheduler := asynq.NewScheduler(redisOpt, &asynq.SchedulerOpts{
+		Logger:   NewLogger(),
+		Location: time.UTC,
+	})
+
+	return &RedisTaskScheduler{
+		scheduler: scheduler,
+	}
+}
+
+func (s *RedisTaskScheduler) Start() error {
+	periodicTasks := []struct {
+		cronspec string
+		task     *asynq.Task
+		opts     []asynq.Option
+	}{
+		{
+			cronspec: "@every 1m",
+			task:     asynq.NewTask(TaskReleaseExpiredHolds, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueDefault), asynq.MaxRetry(0)},
+		},
+		{
+			cronspec: "@every 1m",
+			task:     asynq.NewTask(TaskEnqueueDueScheduledTransfers, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueCritical), asynq.MaxRetry(0)},
+		},
+		{
+			// relay the webhook outbox, also the delay of a new event
+			cronspec: "@every 10s",
+			task:     asynq.NewTask(TaskEnqueueDueWebhookDeliveries, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueDefault), asynq.MaxRetry(0)},
+		},
+		{
+			// accrue the interest of the day that just ended
+			cronspec: "5 0 * * *",
+			task:     asynq.NewTask(TaskEnqueueInterestAccruals, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueLow), asynq.MaxRetry(3)},
+		},
+		{
+			// end of day balances of the day that just ended
+			cronspec: "15 0 * * *",
+			task:     asynq.NewTask(TaskSnapshotAccountBalances, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueLow), asynq.MaxRetry(3)},
+		},
+		{
+			// queued payouts are sent to be settled the next business day
+			cronspec: "30 1 * * *",
+			task:     asynq.NewTask(TaskCreateACHFile, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueCritical), asynq.MaxRetry(3)},
+		},
+		{
+			cronspec: "@every 15m",
+			task:     asynq.NewTask(TaskIngestACHReturns, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueDefault), asynq.MaxRetry(0)},
+		},
+		{
+			// after the interest accruals so the report cover the postings of the night
+			cronspec: "0 2 * * *",
+			task:     asynq.NewTask(TaskReconcileLedger, nil),
+			opts:     []asynq.Option{asynq.Queue(QueueLow), asynq.MaxRetry(1)},
+		},
+	}
+
+	for _, p := range periodicTasks {
+		entryID, err := s.scheduler.Register(p.cronspec, p.task, p.opts...)
+		if err != nil {
+			return err
+		}
+		log.Info().
+			Str("type", p.task.Type()).
+			Str("cronspec", p.cronspec).
+			Str("entry_id", entryID).
+			Msg("registered periodic task")
+	}
+
+	return s.scheduler.Start()
+}
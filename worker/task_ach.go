@@ -0,0 +1,253 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/nacha"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/rs/zerolog/log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	TaskCreateACHFile    = "task:create_ach_file"
+	TaskIngestACHReturns = "task:ingest_ach_returns"
+	// payouts written in one file, the rest wait for the next night
+	maxACHFileEntries = 10000
+	// ach only settle dollars
+	achCurrency         = "USD"
+	achUnwrittenLimit   = 100
+	achProcessedDirName = "processed"
+	achRejectedDirName  = "rejected"
+	achTempFilePrefix   = "."
+)
+
+// ACHConfig is where the ach files are exchanged with the operator and how the bank is identified in them
+type ACHConfig struct {
+	// the nightly files are written here for the transmission to pick them up
+	OutputDir string
+	// returns files dropped here are ingested, then moved to its processed sub directory
+	// or to its rejected sub directory when they can't be read
+	ReturnsDir string
+	Originator nacha.Originator
+}
+
+// ProcessTaskCreateACHFile run nightly and submit the queued payouts in a new ach file, then write every
+// file not written yet to the output directory. The file is saved before it is written, so a failed write
+// is done again by the next run with the same name and content.
+func (p *RedisTaskProcessor) ProcessTaskCreateACHFile(ctx context.Context, task *asynq.Task) error {
+	res, err := p.store.CreateACHFileTx(ctx, achCurrency, maxACHFileEntries, p.renderACHFile)
+	if err != nil {
+		return fmt.Errorf("failed to create ach file: %w", err)
+	}
+
+	written, err := p.writeACHFiles(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to write ach files: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int64("file_id", res.File.ID).
+		Int("entries", res.File.EntryCount).
+		Int64("total_amount", res.File.TotalAmount).
+		Int("written", written).
+		Msg("process task")
+
+	return nil
+}
+
+// renderACHFile write the payouts in one PPD batch effective the next business day,
+// the trace number of a payout is made from its id
+func (p *RedisTaskProcessor) renderACHFile(file *models.ACHFile, sameDayFiles int, payouts []*models.ExternalPayout) error {
+	modifier, err := nacha.FileIDModifier(sameDayFiles)
+	if err != nil {
+		return err
+	}
+
+	f := nacha.File{
+		CreatedAt:      file.CreatedAt,
+		FileIDModifier: modifier,
+		EffectiveDate:  nacha.NextBusinessDay(file.CreatedAt),
+	}
+	for _, po := range payouts {
+		po.TraceNumber = nacha.TraceNumber(p.ach.Originator.OriginatingDFI, po.ID)
+		code := nacha.CheckingCredit
+		if po.AccountType == models.PayoutAccountSavings {
+			code = nacha.SavingsCredit
+		}
+		f.Entries = append(f.Entries, nacha.Entry{
+			TransactionCode: code,
+			RoutingNumber:   po.RoutingNumber,
+			AccountNumber:   po.AccountNumber,
+			Amount:          po.Amount,
+			IndividualID:    fmt.Sprint(po.ID),
+			IndividualName:  po.BeneficiaryName,
+			TraceNumber:     po.TraceNumber,
+		})
+		file.TotalAmount += po.Amount
+	}
+
+	content, err := nacha.Write(p.ach.Originator, f)
+	if err != nil {
+		return err
+	}
+
+	file.FileName = fmt.Sprintf("ACH%s%s.txt", file.CreatedAt.Format("20060102"), modifier)
+	file.FileIDModifier = modifier
+	file.Content = string(content)
+	file.EntryCount = len(payouts)
+	return nil
+}
+
+// writeACHFiles write the saved files that are not in the output directory yet and return how many were written
+func (p *RedisTaskProcessor) writeACHFiles(ctx context.Context) (int, error) {
+	files, err := p.store.GetListUnwrittenACHFiles(ctx, achUnwrittenLimit)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, f := range files {
+		err = writeFileAtomic(filepath.Join(p.ach.OutputDir, f.FileName), []byte(f.Content))
+		if err != nil {
+			return i, err
+		}
+		err = p.store.UpdateACHFileWritten(ctx, f.ID, time.Now())
+		if err != nil {
+			return i, err
+		}
+	}
+
+	return len(files), nil
+}
+
+// writeFileAtomic write data to a hidden temporary file renamed to path once complete,
+// so the transmission never pick up a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), achTempFilePrefix+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Sync()
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// ProcessTaskIngestACHReturns credit back the returned payouts of every file of the returns directory.
+// A file is moved to the processed directory once all its returns are done, a file with a failed return
+// is kept and ingested again by the next run, the payouts already returned are skipped.
+func (p *RedisTaskProcessor) ProcessTaskIngestACHReturns(ctx context.Context, task *asynq.Task) error {
+	if p.ach.ReturnsDir == "" {
+		return nil
+	}
+
+	entries, err := os.ReadDir(p.ach.ReturnsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read ach returns directory: %w", err)
+	}
+
+	var failed int
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), achTempFilePrefix) {
+			continue
+		}
+
+		returned, err := p.ingestACHReturns(ctx, e.Name())
+		if err != nil {
+			failed++
+			log.Error().
+				Err(err).
+				Str("type", task.Type()).
+				Str("file", e.Name()).
+				Msg("failed to ingest ach returns file")
+			continue
+		}
+
+		log.Info().
+			Str("type", task.Type()).
+			Str("file", e.Name()).
+			Int("returned", returned).
+			Msg("process task")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to ingest %d ach returns files", failed)
+	}
+	return nil
+}
+
+// ingestACHReturns return the payouts of the returns file and move it, it return how many payouts were credited back
+func (p *RedisTaskProcessor) ingestACHReturns(ctx context.Context, name string) (int, error) {
+	path := filepath.Join(p.ach.ReturnsDir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	returns, err := nacha.ParseReturns(data)
+	if err != nil {
+		moveErr := moveToDir(path, filepath.Join(p.ach.ReturnsDir, achRejectedDirName))
+		if moveErr != nil {
+			return 0, fmt.Errorf("%w, move to rejected: %s", err, moveErr)
+		}
+		return 0, err
+	}
+
+	var returned int
+	for _, r := range returns {
+		res, err := p.store.ReturnExternalPayoutTx(ctx, r.OriginalTraceNumber, r.ReasonCode)
+		if errors.Is(err, repository.ErrExternalPayoutNotFound) {
+			// not one of our payouts, e.g. a return of an entry sent by another originator
+			log.Warn().
+				Str("file", name).
+				Str("trace_number", r.OriginalTraceNumber).
+				Str("return_code", r.ReasonCode).
+				Msg("ach return of unknown payout")
+			continue
+		}
+		if err != nil {
+			return returned, fmt.Errorf("return trace number %s: %w", r.OriginalTraceNumber, err)
+		}
+		if res.Payout.Status == models.PayoutStatusReturnException {
+			log.Warn().
+				Str("file", name).
+				Int64("payout_id", res.Payout.ID).
+				Str("return_code", r.ReasonCode).
+				Msg("ach return to closed account")
+		}
+		if res.Transfer.ID > 0 {
+			returned++
+		}
+	}
+
+	return returned, moveToDir(path, filepath.Join(p.ach.ReturnsDir, achProcessedDirName))
+}
+
+func moveToDir(path, dir string) error {
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return err
+	}
+	return os.Rename(path, filepath.Join(dir, filepath.Base(path)))
+}
@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	TaskSnapshotAccountBalances      = "task:snapshot_account_balances"
+	snapshotAccountBalancesBatchSize = 1000
+)
+
+// ProcessTaskSnapshotAccountBalances run nightly and snapshot the balance of every account at the end
+// of the previous day. It run a few minutes after midnight so the transfers committed around midnight
+// are included, a retried run skip the accounts that already have a snapshot.
+func (p *RedisTaskProcessor) ProcessTaskSnapshotAccountBalances(ctx context.Context, task *asynq.Task) error {
+	y, m, d := time.Now().UTC().AddDate(0, 0, -1).Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	asOf := date.AddDate(0, 0, 1)
+
+	var afterID, total int64
+	for {
+		lastID, inserted, err := p.store.InsertAccountBalanceSnapshots(ctx, date, asOf, afterID, snapshotAccountBalancesBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot balances of accounts after %d: %w", afterID, err)
+		}
+		total += inserted
+		if lastID == 0 {
+			break
+		}
+		afterID = lastID
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Time("date", date).
+		Int64("inserted", total).
+		Msg("process task")
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	TaskEnqueueInterestAccruals      = "task:enqueue_interest_accruals"
+	TaskAccrueInterest               = "task:accrue_interest"
+	enqueueInterestAccrualsBatchSize = 1000
+)
+
+type PayloadAccrueInterest struct {
+	AccountID int64 `json:"account_id"`
+	// UTC date of the accrued day
+	Date time.Time `json:"date"`
+}
+
+func (d *RedisTaskDistributor) DistributeTaskAccrueInterest(ctx context.Context, payload *PayloadAccrueInterest, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskAccrueInterest, payload, opts...)
+}
+
+// ProcessTaskEnqueueInterestAccruals run nightly and enqueue one accrual task for the previous day
+// of each interest bearing account. The task id is unique for each account and day so a run
+// that is retried doesn't accrue twice.
+func (p *RedisTaskProcessor) ProcessTaskEnqueueInterestAccruals(ctx context.Context, task *asynq.Task) error {
+	y, m, d := time.Now().UTC().AddDate(0, 0, -1).Date()
+	date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	var afterID int64
+	enqueued := 0
+	for {
+		accounts, err := p.store.GetListInterestBearingAccounts(ctx, afterID, enqueueInterestAccrualsBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get interest bearing accounts: %w", err)
+		}
+
+		for _, account := range accounts {
+			afterID = account.ID
+			payload := &PayloadAccrueInterest{
+				AccountID: account.ID,
+				Date:      date,
+			}
+			err = p.distributor.DistributeTaskAccrueInterest(ctx, payload,
+				asynq.TaskID(fmt.Sprintf("interest:%d:%s", account.ID, date.Format("2006-01-02"))),
+				asynq.Queue(QueueLow),
+				asynq.MaxRetry(10),
+			)
+			if err != nil {
+				if errors.Is(err, asynq.ErrTaskIDConflict) {
+					continue
+				}
+				return fmt.Errorf("failed to enqueue interest accrual of account %d: %w", account.ID, err)
+			}
+			enqueued++
+		}
+
+		if len(accounts) < enqueueInterestAccrualsBatchSize {
+			break
+		}
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Time("date", date).
+		Int("enqueued", enqueued).
+		Msg("process task")
+
+	return nil
+}
+
+// ProcessTaskAccrueInterest accrue the interest of the day and post the interest of the month
+// when the day is the last day of the month
+func (p *RedisTaskProcessor) ProcessTaskAccrueInterest(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadAccrueInterest
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	accrual, err := p.store.AccrueInterestTx(ctx, payload.AccountID, payload.Date)
+	if err != nil {
+		if errors.Is(err, repository.ErrAccountNotFound) || errors.Is(err, repository.ErrAccountProductNotFound) {
+			return fmt.Errorf("failed to accrue interest: %s: %w", err, asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to accrue interest: %w", err)
+	}
+
+	logger := log.Info().
+		Str("type", task.Type()).
+		Int64("account_id", payload.AccountID).
+		Time("date", payload.Date).
+		Int64("amount_micros", accrual.AmountMicros)
+
+	if payload.Date.UTC().AddDate(0, 0, 1).Day() == 1 {
+		res, err := p.store.PostInterestTx(ctx, payload.AccountID, payload.Date)
+		if err != nil && !errors.Is(err, repository.ErrInterestAlreadyPosted) {
+			return fmt.Errorf("failed to post interest: %w", err)
+		}
+		logger = logger.Int64("posted", res.Posting.Amount)
+	}
+
+	logger.Msg("process task")
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/util"
+	"github.com/rs/zerolog/log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const TaskSendPayeeAddedEmail = "task:send_payee_added_email"
+
+type PayloadSendPayeeAddedEmail struct {
+	PayeeID int64 `json:"payee_id"`
+	// most the payee can receive in total during its cooling-off period by currency, in minor units
+	CoolingOffLimit map[string]int64 `json:"cooling_off_limits"`
+}
+
+func (d *RedisTaskDistributor) DistributeTaskSendPayeeAddedEmail(ctx context.Context, payload *PayloadSendPayeeAddedEmail, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskSendPayeeAddedEmail, payload, opts...)
+}
+
+// ProcessTaskSendPayeeAddedEmail tell the owner a payee was added so an unknown payee added with a stolen
+// session can be deleted before its cooling-off period end
+func (p *RedisTaskProcessor) ProcessTaskSendPayeeAddedEmail(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendPayeeAddedEmail
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	payee, err := p.store.GetPayeeByID(ctx, payload.PayeeID)
+	if err != nil {
+		return fmt.Errorf("error get payee: %w", err)
+	}
+	if payee.ID < 1 {
+		// deleted meanwhile
+		return fmt.Errorf("payee doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	user, err := p.store.GetUsersByUsername(ctx, payee.Owner)
+	if err != nil {
+		return fmt.Errorf("error get user: %w", err)
+	}
+	if user.Username == "" {
+		return fmt.Errorf("username doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	limits, err := p.formatCoolingOffLimit(ctx, payload.CoolingOffLimit)
+	if err != nil {
+		return fmt.Errorf("error format cooling-off limit: %w", err)
+	}
+	limit := ""
+	if limits != "" {
+		limit = fmt.Sprintf("Until %s transfers to this payee are limited to %s in total.<br/>\n\t",
+			payee.ActiveAt.UTC().Format(time.RFC1123), limits)
+	}
+
+	subject := "New payee added"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	The payee %s with account %d was added to your profile at %s.<br/>
+	%sIf you didn't add this payee, delete it and change your password.<br/>
+	`, user.FullName, payee.Nickname, payee.AccountID, payee.CreatedAt.UTC().Format(time.RFC1123), limit)
+	to := []string{user.Email}
+	err = p.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send payee added email: %s", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Str("email", user.Email).
+		Bytes("payload", task.Payload()).
+		Msg("process task")
+
+	return nil
+}
+
+// formatCoolingOffLimit return the limits as a list of amounts like "100.00 USD, 15000 JPY" sorted by currency
+func (p *RedisTaskProcessor) formatCoolingOffLimit(ctx context.Context, limits map[string]int64) (string, error) {
+	codes := make([]string, 0, len(limits))
+	for code, amount := range limits {
+		if amount > 0 {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	items := make([]string, 0, len(codes))
+	for _, code := range codes {
+		currency, err := p.store.GetCurrency(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if currency.Code == "" {
+			// not a currency of the bank, nothing can be sent in it
+			continue
+		}
+		items = append(items, fmt.Sprintf("%s %s", util.FormatAmount(limits[code], currency.MinorUnit), code))
+	}
+
+	return strings.Join(items, ", "), nil
+}
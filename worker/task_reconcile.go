@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	TaskReconcileLedger = "task:reconcile_ledger"
+	// ReconcileChunkSize is the number of accounts checked by each query of the reconciliation
+	ReconcileChunkSize = 1000
+)
+
+// ProcessTaskReconcileLedger run nightly and save a reconciliation report of the whole ledger,
+// findings are logged as an error so they are alerted on
+func (p *RedisTaskProcessor) ProcessTaskReconcileLedger(ctx context.Context, task *asynq.Task) error {
+	report, err := p.store.ReconcileLedger(ctx, ReconcileChunkSize)
+	if err != nil {
+		return fmt.Errorf("failed to reconcile ledger: %w", err)
+	}
+
+	logger := log.Info()
+	if report.FindingsCount > 0 {
+		logger = log.Error()
+	}
+	logger.
+		Str("type", task.Type()).
+		Int64("report_id", report.ID).
+		Int64("accounts_checked", report.AccountsChecked).
+		Int64("transfers_checked", report.TransfersChecked).
+		Int64("findings", report.FindingsCount).
+		Msg("process task")
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const TaskReleaseExpiredHolds = "task:release_expired_holds"
+
+// releaseExpiredHoldsBatchSize is the number of holds released in one query round
+const releaseExpiredHoldsBatchSize = 100
+
+func (p *RedisTaskProcessor) ProcessTaskReleaseExpiredHolds(ctx context.Context, task *asynq.Task) error {
+	released := 0
+	failed := 0
+
+	for {
+		holds, err := p.store.GetListExpiredHolds(ctx, releaseExpiredHoldsBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get expired holds: %w", err)
+		}
+
+		roundReleased := 0
+		for _, hold := range holds {
+			_, err = p.store.ExpireHoldTx(ctx, hold.ID)
+			if err != nil {
+				// the hold may be captured or voided meanwhile, the next run will retry the others
+				log.Error().Err(err).Int64("hold_id", hold.ID).Msg("failed to release expired hold")
+				failed++
+				continue
+			}
+			roundReleased++
+		}
+		released += roundReleased
+
+		// stop when nothing left, or when nothing could be released to avoid looping over the same failing rows
+		if len(holds) < releaseExpiredHoldsBatchSize || roundReleased == 0 {
+			break
+		}
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int("released", released).
+		Int("failed", failed).
+		Msg("process task")
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/repository"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	TaskEnqueueDueScheduledTransfers      = "task:enqueue_due_scheduled_transfers"
+	TaskExecuteScheduledTransfer          = "task:execute_scheduled_transfer"
+	TaskSendScheduledTransferFailedEmail  = "task:send_scheduled_transfer_failed_email"
+	enqueueDueScheduledTransfersBatchSize = 1000
+)
+
+type PayloadExecuteScheduledTransfer struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledAt         time.Time `json:"scheduled_at"`
+}
+
+type PayloadSendScheduledTransferFailedEmail struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	ScheduledAt         time.Time `json:"scheduled_at"`
+	Error               string    `json:"error"`
+}
+
+func (d *RedisTaskDistributor) DistributeTaskExecuteScheduledTransfer(ctx context.Context, payload *PayloadExecuteScheduledTransfer, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskExecuteScheduledTransfer, payload, opts...)
+}
+
+func (d *RedisTaskDistributor) DistributeTaskSendScheduledTransferFailedEmail(ctx context.Context, payload *PayloadSendScheduledTransferFailedEmail, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskSendScheduledTransferFailedEmail, payload, opts...)
+}
+
+// ProcessTaskEnqueueDueScheduledTransfers enqueue one execution task for each due scheduled transfer.
+// The task id is unique for each run so a run that is still queued from the previous round is not enqueued twice.
+func (p *RedisTaskProcessor) ProcessTaskEnqueueDueScheduledTransfers(ctx context.Context, task *asynq.Task) error {
+	items, err := p.store.GetListDueScheduledTransfers(ctx, time.Now(), enqueueDueScheduledTransfersBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to get due scheduled transfers: %w", err)
+	}
+
+	enqueued := 0
+	for _, st := range items {
+		payload := &PayloadExecuteScheduledTransfer{
+			ScheduledTransferID: st.ID,
+			ScheduledAt:         st.NextRunAt,
+		}
+		err = p.distributor.DistributeTaskExecuteScheduledTransfer(ctx, payload,
+			asynq.TaskID(fmt.Sprintf("scheduled_transfer:%d:%d", st.ID, st.NextRunAt.Unix())),
+			asynq.Queue(QueueCritical),
+			asynq.MaxRetry(3),
+		)
+		if err != nil {
+			if errors.Is(err, asynq.ErrTaskIDConflict) {
+				continue
+			}
+			return fmt.Errorf("failed to enqueue scheduled transfer %d: %w", st.ID, err)
+		}
+		enqueued++
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int("due", len(items)).
+		Int("enqueued", enqueued).
+		Msg("process task")
+
+	return nil
+}
+
+func (p *RedisTaskProcessor) ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadExecuteScheduledTransfer
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	// the failure email is written to the outbox with the failed execution so it isn't lost when redis is down
+	res, err := p.store.ExecuteScheduledTransferTx(ctx, payload.ScheduledTransferID, payload.ScheduledAt, p.payeeRules,
+		func(execution models.ScheduledTransferExecution) (models.OutboxMessage, error) {
+			taskPayload := &PayloadSendScheduledTransferFailedEmail{
+				ScheduledTransferID: execution.ScheduledTransferID,
+				ScheduledAt:         execution.ScheduledAt,
+				Error:               execution.Error,
+			}
+			return NewOutboxMessage(TaskSendScheduledTransferFailedEmail, taskPayload, QueueDefault, 10)
+		})
+	if err != nil {
+		// already executed, cancelled or deleted meanwhile
+		if errors.Is(err, repository.ErrScheduledTransferNotDue) || errors.Is(err, repository.ErrScheduledTransferNotFound) {
+			log.Info().Err(err).Int64("scheduled_transfer_id", payload.ScheduledTransferID).Msg("skip scheduled transfer")
+			return nil
+		}
+		return fmt.Errorf("failed to execute scheduled transfer: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int64("scheduled_transfer_id", payload.ScheduledTransferID).
+		Str("status", res.Execution.Status).
+		Msg("process task")
+
+	return nil
+}
+
+func (p *RedisTaskProcessor) ProcessTaskSendScheduledTransferFailedEmail(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendScheduledTransferFailedEmail
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	st, err := p.store.GetScheduledTransferByID(ctx, payload.ScheduledTransferID)
+	if err != nil {
+		return fmt.Errorf("error get scheduled transfer: %w", err)
+	}
+	if st.ID < 1 {
+		return fmt.Errorf("scheduled transfer doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	user, err := p.store.GetUsersByUsername(ctx, st.Owner)
+	if err != nil {
+		return fmt.Errorf("error get user: %w", err)
+	}
+	if user.Username == "" {
+		return fmt.Errorf("username doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	next := fmt.Sprintf("The next transfer will run at %s.", st.NextRunAt.UTC().Format(time.RFC1123))
+	if st.Status != models.ScheduledTransferStatusActive {
+		next = fmt.Sprintf("The scheduled transfer is now %s.", st.Status)
+	}
+
+	subject := "Scheduled transfer failed"
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Your scheduled transfer of %d from account %d to account %d at %s failed: %s.<br/>
+	%s<br/>
+	`, user.FullName, st.Amount, st.FromAccountID, st.ToAccountID, payload.ScheduledAt.UTC().Format(time.RFC1123), payload.Error, next)
+	to := []string{user.Email}
+	err = p.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send scheduled transfer failed email: %s", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Str("email", user.Email).
+		Bytes("payload", task.Payload()).
+		Msg("process task")
+
+	return nil
+}
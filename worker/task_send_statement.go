@@ -0,0 +1,81 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/mail"
+	"github.com/ismail118/simple-bank/statement"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const TaskSendStatement = "task:send_statement"
+
+type PayloadSendStatement struct {
+	AccountID int64 `json:"account_id"`
+	// first and last day of the period in UTC, both included
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	Format string    `json:"format"`
+}
+
+func (d *RedisTaskDistributor) DistributeTaskSendStatement(ctx context.Context, payload *PayloadSendStatement, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskSendStatement, payload, opts...)
+}
+
+// ProcessTaskSendStatement generate the statement of a period too large to be downloaded directly
+// and email it to the owner of the account as an attachment
+func (p *RedisTaskProcessor) ProcessTaskSendStatement(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendStatement
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	account, err := p.store.GetAccountByID(ctx, payload.AccountID)
+	if err != nil {
+		return fmt.Errorf("error get account: %w", err)
+	}
+	if account.ID < 1 {
+		return fmt.Errorf("account doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	user, err := p.store.GetUsersByUsername(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("error get user: %w", err)
+	}
+	if user.Username == "" {
+		return fmt.Errorf("username doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	st, err := statement.Load(ctx, p.store, account, payload.From, payload.To)
+	if err != nil {
+		return fmt.Errorf("failed to load statement: %w", err)
+	}
+	data, _, err := statement.Render(st, payload.Format)
+	if err != nil {
+		return fmt.Errorf("failed to render statement: %s: %w", err, asynq.SkipRetry)
+	}
+
+	period := fmt.Sprintf("%s to %s", st.From.Format("2006-01-02"), st.To.Format("2006-01-02"))
+	subject := fmt.Sprintf("Statement of account %d", account.ID)
+	content := fmt.Sprintf(`Hello %s,<br/>
+	Please find attached the statement of your account %d from %s.<br/>
+	`, user.FullName, account.ID, period)
+	to := []string{user.Email}
+	err = mail.SendAttachment(p.mailer, subject, content, to, statement.FileName(st, payload.Format), data)
+	if err != nil {
+		return fmt.Errorf("failed to send statement email: %s", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Str("email", user.Email).
+		Int64("account_id", account.ID).
+		Int("lines", len(st.Lines)).
+		Msg("process task")
+
+	return nil
+}
@@ -17,25 +17,7 @@ type PayloadSendVerifyEmail struct {
 }
 
 func (d *RedisTaskDistributor) DistributeTaskSendVerifyEmail(ctx context.Context, payload *PayloadSendVerifyEmail, opts ...asynq.Option) error {
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	task := asynq.NewTask(TaskSendVerifyEmail, jsonPayload)
-	info, err := d.client.EnqueueContext(ctx, task, opts...)
-	if err != nil {
-		return err
-	}
-
-	log.Info().
-		Str("type", task.Type()).
-		Str("queue", info.Queue).
-		Bytes("payload", jsonPayload).
-		Int("max_retry", info.MaxRetry).
-		Msg("enqueued task")
-
-	return nil
+	return d.enqueue(ctx, TaskSendVerifyEmail, payload, opts...)
 }
 
 func (p *RedisTaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error {
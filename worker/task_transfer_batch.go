@@ -0,0 +1,72 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/rs/zerolog/log"
+)
+
+const TaskProcessTransferBatch = "task:process_transfer_batch"
+
+type PayloadProcessTransferBatch struct {
+	BatchID int64 `json:"batch_id"`
+}
+
+func (d *RedisTaskDistributor) DistributeTaskProcessTransferBatch(ctx context.Context, payload *PayloadProcessTransferBatch, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskProcessTransferBatch, payload, opts...)
+}
+
+// ProcessTaskProcessTransferBatch execute the pending items of a best effort batch one by one,
+// a failing item doesn't stop the others. Items executed by a previous attempt are skipped on retry.
+func (p *RedisTaskProcessor) ProcessTaskProcessTransferBatch(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadProcessTransferBatch
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	batch, err := p.store.GetTransferBatchByID(ctx, payload.BatchID)
+	if err != nil {
+		return fmt.Errorf("error get transfer batch: %w", err)
+	}
+	if batch.ID < 1 {
+		return fmt.Errorf("transfer batch doesn't exist: %w", asynq.SkipRetry)
+	}
+	if batch.Status != models.BatchStatusPending {
+		log.Info().Int64("batch_id", batch.ID).Str("status", batch.Status).Msg("skip transfer batch")
+		return nil
+	}
+
+	items, err := p.store.GetListTransferBatchItems(ctx, batch.ID)
+	if err != nil {
+		return fmt.Errorf("error get transfer batch items: %w", err)
+	}
+
+	failed := 0
+	for _, item := range items {
+		res, err := p.store.ExecuteTransferBatchItemTx(ctx, item.ID)
+		if err != nil {
+			return fmt.Errorf("failed to execute transfer batch item %d: %w", item.ID, err)
+		}
+		if res.Item.Status == models.BatchItemStatusFailed {
+			failed++
+		}
+	}
+
+	err = p.store.UpdateTransferBatchStatus(ctx, batch.ID, models.BatchStatusCompleted)
+	if err != nil {
+		return fmt.Errorf("failed to complete transfer batch: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int64("batch_id", batch.ID).
+		Int("items", len(items)).
+		Int("failed", failed).
+		Msg("process task")
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hibiken/asynq"
+	"github.com/ismail118/simple-bank/models"
+	"github.com/ismail118/simple-bank/webhook"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	TaskEnqueueDueWebhookDeliveries      = "task:enqueue_due_webhook_deliveries"
+	TaskDeliverWebhook                   = "task:deliver_webhook"
+	enqueueDueWebhookDeliveriesBatchSize = 1000
+)
+
+// webhookClient send the deliveries, a redirect is a failed attempt so an endpoint can't forward the signed
+// events to another host. The dialer check the resolved address of every connection and no proxy is used,
+// so a host name resolving to an address of the bank network is refused.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: webhook.DialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+type PayloadDeliverWebhook struct {
+	DeliveryID int64 `json:"delivery_id"`
+	// attempts of the delivery when the task was enqueued
+	Attempt int `json:"attempt"`
+}
+
+func (d *RedisTaskDistributor) DistributeTaskDeliverWebhook(ctx context.Context, payload *PayloadDeliverWebhook, opts ...asynq.Option) error {
+	return d.enqueue(ctx, TaskDeliverWebhook, payload, opts...)
+}
+
+// ProcessTaskEnqueueDueWebhookDeliveries relay the pending deliveries written to the outbox by the transactions,
+// and the failed ones whose retry delay is over, to the delivery task. The task id is unique for each attempt so
+// an attempt that is still queued from the previous round is not enqueued twice.
+func (p *RedisTaskProcessor) ProcessTaskEnqueueDueWebhookDeliveries(ctx context.Context, task *asynq.Task) error {
+	items, err := p.store.GetListDueWebhookDeliveries(ctx, time.Now(), enqueueDueWebhookDeliveriesBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to get due webhook deliveries: %w", err)
+	}
+
+	enqueued := 0
+	for _, d := range items {
+		payload := &PayloadDeliverWebhook{
+			DeliveryID: d.ID,
+			Attempt:    d.Attempts,
+		}
+		err = p.distributor.DistributeTaskDeliverWebhook(ctx, payload,
+			asynq.TaskID(fmt.Sprintf("webhook_delivery:%d:%d:%d", d.ID, d.Attempts, d.NextAttemptAt.Unix())),
+			asynq.Queue(QueueDefault),
+			asynq.MaxRetry(3),
+		)
+		if err != nil {
+			if errors.Is(err, asynq.ErrTaskIDConflict) {
+				continue
+			}
+			return fmt.Errorf("failed to enqueue webhook delivery %d: %w", d.ID, err)
+		}
+		enqueued++
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int("due", len(items)).
+		Int("enqueued", enqueued).
+		Msg("process task")
+
+	return nil
+}
+
+// ProcessTaskDeliverWebhook make one attempt of the delivery. A failed attempt is retried after
+// webhook.RetryDelay by the next ProcessTaskEnqueueDueWebhookDeliveries, after webhook.MaxAttempts the
+// delivery is dead until it is replayed.
+func (p *RedisTaskProcessor) ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadDeliverWebhook
+	err := json.Unmarshal(task.Payload(), &payload)
+	if err != nil {
+		return fmt.Errorf("error unmarshall payload: %w", asynq.SkipRetry)
+	}
+
+	delivery, err := p.store.GetWebhookDeliveryByID(ctx, payload.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("error get webhook delivery: %w", err)
+	}
+	if delivery.ID < 1 {
+		// deleted with its endpoint
+		return fmt.Errorf("webhook delivery doesn't exist: %w", asynq.SkipRetry)
+	}
+	if delivery.Status != models.WebhookDeliveryStatusPending || delivery.Attempts != payload.Attempt {
+		// attempted or replayed since the task was enqueued
+		log.Info().
+			Str("type", task.Type()).
+			Int64("delivery_id", delivery.ID).
+			Str("status", delivery.Status).
+			Msg("skip stale webhook delivery")
+		return nil
+	}
+
+	endpoint, err := p.store.GetWebhookEndpointByID(ctx, delivery.EndpointID)
+	if err != nil {
+		return fmt.Errorf("error get webhook endpoint: %w", err)
+	}
+	event, err := p.store.GetWebhookEventByID(ctx, delivery.EventID)
+	if err != nil {
+		return fmt.Errorf("error get webhook event: %w", err)
+	}
+	if endpoint.ID < 1 || event.ID < 1 {
+		return fmt.Errorf("webhook endpoint or event doesn't exist: %w", asynq.SkipRetry)
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode, err = sendWebhook(ctx, endpoint, event, now)
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = now
+	case delivery.Attempts >= webhook.MaxAttempts:
+		delivery.Status = models.WebhookDeliveryStatusDead
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(webhook.RetryDelay(delivery.Attempts))
+	}
+
+	err = p.store.UpdateWebhookDeliveryAttempt(ctx, delivery)
+	if err != nil {
+		// the task is retried and the event sent again, receivers deduplicate by event id
+		return fmt.Errorf("failed to record webhook delivery attempt: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Int64("delivery_id", delivery.ID).
+		Int("attempts", delivery.Attempts).
+		Int("status_code", delivery.LastStatusCode).
+		Str("status", delivery.Status).
+		Msg("process task")
+
+	return nil
+}
+
+// sendWebhook post the event to the endpoint and return the response status code, any status other than 2xx
+// is an error
+func sendWebhook(ctx context.Context, endpoint models.WebhookEndpoint, event models.WebhookEvent, now time.Time) (int, error) {
+	// endpoints created before the url was validated
+	err := webhook.ValidateURL(endpoint.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := webhook.NewRequest(ctx, endpoint, event, now)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	// let the connection be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("endpoint responded %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}